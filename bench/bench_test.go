@@ -0,0 +1,173 @@
+// Package bench benchmarks reading the PDF files of fixtures generated in its tests,
+// so that the costs of opening files and extracting their text can be compared from
+// change to change. Run them with
+//
+//	go test ./bench -run '^$' -bench . -cpuprofile cpu.out -memprofile mem.out
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ScriptRock/pdf"
+)
+
+func open(tb testing.TB, f fixture) *pdf.Reader {
+	tb.Helper()
+	r, err := pdf.NewReader(bytes.NewReader(f.data), int64(len(f.data)))
+	if err != nil {
+		tb.Fatalf("%s: failed to open PDF: %v", f.name, err)
+	}
+	return r
+}
+
+func TestFixtures(t *testing.T) {
+	for _, f := range fixtures() {
+		t.Run(f.name, func(t *testing.T) {
+			r := open(t, f)
+			if n := r.NPages(); n != f.pages {
+				t.Fatalf("got %d pages, want %d", n, f.pages)
+			}
+			if title := r.Info()["Title"]; title != "Benchmark fixture" {
+				t.Errorf("got title %q, want %q", title, "Benchmark fixture")
+			}
+			txt, err := r.Page(f.pages)
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := lineText(f.pages-1, 0); !strings.Contains(txt.String(), want) {
+				t.Errorf("got text %q of the last page, want it to have %q", txt.String(), want)
+			}
+			if w := r.Warnings(); len(w) != 0 {
+				t.Errorf("got warnings %v, want none", w)
+			}
+		})
+	}
+}
+
+func BenchmarkNewReader(b *testing.B) {
+	for _, f := range fixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(f.data)))
+			for range b.N {
+				open(b, f)
+			}
+		})
+	}
+}
+
+func BenchmarkReader_Text(b *testing.B) {
+	for _, f := range fixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(f.data)))
+			for range b.N {
+				if _, err := open(b, f).Text(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReader_Page(b *testing.B) {
+	for _, f := range fixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			r := open(b, f)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				if _, err := r.Page(i%f.pages + 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestManyObjects(t *testing.T) {
+	f := manyObjects()
+	for name, opts := range map[string][]pdf.Option{"eager": nil, "lazy": {pdf.WithLazyXref()}} {
+		t.Run(name, func(t *testing.T) {
+			r, err := pdf.NewReader(bytes.NewReader(f.data), int64(len(f.data)), opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			if n := r.NPages(); n != f.pages {
+				t.Errorf("got %d pages, want %d", n, f.pages)
+			}
+			if title := r.Info()["Title"]; title != "Benchmark fixture" {
+				t.Errorf("got title %q, want %q", title, "Benchmark fixture")
+			}
+			txt, err := r.Page(f.pages)
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := lineText(f.pages-1, 0); txt.String() != want {
+				t.Errorf("got text %q of the last page, want %q", txt.String(), want)
+			}
+		})
+	}
+}
+
+func BenchmarkReader_metadata(b *testing.B) {
+	for _, f := range fixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				r := open(b, f)
+				r.NPages()
+				r.Info()
+				r.Producer()
+			}
+		})
+	}
+}
+
+func BenchmarkPage_Text(b *testing.B) {
+	for _, f := range fixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			p, err := open(b, f).GetPage(1)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				if _, err := p.Text(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReader_metadataManyObjects compares reading the metadata of a file of
+// 400,000 objects, whose newest cross-reference section has all the objects that
+// it needs, opened as usual and with WithLazyXref.
+func BenchmarkReader_metadataManyObjects(b *testing.B) {
+	f := manyObjects()
+	for _, bc := range []struct {
+		name string
+		opts []pdf.Option
+	}{
+		{"eager", nil},
+		{"lazy", []pdf.Option{pdf.WithLazyXref()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				r, err := pdf.NewReader(bytes.NewReader(f.data), int64(len(f.data)), bc.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				r.NPages()
+				r.Info()
+				r.Producer()
+				r.TrailerDict().Key("Encrypt").IsNull()
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ScriptRock/pdf/internal/testpdf"
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A fixture is a generated PDF file of the benchmarks.
+type fixture struct {
+	name  string
+	data  []byte
+	pages int
+}
+
+// document describes the content of a generated document.
+type document struct {
+	pages int
+	// lines is the number of lines of text on each page.
+	lines int
+	// images is the number of images on each page, of 256 by 256 RGB pixels.
+	images int
+	// objStm and encrypt are whether the file uses object streams, and is encrypted.
+	objStm, encrypt bool
+}
+
+// fixtures returns the generated PDF files of the benchmarks: a long document of
+// text, one of images, one with its objects in object streams, and an encrypted one.
+var fixtures = sync.OnceValue(func() []fixture {
+	return []fixture{
+		{name: "text", data: generate(document{pages: 50, lines: 60}), pages: 50},
+		{name: "images", data: generate(document{pages: 20, lines: 5, images: 4}), pages: 20},
+		{name: "objstm", data: generate(document{pages: 200, lines: 10, objStm: true}), pages: 200},
+		{name: "encrypted", data: generate(document{pages: 50, lines: 60, encrypt: true}), pages: 50},
+	}
+})
+
+// lineText returns the text of line j of page i of a generated document.
+func lineText(i, j int) string {
+	return fmt.Sprintf("Page %d, line %d: the quick brown fox jumps over the lazy dog.", i+1, j+1)
+}
+
+// generate returns a PDF file with the content of d.
+func generate(d document) []byte {
+	f := testpdf.File{Trailer: types.Dict{}}
+	if d.objStm {
+		f.ObjectStreams = 100
+	}
+	if d.encrypt {
+		f.EncryptRC4([]byte("0123456789abcdef"))
+	}
+
+	catalog, pages := f.Reserve(), f.Reserve()
+	info := f.Add(types.Dict{
+		"Title":        "Benchmark fixture",
+		"Producer":     "pdf bench",
+		"CreationDate": "D:20240101120000Z",
+	})
+	fonts := types.Dict{
+		"F1": f.Add(types.Dict{"Type": types.Name("Font"), "Subtype": types.Name("Type1"),
+			"BaseFont": types.Name("Helvetica"), "Encoding": types.Name("WinAnsiEncoding")}),
+		"F2": f.Add(types.Dict{"Type": types.Name("Font"), "Subtype": types.Name("Type1"),
+			"BaseFont": types.Name("Times-Bold"), "Encoding": types.Name("WinAnsiEncoding")}),
+	}
+
+	img := make([]byte, 256*256*3)
+	for i := range img {
+		img[i] = byte(i * 7 % 251)
+	}
+
+	var kids types.Array
+	for i := range d.pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F2 16 Tf 72 760 Td (Page %d) Tj ET\n", i+1)
+		for j := range d.lines {
+			fmt.Fprintf(&content, "BT /F1 10 Tf 72 %d Td (%s) Tj ET\n", 740-j*12, lineText(i, j))
+		}
+		res := types.Dict{"Font": fonts}
+		if d.images > 0 {
+			xobjects := types.Dict{}
+			for j := range d.images {
+				name := fmt.Sprintf("Im%d", j)
+				xobjects[types.Name(name)] = f.Add(testpdf.Flate(types.Dict{
+					"Type": types.Name("XObject"), "Subtype": types.Name("Image"), "Width": int64(256), "Height": int64(256),
+					"ColorSpace": types.Name("DeviceRGB"), "BitsPerComponent": int64(8),
+				}, img))
+				fmt.Fprintf(&content, "q 100 0 0 100 %d 72 cm /%s Do Q\n", 72+j*110, name)
+			}
+			res["XObject"] = xobjects
+		}
+		kids = append(kids, f.Add(types.Dict{
+			"Type":      types.Name("Page"),
+			"Parent":    pages,
+			"MediaBox":  types.Array{int64(0), int64(0), int64(612), int64(792)},
+			"Resources": res,
+			"Contents":  f.Add(testpdf.Flate(nil, []byte(content.String()))),
+		}))
+	}
+	f.Set(pages, types.Dict{"Type": types.Name("Pages"), "Kids": kids, "Count": int64(d.pages)})
+	f.Set(catalog, types.Dict{"Type": types.Name("Catalog"), "Pages": pages})
+	f.Trailer["Root"], f.Trailer["Info"] = catalog, info
+	return f.Bytes()
+}
+
+// manyObjects returns a file of 400,000 objects, of a few pages and many small objects
+// besides, updated three times, each update rewriting the catalog, the root of the page
+// tree and the document information dictionary, as editors saving a file do.
+var manyObjects = sync.OnceValue(func() fixture {
+	const objects, pages = 400_000, 10
+	f := testpdf.File{Trailer: types.Dict{}}
+	catalog, tree, info := f.Reserve(), f.Reserve(), f.Reserve()
+	font := f.Add(types.Dict{"Type": types.Name("Font"), "Subtype": types.Name("Type1"),
+		"BaseFont": types.Name("Helvetica"), "Encoding": types.Name("WinAnsiEncoding")})
+	var kids types.Array
+	for i := range pages {
+		kids = append(kids, f.Add(types.Dict{
+			"Type":      types.Name("Page"),
+			"Parent":    tree,
+			"MediaBox":  types.Array{int64(0), int64(0), int64(612), int64(792)},
+			"Resources": types.Dict{"Font": types.Dict{"F1": font}},
+			"Contents":  f.Add(testpdf.Flate(nil, fmt.Appendf(nil, "BT /F1 10 Tf 72 740 Td (%s) Tj ET", lineText(i, 0)))),
+		}))
+	}
+	for f.Add(int64(0)).ID < objects {
+	}
+	f.Set(catalog, types.Dict{"Type": types.Name("Catalog"), "Pages": tree})
+	f.Set(tree, types.Dict{"Type": types.Name("Pages"), "Kids": kids, "Count": int64(pages)})
+	f.Set(info, types.Dict{"Title": "Benchmark fixture"})
+	f.Trailer["Root"], f.Trailer["Info"] = catalog, info
+
+	var refs []string
+	for _, kid := range kids {
+		refs = append(refs, fmt.Sprintf("%d 0 R", kid.(types.Objptr).ID))
+	}
+	data := f.Bytes()
+	for i := range 3 {
+		data = appendUpdate(data, objects+1, map[uint32]string{
+			catalog.ID: fmt.Sprintf("<</Type /Catalog /Pages %d 0 R>>", tree.ID),
+			tree.ID:    fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(refs, " "), pages),
+			info.ID:    fmt.Sprintf("<</Title (Benchmark fixture) /ModDate (D:2024010%d120000Z)>>", i+2),
+		})
+	}
+	return fixture{name: "many objects", data: data, pages: pages}
+})
+
+// appendUpdate appends to the file data an incremental update replacing the objects objs,
+// with a cross-reference table of their entries alone.
+func appendUpdate(data []byte, size int, objs map[uint32]string) []byte {
+	i := bytes.LastIndex(data, []byte("startxref"))
+	var prev int64
+	fmt.Sscan(string(data[i+len("startxref"):]), &prev)
+
+	b := bytes.NewBuffer(slices.Clip(data))
+	var ids []uint32
+	for id := range objs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	offsets := map[uint32]int{}
+	for _, id := range ids {
+		offsets[id] = b.Len()
+		fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", id, objs[id])
+	}
+	xref := b.Len()
+	b.WriteString("xref\n")
+	for _, id := range ids {
+		fmt.Fprintf(b, "%d 1\n%010d 00000 n \n", id, offsets[id])
+	}
+	fmt.Fprintf(b, "trailer\n<</Size %d /Root 1 0 R /Info 3 0 R /Prev %d>>\nstartxref\n%d\n%%%%EOF\n", size, prev, xref)
+	return b.Bytes()
+}
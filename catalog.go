@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// checkCatalog checks that the Root of the trailer is the document catalog, a dictionary
+// of type Catalog whose Pages is the root of a page tree. If it is not, as in files damaged
+// by concatenating others, the catalog is taken to be the first object of the file that is,
+// with a warning. If none is, a Root of type Catalog without a page tree is kept, with a
+// warning, and any other Root is an error. See PDF 32000-1:2008, §7.7.2.
+// Looking for the catalog, which reads every object and object stream of the file, stops
+// with the error of ctx once it is done.
+func (r *Reader) checkCatalog(ctx context.Context) error {
+	problem := r.catalogProblem(r.trailerValue().Key("Root"))
+	if problem == "" {
+		return nil
+	}
+	if ref := r.trailer["Root"]; ref != nil {
+		problem = fmt.Sprintf("trailer Root %s %s", objfmt(ref), problem)
+	} else {
+		problem = "trailer has no Root"
+	}
+	var catalog types.Objptr
+	var ctxErr error
+	r.Objects(func(id uint32, gen uint16, v Value, err error) bool {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			return false
+		}
+		if err == nil && r.catalogProblem(v) == "" {
+			catalog = types.Objptr{ID: id, Gen: gen}
+			return false
+		}
+		return true
+	})
+	if ctxErr != nil {
+		return ctxErr
+	}
+	if catalog == (types.Objptr{}) {
+		// A catalog without a page tree is kept, for the rest of what it has.
+		if r.trailerValue().Key("Root").Key("Type").Name() == "Catalog" {
+			r.warn(XrefWarning, types.Objptr{}, 0, "%s", problem)
+			return nil
+		}
+		return fmt.Errorf("malformed PDF: %s, and no object of the file is a catalog", problem)
+	}
+	r.warn(XrefWarning, catalog, 0, "%s; using the catalog %v instead", problem, objfmt(catalog))
+	r.trailer = maps.Clone(r.trailer)
+	r.trailer["Root"] = catalog
+	return nil
+}
+
+// catalogProblem returns what keeps v from being the catalog, or "" if it is one.
+// The page tree of a Reader opened with WithLinearizedFirstPage may be out of the
+// first page section, and is not checked.
+func (r *Reader) catalogProblem(v Value) string {
+	switch {
+	case v.Kind() != Dict:
+		return "is not a dictionary"
+	case v.Key("Type").Name() != "Catalog":
+		return fmt.Sprintf("is of Type %v, not Catalog", v.Key("Type"))
+	}
+	pages := v.Key("Pages")
+	if r.firstPageOnly && pages.IsNull() {
+		return ""
+	}
+	if pages.Key("Type").Name() != "Pages" {
+		if ref := v.data.(types.Dict)["Pages"]; ref != nil {
+			return fmt.Sprintf("has Pages %s, not a page tree", objfmt(ref))
+		}
+		return "has no Pages"
+	}
+	return ""
+}
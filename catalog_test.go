@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewReader_catalog(t *testing.T) {
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	}
+	root := func(data []byte, ref string) []byte {
+		return bytes.Replace(data, []byte("/Root 1 0 R"), []byte(ref), 1)
+	}
+
+	testCases := map[string]struct {
+		data    []byte
+		text    string
+		warning string
+		err     string
+	}{
+		"valid": {
+			data: buildPDF(objs...),
+			text: "Hello, world",
+		},
+		"Root is a page": {
+			data:    root(buildPDF(objs...), "/Root 3 0 R"),
+			text:    "Hello, world",
+			warning: "trailer Root 3 0 R is of Type /Page, not Catalog; using the catalog 1 0 R instead",
+		},
+		"Root is free": {
+			data:    update(buildPDF(append(objs, objs[0])...), 7, map[int]string{1: ""}),
+			text:    "Hello, world",
+			warning: "trailer Root 1 0 R is not a dictionary; using the catalog 6 0 R instead",
+		},
+		"no Root": {
+			data:    root(buildPDF(objs...), ""),
+			text:    "Hello, world",
+			warning: "trailer has no Root; using the catalog 1 0 R instead",
+		},
+		"Pages is not a page tree": {
+			data:    buildPDF(append([]string{"<</Type /Catalog /Pages 3 0 R>>"}, objs[1:]...)...),
+			warning: "trailer Root 1 0 R has Pages 3 0 R, not a page tree",
+		},
+		"no catalog": {
+			data: root(buildPDF(append([]string{"<</Type /Outlines>>"}, objs[1:]...)...), "/Root 2 0 R"),
+			err:  "trailer Root 2 0 R is of Type /Pages, not Catalog, and no object of the file is a catalog",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			txt, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if txt.String() != tc.text {
+				t.Errorf("got text %q, want %q", txt.String(), tc.text)
+			}
+			var got []string
+			for _, w := range r.Warnings() {
+				got = append(got, w.Message)
+			}
+			if tc.warning == "" && len(got) != 0 || tc.warning != "" && !slices.Contains(got, tc.warning) {
+				t.Errorf("got warnings %q, want %q", got, tc.warning)
+			}
+		})
+	}
+}
+
+func TestNewReader_catalogContext(t *testing.T) {
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := bytes.Replace(buildPDF(objs...), []byte("/Root 1 0 R"), nil, 1)
+	if _, err := NewReaderFromBytes(data, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("looking for the catalog: got error %v, want %v", err, context.Canceled)
+	}
+
+	// The context bounds only the opening of the file.
+	r, err := NewReaderFromBytes(buildPDF(objs...), WithContext(ctx))
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	txt, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if txt.String() != "Hello, world" {
+		t.Errorf("got text %q, want %q", txt.String(), "Hello, world")
+	}
+}
@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"unicode/utf8"
+
+	"github.com/ScriptRock/pdf/internal/state"
+)
+
+// A clipPath follows the path construction operators of a content stream, to set
+// the clipping path of the graphics state to the bounds of the current path when
+// the path ends after W or W*. See PDF 32000-1:2008, §8.5.2 and §8.5.4.
+type clipPath struct {
+	// bounds is the bounds of the current path on the page, if started.
+	bounds  Rect
+	started bool
+	// clip is whether the path clips when it ends.
+	clip bool
+}
+
+// do interprets the path operator op with the operands args, in the graphics
+// state g, and reports whether op is one.
+func (c *clipPath) do(op string, args []Value, g *state.Graphics) bool {
+	switch op {
+	case "m", "l", "c", "v", "y":
+		for i := 0; i+1 < len(args); i += 2 {
+			p := Point{args[i].Float64(), args[i+1].Float64()}
+			c.add(transformRect(g.CTM(), Rect{p, p}))
+		}
+	case "re":
+		x, y, w, h := args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
+		c.add(transformRect(g.CTM(), Rect{Point{min(x, x+w), min(y, y+h)}, Point{max(x, x+w), max(y, y+h)}}))
+	case "h":
+	case "W", "W*":
+		c.clip = true
+	case "n", "f", "F", "f*", "S", "s", "B", "B*", "b", "b*":
+		if c.clip && c.started {
+			g.Clip(c.bounds.Min.X, c.bounds.Min.Y, c.bounds.Max.X, c.bounds.Max.Y)
+		}
+		*c = clipPath{}
+	default:
+		return false
+	}
+	return true
+}
+
+// add adds the rectangle r to the bounds of the path.
+func (c *clipPath) add(r Rect) {
+	if !c.started {
+		c.bounds, c.started = r, true
+		return
+	}
+	c.bounds.Min.X, c.bounds.Min.Y = min(c.bounds.Min.X, r.Min.X), min(c.bounds.Min.Y, r.Min.Y)
+	c.bounds.Max.X, c.bounds.Max.Y = max(c.bounds.Max.X, r.Max.X), max(c.bounds.Max.Y, r.Max.Y)
+}
+
+// visibleRegion returns the region of the page in which text drawn in the graphics
+// state g is visible: the intersection of the crop box of the page, box, and the
+// bounds of the clipping path, and whether either bounds it; a page may have no box.
+func visibleRegion(box Rect, g *state.Graphics) (region Rect, bounded bool) {
+	x0, y0, x1, y1, clipped := g.ClipBounds()
+	if !clipped {
+		return box, !box.Empty()
+	}
+	region = Rect{Point{x0, y0}, Point{x1, y1}}
+	if !box.Empty() {
+		region = region.Intersect(box)
+	}
+	return region, true
+}
+
+// A clipRenderer renders to r the runs of text that are at least partly within
+// region, whole, and counts those that are entirely outside it. An empty region
+// shows no text.
+type clipRenderer struct {
+	r      state.Renderer
+	region Rect
+	// dropped counts the runs dropped, and the glyphs of them.
+	dropped *droppedText
+}
+
+// droppedText is the number of runs of text dropped outside the visible region
+// of a page, and the number of glyphs they show.
+type droppedText struct {
+	runs, glyphs int
+}
+
+func (c clipRenderer) Render(run state.Run) {
+	r := c.region
+	b := run.Box
+	if r.Empty() || b[2] < r.Min.X || b[0] > r.Max.X || b[3] < r.Min.Y || b[1] > r.Max.Y {
+		c.dropped.runs++
+		c.dropped.glyphs += utf8.RuneCountInString(run.Text)
+		return
+	}
+	c.r.Render(run)
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A PageObject is an indirect object of those that a page refers to, as PageObjects
+// returns it.
+type PageObject struct {
+	// Object is the object as it is in the file, of the Go types of objects that
+	// WriteObject writes, with the references in it left as types.Objptr. A stream is
+	// a types.Stream, whose data is Data: WriteObject writes it from the PageObject.
+	Object any
+	// Data is the data of a stream as it is in the file, still encoded by the filters
+	// of its dictionary, but not encrypted, or nil for other objects.
+	Data []byte
+}
+
+// maxPageObjects is the greatest number of objects that PageObjects returns.
+const maxPageObjects = 100_000
+
+// inheritedPageKeys are the keys of the attributes that a page inherits from
+// its ancestors in the page tree. See PDF 32000-1:2008, §7.7.3.4.
+var inheritedPageKeys = [...]types.Name{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// PageObjects returns the reference to the dictionary of page i and the objects that
+// page refers to, directly or through other objects: its content streams, resources,
+// annotations and the rest, by reference. With WriteObject of each PageObject, it lets
+// a page be written as a file of its own, to debug it apart from the rest of a document.
+//
+// The Parent entries of dictionaries are not followed, so that the rest of the page
+// tree is left out; the attributes that the page inherits from it, such as Resources
+// and MediaBox, are set in the page dictionary that PageObjects returns, which still
+// refers to its parent. References to objects that cannot be read are left out, with
+// a warning. PageObjects returns an error if the page refers to more than 100,000 objects.
+func (r *Reader) PageObjects(i int) (types.Objptr, map[types.Objptr]PageObject, error) {
+	p, err := r.GetPage(i)
+	if err != nil {
+		return types.Objptr{}, nil, err
+	}
+	page, ok := p.v.data.(types.Dict)
+	if !ok {
+		return types.Objptr{}, nil, fmt.Errorf("page %d is not a dictionary", i)
+	}
+
+	// The inherited attributes, found along the raw Parent references of the page tree.
+	inherited := types.Dict{}
+	for k, v := range page {
+		inherited[k] = v
+	}
+	for _, key := range inheritedPageKeys {
+		d := page
+		for range maxPageTreeDepth {
+			if x, ok := d[key]; ok {
+				inherited[key] = x
+				break
+			}
+			parent, _ := d["Parent"].(types.Objptr)
+			obj, _ := r.load(parent)
+			if d, ok = obj.(types.Dict); !ok {
+				break
+			}
+		}
+	}
+
+	objs := map[types.Objptr]PageObject{p.v.ptr: {Object: inherited}}
+	queue := []types.Objptr{}
+	var walk func(x types.Object)
+	walk = func(x types.Object) {
+		switch x := x.(type) {
+		case types.Objptr:
+			if _, ok := objs[x]; !ok {
+				objs[x] = PageObject{}
+				queue = append(queue, x)
+			}
+		case types.Dict:
+			for k, v := range x {
+				if k != "Parent" {
+					walk(v)
+				}
+			}
+		case types.Array:
+			for _, v := range x {
+				walk(v)
+			}
+		case types.Stream:
+			walk(x.Hdr)
+		}
+	}
+	walk(inherited)
+
+	for len(queue) > 0 {
+		if len(objs) > maxPageObjects {
+			return types.Objptr{}, nil, fmt.Errorf("page %d refers to more than %d objects", i, maxPageObjects)
+		}
+		ptr := queue[0]
+		queue = queue[1:]
+		obj, err := r.load(ptr)
+		if err != nil || obj == nil {
+			if err != nil {
+				r.warn(XrefWarning, ptr, i, "failed to copy object: %v", err)
+			}
+			delete(objs, ptr)
+			continue
+		}
+		o := PageObject{Object: obj}
+		if s, ok := obj.(types.Stream); ok {
+			if o.Data, err = r.rawStreamData(s); err != nil {
+				r.warn(StreamWarning, ptr, i, "%v", err)
+			}
+		}
+		objs[ptr] = o
+		walk(obj)
+	}
+	return p.v.ptr, objs, nil
+}
@@ -0,0 +1,107 @@
+package pdf
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/ScriptRock/pdf/internal/testpdf"
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+func TestReader_PageObjects(t *testing.T) {
+	// Page 2 inherits its resources and media box, refers to itself through an annotation,
+	// to a pair of objects that refer to each other, and to an object that is missing.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources 5 0 R /MediaBox [0 0 300 200]>>",
+		"<</Type /Page /Parent 2 0 R /Contents 6 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Contents [7 0 R] /Annots [8 0 R] /Missing 99 0 R>>",
+		"<</Font <</F1 9 0 R>>>>",
+		stream("BT /F1 12 Tf 72 100 Td (Page one) Tj ET"),
+		stream("BT /F1 12 Tf 72 100 Td (Page two) Tj ET"),
+		"<</Type /Annot /Subtype /Text /Rect [0 0 10 10] /P 4 0 R /Popup 10 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /Annot /Subtype /Popup /Rect [0 0 50 50] /Parent 8 0 R /Next 11 0 R>>",
+		"<</Prev 10 0 R>>",
+	))
+
+	page, objs, err := r.PageObjects(2)
+	if err != nil {
+		t.Fatal("failed to copy page objects:", err)
+	}
+	if want := (types.Objptr{ID: 4}); page != want {
+		t.Errorf("got page %v, want %v", page, want)
+	}
+	var ids []uint32
+	for ptr := range objs {
+		ids = append(ids, ptr.ID)
+	}
+	slices.Sort(ids)
+	if want := []uint32{4, 5, 7, 8, 9, 10, 11}; !slices.Equal(ids, want) {
+		t.Errorf("got objects %v, want %v", ids, want)
+	}
+	d := objs[page].Object.(types.Dict)
+	if d["Resources"] != (types.Objptr{ID: 5}) || objfmt(d["MediaBox"]) != "[0 0 300 200]" || d["Parent"] != (types.Objptr{ID: 2}) {
+		t.Errorf("got page dictionary %v, want it to have the inherited attributes", objfmt(d))
+	}
+	if got, want := string(objs[types.Objptr{ID: 7}].Data), "BT /F1 12 Tf 72 100 Td (Page two) Tj ET"; got != want {
+		t.Errorf("got contents %q, want %q", got, want)
+	}
+
+	// The objects make a file of the page alone, of the same object numbers,
+	// with a new page tree of the page, object 2 as before.
+	var f testpdf.File
+	for range 11 {
+		f.Reserve()
+	}
+	for ptr, o := range objs {
+		if s, ok := o.Object.(types.Stream); ok {
+			f.Set(ptr, &testpdf.Stream{Hdr: s.Hdr, Data: o.Data})
+		} else {
+			f.Set(ptr, o.Object)
+		}
+	}
+	f.Set(types.Objptr{ID: 1}, types.Dict{"Type": types.Name("Catalog"), "Pages": types.Objptr{ID: 2}})
+	f.Set(types.Objptr{ID: 2}, types.Dict{"Type": types.Name("Pages"), "Kids": types.Array{page}, "Count": int64(1)})
+	f.Trailer = types.Dict{"Root": types.Objptr{ID: 1}}
+	single := openPDF(t, f.Bytes())
+	got, err := single.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Page two"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_PageObjects_WriteObject(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"))
+	page, objs, err := r.PageObjects(1)
+	if err != nil {
+		t.Fatal("failed to copy page objects:", err)
+	}
+
+	// Each object, streams included, is written as it is in a file of the page alone.
+	var f testpdf.File
+	for range 5 {
+		f.Reserve()
+	}
+	for ptr, o := range objs {
+		var b bytes.Buffer
+		if err := WriteObject(&b, o); err != nil {
+			t.Fatalf("failed to write object %v: %v", ptr, err)
+		}
+		f.Set(ptr, testpdf.Raw(b.String()))
+	}
+	f.Set(types.Objptr{ID: 1}, types.Dict{"Type": types.Name("Catalog"), "Pages": types.Objptr{ID: 2}})
+	f.Set(types.Objptr{ID: 2}, types.Dict{"Type": types.Name("Pages"), "Kids": types.Array{page}, "Count": int64(1)})
+	f.Trailer = types.Dict{"Root": types.Objptr{ID: 1}}
+	got, err := openPDF(t, f.Bytes()).Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
@@ -0,0 +1,37 @@
+package pdf
+
+import (
+	"context"
+)
+
+// An Operation is an operator of a content stream, with its operands.
+// See PDF 32000-1:2008, §7.8.2.
+type Operation struct {
+	Op       string
+	Operands []Value
+
+	// Data is the image data of an inline image, which is read as the single
+	// operation "BI", with the image dictionary as its operand.
+	Data []byte
+}
+
+// Contents returns the operations of the page's content streams, in order.
+// If the content is malformed, Contents returns the operations before the
+// error, and the error.
+func (p *Page) Contents() (ops []Operation, err error) {
+	defer catch(&err)
+
+	forEachStream(context.Background(), p, func(stk *stack, op string) {
+		n := stk.Len()
+		args := make([]Value, n)
+		for i := range n {
+			args[n-1-i] = stk.Pop()
+		}
+		o := Operation{Op: op, Operands: args}
+		if op == "BI" && n == 2 {
+			o.Operands, o.Data = args[:1], []byte(args[1].RawString())
+		}
+		ops = append(ops, o)
+	})
+	return ops, nil
+}
@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// opStrings returns the operations ops as strings, of their operators and operands.
+func opStrings(ops []Operation) []string {
+	var s []string
+	for _, op := range ops {
+		o := op.Op
+		for _, arg := range op.Operands {
+			o += " " + arg.String()
+		}
+		if op.Data != nil {
+			o += fmt.Sprintf(" %q", op.Data)
+		}
+		s = append(s, o)
+	}
+	return s
+}
+
+func TestPage_Contents(t *testing.T) {
+	r := openPDF(t, textPDF("q 1 0 0 1 10 20 cm BT /F1 12 Tf [(A) -20 (B)] TJ ET\n"+
+		"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00EI\xff EI Q"))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	ops, err := p.Contents()
+	if err != nil {
+		t.Fatal("failed to read contents:", err)
+	}
+	got := opStrings(ops)
+	want := []string{
+		"q",
+		"cm 1 0 0 1 10 20",
+		"BT",
+		"Tf /F1 12",
+		`TJ ["A" -20 "B"]`,
+		"ET",
+		`BI <</BPC 8 /CS /G /H 1 /W 2>> "\x00EI\xff"`,
+		"Q",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("operations did not match expectation:", diff)
+	}
+}
+
+func TestPage_contentCache(t *testing.T) {
+	data := textPDF("q 1 0 0 1 10 20 cm BT /F1 12 Tf [(A) -20 (B)] TJ ET\n" +
+		"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00EI\xff EI Q BT /F1 10 Tf 72 700 Td (Hello) Tj ET")
+
+	testCases := map[string]struct {
+		opts []Option
+		kept bool
+	}{
+		"default":   {kept: true},
+		"none":      {opts: []Option{WithContentCache(0)}},
+		"too short": {opts: []Option{WithContentCache(8)}},
+		"enough":    {opts: []Option{WithContentCache(13)}, kept: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			p, err := r.GetPage(1)
+			if err != nil {
+				t.Fatal("failed to get page:", err)
+			}
+
+			// Each pass gives the same, whether it reads the streams or the operations kept.
+			var texts, contents []string
+			var renders []runs
+			for range 3 {
+				txt, err := p.Text()
+				if err != nil {
+					t.Fatal("failed to read text:", err)
+				}
+				ops, err := p.Contents()
+				if err != nil {
+					t.Fatal("failed to read contents:", err)
+				}
+				var rs runs
+				if err := p.Render(&rs); err != nil {
+					t.Fatal("failed to render page:", err)
+				}
+				texts = append(texts, txt.String())
+				contents = append(contents, strings.Join(opStrings(ops), "\n"))
+				renders = append(renders, rs)
+			}
+			for i := 1; i < 3; i++ {
+				if texts[i] != texts[0] || contents[i] != contents[0] {
+					t.Errorf("got text %q and contents %q, want %q and %q as the first time", texts[i], contents[i], texts[0], contents[0])
+				}
+				if diff := cmp.Diff(renders[i], renders[0]); diff != "" {
+					t.Error("runs did not match those of the first time:", diff)
+				}
+			}
+			if want := "AB\n\nHello"; texts[0] != want {
+				t.Errorf("got text %q, want %q", texts[0], want)
+			}
+			if kept := p.ops.Load() != nil; kept != tc.kept {
+				t.Errorf("got operations kept %v, want %v", kept, tc.kept)
+			}
+		})
+	}
+}
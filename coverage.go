@@ -0,0 +1,175 @@
+package pdf
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"unicode/utf8"
+)
+
+// TextCoverage describes the text and images on a page, to tell the pages of digital
+// text from scanned ones, whose text is only in images, and from scanned pages
+// that have their text recognized with OCR, which is drawn invisibly over the image.
+type TextCoverage struct {
+	// TextOperators is the number of text-showing operators: Tj, TJ, ' and ".
+	TextOperators int
+	// Glyphs is the number of glyphs they show, counted by the characters they decode to.
+	Glyphs int
+	// InvisibleGlyphs is the number of those glyphs shown with the invisible text
+	// rendering mode, 3, as OCR text is. See PDF 32000-1:2008, §9.3.6.
+	InvisibleGlyphs int
+	// ImageArea is the fraction, from 0 to 1, of the area of the page covered
+	// by images, both image XObjects and inline images.
+	ImageArea float64
+}
+
+// A PageClass is a kind of page, by its text and images.
+type PageClass int
+
+const (
+	EmptyPage    PageClass = iota // A page with neither text nor images covering it.
+	DigitalText                   // A page with visible text.
+	ScannedImage                  // A page covered by images, without text.
+	HybridOCR                     // A page covered by images, with invisible text over them.
+)
+
+// scannedArea is the fraction of a page that images cover on a scanned page.
+const scannedArea = 0.5
+
+// Class classifies the page by its coverage.
+func (c TextCoverage) Class() PageClass {
+	visible := c.Glyphs - c.InvisibleGlyphs
+	scanned := c.ImageArea >= scannedArea
+	switch {
+	case scanned && c.InvisibleGlyphs > visible:
+		return HybridOCR
+	case visible > 0:
+		return DigitalText
+	case scanned:
+		return ScannedImage
+	}
+	return EmptyPage
+}
+
+// TextCoverage returns the coverage of the page by text and images. Like Text, it
+// reads the content of form XObjects, and skips that of optional content groups that
+// are hidden.
+func (p *Page) TextCoverage() (TextCoverage, error) {
+	return p.TextCoverageContext(context.Background())
+}
+
+// TextCoverageContext is like TextCoverage, but stops with the error of ctx once it is done.
+func (p *Page) TextCoverageContext(ctx context.Context) (TextCoverage, error) {
+	var cr coverageRenderer
+	if _, _, err := p.extract(ctx, &cr, false, nil, p.v.r.cfg.builder); err != nil {
+		return TextCoverage{}, err
+	}
+	c := cr.c
+	if a := p.box().Area(); a > 0 {
+		c.ImageArea = min(unionArea(cr.images)/a, 1)
+	}
+	return c, nil
+}
+
+// A coverageRenderer counts the text of a page, and records the bounds of its images,
+// as extract draws them.
+type coverageRenderer struct {
+	c      TextCoverage
+	images []Rect
+}
+
+func (r *coverageRenderer) Render(run TextRun) {
+	n := utf8.RuneCountInString(run.Text)
+	r.c.Glyphs += n
+	if run.RenderMode == 3 {
+		r.c.InvisibleGlyphs += n
+	}
+}
+
+func (r *coverageRenderer) showText() { r.c.TextOperators++ }
+
+func (r *coverageRenderer) drawImage(bounds Rect) { r.images = append(r.images, bounds) }
+
+// box returns the visible region of the page: its crop box, which is its media box
+// unless it says otherwise. See PDF 32000-1:2008, §14.11.2.
+func (p *Page) box() Rect {
+	media := rect(p.findInherited("MediaBox"))
+	if crop := p.findInherited("CropBox"); !crop.IsNull() {
+		return rect(crop).Intersect(media)
+	}
+	return media
+}
+
+// unitSquare returns the bounds of the unit square transformed by the matrix m,
+// given as the operands of cm, where images are drawn. See PDF 32000-1:2008, §8.3.4.
+func unitSquare(m [6]float64) Rect {
+	return transformRect(m, Rect{Max: Point{1, 1}})
+}
+
+// unionArea returns the area covered by the rectangles, counting that of their overlaps
+// once. It sweeps across them from left to right, keeping the length covered of each
+// interval between their edges in y in a coverTree.
+func unionArea(rs []Rect) float64 {
+	rs = slices.DeleteFunc(rs, Rect.Empty)
+	if len(rs) == 0 {
+		return 0
+	}
+	// An edge is the left, d 1, or right, d -1, side of a rectangle.
+	type edge struct {
+		x, y0, y1 float64
+		d         int
+	}
+	ys := make([]float64, 0, 2*len(rs))
+	edges := make([]edge, 0, 2*len(rs))
+	for _, r := range rs {
+		ys = append(ys, r.Min.Y, r.Max.Y)
+		edges = append(edges, edge{r.Min.X, r.Min.Y, r.Max.Y, 1}, edge{r.Max.X, r.Min.Y, r.Max.Y, -1})
+	}
+	slices.Sort(ys)
+	ys = slices.Compact(ys)
+	slices.SortFunc(edges, func(a, b edge) int { return cmp.Compare(a.x, b.x) })
+
+	t := coverTree{ys: ys, count: make([]int, 4*len(ys)), length: make([]float64, 4*len(ys))}
+	var area float64
+	for i, e := range edges {
+		if i > 0 {
+			area += t.length[1] * (e.x - edges[i-1].x)
+		}
+		from, _ := slices.BinarySearch(ys, e.y0)
+		to, _ := slices.BinarySearch(ys, e.y1)
+		t.add(1, 0, len(ys)-1, from, to, e.d)
+	}
+	return area
+}
+
+// A coverTree is a segment tree over the intervals between consecutive ys. Each node,
+// the root being 1 and the children of n being 2n and 2n+1, is a span of the intervals,
+// of how many rectangles cover all of it, count, and the length of it that they cover.
+type coverTree struct {
+	ys     []float64
+	count  []int
+	length []float64
+}
+
+// add adds d to the count of the intervals from, up to to, within those lo up to hi
+// of node.
+func (t *coverTree) add(node, lo, hi, from, to, d int) {
+	if to <= lo || hi <= from {
+		return
+	}
+	if from <= lo && hi <= to {
+		t.count[node] += d
+	} else {
+		mid := (lo + hi) / 2
+		t.add(2*node, lo, mid, from, to, d)
+		t.add(2*node+1, mid, hi, from, to, d)
+	}
+	switch {
+	case t.count[node] > 0:
+		t.length[node] = t.ys[hi] - t.ys[lo]
+	case hi-lo == 1:
+		t.length[node] = 0
+	default:
+		t.length[node] = t.length[2*node] + t.length[2*node+1]
+	}
+}
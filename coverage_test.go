@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPage_TextCoverage(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		want    TextCoverage
+		class   PageClass
+	}{
+		"empty": {},
+		"digital text": {
+			content: "BT /F1 12 Tf 72 720 Td (Hello) Tj [(wor) -20 (ld)] TJ ET",
+			want:    TextCoverage{TextOperators: 2, Glyphs: 10},
+			class:   DigitalText,
+		},
+		"scanned": {
+			content: "q 200 0 0 100 0 0 cm /Im1 Do Q q 200 0 0 100 0 100 cm /Im1 Do Q",
+			want:    TextCoverage{ImageArea: 1},
+			class:   ScannedImage,
+		},
+		"overlapping and off-page images": {
+			content: "q 100 0 0 200 0 0 cm /Im1 Do Q q 100 0 0 100 -50 50 cm /Im1 Do Q /Im1 Do " +
+				"q 0 50 -50 0 300 0 cm /Im1 Do Q",
+			want:  TextCoverage{ImageArea: 0.5},
+			class: ScannedImage,
+		},
+		"inline image": {
+			content: "q 100 0 0 100 0 0 cm BI /W 1 /H 1 /BPC 8 /CS /G ID \x00 EI Q",
+			want:    TextCoverage{ImageArea: 0.25},
+		},
+		"form": {
+			content: "q 200 0 0 200 0 0 cm /Fm1 Do Q",
+		},
+		"OCR layer": {
+			content: "q 200 0 0 200 0 0 cm /Im1 Do Q BT 3 Tr /F1 12 Tf 10 10 Td (Scanned text) Tj ET BT 0 Tr /F1 8 Tf (1) Tj ET",
+			want:    TextCoverage{TextOperators: 2, Glyphs: 13, InvisibleGlyphs: 12, ImageArea: 1},
+			class:   HybridOCR,
+		},
+		"real render mode": {
+			content: "q 200 0 0 200 0 0 cm /Im1 Do Q BT 3.0 Tr /F1 12 Tf 10 10 Td (Scanned text) Tj ET",
+			want:    TextCoverage{TextOperators: 1, Glyphs: 12, InvisibleGlyphs: 12, ImageArea: 1},
+			class:   HybridOCR,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 250]>>",
+				"<</Type /Page /Parent 2 0 R /CropBox [0 0 200 200] /Contents 4 0 R "+
+					"/Resources <</Font <</F1 5 0 R>> /XObject <</Im1 6 0 R /Fm1 7 0 R>>>>>>",
+				stream(tc.content),
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+				"<</Type /XObject /Subtype /Image /Width 1 /Height 1 /BitsPerComponent 8 /ColorSpace /DeviceGray /Length 1>>\nstream\n\x00\nendstream",
+				"<</Type /XObject /Subtype /Form /BBox [0 0 1 1] /Length 0>>\nstream\n\nendstream",
+			))
+			p, err := r.GetPage(1)
+			if err != nil {
+				t.Fatal("failed to get page:", err)
+			}
+
+			got, err := p.TextCoverage()
+			if err != nil {
+				t.Fatal("failed to read coverage:", err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("coverage did not match expectation:", diff)
+			}
+			if class := got.Class(); class != tc.class {
+				t.Errorf("got class %v, want %v", class, tc.class)
+			}
+		})
+	}
+}
+
+func Test_unionArea(t *testing.T) {
+	square := func(x, y, size float64) Rect { return Rect{Point{x, y}, Point{x + size, y + size}} }
+	var grid, same []Rect
+	for i := range 200 {
+		for j := range 200 {
+			grid = append(grid, square(float64(i), float64(j), 1))
+		}
+		same = append(same, square(0, 0, 10))
+	}
+	testCases := map[string]struct {
+		rects []Rect
+		want  float64
+	}{
+		"none":        {},
+		"empty":       {rects: []Rect{{Point{0, 0}, Point{0, 10}}}},
+		"disjoint":    {rects: []Rect{square(0, 0, 2), square(5, 5, 3)}, want: 13},
+		"overlapping": {rects: []Rect{square(0, 0, 2), square(1, 1, 2)}, want: 7},
+		"nested":      {rects: []Rect{square(0, 0, 4), square(1, 1, 2)}, want: 16},
+		"same":        {rects: same, want: 100},
+		"grid":        {rects: grid, want: 40000},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := unionArea(tc.rects); got != tc.want {
+				t.Errorf("got area %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// ResolveDest returns the location of the named destination name: the number of the
+// page it is on and the coordinates on the page, in default user space, of its upper
+// left corner. Coordinates that a destination leaves unspecified are zero. Names are
+// looked up in the catalog's Dests dictionary and in its Names dictionary's Dests
+// name tree. See PDF 32000-1:2008, §12.3.2.
+func (r *Reader) ResolveDest(name string) (page int, x, y float64, err error) {
+	dest, err := r.namedDest(name)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return r.resolveDest(dest)
+}
+
+// namedDest returns the destination array for the named destination name.
+func (r *Reader) namedDest(name string) (Value, error) {
+	root := r.trailerValue().Key("Root")
+	dest := root.Key("Dests").Key(name)
+	if dest.IsNull() {
+		dest = nameTree(root.Key("Names").Key("Dests")).Lookup(name)
+	}
+	if dest.Kind() == Dict {
+		// A dictionary holding the destination, as for named destinations in Dests.
+		dest = dest.Key("D")
+	}
+	if dest.IsNull() {
+		return Value{}, fmt.Errorf("named destination %q not found", name)
+	}
+	return dest, nil
+}
+
+// A Dest is a destination: a view of a page of the document. See PDF 32000-1:2008, §12.3.2.2.
+type Dest struct {
+	// Page is the number of the page, from 1.
+	Page int
+	// Fit is the type of the view: "XYZ", "Fit", "FitH", "FitV", "FitR",
+	// "FitB", "FitBH" or "FitBV".
+	Fit string
+	// Left, Bottom, Right and Top are the coordinates of the edges of the view that
+	// its type gives, in default user space, and Zoom the zoom factor of an XYZ view.
+	// Those that the view leaves unspecified, or unchanged with null, are zero.
+	Left, Bottom, Right, Top float64
+	Zoom                     float64
+}
+
+// resolveDest returns the location of the destination dest, which is either
+// a destination array or the name of a named destination.
+func (r *Reader) resolveDest(dest Value) (page int, x, y float64, err error) {
+	d, err := r.decodeDest(dest)
+	return d.Page, d.Left, d.Top, err
+}
+
+// decodeDest returns the destination dest, which is either a destination
+// array or the name of a named destination.
+func (r *Reader) decodeDest(dest Value) (d Dest, err error) {
+	switch dest.Kind() {
+	case Name:
+		if dest, err = r.namedDest(dest.Name()); err != nil {
+			return Dest{}, err
+		}
+	case String:
+		if dest, err = r.namedDest(dest.RawString()); err != nil {
+			return Dest{}, err
+		}
+	}
+	if dest.Kind() != Array || dest.Len() < 2 {
+		return Dest{}, fmt.Errorf("malformed destination %v", dest)
+	}
+
+	switch pg := dest.Index(0); pg.Kind() {
+	case Dict:
+		var ok bool
+		if d.Page, ok = r.pageNumber(pg.ptr); !ok {
+			return Dest{}, fmt.Errorf("destination page %v not found", pg.ptr)
+		}
+	case Integer:
+		// A page index, as in a remote destination, which some writers use locally.
+		if d.Page = int(pg.Int64()) + 1; d.Page < 1 || d.Page > r.NPages() {
+			return Dest{}, fmt.Errorf("destination page %d out of range: [1, %d]", d.Page, r.NPages())
+		}
+	default:
+		return Dest{}, fmt.Errorf("malformed destination %v", dest)
+	}
+
+	// See Table 151: Destination syntax.
+	d.Fit = dest.Index(1).Name()
+	switch d.Fit {
+	case "XYZ":
+		d.Left, d.Top, d.Zoom = dest.Index(2).Float64(), dest.Index(3).Float64(), dest.Index(4).Float64()
+	case "FitR":
+		d.Left, d.Bottom, d.Right, d.Top = dest.Index(2).Float64(), dest.Index(3).Float64(), dest.Index(4).Float64(), dest.Index(5).Float64()
+	case "FitH", "FitBH":
+		d.Top = dest.Index(2).Float64()
+	case "FitV", "FitBV":
+		d.Left = dest.Index(2).Float64()
+	}
+	return d, nil
+}
+
+// An Action is an action of the document, summarized. See PDF 32000-1:2008, §12.6.
+type Action struct {
+	// Type is the type of the action, its S entry, such as "GoTo", "URI" or "Launch".
+	Type string
+	// Dest is the destination of a GoTo action.
+	Dest Dest
+	// Target is what the action goes to or runs: the URI of a URI action, the file
+	// of a Launch, GoToR or GoToE action, the name of a Named action or the script
+	// of a JavaScript action, or "" for other actions.
+	Target string
+}
+
+// OpenAction returns what the document does when it is opened, its OpenAction: to go
+// to a destination, as a GoTo action does, or another action, followed by those chained
+// after it by the Next entries of the actions, in the order they are done. It returns nil
+// if the document has none. An action chained after itself is skipped, and at most
+// maxActions are returned. Actions are only described, not done.
+// See PDF 32000-1:2008, §12.3.2 and §12.6.2.
+func (r *Reader) OpenAction() ([]Action, error) {
+	root := r.trailerValue().Key("Root")
+	open := root.Key("OpenAction")
+	switch open.Kind() {
+	case Null:
+		return nil, nil
+	case Dict:
+	default:
+		d, err := r.decodeDest(open)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OpenAction: %w", err)
+		}
+		return []Action{{Type: "GoTo", Dest: d}}, nil
+	}
+
+	var actions []Action
+	seen := map[types.Objptr]bool{}
+	// walk appends the action x, of the object parent, and those chained after it.
+	var walk func(parent Value, x types.Object) error
+	walk = func(parent Value, x types.Object) error {
+		if ptr, ok := x.(types.Objptr); ok {
+			if seen[ptr] {
+				return nil
+			}
+			seen[ptr] = true
+		}
+		v := r.resolve(parent.ptr, x)
+		if v.Kind() != Dict || len(actions) >= maxActions {
+			return nil
+		}
+		a, err := r.action(v)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, a)
+
+		next := v.data.(types.Dict)["Next"]
+		if elems, ok := r.resolve(v.ptr, next).data.(types.Array); ok {
+			for _, e := range elems {
+				if err := walk(v, e); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return walk(v, next)
+	}
+	catalog, _ := root.data.(types.Dict)
+	if err := walk(root, catalog["OpenAction"]); err != nil {
+		return nil, fmt.Errorf("failed to read OpenAction: %w", err)
+	}
+	return actions, nil
+}
+
+// action returns the summary of the action dictionary v.
+func (r *Reader) action(v Value) (Action, error) {
+	a := Action{Type: v.Key("S").Name()}
+	switch a.Type {
+	case "GoTo":
+		d, err := r.decodeDest(v.Key("D"))
+		if err != nil {
+			return Action{}, err
+		}
+		a.Dest = d
+	case "URI":
+		a.Target = v.Key("URI").RawString()
+	case "Launch":
+		a.Target = fileSpec(v.Key("F"))
+		if a.Target == "" {
+			a.Target = v.Key("Win").Key("F").RawString()
+		}
+	case "GoToR", "GoToE":
+		a.Target = fileSpec(v.Key("F"))
+	case "Named":
+		a.Target = v.Key("N").Name()
+	case "JavaScript":
+		a.Target = scriptSource(v.Key("JS"), 0)
+	}
+	return a, nil
+}
+
+// fileSpec returns the file name of the file specification v, a string or a
+// dictionary, preferring its Unicode name. See PDF 32000-1:2008, §7.11.
+func fileSpec(v Value) string {
+	if v.Kind() == String {
+		return v.Text()
+	}
+	for _, k := range [...]string{"UF", "F", "Unix", "DOS", "Mac"} {
+		if f := v.Key(k); f.Kind() == String {
+			return f.Text()
+		}
+	}
+	return ""
+}
+
+// pageNumber returns the number of the page object ptr.
+func (r *Reader) pageNumber(ptr types.Objptr) (int, bool) {
+	n := 0
+	seen := map[types.Objptr]bool{}
+	var walk func(node Value) bool
+	walk = func(node Value) bool {
+		if seen[node.ptr] {
+			return false
+		}
+		seen[node.ptr] = true
+		if node.Key("Type").Name() == "Page" {
+			n++
+			return node.ptr == ptr
+		}
+		kids := node.Key("Kids")
+		for i := range kids.Len() {
+			if walk(kids.Index(i)) {
+				return true
+			}
+		}
+		return false
+	}
+	if walk(r.trailerValue().Key("Root").Key("Pages")) {
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+)
+
+// The errors that the errors of reading a file wrap, to tell them apart with errors.Is.
+var (
+	// ErrUnsupportedFeature is the error of a file that uses a feature that the
+	// package does not support, such as a filter it does not decode.
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+	// ErrCorruptContent is the error of a content stream that cannot be interpreted.
+	ErrCorruptContent = errors.New("corrupt content")
+)
+
+// An UnsupportedError is the error of a feature that the package does not support.
+// It is ErrUnsupportedFeature, for errors.Is.
+type UnsupportedError struct {
+	// Feature is the feature, such as "filter LZWDecode".
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "unsupported " + e.Feature
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupportedFeature
+}
+
+// unsupported returns an *UnsupportedError of the feature given by format and args.
+func unsupported(format string, args ...any) error {
+	return &UnsupportedError{Feature: fmt.Sprintf(format, args...)}
+}
+
+// A ContentError is the error of interpreting a content stream, or another stream of
+// PostScript syntax, such as a CMap, that is malformed. It is ErrCorruptContent, for
+// errors.Is, and wraps the error of the stream, if any.
+type ContentError struct {
+	// Offset is the offset in the decoded data of the stream, or of the streams of
+	// the contents of a page, of the failure, or -1 if it is not known, as for the
+	// operations that a Page interprets again from those it keeps.
+	Offset int64
+	// Op is the operator being run, or "" if the stream failed to parse.
+	Op  string
+	Err error
+
+	stack []byte
+}
+
+func (e *ContentError) Error() string {
+	s := "corrupt content"
+	if e.Offset >= 0 {
+		s += fmt.Sprintf(" at offset %d", e.Offset)
+	}
+	if e.Op != "" {
+		s += fmt.Sprintf(" running %s", e.Op)
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *ContentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ContentError) Is(target error) bool {
+	return target == ErrCorruptContent
+}
+
+// Stack returns the stack trace of the panic of the code that the error comes
+// from, or nil if it comes from the data alone.
+func (e *ContentError) Stack() []byte {
+	return e.stack
+}
+
+// contentError returns the value r of a panic interpreting the operator op of a stream
+// at offset as a *ContentError, unless it is already one, or is not the fault of the
+// stream: the error of ctx, once it is done, an *UnmappedCodeError, or an error of
+// ErrUnsupportedFeature. A panic of the code keeps its stack trace, which is logged.
+func contentError(ctx context.Context, r any, offset int64, op string) any {
+	if ctx.Err() != nil {
+		return r
+	}
+	err, ok := r.(error)
+	switch {
+	case !ok:
+		err = fmt.Errorf("%v", r)
+	case errors.As(err, new(*ContentError)), errors.As(err, new(*UnmappedCodeError)), errors.Is(err, ErrUnsupportedFeature):
+		return r
+	}
+	e := &ContentError{Offset: offset, Op: op, Err: err}
+	if _, ok := r.(runtime.Error); ok {
+		e.stack = debug.Stack()
+		slog.Debug("panic interpreting content", slog.Any("err", err), slog.String("stack", string(e.stack)))
+	}
+	return e
+}
+
+// A PageError is the error of reading the text of a page, of the methods of Reader
+// that read the text of each page.
+type PageError struct {
+	Page int
+	Err  error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("failed to read text of page %d: %v", e.Page, e.Err)
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,88 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReader_Text_errors(t *testing.T) {
+	pages := func(content string, filter string) []byte {
+		return buildPDF(
+			"<</Type /Catalog /Pages 2 0 R>>",
+			"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+			"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 7 0 R>>>> /Contents 5 0 R>>",
+			"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 7 0 R>>>> /Contents 6 0 R>>",
+			stream("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"),
+			fmt.Sprintf("<</Length %d %s>>\nstream\n%s\nendstream", len(content), filter, content),
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		)
+	}
+
+	testCases := map[string]struct {
+		data        []byte
+		unsupported bool
+		corrupt     bool
+		offset      int64
+	}{
+		"empty page": {
+			data: pages("", ""),
+		},
+		"unsupported filter": {
+			data:        pages("BT /F1 12 Tf (World) Tj ET", "/Filter /LZWDecode"),
+			unsupported: true,
+		},
+		"malformed syntax": {
+			data:    pages("BT /F1 12 Tf ) (World) Tj ET", ""),
+			corrupt: true,
+			offset:  14,
+		},
+		"malformed PostScript": {
+			data:    pages("BT /F1 12 Tf end (World) Tj ET", ""),
+			corrupt: true,
+			offset:  16,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, tc.data)
+			_, err := r.Text()
+			if !tc.unsupported && !tc.corrupt {
+				if err != nil {
+					t.Fatal("failed to read text:", err)
+				}
+				return
+			}
+
+			var perr *PageError
+			if !errors.As(err, &perr) || perr.Page != 2 {
+				t.Fatalf("got error %v, want a *PageError of page 2", err)
+			}
+			if got := errors.Is(err, ErrUnsupportedFeature); got != tc.unsupported {
+				t.Errorf("got errors.Is(%v, ErrUnsupportedFeature) %v, want %v", err, got, tc.unsupported)
+			}
+			if got := errors.Is(err, ErrCorruptContent); got != tc.corrupt {
+				t.Errorf("got errors.Is(%v, ErrCorruptContent) %v, want %v", err, got, tc.corrupt)
+			}
+			if strings.Contains(err.Error(), "goroutine") {
+				t.Errorf("got error %q, want it without a stack trace", err)
+			}
+
+			// The error of the page alone is that of the Reader without the page number.
+			p, _ := r.GetPage(2)
+			if _, perr := p.Text(); perr == nil || !strings.HasSuffix(err.Error(), perr.Error()) {
+				t.Errorf("got error %v of the page, want that of %v", perr, err)
+			}
+
+			var cerr *ContentError
+			if errors.As(err, &cerr) != tc.corrupt {
+				t.Fatalf("got error %v, want a *ContentError %v", err, tc.corrupt)
+			}
+			if tc.corrupt && (cerr.Offset != tc.offset || cerr.Stack() != nil) {
+				t.Errorf("got error at offset %d, with stack %v, want one at offset %d, without", cerr.Offset, cerr.Stack() != nil, tc.offset)
+			}
+		})
+	}
+}
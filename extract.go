@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"context"
+	"io"
+
+	"github.com/ScriptRock/pdf/text"
+)
+
+// WalkText calls fn with the text of each page of the document, in order, as it is
+// read, keeping no more than the text of one page. If fn returns an error, WalkText
+// stops and returns it; if the text of a page fails to read, WalkText returns why,
+// as a *PageError.
+func (r *Reader) WalkText(fn func(page int, t text.Text) error) error {
+	return r.WalkTextContext(context.Background(), fn)
+}
+
+// WalkTextContext is like WalkText, but stops with the error of ctx once it is done.
+func (r *Reader) WalkTextContext(ctx context.Context, fn func(page int, t text.Text) error) error {
+	for i := range r.NPages() {
+		t, err := r.PageContext(ctx, i+1)
+		if err != nil {
+			return &PageError{Page: i + 1, Err: err}
+		}
+		if err := fn(i+1, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// An ExtractOption configures how ExtractText writes the text of a document.
+type ExtractOption func(*extractConfig)
+
+type extractConfig struct {
+	separator string
+}
+
+// WithPageSeparator sets the separator that ExtractText writes between the text of each
+// page and the next. The default is a form feed, "\f".
+func WithPageSeparator(sep string) ExtractOption {
+	return func(c *extractConfig) { c.separator = sep }
+}
+
+// ExtractText writes the plain text of each page of the document to w, as it is read,
+// separated by a form feed or the separator set with WithPageSeparator. Unlike Text,
+// it keeps no more than the text of one page. It stops at the first error of w.
+func (r *Reader) ExtractText(w io.Writer, opts ...ExtractOption) error {
+	return r.ExtractTextContext(context.Background(), w, opts...)
+}
+
+// ExtractTextContext is like ExtractText, but stops with the error of ctx once it is done.
+func (r *Reader) ExtractTextContext(ctx context.Context, w io.Writer, opts ...ExtractOption) error {
+	c := extractConfig{separator: "\f"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return r.WalkTextContext(ctx, func(page int, t text.Text) error {
+		if page > 1 {
+			if _, err := io.WriteString(w, c.separator); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, t.String())
+		return err
+	})
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ScriptRock/pdf/text"
+)
+
+// threePagePDF returns a PDF of three pages, showing "one", "two" and "three".
+func threePagePDF() []byte {
+	return buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /Resources <</Font <</F1 9 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /Contents 6 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Contents 7 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Contents 8 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (one) Tj ET"),
+		stream("BT /F1 12 Tf 72 720 Td (two) Tj ET"),
+		stream("BT /F1 12 Tf 72 720 Td (three) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	)
+}
+
+func TestReader_ExtractText(t *testing.T) {
+	r := openPDF(t, threePagePDF())
+
+	var b strings.Builder
+	if err := r.ExtractText(&b); err != nil {
+		t.Fatal("failed to extract text:", err)
+	}
+	if want := "one\ftwo\fthree"; b.String() != want {
+		t.Errorf("got text %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	if err := r.ExtractText(&b, WithPageSeparator("\n---\n")); err != nil {
+		t.Fatal("failed to extract text:", err)
+	}
+	if want := "one\n---\ntwo\n---\nthree"; b.String() != want {
+		t.Errorf("got text %q, want %q", b.String(), want)
+	}
+}
+
+func TestReader_WalkText(t *testing.T) {
+	r := openPDF(t, threePagePDF())
+
+	var pages []int
+	var texts []string
+	stop := errors.New("stop")
+	err := r.WalkText(func(page int, txt text.Text) error {
+		pages = append(pages, page)
+		texts = append(texts, txt.String())
+		if page == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("got error %v, want the error of the callback", err)
+	}
+	if diff := cmp.Diff([]int{1, 2}, pages); diff != "" {
+		t.Errorf("pages mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"one", "two"}, texts); diff != "" {
+		t.Errorf("texts mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,34 +1,193 @@
 package pdf
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"log/slog"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/sfnt"
 
 	"github.com/ScriptRock/pdf/internal/encoding"
 )
 
-func newFont(v value) *font {
-	return &font{
-		name:    v.Key("BaseFont").Name(),
-		decoder: getDecoder(v),
+// newFont returns the font of the font dictionary v, or an error if its encoding
+// cannot be read, with the font decoding its text as PDFDocEncoding.
+func newFont(ctx context.Context, v Value) (f *font, err error) {
+	f = &font{
+		name:     v.Key("BaseFont").Name(),
+		vertical: isVertical(v),
+		space:    spaceWidth(v),
 	}
+	f.ascent, f.descent = fontMetrics(v)
+	defer func() {
+		if err != nil {
+			f.decoder = encoding.PDFDoc(getWidths(v))
+		}
+		if c, ok := f.decoder.(interface{ Count(*encoding.Stats) }); ok {
+			c.Count(&f.stats)
+		}
+	}()
+	defer catch(&err)
+	f.decoder, err = getDecoder(ctx, v)
+	return f, err
+}
+
+// An UnmappedCodeError is the error of text extraction with UnmappedError, for
+// a character code shown in a font that has no text for it.
+type UnmappedCodeError struct {
+	// Font is the name of the font, its BaseFont.
+	Font string
+	// Code is the character code, of one or more bytes.
+	Code string
+	// Page is the number of the page that shows the code.
+	Page int
+}
+
+func (e *UnmappedCodeError) Error() string {
+	return fmt.Sprintf("page %d: font %s has no text for code <%x>", e.Page, e.Font, e.Code)
 }
 
 // A font represent a font in a PDF file.
 // The methods interpret a font dictionary stored in V.
 type font struct {
 	decoder
-	name string
+	name     string
+	vertical bool
+	space    float64
+	// ascent and descent are the extent of the glyphs above and below the baseline,
+	// in glyph space units, or zeros if they are unknown.
+	ascent, descent float64
+	// stats counts the codes decoded by the font, if its decoder counts them.
+	stats encoding.Stats
+}
+
+// setUnmapped makes the font, shown on page, extract the codes that have no text as
+// policy has it, if its decoder lets it.
+func (f *font) setUnmapped(policy UnmappedPolicy, page int) {
+	u, ok := f.decoder.(interface {
+		Unmapped(func(code string) string)
+	})
+	if !ok || policy == UnmappedReplace {
+		return
+	}
+	u.Unmapped(func(code string) string {
+		switch policy {
+		case UnmappedDrop:
+			return ""
+		case UnmappedHex:
+			return fmt.Sprintf("\uFFFD<%x>", code)
+		}
+		panic(&UnmappedCodeError{Font: f.name, Code: code, Page: page})
+	})
 }
 
 // BaseFont returns the font's name (BaseFont property).
 func (f font) Name() string { return f.name }
 
-func getWidths(v value) widths {
+// Vertical reports whether the font is in vertical writing mode, in which
+// the widths that Decode returns are vertical displacements.
+func (f font) Vertical() bool { return f.vertical }
+
+// SpaceWidth returns the width of the font's space character, or zero if it is unknown.
+func (f font) SpaceWidth() float64 { return f.space }
+
+// Metrics returns the ascent and descent of the font, or zeros if they are unknown.
+func (f font) Metrics() (ascent, descent float64) { return f.ascent, f.descent }
+
+// fontMetrics returns the ascent and descent of the font v, from its font descriptor,
+// with its CapHeight standing in for a missing Ascent, or else those of the standard
+// font of its name, or zeros if they are unknown. See PDF 32000-1:2008, §9.8.1.
+func fontMetrics(v Value) (ascent, descent float64) {
+	desc := v.Key("FontDescriptor")
+	if v.Key("Subtype").Name() == "Type0" {
+		desc = v.Key("DescendantFonts").Index(0).Key("FontDescriptor")
+	}
+	ascent, descent = desc.Key("Ascent").Float64(), desc.Key("Descent").Float64()
+	if ascent == 0 {
+		ascent = desc.Key("CapHeight").Float64()
+	}
+	if ascent == 0 && descent == 0 {
+		ascent, descent, _ = standardMetrics(v.Key("BaseFont").Name())
+	}
+	return ascent, descent
+}
+
+// spaceWidth returns the width of the space character of the simple font v, whose
+// code is that of ASCII, or zero if it is unknown.
+func spaceWidth(v Value) float64 {
+	if v.Key("Subtype").Name() == "Type0" {
+		return 0
+	}
+	return getWidths(v).CodeWidth(' ')
+}
+
+// isVertical reports whether the font v is a composite font in vertical writing
+// mode: whether its CMap has a WMode of 1, as the predefined CMaps whose names
+// end in -V do. See PDF 32000-1:2008, §9.7.5.
+func isVertical(v Value) bool {
+	if v.Key("Subtype").Name() != "Type0" {
+		return false
+	}
+	switch enc := v.Key("Encoding"); enc.Kind() {
+	case Name:
+		return strings.HasSuffix(enc.Name(), "-V")
+	case Stream:
+		return enc.Key("WMode").Int64() == 1
+	}
+	return false
+}
+
+// getVerticalWidths returns the vertical displacements (w1y) of the glyphs of the
+// composite font v, from its descendant font's W2 and DW2. See PDF 32000-1:2008, §9.7.4.3.
+func getVerticalWidths(v Value) widths {
+	cid := v.Key("DescendantFonts").Index(0)
+
+	dw := -1000.0
+	if dw2 := cid.Key("DW2"); dw2.Len() == 2 {
+		dw = dw2.Index(1).Float64()
+	}
+
+	w2 := cid.Key("W2")
+	var spans []span
+	for i := 1; i < w2.Len(); {
+		s := span{first: int(w2.Index(i - 1).Int64())}
+		switch w2.Index(i).Kind() {
+		case Integer:
+			// cfirst clast w1y v1x v1y
+			s.last = int(w2.Index(i).Int64())
+			s.fixed = w2.Index(i + 1).Float64()
+			i += 5
+		case Array:
+			// c [w1y v1x v1y ...]
+			vs := w2.Index(i)
+			for j := 0; j+2 < vs.Len(); j += 3 {
+				s.linear = append(s.linear, vs.Index(j).Float64())
+			}
+			s.last = s.first + len(s.linear) - 1
+			i += 2
+		default:
+			v.r.warn(FontWarning, v.ptr, 0, "malformed W2 %v", w2)
+			return widths{defaultW: dw, spans: spans}
+		}
+		spans = append(spans, s)
+	}
+	return widths{defaultW: dw, spans: spans}
+}
+
+func getWidths(v Value) widths {
 	switch v.Key("Subtype").String() {
 	case "/Type0":
 		return getWidths(v.Key("DescendantFonts").Index(0))
 	case "/CIDFontType0", "/CIDFontType2":
-		dw := v.Key("DW").Float64()
+		// The default width of CIDFonts is 1000. See PDF 32000-1:2008, §9.7.4.3.
+		dw := 1000.0
+		if d := v.Key("DW"); d.Kind() == Integer || d.Kind() == Real {
+			dw = d.Float64()
+		}
 
 		ww := v.Key("W")
 
@@ -36,14 +195,16 @@ func getWidths(v value) widths {
 		i := 1
 		for i < ww.Len() {
 			span := span{
-				first: int(ww.Index(i - 1).Int64()),
+				first: int(ww.Index(i - 1).Float64()),
 			}
 			switch ww.Index(i).Kind() {
-			case integerKind:
-				span.last = int(ww.Index(i).Int64())
+			case Integer, Real:
+				// cfirst clast w
+				span.last = int(ww.Index(i).Float64())
 				span.fixed = ww.Index(i + 1).Float64()
 				i += 3
-			case arrayKind:
+			case Array:
+				// c [w1 w2 ...]
 				values := ww.Index(i)
 				span.last = span.first + values.Len() - 1
 				span.linear = make([]float64, values.Len())
@@ -52,93 +213,325 @@ func getWidths(v value) widths {
 				}
 				i += 2
 			default:
-				panic("bad w:" + ww.String())
+				v.r.warn(FontWarning, v.ptr, 0, "malformed W %v", ww)
+				return widths{defaultW: dw, spans: spans}
 			}
 			spans = append(spans, span)
 		}
 
 		return widths{defaultW: dw, spans: spans}
 	default:
-		dw := v.Key("FontDescriptor").Key("MissingWidth").Float64()
+		// Codes without widths of their own have the MissingWidth of the font, or
+		// else its AvgWidth. The codes of fonts without any Widths, as the standard
+		// fonts may be, have the width of their glyph in the standard font of the
+		// same name, if there is one.
+		desc := v.Key("FontDescriptor")
+		dw := desc.Key("MissingWidth").Float64()
+		if dw == 0 {
+			dw = desc.Key("AvgWidth").Float64()
+		}
 
-		ww := v.Key("Widths")
-		s := span{
-			first:  int(v.Key("FirstChar").Int64()),
-			last:   int(v.Key("LastChar").Int64()),
-			linear: make([]float64, ww.Len()),
+		var spans []span
+		if ww := v.Key("Widths"); ww.Len() > 0 {
+			s := span{
+				first:  int(v.Key("FirstChar").Int64()),
+				last:   int(v.Key("LastChar").Int64()),
+				linear: make([]float64, ww.Len()),
+			}
+			for i := 0; i < ww.Len(); i++ {
+				s.linear[i] = ww.Index(i).Float64()
+			}
+			spans = append(spans, s)
 		}
-		for i := 0; i < ww.Len(); i++ {
-			s.linear[i] = ww.Index(i).Float64()
+		if len(spans) == 0 {
+			if s, ok := standardWidths(v.Key("BaseFont").Name()); ok {
+				spans = append(spans, s)
+			}
 		}
 
-		return widths{defaultW: dw, spans: []span{s}}
+		return widths{defaultW: dw, spans: spans}
 	}
 }
 
 // See Table 112: Entries in an encoding dictionary.
-func getDifferences(v value) map[byte]string {
+func getDifferences(v Value) (map[byte]string, error) {
 	dd := map[byte]string{}
 	diffs := v.Key("Differences")
 
 	var c int = -1
 	for i := range diffs.Len() {
 		switch e := diffs.Index(i); e.Kind() {
-		case integerKind:
+		case Integer:
 			c = int(e.Int64())
-		case nameKind:
+		case Name:
 			if c < 0 || c > 255 {
-				panic("bad differences array:" + v.String())
+				return nil, fmt.Errorf("bad Differences code %d in %v", c, diffs)
 			}
 			dd[byte(c)] = e.String()[1:]
 			c++
 		default:
-			panic("bad differences array:" + v.String())
+			return nil, fmt.Errorf("bad Differences element %v in %v", e, diffs)
+		}
+	}
+
+	return dd, nil
+}
+
+// nameGlyphIDs renames the glyphs of diffs that are named by their glyph index, as the
+// g123 of subset fonts, with the uXXXX names of the runes that the cmap of the embedded
+// TrueType or OpenType font program of v maps to them, if it has one.
+func nameGlyphIDs(v Value, diffs map[byte]string) {
+	var runes map[sfnt.GlyphIndex]rune
+	for code, name := range diffs {
+		if _, ok := encoding.GlyphText(name); ok {
+			continue
+		}
+		gid, ok := encoding.GlyphID(name)
+		if !ok {
+			continue
+		}
+		if runes == nil {
+			runes = glyphRunes(v)
+		}
+		if r, ok := runes[sfnt.GlyphIndex(gid)]; ok {
+			diffs[code] = fmt.Sprintf("u%04X", r)
 		}
 	}
+}
 
-	return dd
+// glyphRunes returns the runes of the glyphs of the embedded font program of v,
+// by the font's cmap, except for those of the Private Use Area, as the cmaps
+// of symbolic fonts map codes to.
+func glyphRunes(v Value) map[sfnt.GlyphIndex]rune {
+	runes := map[sfnt.GlyphIndex]rune{}
+	rc, err := describeFont(v).Open()
+	if err != nil {
+		return runes
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		v.r.warn(FontWarning, v.ptr, 0, "failed to read font program: %v", err)
+		return runes
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		v.r.warn(FontWarning, v.ptr, 0, "failed to parse font program: %v", err)
+		return runes
+	}
+
+	var buf sfnt.Buffer
+	for r := range rune(0x10000) {
+		if r >= 0xe000 && r <= 0xf8ff {
+			continue
+		}
+		if gid, err := f.GlyphIndex(&buf, r); err == nil && gid != 0 {
+			if _, ok := runes[gid]; !ok {
+				runes[gid] = r
+			}
+		}
+	}
+	return runes
 }
 
-func getDecoder(v value) decoder {
+func getDecoder(ctx context.Context, v Value) (decoder, error) {
 	widths := getWidths(v)
+	if isVertical(v) {
+		widths = getVerticalWidths(v)
+	}
 
 	switch enc := v.Key("Encoding"); enc.Kind() {
-	case nameKind:
+	case Name:
 		switch enc.Name() {
 		case "WinAnsiEncoding":
-			return encoding.WinANSI(widths, nil)
+			return encoding.WinANSI(widths, nil), nil
 		case "MacRomanEncoding":
-			return encoding.MacRoman(widths, nil)
+			return encoding.MacRoman(widths, nil), nil
+		case "StandardEncoding":
+			return encoding.Standard(widths, nil), nil
+		case "Identity-H", "Identity-V":
+			if v.Key("Subtype").Name() == "Type0" {
+				return cidEncoding(ctx, v, widths)
+			}
 		}
-	case dictKind:
+	case Stream:
+		if v.Key("Subtype").Name() == "Type0" {
+			return cidEncoding(ctx, v, widths)
+		}
+	case Dict:
 		// See 9.6.5 Character encoding.
-		diffs := getDifferences(enc)
+		diffs, err := getDifferences(enc)
+		if err != nil {
+			return nil, err
+		}
+		nameGlyphIDs(v, diffs)
 		switch enc.Key("BaseEncoding").Name() {
 		case "WinAnsiEncoding":
-			return encoding.WinANSI(widths, diffs)
+			return encoding.WinANSI(widths, diffs), nil
 		case "MacRomanEncoding":
-			return encoding.MacRoman(widths, diffs)
+			return encoding.MacRoman(widths, diffs), nil
+		case "StandardEncoding":
+			return encoding.Standard(widths, diffs), nil
 		case "Identity-H":
-			return charmapEncoding(v, widths)
+			return charmapEncoding(ctx, v, widths)
+		default:
+			// Without a base encoding, the differences are from the font's built-in
+			// encoding, which is StandardEncoding for most fonts, unless a ToUnicode
+			// map gives the text of each code.
+			if v.Key("ToUnicode").Kind() != Stream {
+				return encoding.Standard(widths, diffs), nil
+			}
 		}
 	}
 
 	if toUnicode := v.Key("ToUnicode"); !toUnicode.IsNull() {
-		return charmapEncoding(toUnicode, widths)
+		return charmapEncoding(ctx, toUnicode, widths)
 	}
+	if v.Key("Encoding").IsNull() && isNonsymbolic(v) {
+		// The built-in encoding of a nonsymbolic simple font, as of the standard fonts
+		// other than Symbol and ZapfDingbats, is StandardEncoding.
+		return encoding.Standard(widths, nil), nil
+	}
+
+	return nil, unsupported("encoding %v of font %s", v.Key("Encoding"), v.Key("BaseFont").Name())
+}
+
+// symbolicFlag is the Symbolic flag of the Flags of a font descriptor, set for fonts
+// with glyphs outside the standard Latin character set. See PDF 32000-1:2008, §9.8.2.
+const symbolicFlag = 1 << 2
+
+// isNonsymbolic reports whether v is a simple font of Type1, MMType1 or TrueType whose
+// glyphs are in the standard Latin character set: by the Symbolic flag of its font
+// descriptor, or, for a standard font without one, by its name.
+func isNonsymbolic(v Value) bool {
+	switch v.Key("Subtype").Name() {
+	case "Type1", "MMType1", "TrueType":
+	default:
+		return false
+	}
+	if flags := v.Key("FontDescriptor").Key("Flags"); flags.Kind() == Integer {
+		return flags.Int64()&symbolicFlag == 0
+	}
+	switch v.Key("BaseFont").Name() {
+	case "Symbol", "ZapfDingbats":
+		return false
+	}
+	return true
+}
+
+func charmapEncoding(ctx context.Context, toUnicode Value, widths widths) (decoder, error) {
+	if toUnicode.Kind() != Stream {
+		return encoding.PDFDoc(widths), nil
+	}
+	m := &encoding.CMap{Widths: widths}
+	if err := parseCMap(ctx, toUnicode, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// cidEncoding returns the decoder of the composite font v whose Encoding is an embedded
+// CMap, which maps its codes to CIDs, or the Identity-H or Identity-V CMap. The text of
+// the codes is given by the ToUnicode CMap of the font, if it has one, or else by the
+// embedded font program. The codespace of the font is that of its Encoding.
+// See PDF 32000-1:2008, §9.7.6.
+func cidEncoding(ctx context.Context, v Value, widths widths) (decoder, error) {
+	m := &encoding.CMap{Widths: widths, CIDText: cidText(v)}
+	if enc := v.Key("Encoding"); enc.Kind() == Stream {
+		useCMap(m, enc.Key("UseCMap").Name())
+		if err := parseCMap(ctx, enc, m); err != nil {
+			return nil, err
+		}
+	} else {
+		useCMap(m, enc.Name())
+	}
+	if toUnicode := v.Key("ToUnicode"); toUnicode.Kind() == Stream {
+		var text encoding.CMap
+		if err := parseCMap(ctx, toUnicode, &text); err != nil {
+			return nil, err
+		}
+		m.BFChars, m.BFRanges = text.BFChars, text.BFRanges
+		if !m.HasCodespace() {
+			// The CMap is based on one that is not known.
+			m.Space = text.Space
+		}
+	}
+	return m, nil
+}
 
-	panic("unsupported encoding: " + v.String())
+// cidText returns the text of the CIDs of the composite font v by the cmap of its
+// embedded TrueType font program, whose glyphs are those of the CIDs by the CIDToGIDMap
+// of its CIDFont, or nil if it has no such font program. The font program and the map
+// are read when the text of a CID is first needed. See PDF 32000-1:2008, §9.7.4.2.
+func cidText(v Value) func(cid int) (string, bool) {
+	cidFont := v.Key("DescendantFonts").Index(0)
+	if cidFont.Key("Subtype").Name() != "CIDFontType2" || describeFont(v).FileType != "FontFile2" {
+		return nil
+	}
+	type glyphs struct {
+		// gids are the glyphs of CIDs by the CIDToGIDMap, or nil if it is Identity.
+		gids  []uint16
+		runes map[sfnt.GlyphIndex]rune
+	}
+	load := sync.OnceValue(func() glyphs {
+		g := glyphs{runes: glyphRunes(v)}
+		if m := cidFont.Key("CIDToGIDMap"); m.Kind() == Stream {
+			rc := m.Reader()
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				v.r.warn(FontWarning, v.ptr, 0, "failed to read CIDToGIDMap: %v", err)
+			}
+			g.gids = make([]uint16, len(data)/2)
+			for i := range g.gids {
+				g.gids[i] = binary.BigEndian.Uint16(data[2*i:])
+			}
+		}
+		return g
+	})
+	return func(cid int) (string, bool) {
+		g := load()
+		gid := cid
+		if g.gids != nil {
+			if cid < 0 || cid >= len(g.gids) {
+				return "", false
+			}
+			gid = int(g.gids[cid])
+		}
+		r, ok := g.runes[sfnt.GlyphIndex(gid)]
+		if !ok {
+			return "", false
+		}
+		return string(r), true
+	}
 }
 
-func charmapEncoding(toUnicode value, widths widths) decoder {
-	if toUnicode.Kind() != streamKind {
-		return encoding.PDFDoc(widths)
+// useCMap adds to m the mappings of the predefined CMap named name, on which it is
+// based, if it is known: only the Identity-H and Identity-V CMaps, of two-byte codes
+// that are their CIDs, are. See PDF 32000-1:2008, §9.7.5.2.
+func useCMap(m *encoding.CMap, name string) {
+	switch name {
+	case "Identity-H", "Identity-V":
+		m.Space[1] = append(m.Space[1], encoding.ByteRange{Lo: "\x00\x00", Hi: "\xff\xff"})
+		m.CIDRanges = append(m.CIDRanges, encoding.CIDRange{Lo: "\x00\x00", Hi: "\xff\xff"})
 	}
+}
+
+// cmapOperands are the numbers of operands of the operators of CMaps that are not read,
+// so that they can be popped. The operands of the operators that end a list of
+// mappings are counted per mapping. See Adobe Technical Note #5014.
+var cmapOperands = map[string]int{
+	"usefont":        1,
+	"endnotdefchar":  2,
+	"endnotdefrange": 3,
+	"endusematrix":   1,
+}
 
+// parseCMap reads the mappings of the CMap stream s into m.
+func parseCMap(ctx context.Context, s Value, m *encoding.CMap) error {
 	n := -1
-	m := encoding.CMap{Widths: widths}
 	ok := true
-	interpret(toUnicode.Reader(), func(stk *stack, op string) {
+	interpret(ctx, s.Reader(), func(stk *stack, op string) {
 		if !ok {
 			return
 		}
@@ -151,7 +544,8 @@ func charmapEncoding(toUnicode value, widths widths) decoder {
 			stk.Push(newDict())
 		case "endcmap":
 			stk.Pop()
-		case "begincodespacerange":
+		case "begincodespacerange", "beginbfchar", "beginbfrange", "begincidchar", "begincidrange",
+			"beginnotdefchar", "beginnotdefrange", "beginusematrix":
 			n = int(stk.Pop().Int64())
 		case "endcodespacerange":
 			if n < 0 {
@@ -161,7 +555,7 @@ func charmapEncoding(toUnicode value, widths widths) decoder {
 			}
 			for i := 0; i < n; i++ {
 				hi, lo := stk.Pop().RawString(), stk.Pop().RawString()
-				if len(lo) == 0 || len(lo) != len(hi) {
+				if len(lo) == 0 || len(lo) > 4 || len(lo) != len(hi) {
 					slog.Debug("bad codespace range", slog.String("lo", lo), slog.String("hi", hi))
 					ok = false
 					return
@@ -169,46 +563,84 @@ func charmapEncoding(toUnicode value, widths widths) decoder {
 				m.Space[len(lo)-1] = append(m.Space[len(lo)-1], encoding.ByteRange{Lo: lo, Hi: hi})
 			}
 			n = -1
-		case "beginbfchar":
-			n = int(stk.Pop().Int64())
 		case "endbfchar":
 			if n < 0 {
-				panic("missing beginbfchar")
+				slog.Debug("missing beginbfchar")
+				ok = false
+				return
 			}
 			for i := 0; i < n; i++ {
 				repl, orig := stk.Pop().RawString(), stk.Pop().RawString()
 				m.BFChars = append(m.BFChars, encoding.BFChar{Orig: orig, Repl: repl})
 			}
-		case "beginbfrange":
-			n = int(stk.Pop().Int64())
+			n = -1
 		case "endbfrange":
 			if n < 0 {
-				panic("missing beginbfrange")
+				slog.Debug("missing beginbfrange")
+				ok = false
+				return
 			}
 			for i := 0; i < n; i++ {
 				dst, srcHi, srcLo := stk.Pop(), stk.Pop().RawString(), stk.Pop().RawString()
 				bfr := encoding.BFRange{Lo: srcLo, Hi: srcHi}
 				switch dst.Kind() {
-				case stringKind:
+				case String:
 					bfr.DstS = dst.RawString()
-				case arrayKind:
-					bfr.DstA = dst.RawElements(stringKind)
+				case Array:
+					bfr.DstA = dst.RawElements(String)
 				}
 				m.BFRanges = append(m.BFRanges, bfr)
 			}
+			n = -1
+		case "endcidchar":
+			if n < 0 {
+				slog.Debug("missing begincidchar")
+				ok = false
+				return
+			}
+			for i := 0; i < n; i++ {
+				cid, code := stk.Pop().Int64(), stk.Pop().RawString()
+				m.CIDChars = append(m.CIDChars, encoding.CIDChar{Code: code, CID: int(cid)})
+			}
+			n = -1
+		case "endcidrange":
+			if n < 0 {
+				slog.Debug("missing begincidrange")
+				ok = false
+				return
+			}
+			for i := 0; i < n; i++ {
+				cid, hi, lo := stk.Pop().Int64(), stk.Pop().RawString(), stk.Pop().RawString()
+				m.CIDRanges = append(m.CIDRanges, encoding.CIDRange{Lo: lo, Hi: hi, CID: int(cid)})
+			}
+			n = -1
+		case "usecmap":
+			useCMap(m, stk.Pop().Name())
 		case "defineresource":
 			stk.Pop().Name() // category
-			value := stk.Pop()
+			v := stk.Pop()
 			stk.Pop().Name() // key
-			stk.Push(value)
+			stk.Push(v)
 		default:
-			slog.Debug("unhandled op", slog.String("op", op))
+			count, known := cmapOperands[op]
+			if !known {
+				slog.Debug("unhandled op", slog.String("op", op))
+				return
+			}
+			if strings.HasPrefix(op, "end") {
+				// The operands of the mappings, counted at the operator that began them.
+				count *= max(n, 0)
+				n = -1
+			}
+			for range count {
+				stk.Pop()
+			}
 		}
 	})
 	if !ok {
-		panic("bad ToUnicode stream: " + toUnicode.String())
+		return fmt.Errorf("bad CMap stream %v", s)
 	}
-	return &m
+	return nil
 }
 
 type widths struct {
@@ -222,12 +654,25 @@ type span struct {
 	linear      []float64
 }
 
+// IsDefault reports whether the code has no width of its own, and has the default width.
+func (w widths) IsDefault(code int) bool {
+	for _, s := range w.spans {
+		if code >= s.first && code <= s.last {
+			return len(s.linear) > 0 && code-s.first >= len(s.linear)
+		}
+	}
+	return true
+}
+
 func (w widths) CodeWidth(code int) float64 {
 	for _, s := range w.spans {
 		if code >= s.first && code <= s.last {
 			switch {
 			case len(s.linear) > 0:
-				return s.linear[code-s.first]
+				if i := code - s.first; i < len(s.linear) {
+					return s.linear[i]
+				}
+				return w.defaultW
 			default:
 				return s.fixed
 			}
@@ -0,0 +1,62 @@
+package pdf
+
+import "testing"
+
+func TestGetWidths(t *testing.T) {
+	testCases := map[string]struct {
+		font string
+		code int
+		want float64
+	}{
+		"Widths": {
+			font: "<</Type /Font /Subtype /TrueType /BaseFont /Arial /FirstChar 65 /LastChar 66 /Widths [700 600]>>",
+			code: 'B', want: 600,
+		},
+		"MissingWidth": {
+			font: "<</Type /Font /Subtype /TrueType /BaseFont /Arial /FirstChar 65 /LastChar 66 /Widths [700 600] " +
+				"/FontDescriptor <</MissingWidth 250 /AvgWidth 400>>>>",
+			code: 'C', want: 250,
+		},
+		"AvgWidth": {
+			font: "<</Type /Font /Subtype /TrueType /BaseFont /Arial /FirstChar 65 /LastChar 66 /Widths [700 600] " +
+				"/FontDescriptor <</AvgWidth 400>>>>",
+			code: 'C', want: 400,
+		},
+		"standard font": {
+			font: "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold>>",
+			code: 'm', want: 833,
+		},
+		"standard font subset": {
+			font: "<</Type /Font /Subtype /TrueType /BaseFont /ABCDEF+TimesNewRomanPSMT>>",
+			code: ' ', want: 250,
+		},
+		"W with indirect elements": {
+			font: "<</Type /Font /Subtype /Type0 /BaseFont /Noto /Encoding /Identity-H " +
+				"/DescendantFonts [<</Type /Font /Subtype /CIDFontType2 /W [10 3 0 R 20 30 500]>>]>>",
+			code: 11, want: 300,
+		},
+		"W without DW": {
+			font: "<</Type /Font /Subtype /Type0 /BaseFont /Noto /Encoding /Identity-H " +
+				"/DescendantFonts [<</Type /Font /Subtype /CIDFontType2 /W [10 3 0 R 20 30 500]>>]>>",
+			code: 40, want: 1000,
+		},
+		"malformed W": {
+			font: "<</Type /Font /Subtype /Type0 /BaseFont /Noto /Encoding /Identity-H " +
+				"/DescendantFonts [<</Type /Font /Subtype /CIDFontType2 /DW 400 /W [10 [200] 20 (x)]>>]>>",
+			code: 20, want: 400,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF("<</Type /Catalog>>", tc.font, "[200 300]"))
+			font, err := r.Object(2, 0)
+			if err != nil {
+				t.Fatal("failed to read font:", err)
+			}
+			if got := getWidths(font).CodeWidth(tc.code); got != tc.want {
+				t.Errorf("got width %v of code %d, want %v", got, tc.code, tc.want)
+			}
+		})
+	}
+}
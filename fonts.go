@@ -0,0 +1,131 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A Font describes a font used by the pages of a document, and its embedded font program.
+type Font struct {
+	// BaseFont is the PostScript name of the font, without any subset prefix.
+	BaseFont string
+	// Subset is the tag of a font subset, such as "EOODIA" of "EOODIA+Poetica",
+	// or "" if the font is not a subset. See PDF 32000-1:2008, §9.6.4.
+	Subset string
+	// Subtype is the type of the font: Type1, MMType1, TrueType, Type3 or Type0.
+	Subtype string
+
+	// FileType is the key of the font program in the font descriptor: FontFile
+	// for Type 1, FontFile2 for TrueType or FontFile3 for the programs of the type
+	// FileSubtype, such as Type1C or OpenType; or "" if the font is not embedded.
+	// See PDF 32000-1:2008, §9.9.
+	FileType    string
+	FileSubtype string
+	// Length1, Length2 and Length3 are the lengths of the parts of a Type 1 font
+	// program, in clear text, encrypted and in the fixed-content trailer, or the
+	// length of a TrueType font program in Length1, as the font file declares them.
+	Length1, Length2, Length3 int64
+
+	// ID and Gen are the object number and generation of the font dictionary,
+	// as for Reader.Object, or zero if it is not an indirect object.
+	ID  uint32
+	Gen uint16
+
+	file Value
+}
+
+// Embedded reports whether the font program is embedded in the document.
+func (f Font) Embedded() bool { return f.FileType != "" }
+
+// Open returns the decoded data of the embedded font program.
+func (f Font) Open() (io.ReadCloser, error) {
+	if !f.Embedded() {
+		return nil, fmt.Errorf("font %s is not embedded", f.BaseFont)
+	}
+	return f.file.Reader(), nil
+}
+
+// Fonts returns the fonts used by the page, in the order of their resource names.
+func (p *Page) Fonts() []Font {
+	return p.appendFonts(nil, map[types.Objptr]bool{})
+}
+
+// Fonts returns the fonts used by the pages of the document, in page order.
+// A font used by several pages is listed once.
+func (r *Reader) Fonts() []Font {
+	var fonts []Font
+	seen := map[types.Objptr]bool{}
+	for i := range r.NPages() {
+		p, err := r.GetPage(i + 1)
+		if err != nil {
+			continue
+		}
+		fonts = p.appendFonts(fonts, seen)
+	}
+	return fonts
+}
+
+// appendFonts appends to fonts those of the page that are not seen already.
+func (p *Page) appendFonts(fonts []Font, seen map[types.Objptr]bool) []Font {
+	res := p.resources()
+	for _, name := range res.names("Font") {
+		d := res.dict("Font", name)
+		refs, _ := d.data.(types.Dict)
+		ref, indirect := refs[types.Name(name)].(types.Objptr)
+		if indirect {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+		}
+		if v := d.Key(name); v.Kind() == Dict {
+			f := describeFont(v)
+			if indirect {
+				f.ID, f.Gen = ref.ID, ref.Gen
+			}
+			fonts = append(fonts, f)
+		}
+	}
+	return fonts
+}
+
+// describeFont describes the font dictionary v. The font descriptor of a composite
+// font is that of its descendant CIDFont. See PDF 32000-1:2008, §9.7.6.
+func describeFont(v Value) Font {
+	f := Font{BaseFont: v.Key("BaseFont").Name(), Subtype: v.Key("Subtype").Name()}
+	if tag, name, ok := strings.Cut(f.BaseFont, "+"); ok && isSubsetTag(tag) {
+		f.Subset, f.BaseFont = tag, name
+	}
+
+	desc := v.Key("FontDescriptor")
+	if f.Subtype == "Type0" {
+		desc = v.Key("DescendantFonts").Index(0).Key("FontDescriptor")
+	}
+	for _, key := range [...]string{"FontFile", "FontFile2", "FontFile3"} {
+		if file := desc.Key(key); file.Kind() == Stream {
+			f.FileType, f.file = key, file
+			f.FileSubtype = file.Key("Subtype").Name()
+			f.Length1 = file.Key("Length1").Int64()
+			f.Length2 = file.Key("Length2").Int64()
+			f.Length3 = file.Key("Length3").Int64()
+			break
+		}
+	}
+	return f
+}
+
+// isSubsetTag reports whether tag is the tag of a font subset: six uppercase letters.
+func isSubsetTag(tag string) bool {
+	if len(tag) != 6 {
+		return false
+	}
+	for _, c := range []byte(tag) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
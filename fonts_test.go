@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestReader_Fonts(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R /F2 6 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R /F3 8 0 R /F4 "+
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>>>>>>>",
+		"<</Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Arial /FontDescriptor 7 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Times-Roman>>",
+		"<</Type /FontDescriptor /FontName /ABCDEF+Arial /FontFile2 9 0 R>>",
+		"<</Type /Font /Subtype /Type0 /BaseFont /XYZABC+NotoSans-Identity-H /DescendantFonts "+
+			"[<</Type /Font /Subtype /CIDFontType2 /FontDescriptor <</FontFile3 10 0 R>>>>]>>",
+		"<</Length 8 /Length1 8>>\nstream\ntruetype\nendstream",
+		"<</Length 8 /Subtype /OpenType>>\nstream\nopentype\nendstream",
+	))
+
+	want := []Font{
+		{BaseFont: "Arial", Subset: "ABCDEF", Subtype: "TrueType", FileType: "FontFile2", Length1: 8, ID: 5},
+		{BaseFont: "Times-Roman", Subtype: "Type1", ID: 6},
+		{BaseFont: "NotoSans-Identity-H", Subset: "XYZABC", Subtype: "Type0", FileType: "FontFile3", FileSubtype: "OpenType", ID: 8},
+		{BaseFont: "Helvetica", Subtype: "Type1"},
+	}
+	data := []string{"truetype", "", "opentype", ""}
+
+	fonts := r.Fonts()
+	if diff := cmp.Diff(want, fonts, cmpopts.IgnoreUnexported(Font{})); diff != "" {
+		t.Fatalf("Fonts() mismatch (-want +got):\n%s", diff)
+	}
+	for i, f := range fonts {
+		rc, err := f.Open()
+		if !f.Embedded() {
+			if err == nil {
+				t.Errorf("Open() of %s: got no error for a font that is not embedded", f.BaseFont)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Open() of %s: %v", f.BaseFont, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(got) != data[i] {
+			t.Errorf("font program of %s = %q, %v, want %q", f.BaseFont, got, err, data[i])
+		}
+	}
+
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+	if diff := cmp.Diff(want[:2], p.Fonts(), cmpopts.IgnoreUnexported(Font{})); diff != "" {
+		t.Errorf("Page.Fonts() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPage_FontStats(t *testing.T) {
+	// F1 has widths from A to C, and shows a code that WinAnsiEncoding leaves undefined;
+	// F2 and F3 are both MyFont, with neither widths nor text.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R /F2 6 0 R /F3 7 0 R /F4 5 0 R /Unused 8 0 R>>>>>>",
+		stream("BT /F1 12 Tf (ABCD\x81) Tj /F4 12 Tf (A) Tj /F2 12 Tf <0001> Tj /F3 12 Tf <0002> Tj ET"),
+		"<</Type /Font /Subtype /TrueType /BaseFont /Arial /Encoding /WinAnsiEncoding /FirstChar 65 /LastChar 67 /Widths [667 667 722]>>",
+		"<</Type /Font /Subtype /Type0 /BaseFont /MyFont /Encoding /Identity-H /DescendantFonts [<</Subtype /CIDFontType2 /W [1 [500]]>>]>>",
+		"<</Type /Font /Subtype /Type0 /BaseFont /MyFont /Encoding /Identity-H /DescendantFonts [<</Subtype /CIDFontType2>>]>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Courier>>",
+	))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+	if got := p.FontStats(); got != nil {
+		t.Errorf("got statistics %v before reading the text, want none", got)
+	}
+	if _, err := p.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	want := []FontStats{
+		{Font: "Arial", Codes: 6, NoRune: 1, DefaultWidths: 2},
+		{Font: "MyFont", Codes: 2, NoRune: 2, DefaultWidths: 1},
+	}
+	if diff := cmp.Diff(want, p.FontStats()); diff != "" {
+		t.Errorf("FontStats() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// FontStats tallies the character codes shown in a font on a page, to estimate how
+// faithfully the text of the page is extracted: the text of codes without any comes
+// out as U+FFFD, and codes without widths of their own may be spaced wrongly.
+type FontStats struct {
+	// Font is the name of the font, its BaseFont. The fonts of the same name
+	// are tallied together.
+	Font string
+	// Codes is the number of codes shown in the font.
+	Codes int
+	// NoRune is the number of them without any text, extracted as U+FFFD.
+	NoRune int
+	// DefaultWidths is the number of them without a width of their own in the font
+	// dictionary, which have its default width.
+	DefaultWidths int
+}
+
+// fontStats holds the statistics of the fonts of the pages of a Reader.
+type fontStats struct {
+	mu    sync.Mutex
+	pages map[int][]FontStats
+}
+
+// FontStats returns the statistics of the fonts shown on the page by the last extraction
+// of its text, by Text or any of the methods that read it, in order of the names of the
+// fonts, or nil if its text has not been read. Fonts that show no codes are left out.
+func (p *Page) FontStats() []FontStats {
+	s := &p.v.r.fontStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.pages[p.num])
+}
+
+// setFontStats records the statistics of the fonts shown on the page, read by content.
+func (p *Page) setFontStats(content *contentReader) {
+	var stats []FontStats
+	for _, f := range content.all {
+		if f.stats.Codes == 0 {
+			continue
+		}
+		i := slices.IndexFunc(stats, func(s FontStats) bool { return s.Font == f.name })
+		if i < 0 {
+			stats = append(stats, FontStats{Font: f.name})
+			i = len(stats) - 1
+		}
+		stats[i].Codes += f.stats.Codes
+		stats[i].NoRune += f.stats.NoRune
+		stats[i].DefaultWidths += f.stats.DefaultWidths
+	}
+	slices.SortFunc(stats, func(a, b FontStats) int { return cmp.Compare(a.Font, b.Font) })
+
+	s := &p.v.r.fontStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pages == nil {
+		s.pages = map[int][]FontStats{}
+	}
+	s.pages[p.num] = stats
+}
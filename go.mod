@@ -4,5 +4,6 @@ go 1.22.1
 
 require (
 	github.com/google/go-cmp v0.6.0
+	golang.org/x/image v0.15.0
 	golang.org/x/text v0.14.0
 )
@@ -0,0 +1,250 @@
+package pdf
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Defaults for the block cache of an HTTPReaderAt.
+const (
+	defaultHTTPBlockSize   = 64 << 10
+	defaultHTTPCacheBlocks = 64
+)
+
+// WithHTTPCache sets the size of the blocks fetched by an HTTPReaderAt,
+// and the number of blocks it caches.
+func WithHTTPCache(blockSize, blocks int) Option {
+	return func(c *config) {
+		c.httpBlockSize = blockSize
+		c.httpCacheBlocks = blocks
+	}
+}
+
+// OpenHTTP opens the file at url for reading, fetching with HTTP range requests only
+// the parts of the file that are read. See HTTPReaderAt.
+// The requests made by the returned Reader, and the opening of the file, are bounded by ctx.
+func OpenHTTP(ctx context.Context, url string, client *http.Client, opts ...Option) (*Reader, error) {
+	h, err := NewHTTPReaderAt(ctx, url, client, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(h, h.Size(), append([]Option{WithContext(ctx)}, opts...)...)
+}
+
+// An HTTPReaderAt is an io.ReaderAt over a remote file, read with HTTP range requests.
+// As the cross-reference table needed to find objects in a PDF is at the end of the file,
+// a Reader can read parts of a large remote file without downloading all of it.
+//
+// Data is fetched in fixed size blocks, kept in a least recently used cache;
+// adjacent blocks missing from the cache are fetched in a single request.
+// If the server does not support range requests, the whole file is downloaded once.
+// An HTTPReaderAt is safe for concurrent use.
+type HTTPReaderAt struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	size   int64
+
+	blockSize int64
+	maxBlocks int
+
+	// full holds the whole file when the server ignores range requests.
+	full []byte
+	// tail holds the end of the file, fetched when the file is opened, and head
+	// its start, fetched instead with WithLinearizedFirstPage.
+	tail []byte
+	head []byte
+
+	mu     sync.Mutex
+	lru    *list.List // of *httpBlock, most recently used first.
+	blocks map[int64]*list.Element
+}
+
+type httpBlock struct {
+	n    int64
+	data []byte
+}
+
+// NewHTTPReaderAt returns an HTTPReaderAt for the file at url, using client to make
+// requests, or http.DefaultClient if client is nil. Requests are made with ctx, so
+// cancelling ctx aborts any later reads. Of the options, only WithHTTPCache
+// and WithLinearizedFirstPage apply.
+func NewHTTPReaderAt(ctx context.Context, url string, client *http.Client, opts ...Option) (*HTTPReaderAt, error) {
+	cfg := config{httpBlockSize: defaultHTTPBlockSize, httpCacheBlocks: defaultHTTPCacheBlocks}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	h := &HTTPReaderAt{
+		ctx:       ctx,
+		client:    client,
+		url:       url,
+		blockSize: int64(max(cfg.httpBlockSize, 1)),
+		maxBlocks: max(cfg.httpCacheBlocks, 1),
+		lru:       list.New(),
+		blocks:    map[int64]*list.Element{},
+	}
+
+	// Fetch the end of the file, which also gives the size of the file;
+	// or its start, for the first page of a linearized file.
+	rng := fmt.Sprintf("bytes=-%d", h.blockSize)
+	if cfg.linearizedFirstPage {
+		rng = fmt.Sprintf("bytes=0-%d", h.blockSize-1)
+	}
+	resp, err := h.get(rng)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var first, last int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &first, &last, &h.size); err != nil {
+			return nil, fmt.Errorf("fetching %s: invalid Content-Range %q", url, resp.Header.Get("Content-Range"))
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
+		if int64(len(data)) != last-first+1 {
+			return nil, fmt.Errorf("fetching %s: got %d bytes for Content-Range %q", url, len(data), resp.Header.Get("Content-Range"))
+		}
+		if cfg.linearizedFirstPage {
+			h.head = data
+		} else {
+			h.tail = data
+		}
+	case http.StatusOK:
+		// Range requests are not supported.
+		if h.full, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
+		h.size = int64(len(h.full))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// An empty file.
+	default:
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+
+	return h, nil
+}
+
+// Size returns the size of the remote file.
+func (h *HTTPReaderAt) Size() int64 { return h.size }
+
+// ReadAt implements io.ReaderAt.
+func (h *HTTPReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= h.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), h.size)
+
+	var err error
+	switch tailOff := h.size - int64(len(h.tail)); {
+	case h.full != nil:
+		copy(p, h.full[off:end])
+	case len(h.tail) > 0 && off >= tailOff:
+		copy(p, h.tail[off-tailOff:end-tailOff])
+	case end <= int64(len(h.head)):
+		copy(p, h.head[off:end])
+	default:
+		err = h.readBlocks(p[:end-off], off)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n := int(end - off); n < len(p) {
+		return n, io.EOF
+	}
+	return len(p), nil
+}
+
+// readBlocks reads p from the blocks at off, fetching the blocks not in the cache.
+func (h *HTTPReaderAt) readBlocks(p []byte, off int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	first, last := off/h.blockSize, (off+int64(len(p))-1)/h.blockSize
+	data := make(map[int64][]byte, last-first+1)
+	for n := first; n <= last; n++ {
+		if e, ok := h.blocks[n]; ok {
+			h.lru.MoveToFront(e)
+			data[n] = e.Value.(*httpBlock).data
+		}
+	}
+
+	// Fetch each run of missing blocks in a single request.
+	for n := first; n <= last; n++ {
+		if data[n] != nil {
+			continue
+		}
+		m := n
+		for m < last && data[m+1] == nil {
+			m++
+		}
+		if err := h.fetch(n, m, data); err != nil {
+			return err
+		}
+		n = m
+	}
+
+	for n := first; n <= last; n++ {
+		b := data[n]
+		start := max(off, n*h.blockSize)
+		copy(p[start-off:], b[start-n*h.blockSize:])
+	}
+	return nil
+}
+
+// fetch fetches blocks first to last, storing them in data and in the cache.
+// h.mu must be held.
+func (h *HTTPReaderAt) fetch(first, last int64, data map[int64][]byte) error {
+	start, end := first*h.blockSize, min((last+1)*h.blockSize, h.size)
+	resp, err := h.get(fmt.Sprintf("bytes=%d-%d", start, end-1))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("fetching %s: %s", h.url, resp.Status)
+	}
+	buf := make([]byte, end-start)
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		return fmt.Errorf("fetching %s: %w", h.url, err)
+	}
+
+	for n := first; n <= last; n++ {
+		b := buf[(n-first)*h.blockSize : min((n-first+1)*h.blockSize, int64(len(buf)))]
+		data[n] = b
+		h.blocks[n] = h.lru.PushFront(&httpBlock{n: n, data: b})
+		for h.lru.Len() > h.maxBlocks {
+			e := h.lru.Back()
+			h.lru.Remove(e)
+			delete(h.blocks, e.Value.(*httpBlock).n)
+		}
+	}
+	return nil
+}
+
+func (h *HTTPReaderAt) get(rng string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", rng)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", h.url, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOpenHTTP(t *testing.T) {
+	// An unreferenced object pads the file, so most of it need not be fetched.
+	data := buildPDF(
+		"<</Type /Catalog /Pages 3 0 R>>",
+		stream(strings.Repeat("x", 100_000)),
+		"<</Type /Pages /Kids [4 0 R] /Count 1>>",
+		"<</Type /Page /Parent 3 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 6 0 R>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"),
+	)
+
+	testCases := map[string]struct {
+		handler  http.HandlerFunc
+		maxBytes int64
+	}{
+		"range requests": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+			},
+			maxBytes: int64(len(data)) / 2,
+		},
+		"range ignored": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write(data)
+			},
+			maxBytes: int64(len(data)),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var sent atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tc.handler(&countingWriter{ResponseWriter: w, n: &sent}, r)
+			}))
+			defer srv.Close()
+
+			r, err := OpenHTTP(context.Background(), srv.URL, srv.Client(), WithHTTPCache(1024, 8))
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			got, err := r.Page(1)
+			if err != nil {
+				t.Fatal("failed to read page:", err)
+			}
+			if want := "Hello, world"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+			if got, want := sent.Load(), tc.maxBytes; got > want {
+				t.Errorf("fetched %d bytes, want at most %d", got, want)
+			}
+		})
+	}
+}
+
+func TestHTTPReaderAt_ReadAt(t *testing.T) {
+	data := make([]byte, 10_000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	h, err := NewHTTPReaderAt(context.Background(), srv.URL, srv.Client(), WithHTTPCache(100, 4))
+	if err != nil {
+		t.Fatal("failed to create reader:", err)
+	}
+	if h.Size() != int64(len(data)) {
+		t.Fatalf("got size %d, want %d", h.Size(), len(data))
+	}
+
+	for _, off := range []int64{0, 50, 350, 9_950, 320} {
+		requests.Store(0)
+		p := make([]byte, 300)
+		n, err := h.ReadAt(p, off)
+		if want := min(len(p), len(data)-int(off)); n != want {
+			t.Errorf("ReadAt(%d): read %d bytes, want %d (%v)", off, n, want, err)
+		}
+		if !bytes.Equal(p[:n], data[off:off+int64(n)]) {
+			t.Errorf("ReadAt(%d): wrong data", off)
+		}
+		if got := requests.Load(); got > 1 {
+			t.Errorf("ReadAt(%d): made %d requests, want at most 1", off, got)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h, err = NewHTTPReaderAt(ctx, srv.URL, srv.Client(), WithHTTPCache(100, 4))
+	if err != nil {
+		t.Fatal("failed to create reader:", err)
+	}
+	cancel()
+	if _, err := h.ReadAt(make([]byte, 10), 0); err == nil {
+		t.Error("ReadAt succeeded after context was cancelled")
+	}
+}
+
+// countingWriter counts the bytes of the response body written to it.
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,218 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+)
+
+// maxImagePixels is the greatest number of pixels of an image that Image decodes,
+// as many as a page of A4 scanned at 600 dpi has, and more.
+const maxImagePixels = 1 << 26
+
+// maxJPEGBytes is the greatest number of bytes of JPEG data that Image reads.
+const maxJPEGBytes = 1 << 26
+
+// Image decodes the image XObject v, whose data is either JPEG (DCTDecode) encoded or
+// samples in a gray, RGB, CMYK or indexed color space, or an image mask, which is drawn
+// black. The samples are not converted from calibrated or ICC-based color spaces, which
+// are treated as the device space with their number of components.
+// See PDF 32000-1:2008, §8.9.
+func (v Value) Image() (image.Image, error) {
+	if v.Kind() != Stream || v.Key("Subtype").Name() != "Image" {
+		return nil, fmt.Errorf("not an image XObject")
+	}
+
+	rc := v.Reader()
+	defer rc.Close()
+
+	switch filters := v.UndecodedFilters(); {
+	case len(filters) == 0:
+	case len(filters) == 1 && filters[0] == "DCTDecode":
+		return decodeJPEG(rc)
+	default:
+		return nil, unsupported("image filter %v", filters[0])
+	}
+
+	w, h := v.Key("Width").Int64(), v.Key("Height").Int64()
+	if !validImageSize(w, h) {
+		return nil, fmt.Errorf("invalid image size %dx%d", w, h)
+	}
+	bpc := v.Key("BitsPerComponent").Int64()
+	cs := v.Key("ColorSpace")
+	if v.Key("ImageMask").Bool() {
+		bpc, cs = 1, Value{}
+	}
+	switch bpc {
+	case 1, 2, 4, 8:
+	default:
+		return nil, unsupported("BitsPerComponent %d", bpc)
+	}
+
+	space, err := imageSpace(cs, v.Key("ImageMask").Bool())
+	if err != nil {
+		return nil, err
+	}
+	rowBytes := (w*int64(space.n)*bpc + 7) / 8
+	data := make([]byte, rowBytes*h)
+	// The data may be short, leaving the rest of the image blank, but not empty.
+	if _, err := io.ReadFull(rc, data); err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("reading image data: %w", err)
+	}
+
+	// The Decode array maps the samples to component values. It inverts those of
+	// images with black 1, such as some of CCITT fax data, and of masks painted
+	// where their samples are 1 rather than 0. See PDF 32000-1:2008, §8.9.5.2.
+	d := v.Key("Decode")
+	invert := d.Kind() == Array && d.Len() >= 2 && d.Index(0).Float64() > d.Index(1).Float64()
+
+	rect := image.Rect(0, 0, int(w), int(h))
+	maxSample := 1<<bpc - 1
+	sample := func(row []byte, i int) int {
+		bit := i * int(bpc)
+		s := int(row[bit/8]) >> (8 - int(bpc) - bit%8) & maxSample
+		if invert {
+			s = maxSample - s
+		}
+		return s
+	}
+	scale := func(s int) uint8 { return uint8(s * 255 / maxSample) }
+
+	var img image.Image
+	switch {
+	case space.palette != nil:
+		p := image.NewPaletted(rect, space.palette)
+		for y := range int(h) {
+			row := data[int64(y)*rowBytes:]
+			for x := range int(w) {
+				p.Pix[y*p.Stride+x] = uint8(min(sample(row, x), len(space.palette)-1))
+			}
+		}
+		img = p
+	case space.n == 1:
+		g := image.NewGray(rect)
+		for y := range int(h) {
+			row := data[int64(y)*rowBytes:]
+			for x := range int(w) {
+				g.Pix[y*g.Stride+x] = scale(sample(row, x))
+			}
+		}
+		img = g
+	case space.n == 3:
+		c := image.NewRGBA(rect)
+		for y := range int(h) {
+			row := data[int64(y)*rowBytes:]
+			for x := range int(w) {
+				i := y*c.Stride + x*4
+				c.Pix[i], c.Pix[i+1], c.Pix[i+2] = scale(sample(row, 3*x)), scale(sample(row, 3*x+1)), scale(sample(row, 3*x+2))
+				c.Pix[i+3] = 0xff
+			}
+		}
+		img = c
+	default:
+		c := image.NewCMYK(rect)
+		for y := range int(h) {
+			row := data[int64(y)*rowBytes:]
+			for x := range int(w) {
+				for j := range 4 {
+					c.Pix[y*c.Stride+x*4+j] = scale(sample(row, 4*x+j))
+				}
+			}
+		}
+		img = c
+	}
+	return img, nil
+}
+
+// validImageSize reports whether an image of w by h pixels is one that Image decodes.
+func validImageSize(w, h int64) bool {
+	return w >= 1 && h >= 1 && w <= maxImagePixels/h
+}
+
+// decodeJPEG decodes the JPEG data read from rd, of at most maxJPEGBytes, once
+// its header shows that the image is of a size that Image decodes.
+func decodeJPEG(rd io.Reader) (image.Image, error) {
+	rd = io.LimitReader(rd, maxJPEGBytes)
+	var head bytes.Buffer
+	cfg, err := jpeg.DecodeConfig(io.TeeReader(rd, &head))
+	if err != nil {
+		return nil, fmt.Errorf("DCTDecode: %w", err)
+	}
+	if !validImageSize(int64(cfg.Width), int64(cfg.Height)) {
+		return nil, fmt.Errorf("DCTDecode: invalid image size %dx%d", cfg.Width, cfg.Height)
+	}
+	img, err := jpeg.Decode(io.MultiReader(&head, rd))
+	if err != nil {
+		return nil, fmt.Errorf("DCTDecode: %w", err)
+	}
+	return img, nil
+}
+
+// A sampleSpace is the color space of the samples of an image:
+// the number of components of each, or the color palette they index.
+type sampleSpace struct {
+	n       int
+	palette color.Palette
+}
+
+// imageSpace returns the color space of the samples of an image with the color
+// space cs, or of an image mask, whose samples are drawn black or not at all.
+func imageSpace(cs Value, mask bool) (sampleSpace, error) {
+	if mask {
+		return sampleSpace{n: 1}, nil
+	}
+	name := cs.Name()
+	if cs.Kind() == Array {
+		name = cs.Index(0).Name()
+	}
+	switch name {
+	case "DeviceGray", "CalGray", "G":
+		return sampleSpace{n: 1}, nil
+	case "DeviceRGB", "CalRGB", "Lab", "RGB":
+		return sampleSpace{n: 3}, nil
+	case "DeviceCMYK", "CMYK":
+		return sampleSpace{n: 4}, nil
+	case "ICCBased":
+		switch n := cs.Index(1).Key("N").Int64(); n {
+		case 1, 3, 4:
+			return sampleSpace{n: int(n)}, nil
+		}
+	case "Indexed", "I":
+		base, err := imageSpace(cs.Index(1), false)
+		if err != nil || base.palette != nil {
+			return sampleSpace{}, unsupported("indexed color space %v", cs)
+		}
+		hival := int(min(max(cs.Index(2).Int64(), 0), 255))
+		lookup := []byte(cs.Index(3).RawString())
+		if s := cs.Index(3); s.Kind() == Stream {
+			var buf bytes.Buffer
+			rc := s.Reader()
+			_, err := buf.ReadFrom(rc)
+			rc.Close()
+			if err != nil {
+				return sampleSpace{}, fmt.Errorf("reading color lookup table: %w", err)
+			}
+			lookup = buf.Bytes()
+		}
+		palette := make(color.Palette, hival+1)
+		for i := range palette {
+			c := make([]byte, base.n)
+			if off := i * base.n; off+base.n <= len(lookup) {
+				copy(c, lookup[off:])
+			}
+			switch base.n {
+			case 1:
+				palette[i] = color.Gray{Y: c[0]}
+			case 3:
+				palette[i] = color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xff}
+			default:
+				palette[i] = color.CMYK{C: c[0], M: c[1], Y: c[2], K: c[3]}
+			}
+		}
+		return sampleSpace{n: 1, palette: palette}, nil
+	}
+	return sampleSpace{}, unsupported("image color space %v", cs)
+}
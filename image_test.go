@@ -0,0 +1,268 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+func TestValue_Image(t *testing.T) {
+	testCases := map[string]struct {
+		dict string
+		data string
+		want []color.Color
+	}{
+		"gray": {
+			dict: "/Width 2 /Height 1 /BitsPerComponent 8 /ColorSpace /DeviceGray",
+			data: "\x00\x80",
+			want: []color.Color{color.Gray{0}, color.Gray{0x80}},
+		},
+		"1-bit inverted": {
+			dict: "/Width 3 /Height 1 /BitsPerComponent 1 /ColorSpace /DeviceGray /Decode [1 0]",
+			data: "\x40",
+			want: []color.Color{color.Gray{0xff}, color.Gray{0}, color.Gray{0xff}},
+		},
+		"rgb": {
+			dict: "/Width 1 /Height 1 /BitsPerComponent 8 /ColorSpace /DeviceRGB",
+			data: "\x10\x20\x30",
+			want: []color.Color{color.RGBA{0x10, 0x20, 0x30, 0xff}},
+		},
+		"indexed": {
+			dict: "/Width 2 /Height 1 /BitsPerComponent 4 /ColorSpace [/Indexed /DeviceRGB 1 <ff000000ff00>]",
+			data: "\x10",
+			want: []color.Color{color.RGBA{0, 0xff, 0, 0xff}, color.RGBA{0xff, 0, 0, 0xff}},
+		},
+		"mask": {
+			dict: "/Width 2 /Height 1 /ImageMask true",
+			data: "\x80",
+			want: []color.Color{color.Gray{0xff}, color.Gray{0}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog>>",
+				fmt.Sprintf("<</Type /XObject /Subtype /Image %s /Length %d>>\nstream\n%s\nendstream", tc.dict, len(tc.data), tc.data),
+			))
+			img, err := r.resolve(types.Objptr{}, types.Objptr{ID: 2}).Image()
+			if err != nil {
+				t.Fatal("failed to decode image:", err)
+			}
+
+			var got []color.Color
+			b := img.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					got = append(got, img.At(x, y))
+				}
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("pixels did not match expectation:", diff)
+			}
+		})
+	}
+
+	if _, err := (Value{}).Image(); err == nil {
+		t.Error("decoded an image from a null value")
+	}
+}
+
+func TestValue_Image_size(t *testing.T) {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal("failed to encode JPEG:", err)
+	}
+	// The height and width of the frame, after the marker, the length of the
+	// segment and the sample precision, made as large as they go.
+	huge := bytes.Clone(jpg.Bytes())
+	sof := bytes.Index(huge, []byte{0xff, 0xc0})
+	copy(huge[sof+5:], []byte{0xff, 0xff, 0xff, 0xff})
+
+	testCases := map[string]struct {
+		dict, data string
+		ok         bool
+	}{
+		"samples":              {dict: "/Width 8 /Height 8 /BitsPerComponent 8 /ColorSpace /DeviceGray", data: string(make([]byte, 64)), ok: true},
+		"too many samples":     {dict: "/Width 100000 /Height 100000 /BitsPerComponent 8 /ColorSpace /DeviceGray"},
+		"overflowing size":     {dict: "/Width 4294967296 /Height 4294967296 /BitsPerComponent 8 /ColorSpace /DeviceGray"},
+		"JPEG":                 {dict: "/Filter /DCTDecode", data: jpg.String(), ok: true},
+		"too many JPEG pixels": {dict: "/Filter /DCTDecode", data: string(huge)},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog>>",
+				fmt.Sprintf("<</Type /XObject /Subtype /Image %s /Length %d>>\nstream\n%s\nendstream", tc.dict, len(tc.data), tc.data),
+			))
+			img, err := r.resolve(types.Objptr{}, types.Objptr{ID: 2}).Image()
+			if tc.ok {
+				if err != nil {
+					t.Fatal("failed to decode image:", err)
+				}
+				if got := img.Bounds(); got != image.Rect(0, 0, 8, 8) {
+					t.Errorf("got image bounds %v, want 8x8", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "invalid image size") {
+				t.Errorf("got error %v, want an invalid image size", err)
+			}
+		})
+	}
+}
+
+// pngFilter returns the rows of rowLen bytes of raw, of pixels of bpp bytes, each
+// after a byte of its PNG filter type, the types taken in turn from row to row.
+func pngFilter(raw []byte, rowLen, bpp int) []byte {
+	var out []byte
+	prev := make([]byte, rowLen)
+	for y := 0; y*rowLen < len(raw); y++ {
+		row := raw[y*rowLen : (y+1)*rowLen]
+		ft := byte(y % 5)
+		out = append(out, ft)
+		for i, c := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			var pred byte
+			switch ft {
+			case 1:
+				pred = left
+			case 2:
+				pred = prev[i]
+			case 3:
+				pred = byte((int(left) + int(prev[i])) / 2)
+			case 4:
+				pred = paeth(left, prev[i], upLeft)
+			}
+			out = append(out, c-pred)
+		}
+		prev = row
+	}
+	return out
+}
+
+// pngData returns the image data of the PNG image img, as image/png encodes
+// it, with the filter types it chooses, and the pixels it decodes to, in rows
+// of RGB samples of 8 bits.
+func pngData(t *testing.T, img image.Image) (data, pixels []byte) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal("failed to encode PNG:", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal("failed to decode PNG:", err)
+	}
+	b := decoded.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA)
+			pixels = append(pixels, c.R, c.G, c.B)
+		}
+	}
+
+	// The chunks after the signature, of their length, type, data and CRC.
+	chunks := buf.Bytes()[8:]
+	for len(chunks) >= 12 {
+		n := binary.BigEndian.Uint32(chunks)
+		if string(chunks[4:8]) == "IDAT" {
+			data = append(data, chunks[8:8+n]...)
+		}
+		chunks = chunks[12+n:]
+	}
+	return data, pixels
+}
+
+func TestValue_Reader_predictors(t *testing.T) {
+	deflate := func(data []byte) []byte {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		return z.Bytes()
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(rng.UintN(256))
+		}
+		return b
+	}
+
+	rgb := image.NewRGBA(image.Rect(0, 0, 7, 5))
+	for y := range 5 {
+		for x := range 7 {
+			rgb.Set(x, y, color.RGBA{uint8(x * 30), uint8(y * 50), uint8(x*y*7 + 3), 0xff})
+		}
+	}
+	rgbData, rgbPixels := pngData(t, rgb)
+
+	bitmap := random(2 * 10)
+	rgb2 := random(3 * 8)
+	rgb16 := random(3 * 2 * 4 * 6)
+
+	testCases := map[string]struct {
+		dict string
+		data []byte
+		want []byte
+	}{
+		"RGB, from image/png": {
+			dict: "/Width 7 /Height 5 /BitsPerComponent 8 /ColorSpace /DeviceRGB /Filter /FlateDecode " +
+				"/DecodeParms <</Predictor 15 /Colors 3 /Columns 7>>",
+			data: rgbData,
+			want: rgbPixels,
+		},
+		"1-bit": {
+			dict: "/Width 13 /Height 10 /BitsPerComponent 1 /ColorSpace /DeviceGray /Filter /FlateDecode " +
+				"/DecodeParms <</Predictor 15 /BitsPerComponent 1 /Columns 13>>",
+			data: deflate(pngFilter(bitmap, 2, 1)),
+			want: bitmap,
+		},
+		"2-bit RGB": {
+			dict: "/Width 4 /Height 8 /BitsPerComponent 2 /ColorSpace /DeviceRGB /Filter /FlateDecode " +
+				"/DecodeParms <</Predictor 15 /Colors 3 /BitsPerComponent 2 /Columns 4>>",
+			data: deflate(pngFilter(rgb2, 3, 1)),
+			want: rgb2,
+		},
+		"16-bit RGB": {
+			dict: "/Width 4 /Height 6 /BitsPerComponent 16 /ColorSpace /DeviceRGB /Filter /FlateDecode " +
+				"/DecodeParms <</Predictor 15 /Colors 3 /BitsPerComponent 16 /Columns 4>>",
+			data: deflate(pngFilter(rgb16, 24, 6)),
+			want: rgb16,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog>>",
+				fmt.Sprintf("<</Type /XObject /Subtype /Image %s /Length %d>>\nstream\n%s\nendstream", tc.dict, len(tc.data), tc.data),
+			))
+			got, err := io.ReadAll(r.resolve(types.Objptr{}, types.Objptr{ID: 2}).Reader())
+			if err != nil {
+				t.Fatal("failed to read image data:", err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("got image data %x, want %x", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+)
+
+// inflateProbe is the number of bytes of FlateDecode data without a zlib header that
+// are inflated, as raw deflate data, to tell whether it is compressed at all.
+const inflateProbe = 512
+
+// inflate returns a reader inflating the zlib data of the stream v read from rd.
+// Unless strict, data without a zlib header is inflated as raw deflate
+// data if it is that, as some writers leave the header off, or else read as it is,
+// as some writers declare FlateDecode on data that is not compressed.
+func (v Value) inflate(rd io.Reader, strict bool) (io.Reader, error) {
+	if strict {
+		return zlib.NewReader(rd)
+	}
+	br, ok := rd.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(rd)
+	}
+	head, _ := br.Peek(inflateProbe)
+	switch {
+	case len(head) == 0:
+		return br, nil
+	case zlibHeader(head):
+		return zlib.NewReader(br)
+	case !isText(head) && rawDeflate(head):
+		v.r.warn(StreamWarning, v.ptr, 0, "FlateDecode data has no zlib header")
+		return flate.NewReader(br), nil
+	}
+	v.r.warn(StreamWarning, v.ptr, 0, "FlateDecode data is not compressed")
+	return br, nil
+}
+
+// zlibHeader reports whether data begins with a zlib header, of deflate data
+// without a preset dictionary. See RFC 1950, §2.2.
+func zlibHeader(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	cmf, flg := data[0], data[1]
+	return cmf&0x0f == 8 && cmf>>4 <= 7 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// rawDeflate reports whether data begins raw deflate data: whether it inflates
+// without error, as far as it goes.
+func rawDeflate(data []byte) bool {
+	fr := flate.NewReader(bytes.NewReader(data))
+	_, err := io.Copy(io.Discard, io.LimitReader(fr, 64*inflateProbe))
+	return err == nil || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isText reports whether data is printable ASCII and white space, as the data
+// of content streams and of other streams of text is.
+func isText(data []byte) bool {
+	for _, c := range data {
+		if (c < ' ' || c > '~') && c != '\n' && c != '\r' && c != '\t' && c != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+// contentStream returns a reader of the decoded data of the content stream v.
+// Unless WithStrictFilters, data that is still zlib data once decoded, as that
+// of a stream compressed twice, is inflated again, with a warning. Content may
+// begin with what looks like a zlib header, as "(S" does, so the data is only
+// inflated again if its start fails to be read as content.
+func (v Value) contentStream() io.ReadCloser {
+	rc := v.Reader()
+	sr, ok := rc.(*streamReadCloser)
+	if !ok || v.r.cfg.strictFilters {
+		return rc
+	}
+	br := bufio.NewReaderSize(sr.Reader, inflateProbe)
+	sr.push(br)
+	if head, err := br.Peek(inflateProbe); zlibHeader(head) && !isContent(head, err == nil) {
+		if zr, err := zlib.NewReader(br); err == nil {
+			v.r.warn(StreamWarning, v.ptr, 0, "content stream is compressed twice")
+			sr.push(zr)
+		}
+	}
+	return sr
+}
+
+// isContent reports whether data, the start of a content stream, or all of it unless
+// truncated, is read as content: whether it is interpreted, to operators of text,
+// without failing, or failing only once it runs out of truncated data.
+func isContent(data []byte, truncated bool) (ok bool) {
+	rd := &eofReader{Reader: bytes.NewReader(data)}
+	defer func() {
+		if recover() != nil {
+			ok = truncated && rd.eof
+		}
+	}()
+	interpret(context.Background(), rd, func(_ *stack, op string) {
+		if !isText([]byte(op)) {
+			panic("not an operator")
+		}
+	})
+	return true
+}
+
+// eofReader is a reader that records whether it has been read to its end.
+type eofReader struct {
+	io.Reader
+	eof bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Info returns the text entries of the document information dictionary, such as
+// Title, Author, Creator and Producer, by key, decoded to UTF-8 from PDFDocEncoding,
+// UTF-16 or, in PDF 2.0, UTF-8. Entries that are not strings are left out, and a document without
+// the dictionary has none. See PDF 32000-1:2008, §14.3.3.
+func (r *Reader) Info() map[string]string {
+	info := r.trailerValue().Key("Info")
+	var m map[string]string
+	for _, k := range info.Keys() {
+		if v := info.Key(k); v.Kind() == String {
+			if m == nil {
+				m = map[string]string{}
+			}
+			m[k] = v.Text()
+		}
+	}
+	return m
+}
+
+// maxMetadataBytes is the greatest number of bytes of the decoded data of the
+// Metadata stream of a document.
+const maxMetadataBytes = 1 << 24
+
+// Metadata returns the XMP metadata of the document, the data of the Metadata stream
+// of its catalog, or nil if it has none. It fails for a stream of more than 16 MiB.
+// See PDF 32000-1:2008, §14.3.2.
+func (r *Reader) Metadata() ([]byte, error) {
+	md := r.trailerValue().Key("Root").Key("Metadata")
+	if md.Kind() != Stream {
+		return nil, nil
+	}
+	rc := md.Reader()
+	defer rc.Close()
+	data, err := readAll(rc, maxMetadataBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	return data, nil
+}
+
+// The namespaces of the XMP properties read from the metadata.
+const (
+	xmpNamespace    = "http://ns.adobe.com/xap/1.0/"
+	xmpPDFNamespace = "http://ns.adobe.com/pdf/1.3/"
+)
+
+// xmpProperty returns the value of the simple XMP property of the namespace space
+// with the local name local, written as an element or as an attribute of an
+// rdf:Description, or "" if data has none or is not XML.
+func xmpProperty(data []byte, space, local string) string {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, a := range start.Attr {
+			if a.Name.Space == space && a.Name.Local == local {
+				return strings.TrimSpace(a.Value)
+			}
+		}
+		if start.Name.Space == space && start.Name.Local == local {
+			var s string
+			if d.DecodeElement(&s, &start) != nil {
+				return ""
+			}
+			return strings.TrimSpace(s)
+		}
+	}
+}
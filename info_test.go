@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReader_Metadata(t *testing.T) {
+	metadata := func(hdr, data string) []byte {
+		return buildPDF(
+			"<</Type /Catalog /Pages 2 0 R /Metadata 3 0 R>>",
+			"<</Type /Pages /Kids [] /Count 0>>",
+			fmt.Sprintf("<</Type /Metadata /Subtype /XML %s /Length %d>>\nstream\n%s\nendstream", hdr, len(data), data),
+		)
+	}
+	// The long stream decodes to a byte more than the metadata may have.
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(make([]byte, maxMetadataBytes+1))
+	zw.Close()
+
+	testCases := map[string]struct {
+		data []byte
+		want string
+		err  string
+	}{
+		"none": {
+			data: buildPDF("<</Type /Catalog /Pages 2 0 R>>", "<</Type /Pages /Kids [] /Count 0>>"),
+		},
+		"stream": {
+			data: metadata("", "<x:xmpmeta/>"),
+			want: "<x:xmpmeta/>",
+		},
+		"too long": {
+			data: metadata("/Filter /FlateDecode", z.String()),
+			err:  fmt.Sprintf("failed to read metadata: data longer than %d bytes", maxMetadataBytes),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := openPDF(t, tc.data).Metadata()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to read metadata:", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got metadata %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -42,8 +42,11 @@ func New(password string, encrypt types.Dict, id string) (*Decrypter, error) {
 	pw := []byte(password)
 
 	if r == 6 {
-		ue := encrypt["UE"].(string)
-		perms := encrypt["Perms"].(string)
+		ue, _ := encrypt["UE"].(string)
+		perms, _ := encrypt["Perms"].(string)
+		if len(ue) != 32 || len(perms) != 16 {
+			return nil, fmt.Errorf("malformed PDF: missing UE= or Perms= encryption parameters")
+		}
 		return newR6(pw, []byte(u), []byte(ue), []byte(perms))
 	}
 
@@ -219,7 +222,7 @@ func (d *Decrypter) Decrypt(ptr types.Objptr, rd io.Reader) (io.Reader, error) {
 		iv := make([]byte, 16)
 		io.ReadFull(rd, iv)
 		cbc := cipher.NewCBCDecrypter(cb, iv)
-		rd = &cbcReader{cbc: cbc, rd: rd, buf: make([]byte, 16)}
+		rd = &cbcReader{cbc: cbc, rd: rd}
 	} else {
 		c, _ := rc4.NewCipher(key)
 		rd = &cipher.StreamReader{S: c, R: rd}
@@ -244,18 +247,18 @@ func (d *Decrypter) cryptKey(ptr types.Objptr) []byte {
 type cbcReader struct {
 	cbc  cipher.BlockMode
 	rd   io.Reader
-	buf  []byte
+	buf  [aes.BlockSize]byte
 	pend []byte
 }
 
 func (r *cbcReader) Read(b []byte) (n int, err error) {
 	if len(r.pend) == 0 {
-		_, err = io.ReadFull(r.rd, r.buf)
+		_, err = io.ReadFull(r.rd, r.buf[:])
 		if err != nil {
 			return 0, err
 		}
-		r.cbc.CryptBlocks(r.buf, r.buf)
-		r.pend = r.buf
+		r.cbc.CryptBlocks(r.buf[:], r.buf[:])
+		r.pend = r.buf[:]
 	}
 	n = copy(b, r.pend)
 	r.pend = r.pend[n:]
@@ -286,7 +289,10 @@ func validateVersion(v int64, encrypt types.Dict) bool {
 	if stmf != strf {
 		return false
 	}
-	cfparam := cf[stmf].(types.Dict)
+	cfparam, ok := cf[stmf].(types.Dict)
+	if !ok {
+		return false
+	}
 	if cfparam["AuthEvent"] != nil && cfparam["AuthEvent"] != types.Name("DocOpen") {
 		return false
 	}
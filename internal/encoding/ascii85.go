@@ -5,48 +5,55 @@
 package encoding
 
 import (
+	"bufio"
 	"io"
 )
 
+// NewAlphaReader returns a reader of the ASCII base-85 encoded data of reader, as
+// encoding/ascii85 decodes it: the characters of the encoding, from '!' to 'u', and
+// 'z', which stands for four zero bytes, up to the end-of-data marker "~>". White space
+// and other characters are skipped, as is the "<~" that some producers start the data
+// with, and the data ends at the marker, or at the end of reader if it has none.
+// See PDF 32000-1:2008, §7.4.3.
 func NewAlphaReader(reader io.Reader) *alphaReader {
-	return &alphaReader{reader: reader}
+	return &alphaReader{reader: bufio.NewReader(reader)}
 }
 
 type alphaReader struct {
-	reader io.Reader
+	reader *bufio.Reader
+	// started is whether any of the data has been read, and done whether all of it has.
+	started, done bool
 }
 
 func (a *alphaReader) Read(p []byte) (int, error) {
-	n, err := a.reader.Read(p)
-	if err != nil {
-		return n, err
-	}
-	buf := make([]byte, n)
-	tilda := false
-	for i := 0; i < n; i++ {
-		char := checkASCII85(p[i])
-		if char == '>' && tilda { // end of data
-			break
-		}
-		if char > 1 {
-			buf[i] = char
+	n := 0
+	for n < len(p) && !a.done {
+		c, err := a.reader.ReadByte()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
 		}
-		if char == 1 {
-			tilda = true // possible end of data
+		switch {
+		case c == '~':
+			// The end-of-data marker, whose '>' may be missing. What follows it,
+			// such as the end of the stream, is not data.
+			a.done = true
+		case c == '<' && !a.started:
+			if next, err := a.reader.Peek(1); err == nil && next[0] == '~' {
+				a.reader.Discard(1)
+				continue
+			}
+			fallthrough
+		case c >= '!' && c <= 'u', c == 'z':
+			a.started = true
+			p[n] = c
+			n++
 		}
 	}
-
-	copy(p, buf)
-	return n, nil
-}
-
-func checkASCII85(r byte) byte {
-	switch {
-	case r == '~': // for marking possible end of data
-		return 1
-	case r >= '!' && r <= 'u': // 33 <= ascii85 <=117
-		return r
-	default:
-		return 0 // if non-ascii85
+	if n == 0 && a.done {
+		return 0, io.EOF
 	}
+	return n, nil
 }
@@ -0,0 +1,39 @@
+package encoding
+
+import (
+	"encoding/ascii85"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAlphaReader(t *testing.T) {
+	testCases := map[string]struct {
+		data string
+		want string
+	}{
+		"plain":          {data: "87cURD_*#TDfTZ)~>", want: "Hello, world"},
+		"white space":    {data: "87cUR D_*#T\r\nDfTZ)\n~>", want: "Hello, world"},
+		"zeros":          {data: "z@:E_Wz~>", want: "\x00\x00\x00\x00abcd\x00\x00\x00\x00"},
+		"partial group":  {data: "@:B~>", want: "ab"},
+		"start marker":   {data: "<~87cURD_*#TDfTZ)~>", want: "Hello, world"},
+		"data after end": {data: "87cURD_*#TDfTZ)~>\r\nendstream\x00\xff", want: "Hello, world"},
+		"no '>'":         {data: "87cURD_*#TDfTZ)~\nendstream", want: "Hello, world"},
+		"no end marker":  {data: "87cURD_*#TDfTZ)\n", want: "Hello, world"},
+		"other bytes":    {data: "87cURD_*#TD\x00fT\xffZ)~>", want: "Hello, world"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// One byte at a time, so that the markers are split between reads.
+			got, err := io.ReadAll(ascii85.NewDecoder(NewAlphaReader(iotest.OneByteReader(strings.NewReader(tc.data)))))
+			if err != nil {
+				t.Fatal("failed to decode:", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
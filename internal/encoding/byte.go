@@ -5,10 +5,32 @@ import (
 	"unicode"
 )
 
+// A Byte decodes the codes of a simple font, one byte each, by a base encoding
+// and the differences from it of the font's encoding dictionary.
 type Byte struct {
-	table       *[256]rune
-	widths      Sizer
-	differences map[byte]string
+	table  *[256]rune
+	widths Sizer
+	// differences holds the text of the codes whose glyph names in the differences
+	// have any, or "" for those decoded by the base encoding table.
+	differences *[256]string
+	stats       *Stats
+	unmapped    func(code string) string
+}
+
+// newByte returns the encoding with the base encoding table and the differences d
+// from it, which map codes to the names of their glyphs. The codes of glyphs without
+// any text, by GlyphText, are decoded by the base encoding.
+func newByte(table *[256]rune, s Sizer, d map[byte]string) *Byte {
+	e := &Byte{table: table, widths: s}
+	for code, name := range d {
+		if text, ok := GlyphText(name); ok {
+			if e.differences == nil {
+				e.differences = new([256]string)
+			}
+			e.differences[code] = text
+		}
+	}
+	return e
 }
 
 func (e *Byte) Decode(raw string) (string, float64) {
@@ -17,24 +39,41 @@ func (e *Byte) Decode(raw string) (string, float64) {
 	var b strings.Builder
 	for i := 0; i < len(raw); i++ {
 		code := raw[i]
-		val := e.table[code]
-		if name, ok := e.differences[code]; ok {
-			if v, ok := nameToRune[name]; ok {
-				val = v
-			}
-		}
-		b.WriteRune(val)
 		w += e.widths.CodeWidth(int(code))
+		if e.differences != nil && e.differences[code] != "" {
+			e.stats.add(e.widths, int(code), false)
+			b.WriteString(e.differences[code])
+			continue
+		}
+		e.stats.add(e.widths, int(code), e.table[code] == NoRune)
+		if e.table[code] == NoRune && e.unmapped != nil {
+			b.WriteString(e.unmapped(raw[i : i+1]))
+			continue
+		}
+		b.WriteRune(e.table[code])
 	}
 	return b.String(), w
 }
 
+// Count makes the encoding count the codes that it decodes in s.
+func (e *Byte) Count(s *Stats) { e.stats = s }
+
+// Unmapped makes the encoding decode the codes that have no text to fn(code),
+// rather than to NoRune.
+func (e *Byte) Unmapped(fn func(code string) string) { e.unmapped = fn }
+
 func WinANSI(s Sizer, d map[byte]string) *Byte {
-	return &Byte{table: &winAnsiEncoding, widths: s, differences: d}
+	return newByte(&winAnsiEncoding, s, d)
 }
 
 func MacRoman(s Sizer, d map[byte]string) *Byte {
-	return &Byte{table: &macRomanEncoding, widths: s, differences: d}
+	return newByte(&macRomanEncoding, s, d)
+}
+
+// Standard returns the StandardEncoding, the built-in encoding of most Latin-text
+// Type 1 fonts, with the differences d from it.
+func Standard(s Sizer, d map[byte]string) *Byte {
+	return newByte(&standardEncoding, s, d)
 }
 
 func PDFDoc(s Sizer) *Byte { return &Byte{table: &pdfDocEncoding, widths: s} }
@@ -111,6 +150,42 @@ var macRomanEncoding = [256]rune{
 	0x00af, 0x02d8, 0x02d9, 0x02da, 0x00b8, 0x02dd, 0x02db, 0x02c7,
 }
 
+// See PDF 32000-1:2008, Table D.2
+var standardEncoding = [256]rune{
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	0x0020, 0x0021, 0x0022, 0x0023, 0x0024, 0x0025, 0x0026, 0x2019,
+	0x0028, 0x0029, 0x002a, 0x002b, 0x002c, 0x002d, 0x002e, 0x002f,
+	0x0030, 0x0031, 0x0032, 0x0033, 0x0034, 0x0035, 0x0036, 0x0037,
+	0x0038, 0x0039, 0x003a, 0x003b, 0x003c, 0x003d, 0x003e, 0x003f,
+	0x0040, 0x0041, 0x0042, 0x0043, 0x0044, 0x0045, 0x0046, 0x0047,
+	0x0048, 0x0049, 0x004a, 0x004b, 0x004c, 0x004d, 0x004e, 0x004f,
+	0x0050, 0x0051, 0x0052, 0x0053, 0x0054, 0x0055, 0x0056, 0x0057,
+	0x0058, 0x0059, 0x005a, 0x005b, 0x005c, 0x005d, 0x005e, 0x005f,
+	0x2018, 0x0061, 0x0062, 0x0063, 0x0064, 0x0065, 0x0066, 0x0067,
+	0x0068, 0x0069, 0x006a, 0x006b, 0x006c, 0x006d, 0x006e, 0x006f,
+	0x0070, 0x0071, 0x0072, 0x0073, 0x0074, 0x0075, 0x0076, 0x0077,
+	0x0078, 0x0079, 0x007a, 0x007b, 0x007c, 0x007d, 0x007e, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, 0x00a1, 0x00a2, 0x00a3, 0x2044, 0x00a5, 0x0192, 0x00a7,
+	0x00a4, 0x0027, 0x201c, 0x00ab, 0x2039, 0x203a, 0xfb01, 0xfb02,
+	NoRune, 0x2013, 0x2020, 0x2021, 0x00b7, NoRune, 0x00b6, 0x2022,
+	0x201a, 0x201e, 0x201d, 0x00bb, 0x2026, 0x2030, NoRune, 0x00bf,
+	NoRune, 0x0060, 0x00b4, 0x02c6, 0x02dc, 0x00af, 0x02d8, 0x02d9,
+	0x00a8, NoRune, 0x02da, 0x00b8, NoRune, 0x02dd, 0x02db, 0x02c7,
+	0x2014, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
+	NoRune, 0x00c6, NoRune, 0x00aa, NoRune, NoRune, NoRune, NoRune,
+	0x0141, 0x00d8, 0x0152, 0x00ba, NoRune, NoRune, NoRune, NoRune,
+	NoRune, 0x00e6, NoRune, NoRune, NoRune, 0x0131, NoRune, NoRune,
+	0x0142, 0x00f8, 0x0153, 0x00df, NoRune, NoRune, NoRune, NoRune,
+}
+
 // See PDF 32000-1:2008, Table D.2
 var pdfDocEncoding = [256]rune{
 	NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune, NoRune,
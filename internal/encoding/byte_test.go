@@ -0,0 +1,48 @@
+package encoding
+
+import "testing"
+
+type noWidths struct{}
+
+func (noWidths) CodeWidth(int) float64 { return 0 }
+
+func TestByte_Decode(t *testing.T) {
+	testCases := map[string]struct {
+		enc  *Byte
+		raw  string
+		want string
+	}{
+		"no differences": {
+			enc:  WinANSI(noWidths{}, nil),
+			raw:  "a\x80\x95",
+			want: "a€•",
+		},
+		"non-contiguous differences": {
+			enc: Standard(noWidths{}, map[byte]string{
+				'a': "Adieresis", 'b': "Odieresis",
+				'x':  "bullet",
+				0xb7: "endash", 0xb8: "emdash", 0xb9: "quotedblleft",
+			}),
+			raw:  "abcx\xb7\xb8\xb9\xba",
+			want: "ÄÖc•–—“”",
+		},
+		"unknown glyph names": {
+			enc:  MacRoman(noWidths{}, map[byte]string{'a': "g12", 0x80: "unknown", 'c': "uniZZZZ"}),
+			raw:  "abc\x80",
+			want: "abcÄ",
+		},
+		"multiple runes": {
+			enc:  Standard(noWidths{}, map[byte]string{'a': "f_f", 'b': "uni0066006C"}),
+			raw:  "ab",
+			want: "fffl",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, _ := tc.enc.Decode(tc.raw); got != tc.want {
+				t.Errorf("Decode(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
@@ -22,11 +22,96 @@ type BFRange struct {
 	DstA []any
 }
 
+// A CIDChar maps the code Code to the CID CID.
+type CIDChar struct {
+	Code string
+	CID  int
+}
+
+// A CIDRange maps the codes from Lo to Hi to the CIDs from CID on.
+type CIDRange struct {
+	Lo, Hi string
+	CID    int
+}
+
 type CMap struct {
 	Widths   Sizer
 	Space    [4][]ByteRange // codespace range
 	BFRanges []BFRange
 	BFChars  []BFChar
+	// CIDChars and CIDRanges map codes to CIDs, in the CMap that is the
+	// encoding of a composite font. The widths of the font are those of CIDs.
+	CIDChars  []CIDChar
+	CIDRanges []CIDRange
+	// CIDText, if not nil, returns the text of a CID, for the codes that the
+	// BFChars and BFRanges do not map to any.
+	CIDText func(cid int) (string, bool)
+
+	stats    *Stats
+	unmapped func(code string) string
+}
+
+// Count makes the CMap count the codes that it decodes in s.
+func (m *CMap) Count(s *Stats) { m.stats = s }
+
+// Unmapped makes the CMap decode the codes that have no text to fn(code),
+// rather than to NoRune.
+func (m *CMap) Unmapped(fn func(code string) string) { m.unmapped = fn }
+
+// HasCodespace reports whether the CMap has any codespace ranges.
+func (m *CMap) HasCodespace() bool {
+	for _, space := range m.Space {
+		if len(space) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CID returns the CID of the code, and reports whether the CMap maps it to one.
+func (m *CMap) CID(code string) (int, bool) {
+	for _, c := range m.CIDChars {
+		if c.Code == code {
+			return c.CID, true
+		}
+	}
+	for _, r := range m.CIDRanges {
+		if len(r.Lo) == len(code) && r.Lo <= code && code <= r.Hi {
+			return r.CID + codeInt(code) - codeInt(r.Lo), true
+		}
+	}
+	return 0, false
+}
+
+// widthCode returns the CID whose width is that of the code, whose value is n: its CID,
+// or CID 0 if it has none, if the CMap maps codes to CIDs, or else the code as a CID.
+func (m *CMap) widthCode(code string, n int) int {
+	if len(m.CIDChars) > 0 || len(m.CIDRanges) > 0 {
+		cid, _ := m.CID(code)
+		return cid
+	}
+	return n
+}
+
+// cidText returns the text of the CID of the code by CIDText, or NoRune if it has none.
+func (m *CMap) cidText(code string) string {
+	if m.CIDText != nil {
+		if cid, ok := m.CID(code); ok {
+			if s, ok := m.CIDText(cid); ok {
+				return s
+			}
+		}
+	}
+	return string(NoRune)
+}
+
+// codeInt returns the value of the code, as a big-endian number.
+func codeInt(code string) int {
+	var n int
+	for i := 0; i < len(code); i++ {
+		n = n<<8 | int(code[i])
+	}
+	return n
 }
 
 func (m *CMap) Decode(raw string) (string, float64) {
@@ -34,58 +119,96 @@ func (m *CMap) Decode(raw string) (string, float64) {
 		w float64
 		r strings.Builder
 	)
-
-Parse:
 	for len(raw) > 0 {
-		var code int
-		for n := 1; n <= 4 && n <= len(raw); n++ { // number of digits in character replacement (1-4 possible)
-			code = (code << 8) | int(raw[n-1])
-			for _, space := range m.Space[n-1] { // find matching codespace Ranges for number of digits
-				if space.Lo <= raw[:n] && raw[:n] <= space.Hi { // see if value is in range
-					text := raw[:n]
-					raw = raw[n:]
-					for _, bfchar := range m.BFChars { // check for matching bfchar
-						if len(bfchar.Orig) == n && bfchar.Orig == text {
-							r.WriteString(UTF16Decode(bfchar.Repl))
-							w += m.Widths.CodeWidth(code)
-							continue Parse
-						}
-					}
-					for _, bfrange := range m.BFRanges { // check for matching bfrange
-						if len(bfrange.Lo) == n && bfrange.Lo <= text && text <= bfrange.Hi {
-							switch {
-							case len(bfrange.DstS) > 0:
-								s := bfrange.DstS
-								if bfrange.Lo != text { // value isn't at the beginning of the range so scale result
-									b := []byte(s)
-									b[len(b)-1] += text[len(text)-1] - bfrange.Lo[len(bfrange.Lo)-1] // increment last byte by difference
-									s = string(b)
-								}
-								r.WriteString(UTF16Decode(s))
-								w += m.Widths.CodeWidth(code)
-								continue Parse
-							case len(bfrange.DstA) > 0:
-								n := text[len(text)-1] - bfrange.Lo[len(bfrange.Lo)-1]
-								s := bfrange.DstA[int(n)].(string)
-								r.WriteString(UTF16Decode(s))
-								w += m.Widths.CodeWidth(code)
-								continue Parse
-							default:
-								slog.Debug("unknown dst", slog.Any("dst", bfrange.DstA))
-							}
-							r.WriteRune(NoRune)
-							continue Parse
-						}
-					}
-					r.WriteRune(NoRune)
-					continue Parse
-				}
+		n, ok := m.codeLen(raw)
+		text := raw[:n]
+		raw = raw[n:]
+		if !ok {
+			slog.Debug("no code space found")
+			m.stats.add(nil, 0, true)
+			r.WriteString(m.noText(text))
+			continue
+		}
+		s := m.text(text)
+		wc := m.widthCode(text, codeInt(text))
+		m.stats.add(m.Widths, wc, s == string(NoRune))
+		if s == string(NoRune) {
+			s = m.noText(text)
+		}
+		r.WriteString(s)
+		w += m.Widths.CodeWidth(wc)
+	}
+	return r.String(), w
+}
+
+// noText returns the text of the code, which has none: NoRune, unless Unmapped is set.
+func (m *CMap) noText(code string) string {
+	if m.unmapped != nil {
+		return m.unmapped(code)
+	}
+	return string(NoRune)
+}
+
+// codeLen returns the length of the code at the start of raw: that of the longest
+// codespace range that it is in, byte by byte, and reports whether there is one.
+// Otherwise, the code is as long as the shortest codespace range, as are those that
+// are not in a range. See Adobe Technical Note #5014, §4.3.
+func (m *CMap) codeLen(raw string) (int, bool) {
+	for n := min(len(raw), 4); n > 0; n-- {
+		for _, space := range m.Space[n-1] {
+			if inSpace(raw[:n], space) {
+				return n, true
 			}
 		}
-		slog.Debug("no code space found")
-		r.WriteRune(NoRune)
-		raw = raw[1:]
 	}
+	for n := 1; n <= 4; n++ {
+		if len(m.Space[n-1]) > 0 {
+			return min(n, len(raw)), false
+		}
+	}
+	return 1, false
+}
 
-	return r.String(), w
+// inSpace reports whether each byte of the code is within those of the range.
+func inSpace(code string, space ByteRange) bool {
+	for i := 0; i < len(code); i++ {
+		if code[i] < space.Lo[i] || code[i] > space.Hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// text returns the text of the code, by the BFChars and BFRanges, or else by CIDText.
+func (m *CMap) text(code string) string {
+	for _, bfchar := range m.BFChars { // check for matching bfchar
+		if bfchar.Orig == code {
+			return UTF16Decode(bfchar.Repl)
+		}
+	}
+	for _, bfrange := range m.BFRanges { // check for matching bfrange
+		if len(bfrange.Lo) != len(code) || code < bfrange.Lo || code > bfrange.Hi {
+			continue
+		}
+		switch {
+		case len(bfrange.DstS) > 0:
+			s := bfrange.DstS
+			if bfrange.Lo != code { // value isn't at the beginning of the range so scale result
+				b := []byte(s)
+				b[len(b)-1] += code[len(code)-1] - bfrange.Lo[len(bfrange.Lo)-1] // increment last byte by difference
+				s = string(b)
+			}
+			return UTF16Decode(s)
+		case len(bfrange.DstA) > 0:
+			if n := int(code[len(code)-1] - bfrange.Lo[len(bfrange.Lo)-1]); n < len(bfrange.DstA) {
+				if s, ok := bfrange.DstA[n].(string); ok {
+					return UTF16Decode(s)
+				}
+			}
+		default:
+			slog.Debug("unknown dst", slog.Any("dst", bfrange.DstA))
+		}
+		return string(NoRune)
+	}
+	return m.cidText(code)
 }
@@ -0,0 +1,47 @@
+package encoding
+
+import "testing"
+
+func TestCMap_Decode(t *testing.T) {
+	text := []BFChar{
+		{Orig: "A", Repl: "\x00A"},
+		{Orig: "\x81\x40", Repl: "\x30\x01"},
+		{Orig: "\x00A", Repl: "\x00B"},
+	}
+	testCases := map[string]struct {
+		space [4][]ByteRange
+		raw   string
+		want  string
+	}{
+		"one and two bytes": {
+			space: [4][]ByteRange{{{Lo: "\x00", Hi: "\x80"}}, {{Lo: "\x81\x40", Hi: "\x9f\xfc"}}},
+			raw:   "A\x81\x40A",
+			want:  "A、A",
+		},
+		"two bytes with a leading byte in a one-byte range": {
+			space: [4][]ByteRange{{{Lo: "\x00", Hi: "\xff"}}, {{Lo: "\x81\x40", Hi: "\x9f\xfc"}}},
+			raw:   "\x81\x40A\x81",
+			want:  "、A�",
+		},
+		"bytes within ranges": {
+			// 0x8180 is between 0x8140 and 0x9ffc, but its second byte is not within theirs.
+			space: [4][]ByteRange{{{Lo: "\x00", Hi: "\x80"}}, {{Lo: "\x81\x40", Hi: "\x9f\x7e"}}},
+			raw:   "\x81\x80A",
+			want:  "��A",
+		},
+		"not in any range": {
+			space: [4][]ByteRange{1: {{Lo: "\x00\x00", Hi: "\x7f\xff"}}},
+			raw:   "\x80\x00\x00A",
+			want:  "�B",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			m := CMap{Widths: noWidths{}, Space: tc.space, BFChars: text}
+			if got, _ := m.Decode(tc.raw); got != tc.want {
+				t.Errorf("Decode(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
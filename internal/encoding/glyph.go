@@ -0,0 +1,73 @@
+package encoding
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// GlyphText returns the text of the glyph named name, by the algorithm of the Adobe
+// Glyph List Specification: the name without a suffix after a period is split into
+// components at underscores, each of which is a name of the Adobe Glyph List, a name
+// uniXXXX of one or more UTF-16 code units without surrogates, or a name uXXXX to
+// uXXXXXX of a code point. Other components have no text. GlyphText reports whether
+// the name has any text.
+func GlyphText(name string) (string, bool) {
+	name, _, _ = strings.Cut(name, ".")
+	var b strings.Builder
+	for _, c := range strings.Split(name, "_") {
+		if r, ok := nameToRune[c]; ok {
+			b.WriteRune(r)
+			continue
+		}
+		if hex, ok := strings.CutPrefix(c, "uni"); ok && len(hex) > 0 && len(hex)%4 == 0 {
+			if s, ok := codeUnits(hex); ok {
+				b.WriteString(s)
+				continue
+			}
+		}
+		if hex, ok := strings.CutPrefix(c, "u"); ok && len(hex) >= 4 && len(hex) <= 6 {
+			if r, ok := codePoint(hex); ok {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String(), b.Len() > 0
+}
+
+// codeUnits returns the runes of the sequence of four-digit hexadecimal numbers hex.
+func codeUnits(hex string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(hex); i += 4 {
+		r, ok := codePoint(hex[i : i+4])
+		if !ok {
+			return "", false
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), true
+}
+
+// codePoint returns the rune of the hexadecimal number hex, if it is a valid code point.
+func codePoint(hex string) (rune, bool) {
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// GlyphID returns the glyph index of the names of glyphs that producers give the glyphs
+// of subset fonts without any meaningful name, as g123 or gid123, and reports whether
+// name is such a name. The text of such glyphs is only given by the font's own cmap.
+func GlyphID(name string) (uint16, bool) {
+	digits, ok := strings.CutPrefix(name, "gid")
+	if !ok {
+		digits, ok = strings.CutPrefix(name, "g")
+	}
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(digits, 10, 16)
+	return uint16(n), err == nil
+}
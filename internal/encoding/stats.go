@@ -0,0 +1,35 @@
+package encoding
+
+// Stats tallies the codes that a decoder decodes, to judge how faithfully the text
+// of its font is extracted. A decoder that Count is called on counts them.
+type Stats struct {
+	// Codes is the number of codes decoded.
+	Codes int
+	// NoRune is the number of them that have no text, and decode to NoRune.
+	NoRune int
+	// DefaultWidths is the number of them that have no width of their own,
+	// and have the default width of the font.
+	DefaultWidths int
+}
+
+// A DefaultSizer is a Sizer that reports which codes have no width of their own,
+// and have its default width.
+type DefaultSizer interface {
+	Sizer
+	IsDefault(code int) bool
+}
+
+// add counts a code, whose width is that of code by widths, if any, and whose text
+// is NoRune if noRune is set.
+func (s *Stats) add(widths Sizer, code int, noRune bool) {
+	if s == nil {
+		return
+	}
+	s.Codes++
+	if noRune {
+		s.NoRune++
+	}
+	if d, ok := widths.(DefaultSizer); ok && d.IsDefault(code) {
+		s.DefaultWidths++
+	}
+}
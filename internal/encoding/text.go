@@ -5,13 +5,14 @@
 package encoding
 
 import (
+	"strings"
 	"unicode/utf16"
 
 	"golang.org/x/text/unicode/norm"
 )
 
 func IsPDFDocEncoded(s string) bool {
-	if IsUTF16(s) {
+	if IsUTF16(s) || IsUTF8(s) || IsUTF16LE(s) {
 		return false
 	}
 	for i := 0; i < len(s); i++ {
@@ -42,6 +43,50 @@ func IsUTF16(s string) bool {
 	return len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff && len(s)%2 == 0
 }
 
+// IsUTF16LE reports whether s is little-endian UTF-16 with a byte order mark,
+// which some writers use for text strings, though only big-endian is allowed.
+func IsUTF16LE(s string) bool {
+	return len(s) >= 2 && s[0] == 0xff && s[1] == 0xfe && len(s)%2 == 0
+}
+
+// IsUTF8 reports whether s is UTF-8 with a byte order mark, as the text
+// strings of PDF 2.0 may be. See ISO 32000-2:2020, §7.9.2.2.
+func IsUTF8(s string) bool {
+	return strings.HasPrefix(s, "\xef\xbb\xbf")
+}
+
+// DecodeText returns the text string s, in PDFDocEncoding, UTF-16 or UTF-8 by
+// its byte order mark, converted to UTF-8 without the mark. Strings in none of
+// them are returned as they are.
+func DecodeText(s string) string {
+	switch {
+	case IsUTF16(s):
+		return UTF16Decode(s[2:])
+	case IsUTF16LE(s):
+		return UTF16LEDecode(s[2:])
+	case IsUTF8(s):
+		return UTF8Decode(s[3:])
+	case IsPDFDocEncoded(s):
+		return PDFDocDecode(s)
+	}
+	return s
+}
+
+// UTF8Decode returns the UTF-8 string s, normalized as UTF16Decode does.
+// Invalid bytes are replaced by U+FFFD.
+func UTF8Decode(s string) string {
+	return norm.NFKC.String(strings.ToValidUTF8(s, "\ufffd"))
+}
+
+// UTF16LEDecode is like UTF16Decode, but s is little-endian.
+func UTF16LEDecode(s string) string {
+	var u []uint16
+	for i := 0; i+1 < len(s); i += 2 {
+		u = append(u, uint16(s[i+1])<<8|uint16(s[i]))
+	}
+	return norm.NFKC.String(string(utf16.Decode(u)))
+}
+
 func UTF16Decode(s string) string {
 	var u []uint16
 	for i := 0; i < len(s); i += 2 {
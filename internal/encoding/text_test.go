@@ -0,0 +1,43 @@
+package encoding
+
+import "testing"
+
+func TestDecodeText(t *testing.T) {
+	testCases := map[string]struct {
+		raw  string
+		want string
+	}{
+		"PDFDocEncoding": {
+			raw:  "Caf\xe9 \x80",
+			want: "Café •",
+		},
+		"UTF-16BE": {
+			raw:  "\xfe\xff\x00C\x00a\x00f\x00\xe9",
+			want: "Café",
+		},
+		"UTF-16LE": {
+			raw:  "\xff\xfeC\x00a\x00f\x00\xe9\x00",
+			want: "Café",
+		},
+		"UTF-8": {
+			raw:  "\xef\xbb\xbfCaf\xc3\xa9 \xe2\x82\xac",
+			want: "Café €",
+		},
+		"UTF-8 decomposed": {
+			raw:  "\xef\xbb\xbfCafe\xcc\x81",
+			want: "Café",
+		},
+		"invalid UTF-8": {
+			raw:  "\xef\xbb\xbfCaf\xe9",
+			want: "Caf�",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := DecodeText(tc.raw); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package matrix implements the 3x3 matrices of the transformations of PDF: the
+// current transformation matrix, the text matrices and the matrices of forms,
+// patterns and fonts. See PDF 32000-1:2008, §8.3.4.
+package matrix
+
+import "math"
+
+// A Matrix is a 3x3 matrix that transforms row vectors [x y 1], as the matrices
+// of PDF do. The matrix of the operands a b c d e f of the cm operator is
+//
+//	a b 0
+//	c d 0
+//	e f 1
+type Matrix [3][3]float64
+
+// Identity returns the identity matrix.
+func Identity() *Matrix {
+	return &Matrix{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0, 0, 1},
+	}
+}
+
+// New returns the matrix of the operands a b c d e f of the cm operator.
+func New(a, b, c, d, e, f float64) *Matrix {
+	return &Matrix{
+		{a, b, 0},
+		{c, d, 0},
+		{e, f, 1},
+	}
+}
+
+// Mul returns the product m×n: the transformation by m, then by n.
+func (m *Matrix) Mul(n *Matrix) *Matrix {
+	if m.affine() && n.affine() {
+		// The general product, less the terms of the final columns, which are zero
+		// but for the one that is one.
+		return &Matrix{
+			{m[0][0]*n[0][0] + m[0][1]*n[1][0], m[0][0]*n[0][1] + m[0][1]*n[1][1], 0},
+			{m[1][0]*n[0][0] + m[1][1]*n[1][0], m[1][0]*n[0][1] + m[1][1]*n[1][1], 0},
+			{m[2][0]*n[0][0] + m[2][1]*n[1][0] + n[2][0], m[2][0]*n[0][1] + m[2][1]*n[1][1] + n[2][1], 1},
+		}
+	}
+
+	var p Matrix
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				p[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return &p
+}
+
+// affine reports whether the final column of m is that of the matrices of PDF, 0 0 1.
+func (m *Matrix) affine() bool {
+	return m[0][2] == 0 && m[1][2] == 0 && m[2][2] == 1
+}
+
+// Invert returns the inverse of m, and reports whether there is one: whether m is
+// not singular, and its inverse has no infinite or NaN elements.
+func (m *Matrix) Invert() (*Matrix, bool) {
+	// The adjugate, the transpose of the matrix of cofactors, over the determinant.
+	var adj Matrix
+	for i := range 3 {
+		for j := range 3 {
+			r0, r1 := (j+1)%3, (j+2)%3
+			c0, c1 := (i+1)%3, (i+2)%3
+			adj[i][j] = m[r0][c0]*m[r1][c1] - m[r0][c1]*m[r1][c0]
+		}
+	}
+	det := m[0][0]*adj[0][0] + m[0][1]*adj[1][0] + m[0][2]*adj[2][0]
+	if det == 0 {
+		return nil, false
+	}
+	for i := range 3 {
+		for j := range 3 {
+			adj[i][j] /= det
+			if math.IsNaN(adj[i][j]) || math.IsInf(adj[i][j], 0) {
+				return nil, false
+			}
+		}
+	}
+	return &adj, true
+}
+
+// Apply returns the point (x, y) transformed by m.
+func (m *Matrix) Apply(x, y float64) (float64, float64) {
+	tx := x*m[0][0] + y*m[1][0] + m[2][0]
+	ty := x*m[0][1] + y*m[1][1] + m[2][1]
+	if m.affine() {
+		return tx, ty
+	}
+	w := x*m[0][2] + y*m[1][2] + m[2][2]
+	return tx / w, ty / w
+}
@@ -0,0 +1,102 @@
+package matrix
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// naiveMul returns the product m×n by the definition of the matrix product.
+func naiveMul(m, n *Matrix) *Matrix {
+	var p Matrix
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				p[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return &p
+}
+
+// randomAffine returns a random matrix of the operands of cm, of elements in
+// [-100, 100), with each of the elements zero one time in four, so that rotations,
+// scalings and translations alone are among them.
+func randomAffine(rng *rand.Rand) *Matrix {
+	var v [6]float64
+	for i := range v {
+		if rng.IntN(4) > 0 {
+			v[i] = rng.Float64()*200 - 100
+		}
+	}
+	return New(v[0], v[1], v[2], v[3], v[4], v[5])
+}
+
+func equal(m, n *Matrix, tol float64) bool {
+	for i := range 3 {
+		for j := range 3 {
+			if math.Abs(m[i][j]-n[i][j]) > tol*max(1, math.Abs(n[i][j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestMatrix_Mul(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 10_000 {
+		m, n := randomAffine(rng), randomAffine(rng)
+		if got, want := m.Mul(n), naiveMul(m, n); *got != *want {
+			t.Fatalf("got %v×%v = %v, want %v", m, n, got, want)
+		}
+	}
+
+	// Matrices that are not affine take the general product.
+	m := &Matrix{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	if got, want := m.Mul(m), naiveMul(m, m); *got != *want {
+		t.Errorf("got %v×%v = %v, want %v", m, m, got, want)
+	}
+}
+
+func TestMatrix_Invert(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 10_000 {
+		m := randomAffine(rng)
+		inv, ok := m.Invert()
+		if det := m[0][0]*m[1][1] - m[0][1]*m[1][0]; !ok {
+			if det != 0 {
+				t.Fatalf("found no inverse of %v, of determinant %v", m, det)
+			}
+			continue
+		}
+		if got := m.Mul(inv); !equal(got, Identity(), 1e-6) {
+			t.Fatalf("got %v×%v = %v, want the identity", m, inv, got)
+		}
+		x, y := rng.Float64()*200-100, rng.Float64()*200-100
+		if gx, gy := inv.Apply(m.Apply(x, y)); math.Abs(gx-x) > 1e-6 || math.Abs(gy-y) > 1e-6 {
+			t.Fatalf("got (%v, %v) transformed by %v and its inverse, want (%v, %v)", gx, gy, m, x, y)
+		}
+	}
+
+	if inv, ok := New(1, 2, 2, 4, 5, 6).Invert(); ok {
+		t.Errorf("got inverse %v of a singular matrix", inv)
+	}
+}
+
+func TestMatrix_Apply(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 10_000 {
+		m, n := randomAffine(rng), randomAffine(rng)
+		x, y := rng.Float64()*200-100, rng.Float64()*200-100
+		// Transforming by m, then by n, is transforming by m×n.
+		wx, wy := m.Mul(n).Apply(x, y)
+		if gx, gy := n.Apply(m.Apply(x, y)); math.Abs(gx-wx) > 1e-6*max(1, math.Abs(wx)) || math.Abs(gy-wy) > 1e-6*max(1, math.Abs(wy)) {
+			t.Fatalf("got (%v, %v) transformed by %v and %v, want (%v, %v)", gx, gy, m, n, wx, wy)
+		}
+	}
+
+	if x, y := New(0, 1, -1, 0, 10, 20).Apply(1, 2); x != 8 || y != 21 {
+		t.Errorf("got (%v, %v), want (8, 21)", x, y)
+	}
+}
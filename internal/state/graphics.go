@@ -1,5 +1,12 @@
 package state
 
+import (
+	"log/slog"
+	"math"
+
+	"github.com/ScriptRock/pdf/internal/matrix"
+)
+
 // Graphics holds some state defined in:
 // PDF_ISO_32000-2: Table 51: Device-independent graphics state parameters
 // and
@@ -13,40 +20,96 @@ type Graphics struct {
 }
 
 type gState struct {
-	ctm *matrix
+	ctm *matrix.Matrix
+	// clip is the bounds of the clipping path in default user space, [llx lly urx ury],
+	// or nil if no clipping path has been set. See PDF_ISO_32000-2: 8.5.4 Clipping path operators.
+	clip *[4]float64
 	Text
 }
 
 func (g *Graphics) Push() {
 	if g.gState.ctm == nil {
-		g.gState.ctm = identity()
+		g.gState.ctm = matrix.Identity()
 	}
 
 	g.stack = append(g.stack, g.gState)
 }
 
+// Pop restores the graphics state saved by the matching Push.
+// Without one, it keeps the current state.
 func (g *Graphics) Pop() {
 	n := len(g.stack)
+	if n == 0 {
+		slog.Debug("graphics state restored without being saved")
+		return
+	}
 	g.gState = g.stack[n-1]
 	g.stack = g.stack[:n-1]
 }
 
+// Depth returns the number of graphics states saved by Push and not yet restored.
+func (g *Graphics) Depth() int {
+	return len(g.stack)
+}
+
 func (g *Graphics) Tj(r Renderer, raw string) {
 	if g.gState.ctm == nil {
-		g.gState.ctm = identity()
+		g.gState.ctm = matrix.Identity()
 	}
 	g.gState.Text.Tj(g.gState.ctm, r, raw)
 }
 
 func (g *Graphics) CM(a, b, c, d, e, f float64) {
-	m := &matrix{
-		{a, b, 0},
-		{c, d, 0},
-		{e, f, 1},
+	if !finite(a, b, c, d, e, f) {
+		slog.Debug("ignoring non-finite transformation matrix", slog.Any("cm", []float64{a, b, c, d, e, f}))
+		return
 	}
+	m := matrix.New(a, b, c, d, e, f)
 	if g.gState.ctm == nil {
 		g.gState.ctm = m
 	} else {
 		g.gState.ctm = m.Mul(g.gState.ctm)
 	}
 }
+
+// CTM returns the current transformation matrix, as the operands of the cm operator.
+func (g *Graphics) CTM() [6]float64 {
+	m := g.gState.ctm
+	if m == nil {
+		m = matrix.Identity()
+	}
+	return [6]float64{m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1]}
+}
+
+// Clip intersects the clipping path with the rectangle from (x0, y0) to (x1, y1),
+// in default user space. The clipping path is approximated by its bounds, which
+// are empty once a clipping path has no area.
+func (g *Graphics) Clip(x0, y0, x1, y1 float64) {
+	c := [4]float64{min(x0, x1), min(y0, y1), max(x0, x1), max(y0, y1)}
+	if g.gState.clip != nil {
+		c[0], c[1] = max(c[0], g.gState.clip[0]), max(c[1], g.gState.clip[1])
+		c[2], c[3] = min(c[2], g.gState.clip[2]), min(c[3], g.gState.clip[3])
+	}
+	g.gState.clip = &c
+}
+
+// ClipBounds returns the bounds of the clipping path, in default user space,
+// and whether one has been set. The bounds are empty, with x0 >= x1 or y0 >= y1,
+// if the clipping path has no area.
+func (g *Graphics) ClipBounds() (x0, y0, x1, y1 float64, ok bool) {
+	if g.gState.clip == nil {
+		return 0, 0, 0, 0, false
+	}
+	c := g.gState.clip
+	return c[0], c[1], c[2], c[3], true
+}
+
+// finite reports whether none of vs is infinite or NaN.
+func finite(vs ...float64) bool {
+	for _, v := range vs {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
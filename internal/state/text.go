@@ -1,7 +1,10 @@
 package state
 
 import (
+	"log/slog"
 	"math"
+
+	"github.com/ScriptRock/pdf/internal/matrix"
 )
 
 type Font interface {
@@ -9,6 +12,69 @@ type Font interface {
 	Decode(string) (string, float64)
 }
 
+// A VerticalFont is a Font that may be in vertical writing mode. The widths that
+// Decode returns for a font in vertical writing mode are vertical displacements.
+type VerticalFont interface {
+	Font
+	Vertical() bool
+}
+
+// A SpacedFont is a Font that knows the width of its space character.
+type SpacedFont interface {
+	Font
+	// SpaceWidth returns the width of a space in glyph space units, or zero if it is unknown.
+	SpaceWidth() float64
+}
+
+// A MetricFont is a Font that knows how far its glyphs extend above and below the baseline.
+type MetricFont interface {
+	Font
+	// Metrics returns the ascent and descent of the font, in glyph space units, the
+	// descent being negative, or zeros if they are unknown.
+	Metrics() (ascent, descent float64)
+}
+
+// The ascent and descent, in glyph space units, of a font whose metrics are unknown.
+const (
+	defaultAscent  = 750
+	defaultDescent = -250
+)
+
+// metrics returns the ascent and descent of the font f, in text space units.
+func metrics(f Font) (ascent, descent float64) {
+	if m, ok := f.(MetricFont); ok {
+		ascent, descent = m.Metrics()
+	}
+	switch {
+	case ascent == 0 && descent == 0:
+		ascent, descent = defaultAscent, defaultDescent
+	case ascent <= 0:
+		ascent = defaultAscent
+	}
+	// Some producers give the descent as a positive distance below the baseline.
+	return ascent / 1000, -math.Abs(descent) / 1000
+}
+
+// vertical reports whether the font f is in vertical writing mode.
+func vertical(f Font) bool {
+	v, ok := f.(VerticalFont)
+	return ok && v.Vertical()
+}
+
+// identityFont stands in for a font that is missing: it maps each byte
+// to the code point of the same value, and gives each glyph zero width.
+type identityFont struct{}
+
+func (identityFont) Name() string { return "" }
+
+func (identityFont) Decode(raw string) (string, float64) {
+	r := make([]rune, len(raw))
+	for i := range len(raw) {
+		r[i] = rune(raw[i])
+	}
+	return string(r), 0
+}
+
 // Text holds most state defined in:
 // PDF_ISO_32000-2: Table 102: Text state parameters
 //
@@ -23,25 +89,46 @@ type Text struct {
 	tl    float64
 	tf    Font
 	tfs   float64
-	tm    *matrix
-	tlm   *matrix
+	tr    int
+	tm    *matrix.Matrix
+	tlm   *matrix.Matrix
 }
 
 func (t *Text) Tc(v float64) { t.tc = v }
 
 func (t *Text) Tw(v float64) { t.tw = v }
 
-func (t *Text) Tz(v float64) { t.logTh = math.Log(v / 100) }
+// minTz is the least horizontal scaling, in percent, that Tz sets. Smaller
+// values, including zero or negative ones, are sometimes used to hide text.
+const minTz = 0.01
+
+func (t *Text) Tz(v float64) {
+	switch {
+	case math.IsNaN(v), math.IsInf(v, 0):
+		slog.Debug("ignoring non-finite horizontal scaling", slog.Float64("Tz", v))
+		return
+	case v < minTz:
+		v = minTz
+	}
+	t.logTh = math.Log(v / 100)
+}
 
 func (t *Text) TL(v float64) { t.tl = v }
 
+func (t *Text) Tr(v int) { t.tr = v }
+
+// Tf sets the font and font size. A nil font is replaced by one
+// that maps each byte to the code point of the same value.
 func (t *Text) Tf(font Font, size float64) {
+	if font == nil {
+		font = identityFont{}
+	}
 	t.tf = font
 	t.tfs = size
 }
 
 func (t *Text) BT() {
-	t.tlm = identity()
+	t.tlm = matrix.Identity()
 	t.tm = t.tlm
 }
 
@@ -50,13 +137,18 @@ func (t *Text) ET() {
 	t.tm = nil
 }
 
-func (t *Text) Td(tx, ty float64) {
-	m := matrix{
-		{1, 0, 0},
-		{0, 1, 0},
-		{tx, ty, 1},
+// inText initializes the text matrices, as BT does, if the operator op
+// appears outside a text object.
+func (t *Text) inText(op string) {
+	if t.tm == nil {
+		slog.Debug("text operator outside BT and ET", slog.String("op", op))
+		t.BT()
 	}
-	t.tlm = m.Mul(t.tlm)
+}
+
+func (t *Text) Td(tx, ty float64) {
+	t.inText("Td")
+	t.tlm = matrix.New(1, 0, 0, 1, tx, ty).Mul(t.tlm)
 	t.tm = t.tlm
 }
 
@@ -66,11 +158,11 @@ func (t *Text) TD(tx, ty float64) {
 }
 
 func (t *Text) Tm(a, b, c, d, e, f float64) {
-	t.tlm = &matrix{
-		{a, b, 0},
-		{c, d, 0},
-		{e, f, 1},
+	if !finite(a, b, c, d, e, f) {
+		slog.Debug("ignoring non-finite text matrix", slog.Any("Tm", []float64{a, b, c, d, e, f}))
+		return
 	}
+	t.tlm = matrix.New(a, b, c, d, e, f)
 	t.tm = t.tlm
 }
 
@@ -78,36 +170,112 @@ func (t *Text) Tstar() {
 	t.TD(0, -t.tl)
 }
 
+// A Run is a run of text shown by a text-showing operator.
+type Run struct {
+	// X and Y are the origin of the run on the page, Y being its baseline, W is its
+	// width and H the font size on the page, by which lines are spaced.
+	X, Y, W, H float64
+	Font       string
+	FontSize   float64
+	RenderMode int
+	// CTM is the current transformation matrix, [a b c d e f].
+	CTM [6]float64
+	// SpaceWidth is the width of a space in the font on the page, or zero if it is unknown.
+	SpaceWidth float64
+	// Box is the bounds of the run on the page, [llx lly urx ury], in default user space,
+	// from the descent of the font to its ascent.
+	Box  [4]float64
+	Text string
+}
+
 type Renderer interface {
-	Render(x, y, w, h float64, font, s string)
+	Render(Run)
 }
 
-func (t *Text) Tj(ctm *matrix, r Renderer, raw string) {
+func (t *Text) Tj(ctm *matrix.Matrix, r Renderer, raw string) {
+	if t.tf == nil {
+		// No font has been set.
+		t.tf = identityFont{}
+	}
+	t.inText("Tj")
 	fn := t.tf.Name()
 	s, w0 := t.tf.Decode(raw)
-	x, y, w, h := t.textDims(ctm, s, w0)
+	start := t.trm(ctm)
+	var x, y, w, h float64
+	if vertical(t.tf) {
+		x, y, w, h = t.verticalDims(ctm, s, w0)
+	} else {
+		x, y, w, h = t.textDims(ctm, s, w0)
+	}
 
-	r.Render(x, y, w, h, fn, s)
+	var space float64
+	if f, ok := t.tf.(SpacedFont); ok && !vertical(t.tf) {
+		rm := t.trm(ctm)
+		space = f.SpaceWidth() / 1000 * math.Sqrt(rm[0][0]*rm[0][0]+rm[0][1]*rm[0][1])
+	}
+
+	r.Render(Run{
+		X: x, Y: y, W: w, H: h,
+		SpaceWidth: space,
+		Font:       fn,
+		FontSize:   t.tfs,
+		RenderMode: t.tr,
+		CTM:        [6]float64{ctm[0][0], ctm[0][1], ctm[1][0], ctm[1][1], ctm[2][0], ctm[2][1]},
+		Box:        t.box(start, t.trm(ctm)),
+		Text:       s,
+	})
+}
+
+// box returns the bounds on the page of the glyphs shown from the text rendering
+// matrix start to end, [llx lly urx ury]. The glyphs extend from the descent of the
+// font to its ascent, or are a unit of text space wide, centred on their origin, in
+// vertical writing mode.
+func (t *Text) box(start, end *matrix.Matrix) [4]float64 {
+	ascent, descent := metrics(t.tf)
+	corners := [2][2]float64{{0, descent}, {0, ascent}}
+	if vertical(t.tf) {
+		corners = [2][2]float64{{-0.5, 0}, {0.5, 0}}
+	}
+	b := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, m := range [...]*matrix.Matrix{start, end} {
+		for _, c := range corners {
+			x, y := m.Apply(c[0], c[1])
+			b[0], b[1] = min(b[0], x), min(b[1], y)
+			b[2], b[3] = max(b[2], x), max(b[3], y)
+		}
+	}
+	return b
 }
 
 // TJDisplace handles that part of a TJ operator when one of the array elements is a glyph displacement.
 func (t *Text) TJDisplace(v float64) {
+	t.inText("TJ")
 	t.displace(-v, 0, 0)
 }
 
 // displace update the text matrix (cursor), but not the text line matrix (representing the beginning of the line),
 // in response to a glyph render or TJ glyph displacement.
+//
+// In vertical writing mode, the displacement is down the page, and v is negative.
 func (t *Text) displace(v, nc, nw float64) {
+	if vertical(t.tf) {
+		ty := v/1000*t.tfs - nc*t.tc - nw*t.tw
+		t.tm = matrix.New(1, 0, 0, 1, 0, ty).Mul(t.tm)
+		return
+	}
 	tx := (v/1000*t.tfs + nc*t.tc + nw*t.tw) * math.Exp(t.logTh)
-	t.tm = (&matrix{
-		{1, 0, 0},
-		{0, 1, 0},
-		{tx, 0, 1},
-	}).Mul(t.tm)
+	t.tm = matrix.New(1, 0, 0, 1, tx, 0).Mul(t.tm)
 }
 
+// textDims returns the origin of the text s, of width w0 in glyph space, in the frame
+// of its baseline, its width along the baseline and its height, and moves the text
+// matrix past it. The frame is that of the text rendering matrix rotated and skewed
+// back to upright: x is the distance along the baseline, y the distance across it,
+// and the height is that of the text measured across the baseline, so that text
+// drawn at any angle is measured as if it were drawn horizontally.
+//
 // See PDF_ISO_32000-2: 9.4.4 Text space details.
-func (t *Text) textDims(ctm *matrix, s string, w0 float64) (x, y, w, h float64) {
+func (t *Text) textDims(ctm *matrix.Matrix, s string, w0 float64) (x, y, w, h float64) {
 	rm := t.trm(ctm)
 
 	var nc, nw float64
@@ -123,34 +291,69 @@ func (t *Text) textDims(ctm *matrix, s string, w0 float64) (x, y, w, h float64)
 
 	trm := t.trm(ctm)
 
-	// Scale in x and y before write: these are diagonal elements of rm*rm^T.
-	sx := math.Sqrt(rm[0][0]*rm[0][0] + rm[0][1]*rm[0][1])
-	sy := math.Sqrt(rm[1][1]*rm[1][1] + rm[1][0]*rm[1][0])
-	// Scale in x and y after write.
-	tmsx := math.Sqrt(trm[0][0]*trm[0][0] + trm[0][1]*trm[0][1])
-	tmsy := math.Sqrt(trm[1][1]*trm[1][1] + trm[1][0]*trm[1][0])
-
-	// Calc pre-write translation: these are rm^-1 * the translation vector.
-	x = rm[0][0]/sx*rm[2][0] + rm[0][1]/sy*rm[2][1]
-	y = rm[1][0]/sy*rm[2][0] + rm[1][1]/sy*rm[2][1]
-	// Calc post-write x-translation.
-	xp := trm[0][0]/tmsx*trm[2][0] + trm[0][1]/tmsy*trm[2][1]
-	// Width is cursor post-write - cursor pre-write.
-	w = xp - x
-	// Height is vertical scale.
-	h = sy
+	// The unit vector along the baseline, and the scale of text space along it.
+	sx := math.Hypot(rm[0][0], rm[0][1])
+	ux, uy := unit(rm[0][0], sx), unit(rm[0][1], sx)
+	// The unit vector across the baseline: the vertical of text space, less its
+	// part along the baseline, which is the skew. Its length is the height of
+	// a unit of text space across the baseline.
+	vx, vy := rm[1][0], rm[1][1]
+	along := vx*ux + vy*uy
+	vx, vy = vx-along*ux, vy-along*uy
+	h = math.Hypot(vx, vy)
+	vx, vy = unit(vx, h), unit(vy, h)
+
+	x = ux*rm[2][0] + uy*rm[2][1]
+	y = vx*rm[2][0] + vy*rm[2][1]
+	// The width is the displacement of the origin, which is along the baseline,
+	// negative if the text moved backwards.
+	w = ux*(trm[2][0]-rm[2][0]) + uy*(trm[2][1]-rm[2][1])
 
 	return
 }
 
-// trm calculates the text rendering matrix,
-// see PDF_ISO_32000-2: 9.4.4 Text space details.
-func (t *Text) trm(ctm *matrix) *matrix {
-	m := &matrix{
-		{t.tfs * math.Exp(t.logTh), 0, 0},
-		{0, t.tfs, 0},
-		{0, 0, 1},
+// verticalDims is like textDims, for a font in vertical writing mode. So that
+// a Renderer that expects horizontal lines of text reads columns of vertical text
+// top to bottom, and the columns right to left, the dimensions are those of the
+// text rotated a quarter turn anticlockwise: x increases down the page, y
+// increases to the right, w is the distance down the page that the text covers,
+// and h is the width of a column.
+func (t *Text) verticalDims(ctm *matrix.Matrix, s string, w1 float64) (x, y, w, h float64) {
+	rm := t.trm(ctm)
+
+	var nc, nw float64
+	for _, r := range s {
+		if r == ' ' {
+			nw++
+		} else {
+			nc++
+		}
+	}
+
+	t.displace(w1, nc, nw)
+
+	trm := t.trm(ctm)
+
+	x = -rm[2][1]
+	y = rm[2][0]
+	w = rm[2][1] - trm[2][1]
+	h = math.Sqrt(rm[0][0]*rm[0][0] + rm[0][1]*rm[0][1])
+	return
+}
+
+// unit returns the element v of a matrix row or column with scale s, scaled to a
+// unit scale. A row or column of zero scale, such as that of a singular matrix or
+// zero font size, has no direction, and its elements scale to zero.
+func unit(v, s float64) float64 {
+	if s == 0 {
+		return 0
 	}
+	return v / s
+}
 
+// trm calculates the text rendering matrix,
+// see PDF_ISO_32000-2: 9.4.4 Text space details.
+func (t *Text) trm(ctm *matrix.Matrix) *matrix.Matrix {
+	m := matrix.New(t.tfs*math.Exp(t.logTh), 0, 0, t.tfs, 0, 0)
 	return m.Mul(t.tm).Mul(ctm)
 }
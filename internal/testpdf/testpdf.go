@@ -0,0 +1,350 @@
+// Package testpdf assembles small PDF files for the tests of the pdf package, from
+// objects of the types of package types, so that the files under test are written in
+// Go rather than committed as binaries. It writes files that the pdf package reads,
+// and the few malformations that its tests need: it is not a general PDF writer.
+package testpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A Stream is a stream object, of the dictionary Hdr and the data Data, which is
+// written as it is, already encoded by the filters that Hdr names.
+type Stream struct {
+	Hdr  types.Dict
+	Data []byte
+	// Length, if not zero, is written as the Length of the stream in place of the
+	// length of Data, to make a stream whose Length is wrong.
+	Length int64
+}
+
+// Flate returns a stream of the dictionary hdr, which may be nil, and the data
+// encoded by the FlateDecode filter.
+func Flate(hdr types.Dict, data []byte) *Stream {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+	d := types.Dict{"Filter": types.Name("FlateDecode")}
+	for k, v := range hdr {
+		d[k] = v
+	}
+	return &Stream{Hdr: d, Data: z.Bytes()}
+}
+
+// Raw is an object written as it is, in PDF syntax, such as a stream written by hand
+// or syntax that is malformed. Raw objects are never put in object streams.
+type Raw string
+
+// A File is a PDF file being assembled. The zero File has no objects, and is
+// written with a cross-reference table.
+type File struct {
+	// Trailer holds the entries of the trailer, such as Root and Info, besides Size,
+	// which is always written, first.
+	Trailer types.Dict
+	// XrefStream is whether the file is indexed by a cross-reference stream,
+	// rather than a table. See PDF 32000-1:2008, §7.5.8.
+	XrefStream bool
+	// ObjectStreams, if not zero, is the number of objects written to each object
+	// stream; objects other than streams, Raw objects and the encryption dictionary
+	// are written to them. Object streams need a cross-reference stream.
+	ObjectStreams int
+	// StartxrefDelta is added to the offset of the cross-reference section given
+	// after startxref, to make a file whose startxref is wrong.
+	StartxrefDelta int64
+
+	objs []types.Object
+	// key is the file key of the encryption of the file, or nil,
+	// and encrypt the encryption dictionary.
+	key     []byte
+	encrypt types.Objptr
+}
+
+// Add adds the object obj, one of the types of objects, a *Stream or Raw,
+// and returns a reference to it.
+func (f *File) Add(obj types.Object) types.Objptr {
+	f.objs = append(f.objs, obj)
+	return types.Objptr{ID: uint32(len(f.objs))}
+}
+
+// Reserve adds an object to be set later, and returns a reference to it,
+// for objects that refer to each other.
+func (f *File) Reserve() types.Objptr {
+	return f.Add(nil)
+}
+
+// Set sets the object that ptr refers to, returned by Add or Reserve, to obj.
+func (f *File) Set(ptr types.Objptr, obj types.Object) {
+	f.objs[ptr.ID-1] = obj
+}
+
+// passwordPad pads passwords to 32 bytes. See PDF 32000-1:2008, §7.6.3.3.
+var passwordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// EncryptRC4 encrypts the file by 128-bit RC4 with an empty user password, as
+// revision 3 of the standard security handler does, with the file identifier id.
+// It adds the encryption dictionary, and the Encrypt and ID entries of the trailer.
+// The strings and streams of objects are encrypted as they are written, except
+// for Raw objects. See PDF 32000-1:2008, §7.6.
+func (f *File) EncryptRC4(id []byte) {
+	owner := bytes.Repeat([]byte{0xAA}, 32)
+	const perms = -4
+
+	// Algorithm 2 computes the file key, and algorithm 5 the user password entry.
+	h := md5.New()
+	h.Write(passwordPad)
+	h.Write(owner)
+	h.Write([]byte{byte(perms & 0xff), byte(perms >> 8 & 0xff), byte(perms >> 16 & 0xff), byte(perms >> 24 & 0xff)})
+	h.Write(id)
+	key := h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	h.Reset()
+	h.Write(passwordPad)
+	h.Write(id)
+	user := h.Sum(nil)
+	for i := range 20 {
+		k := bytes.Clone(key)
+		for j := range k {
+			k[j] ^= byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(user, user)
+	}
+	user = append(user, make([]byte, 16)...)
+
+	f.encrypt = f.Add(types.Dict{
+		"Filter": types.Name("Standard"),
+		"V":      int64(2),
+		"R":      int64(3),
+		"Length": int64(128),
+		"P":      int64(perms),
+		"O":      string(owner),
+		"U":      string(user),
+	})
+	f.key = key
+	if f.Trailer == nil {
+		f.Trailer = types.Dict{}
+	}
+	f.Trailer["Encrypt"] = f.encrypt
+	f.Trailer["ID"] = types.Array{string(id), string(id)}
+}
+
+// crypt returns data encrypted for the object ptr, if the file is encrypted.
+func (f *File) crypt(ptr types.Objptr, data []byte) []byte {
+	if f.key == nil || ptr == f.encrypt || ptr.ID == 0 {
+		return data
+	}
+	// Algorithm 1 of PDF 32000-1:2008, §7.6.2.
+	h := md5.New()
+	h.Write(f.key)
+	h.Write([]byte{byte(ptr.ID), byte(ptr.ID >> 8), byte(ptr.ID >> 16), byte(ptr.Gen), byte(ptr.Gen >> 8)})
+	c, _ := rc4.NewCipher(h.Sum(nil))
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// inObjectStream reports whether the object i, counted from 0, is written to an object stream.
+func (f *File) inObjectStream(i int) bool {
+	switch f.objs[i].(type) {
+	case *Stream, Raw:
+		return false
+	}
+	return f.ObjectStreams > 0 && types.Objptr{ID: uint32(i + 1)} != f.encrypt
+}
+
+// Bytes returns the file. It panics if an object is not one of the types of objects.
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+
+	// The cross-reference entries of the objects, by number: their type, 1 for an
+	// object in the file and 2 for one in an object stream, and the offset of the
+	// object, or the number of its object stream and its index in it.
+	n := len(f.objs)
+	kinds := make([]byte, n+1)
+	fields := make([][2]int64, n+1)
+	for i := range f.objs {
+		if f.inObjectStream(i) {
+			continue
+		}
+		ptr := types.Objptr{ID: uint32(i + 1)}
+		kinds[ptr.ID], fields[ptr.ID] = 1, [2]int64{int64(b.Len()), 0}
+		f.writeObject(&b, ptr, f.objs[i])
+	}
+
+	if f.ObjectStreams > 0 {
+		var pending []int
+		flush := func() {
+			if len(pending) == 0 {
+				return
+			}
+			n++
+			var table, data bytes.Buffer
+			for j, i := range pending {
+				kinds[i+1], fields[i+1] = 2, [2]int64{int64(n), int64(j)}
+				fmt.Fprintf(&table, "%d %d ", i+1, data.Len())
+				// The objects of an object stream are encrypted with the stream.
+				f.write(&data, types.Objptr{}, f.objs[i])
+				data.WriteByte('\n')
+			}
+			kinds, fields = append(kinds, 1), append(fields, [2]int64{int64(b.Len()), 0})
+			ptr := types.Objptr{ID: uint32(n)}
+			f.writeObject(&b, ptr, &Stream{
+				Hdr:  types.Dict{"Type": types.Name("ObjStm"), "N": int64(len(pending)), "First": int64(table.Len())},
+				Data: append(table.Bytes(), data.Bytes()...),
+			})
+			pending = pending[:0]
+		}
+		for i := range f.objs {
+			if f.inObjectStream(i) {
+				if pending = append(pending, i); len(pending) == f.ObjectStreams {
+					flush()
+				}
+			}
+		}
+		flush()
+	}
+
+	xref := int64(b.Len())
+	if f.XrefStream || f.ObjectStreams > 0 {
+		n++
+		kinds, fields = append(kinds, 1), append(fields, [2]int64{xref, 0})
+		entries := []byte{0, 0, 0, 0, 0, 0xff, 0xff}
+		for id := 1; id <= n; id++ {
+			entries = append(entries, kinds[id])
+			entries = binary.BigEndian.AppendUint32(entries, uint32(fields[id][0]))
+			entries = binary.BigEndian.AppendUint16(entries, uint16(fields[id][1]))
+		}
+		hdr := types.Dict{"Type": types.Name("XRef"), "Size": int64(n + 1), "W": types.Array{int64(1), int64(4), int64(2)}}
+		for k, v := range f.Trailer {
+			hdr[k] = v
+		}
+		// The cross-reference stream is not encrypted.
+		fmt.Fprintf(&b, "%d 0 obj\n", n)
+		f.write(&b, types.Objptr{}, &Stream{Hdr: hdr, Data: entries})
+		b.WriteString("\nendobj\n")
+	} else {
+		fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", n+1)
+		for id := 1; id <= n; id++ {
+			fmt.Fprintf(&b, "%010d 00000 n \n", fields[id][0])
+		}
+		b.WriteString("trailer\n")
+		f.writeDict(&b, types.Objptr{}, f.Trailer, fmt.Sprintf("/Size %d", n+1), "")
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref+f.StartxrefDelta)
+	return b.Bytes()
+}
+
+// writeObject writes the indirect object obj, referred to by ptr.
+func (f *File) writeObject(b *bytes.Buffer, ptr types.Objptr, obj types.Object) {
+	fmt.Fprintf(b, "%d %d obj\n", ptr.ID, ptr.Gen)
+	f.write(b, ptr, obj)
+	b.WriteString("\nendobj\n")
+}
+
+// write writes the object obj, of the indirect object ptr, in PDF syntax.
+func (f *File) write(b *bytes.Buffer, ptr types.Objptr, obj types.Object) {
+	switch x := obj.(type) {
+	default:
+		panic(fmt.Sprintf("testpdf: cannot write %T", x))
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(x))
+	case int64:
+		b.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		if math.Trunc(x) == x {
+			fmt.Fprintf(b, "%.1f", x)
+		} else {
+			b.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+		}
+	case string:
+		fmt.Fprintf(b, "<%x>", f.crypt(ptr, []byte(x)))
+	case types.Name:
+		b.WriteByte('/')
+		for i := range len(x) {
+			if c := x[i]; c <= ' ' || c > '~' || bytes.IndexByte([]byte("#()<>[]{}/%"), c) >= 0 {
+				fmt.Fprintf(b, "#%02X", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	case types.Dict:
+		f.writeDict(b, ptr, x, "", "")
+	case types.Array:
+		b.WriteByte('[')
+		for i, elem := range x {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			f.write(b, ptr, elem)
+		}
+		b.WriteByte(']')
+	case types.Objptr:
+		fmt.Fprintf(b, "%d %d R", x.ID, x.Gen)
+	case *Stream:
+		data := f.crypt(ptr, x.Data)
+		length := int64(len(data))
+		if x.Length != 0 {
+			length = x.Length
+		}
+		hdr := types.Dict{}
+		for k, v := range x.Hdr {
+			if k != "Length" {
+				hdr[k] = v
+			}
+		}
+		f.writeDict(b, ptr, hdr, "", fmt.Sprintf("/Length %d", length))
+		b.WriteString("\nstream\n")
+		b.Write(data)
+		b.WriteString("\nendstream")
+	case Raw:
+		b.WriteString(string(x))
+	}
+}
+
+// writeDict writes the dictionary d, with its entries in order of their keys,
+// after the entries first and before the entries last, written in PDF syntax.
+func (f *File) writeDict(b *bytes.Buffer, ptr types.Objptr, d types.Dict, first, last string) {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	b.WriteString("<<")
+	b.WriteString(first)
+	for i, k := range keys {
+		if i > 0 || first != "" {
+			b.WriteByte(' ')
+		}
+		f.write(b, ptr, types.Name(k))
+		b.WriteByte(' ')
+		f.write(b, ptr, d[types.Name(k)])
+	}
+	if last != "" {
+		if len(keys) > 0 || first != "" {
+			b.WriteByte(' ')
+		}
+		b.WriteString(last)
+	}
+	b.WriteString(">>")
+}
@@ -41,6 +41,7 @@ type Objdef struct {
 
 type Xref struct {
 	Ptr      Objptr
+	Free     bool // Ptr.Gen is the generation number for the object's next use.
 	InStream bool
 	Stream   Objptr
 	Offset   int64
@@ -0,0 +1,199 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ScriptRock/pdf/internal/encoding"
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A Script is a JavaScript action of a document, found by JavaScript.
+// See PDF 32000-1:2008, §12.6.4.16.
+type Script struct {
+	// Location is where the action is attached: "Names/JavaScript/" and its name in the
+	// JavaScript name tree, "OpenAction", "AA/" and its trigger in the additional-actions
+	// dictionary of the catalog, "Page/AA/" and its trigger in that of a page,
+	// "Annot/A" or "Annot/AA/" and its trigger in those of an annotation, or "Field " and the
+	// fully qualified name of a form field, then "/A" or "/AA/" and its trigger. An action
+	// run after another, by its Next entry, has the location of the first, followed by "/Next".
+	Location string
+	// Page is the number of the page of the action, for those of pages and
+	// annotations, or zero.
+	Page int
+	// Source is the script, decoded from its JS string or stream. The script of a
+	// stream is read up to 16 MiB, and one that is longer is truncated, with a warning.
+	Source string
+}
+
+// maxScriptBytes is the greatest number of bytes of the JS stream of an action
+// that is read as its script.
+const maxScriptBytes = 1 << 24
+
+// maxActions is the greatest number of actions followed from one through their Next entries.
+const maxActions = 100
+
+// JavaScript returns the JavaScript actions of the document: those of its JavaScript name
+// tree, its OpenAction and additional actions, and those of its pages, annotations and form
+// fields, with where they are attached. The scripts are only read, not run.
+func (r *Reader) JavaScript() []Script {
+	var scripts []Script
+	r.walkScripts(func(s Script) bool {
+		scripts = append(scripts, s)
+		return true
+	})
+	return scripts
+}
+
+// HasJavaScript reports whether the document has any of the JavaScript actions
+// that JavaScript returns, stopping at the first.
+func (r *Reader) HasJavaScript() bool {
+	found := false
+	r.walkScripts(func(Script) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// walkScripts calls fn for each JavaScript action of the document, until fn returns false.
+func (r *Reader) walkScripts(fn func(Script) bool) {
+	w := scriptWalker{fn: fn}
+	root := r.trailerValue().Key("Root")
+
+	nameTree(root.Key("Names").Key("JavaScript")).Walk(func(name string, v Value) bool {
+		return w.action("Names/JavaScript/"+encoding.DecodeText(name), 0, v)
+	})
+	if w.stopped || !w.action("OpenAction", 0, root.Key("OpenAction")) || !w.additional("", 0, root.Key("AA")) {
+		return
+	}
+
+	// The fields of the form, whose widget annotations are left out of those of the pages.
+	fields := map[types.Objptr]bool{}
+	var walkFields func(field Value, parent types.Objptr, name string, depth int) bool
+	walkFields = func(field Value, parent types.Objptr, name string, depth int) bool {
+		if field.Kind() != Dict || depth > maxTreeDepth {
+			return true
+		}
+		if field.ptr != parent {
+			// An indirect field, which may be reached again through a cycle.
+			if fields[field.ptr] {
+				return true
+			}
+			fields[field.ptr] = true
+		}
+		if t := field.Key("T"); t.Kind() == String {
+			if name != "" {
+				name += "."
+			}
+			name += t.Text()
+		}
+		loc := fmt.Sprintf("Field %s/", name)
+		if !w.action(loc+"A", 0, field.Key("A")) || !w.additional(loc, 0, field.Key("AA")) {
+			return false
+		}
+		kids := field.Key("Kids")
+		for i := range kids.Len() {
+			if !walkFields(kids.Index(i), field.ptr, name, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	form := root.Key("AcroForm").Key("Fields")
+	for i := range form.Len() {
+		if !walkFields(form.Index(i), form.ptr, "", 0) {
+			return
+		}
+	}
+
+	for i := range r.NPages() {
+		p, err := r.GetPage(i + 1)
+		if err != nil {
+			continue
+		}
+		if !w.additional("Page/", i+1, p.v.Key("AA")) {
+			return
+		}
+		annots := p.v.Key("Annots")
+		for j := range annots.Len() {
+			annot := annots.Index(j)
+			if annot.Kind() != Dict || fields[annot.ptr] {
+				continue
+			}
+			if !w.action("Annot/A", i+1, annot.Key("A")) || !w.additional("Annot/", i+1, annot.Key("AA")) {
+				return
+			}
+		}
+	}
+}
+
+// A scriptWalker finds the JavaScript actions in action dictionaries.
+type scriptWalker struct {
+	fn      func(Script) bool
+	stopped bool
+}
+
+// additional calls fn for the JavaScript actions of the additional-actions dictionary aa,
+// in order of their triggers, and reports whether to go on.
+func (w *scriptWalker) additional(loc string, page int, aa Value) bool {
+	keys := aa.Keys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !w.action(loc+"AA/"+k, page, aa.Key(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+// action calls fn for the action dictionary v, if it is a JavaScript action, and
+// for those of its Next entries that are, and reports whether to go on.
+func (w *scriptWalker) action(loc string, page int, v Value) bool {
+	n := 0
+	var walk func(loc string, v Value) bool
+	walk = func(loc string, v Value) bool {
+		if w.stopped || v.Kind() != Dict || n >= maxActions {
+			return !w.stopped
+		}
+		n++
+		if v.Key("S").Name() == "JavaScript" {
+			if w.stopped = !w.fn(Script{Location: loc, Page: page, Source: scriptSource(v.Key("JS"), page)}); w.stopped {
+				return false
+			}
+		}
+		next := v.Key("Next")
+		if next.Kind() == Dict {
+			return walk(loc+"/Next", next)
+		}
+		for i := range next.Len() {
+			if !walk(loc+"/Next", next.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(loc, v)
+}
+
+// scriptSource returns the script of the JS entry js of a JavaScript action, a text
+// string or a stream of text, which may be in UTF-16. A stream that fails to decode
+// gives the script as far as it does, and one longer than maxScriptBytes is truncated
+// to them, with a warning.
+func scriptSource(js Value, page int) string {
+	if js.Kind() != Stream {
+		return js.Text()
+	}
+	rc := js.Reader()
+	defer rc.Close()
+	var b strings.Builder
+	n, _ := io.Copy(&b, io.LimitReader(rc, maxScriptBytes+1))
+	src := b.String()
+	if n > maxScriptBytes {
+		js.r.warn(StreamWarning, js.ptr, page, "JavaScript stream longer than %d bytes; truncating it", maxScriptBytes)
+		src = src[:maxScriptBytes]
+	}
+	return encoding.DecodeText(src)
+}
@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_JavaScript(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /Names <</JavaScript <</Names [(init) 5 0 R]>>>> "+
+			"/OpenAction <</S /GoTo /D [3 0 R /Fit] /Next [<</S /JavaScript /JS (open\\(\\))>>]>> "+
+			"/AA <</WC <</S /JavaScript /JS (close\\(\\))>>>> /AcroForm <</Fields [6 0 R]>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Annots [7 0 R 8 0 R] /AA <</O <</S /JavaScript /JS (page\\(\\))>>>>>>",
+		stream("keystroke()"),
+		"<</S /JavaScript /JS (init\\(\\))>>",
+		"<</T (form) /Kids [7 0 R]>>",
+		"<</Type /Annot /Subtype /Widget /Parent 6 0 R /T (total) /AA <</K <</S /JavaScript /JS 4 0 R>>>>>>",
+		// The script of the link is in UTF-16, with a byte order mark.
+		"<</Type /Annot /Subtype /Link /A <</S /JavaScript /JS <FEFF006C0069006E006B00280029>>>>>",
+	))
+
+	want := []Script{
+		{Location: "Names/JavaScript/init", Source: "init()"},
+		{Location: "OpenAction/Next", Source: "open()"},
+		{Location: "AA/WC", Source: "close()"},
+		{Location: "Field form.total/AA/K", Source: "keystroke()"},
+		{Location: "Page/AA/O", Page: 1, Source: "page()"},
+		{Location: "Annot/A", Page: 1, Source: "link()"},
+	}
+	if diff := cmp.Diff(want, r.JavaScript()); diff != "" {
+		t.Errorf("scripts mismatch (-want +got):\n%s", diff)
+	}
+	if !r.HasJavaScript() {
+		t.Error("got HasJavaScript false for a document with scripts")
+	}
+
+	r = openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"))
+	if got := r.JavaScript(); got != nil {
+		t.Errorf("got scripts %v for a document without any", got)
+	}
+	if r.HasJavaScript() {
+		t.Error("got HasJavaScript true for a document without scripts")
+	}
+}
+
+func TestReader_JavaScript_long(t *testing.T) {
+	// The stream decodes to a byte more than a script may have.
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("run();"))
+	zw.Write(bytes.Repeat([]byte{' '}, maxScriptBytes-5))
+	zw.Close()
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /OpenAction <</S /JavaScript /JS 3 0 R>>>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		fmt.Sprintf("<</Filter /FlateDecode /Length %d>>\nstream\n%s\nendstream", z.Len(), z.Bytes()),
+	))
+
+	scripts := r.JavaScript()
+	if len(scripts) != 1 {
+		t.Fatalf("got %d scripts, want 1", len(scripts))
+	}
+	if src := scripts[0].Source; len(src) != maxScriptBytes || !strings.HasPrefix(src, "run();") {
+		t.Errorf("got script of %d bytes starting %.10q, want %d bytes starting %q", len(src), src, maxScriptBytes, "run();")
+	}
+	want := Warning{Category: StreamWarning, ID: 3, Message: fmt.Sprintf("JavaScript stream longer than %d bytes; truncating it", maxScriptBytes)}
+	if w := r.Warnings(); !slices.Contains(w, want) {
+		t.Errorf("got warnings %v, want %v", w, want)
+	}
+}
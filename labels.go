@@ -0,0 +1,115 @@
+package pdf
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PageLabels returns the label of each page of the document, in page order: the page
+// numbers displayed by a viewer, such as "iv" for a page of front matter, or "1" for the
+// first page of the body. Pages without a label, as are all the pages of a document
+// without page labels, are labelled with their page number. See PDF 32000-1:2008, §12.4.2.
+func (r *Reader) PageLabels() []string {
+	n := r.NPages()
+	if n < 0 || n > 1<<20 {
+		return nil
+	}
+	labels := make([]string, n)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i + 1)
+	}
+
+	type labelRange struct {
+		key  int64
+		dict Value
+	}
+	var ranges []labelRange
+	numberTree(r.trailerValue().Key("Root").Key("PageLabels")).Walk(func(key int64, v Value) bool {
+		ranges = append(ranges, labelRange{key, v})
+		return true
+	})
+	// The ranges of a malformed tree may be out of order.
+	slices.SortStableFunc(ranges, func(a, b labelRange) int { return cmp.Compare(a.key, b.key) })
+	for i, rng := range ranges {
+		start, end := rng.key, int64(n)
+		if i+1 < len(ranges) {
+			end = min(ranges[i+1].key, end)
+		}
+		if start < 0 {
+			continue
+		}
+
+		prefix := rng.dict.Key("P").Text()
+		style := rng.dict.Key("S").Name()
+		first := int64(1)
+		if st := rng.dict.Key("St"); st.Kind() == Integer && st.Int64() > 0 {
+			first = st.Int64()
+		}
+		for page := start; page < end; page++ {
+			labels[page] = prefix + formatPageNumber(style, first+page-start)
+		}
+	}
+	return labels
+}
+
+// PageByLabel returns the number of the first page with the given label.
+// See PageLabels.
+func (r *Reader) PageByLabel(label string) (int, error) {
+	for i, l := range r.PageLabels() {
+		if l == label {
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("page label %q not found", label)
+}
+
+// formatPageNumber formats the page number n in the numbering style of a page label.
+func formatPageNumber(style string, n int64) string {
+	switch style {
+	case "D":
+		return strconv.FormatInt(n, 10)
+	case "R":
+		return roman(n)
+	case "r":
+		return strings.ToLower(roman(n))
+	case "A":
+		return letters(n)
+	case "a":
+		return strings.ToLower(letters(n))
+	}
+	// Labels without a numbering style have only a prefix.
+	return ""
+}
+
+// roman returns n as an upper case roman numeral.
+func roman(n int64) string {
+	if n <= 0 || n >= 1<<20 {
+		return strconv.FormatInt(n, 10)
+	}
+	numerals := []struct {
+		n       int64
+		numeral string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var b strings.Builder
+	for _, num := range numerals {
+		for ; n >= num.n; n -= num.n {
+			b.WriteString(num.numeral)
+		}
+	}
+	return b.String()
+}
+
+// letters returns n in the upper case letter numbering of page labels:
+// A to Z for 1 to 26, then AA to ZZ for 27 to 52, and so on.
+func letters(n int64) string {
+	if n <= 0 || n >= 1<<20 {
+		return strconv.FormatInt(n, 10)
+	}
+	return strings.Repeat(string(rune('A'+(n-1)%26)), int((n-1)/26+1))
+}
@@ -0,0 +1,12 @@
+package pdf
+
+import "strings"
+
+// Lang returns the natural language of the document declared by the Lang entry of its
+// catalog, as a language tag such as "en-US", or "" if it is not declared. The text of
+// structure elements in a different language is tagged with theirs by Sectioned, and
+// the script of undeclared text can be guessed with text.Text.Script.
+// See PDF 32000-1:2008, §14.9.2.
+func (r *Reader) Lang() string {
+	return strings.TrimSpace(r.trailerValue().Key("Root").Key("Lang").Text())
+}
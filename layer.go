@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// WithAllLayers makes text extraction include the text of every optional content group
+// (layer), including those hidden in the document's default configuration.
+func WithAllLayers() Option {
+	return func(c *config) {
+		c.allLayers = true
+		c.layers = nil
+	}
+}
+
+// WithLayers makes text extraction include the text of the named optional content
+// groups (layers) only, regardless of the document's default configuration.
+// Text outside any optional content group is always included.
+func WithLayers(names ...string) Option {
+	return func(c *config) {
+		c.allLayers = false
+		c.layers = append([]string{}, names...)
+	}
+}
+
+// Layers returns the names of the document's optional content groups (layers),
+// in the order they are listed in the catalog's OCProperties.
+// See PDF 32000-1:2008, §8.11.
+func (r *Reader) Layers() []string {
+	ocgs := r.trailerValue().Key("Root").Key("OCProperties").Key("OCGs")
+	var names []string
+	for i := range ocgs.Len() {
+		names = append(names, ocgs.Index(i).Key("Name").Text())
+	}
+	return names
+}
+
+// hiddenLayers returns the optional content groups whose content text
+// extraction skips: those that are OFF in the default configuration,
+// unless the Reader was opened with WithAllLayers or WithLayers.
+func (r *Reader) hiddenLayers() map[types.Objptr]bool {
+	ocprops := r.trailerValue().Key("Root").Key("OCProperties")
+	ocgs := ocprops.Key("OCGs")
+	hidden := map[types.Objptr]bool{}
+
+	switch {
+	case r.cfg.allLayers:
+		// Nothing is hidden.
+	case r.cfg.layers != nil:
+		shown := map[string]bool{}
+		for _, name := range r.cfg.layers {
+			shown[name] = true
+		}
+		for i := range ocgs.Len() {
+			if ocg := ocgs.Index(i); !shown[ocg.Key("Name").Text()] {
+				hidden[ocg.ptr] = true
+			}
+		}
+	default:
+		d := ocprops.Key("D")
+		if d.Key("BaseState").Name() == "OFF" {
+			for i := range ocgs.Len() {
+				hidden[ocgs.Index(i).ptr] = true
+			}
+		}
+		on, off := d.Key("ON"), d.Key("OFF")
+		for i := range on.Len() {
+			delete(hidden, on.Index(i).ptr)
+		}
+		for i := range off.Len() {
+			hidden[off.Index(i).ptr] = true
+		}
+	}
+	return hidden
+}
+
+// visible reports whether the content of the optional content group or membership
+// dictionary v is visible, given the hidden groups.
+// See PDF 32000-1:2008, §8.11.2.2.
+func visible(v Value, hidden map[types.Objptr]bool) bool {
+	if v.Key("Type").Name() != "OCMD" {
+		return !hidden[v.ptr]
+	}
+
+	// A membership dictionary names one group, or an array of them.
+	var on, off int
+	switch ocgs := v.Key("OCGs"); ocgs.Kind() {
+	case Dict:
+		if hidden[ocgs.ptr] {
+			off++
+		} else {
+			on++
+		}
+	case Array:
+		for i := range ocgs.Len() {
+			if ocg := ocgs.Index(i); ocg.Kind() != Dict {
+				continue
+			} else if hidden[ocg.ptr] {
+				off++
+			} else {
+				on++
+			}
+		}
+	default:
+		return true
+	}
+
+	switch v.Key("P").Name() {
+	case "AllOn":
+		return off == 0
+	case "AnyOff":
+		return off > 0
+	case "AllOff":
+		return on == 0
+	default: // AnyOn
+		return on > 0
+	}
+}
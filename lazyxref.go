@@ -0,0 +1,197 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// lazyXrefSparse is the number of entries up to which a lazyXref keeps them in a map.
+const lazyXrefSparse = 1 << 16
+
+// A lazyXref is the cross-reference table of a Reader opened with WithLazyXref, whose
+// sections are read newest first, as the entries of the sections read so far run out.
+type lazyXref struct {
+	mu   sync.Mutex
+	size uint32 // the Size of the trailer: greater object numbers have no entry.
+	// sparse holds the entries read, until there are more than lazyXrefSparse of them,
+	// and dense holds them after, indexed by object number.
+	sparse map[uint32]types.Xref
+	dense  []types.Xref
+	next   int64 // the offset of the next older section, or -1 if there is none.
+	seen   map[int64]bool
+}
+
+// readLazyXref reads the cross-reference section at startxref, and the older ones
+// until one has a trailer with a Root, and returns the table of the Reader and the
+// trailer, with the keys inherited from the older trailers read.
+func readLazyXref(ctx context.Context, r *Reader, startxref int64) (*lazyXref, types.Objptr, types.Dict, error) {
+	r.xrefChain = []int64{startxref}
+	section, trailerptr, trailer, err := readXrefSection(ctx, r, startxref)
+	if err != nil {
+		return nil, types.Objptr{}, nil, err
+	}
+	size, ok := trailer["Size"].(int64)
+	if !ok {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: trailer missing /Size entry")
+	}
+	if size < 0 || size > int64(r.cfg.maxObjects) {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: trailer Size %d outside [0, %d]", size, r.cfg.maxObjects)
+	}
+	l := &lazyXref{size: uint32(size), sparse: map[uint32]types.Xref{}, next: -1, seen: map[int64]bool{startxref: true}}
+	l.merge(section)
+	l.setNext(trailer)
+
+	for trailer["Root"] == nil && l.next >= 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		older, err := l.readNext(ctx, r)
+		if err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		mergeTrailer(trailer, older)
+	}
+	return l, trailerptr, trailer, nil
+}
+
+// entry returns the entry of the object id, reading older sections until one has it,
+// and reports whether id is below the Size of the trailer.
+func (l *lazyXref) entry(r *Reader, id uint32) (types.Xref, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id >= l.size {
+		return types.Xref{}, false
+	}
+	for {
+		if e := l.get(id); e != (types.Xref{}) || l.next < 0 {
+			return e, true
+		}
+		if _, err := l.readNext(context.Background(), r); err != nil {
+			r.warn(XrefWarning, types.Objptr{ID: id}, 0, "%v", err)
+		}
+	}
+}
+
+// table returns the whole table, reading the sections not read yet.
+func (l *lazyXref) table(r *Reader) []types.Xref {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.next >= 0 {
+		if _, err := l.readNext(context.Background(), r); err != nil {
+			r.warn(XrefWarning, types.Objptr{}, 0, "%v", err)
+		}
+	}
+	l.densify()
+	return l.dense
+}
+
+func (l *lazyXref) get(id uint32) types.Xref {
+	if l.dense != nil {
+		return l.dense[id]
+	}
+	return l.sparse[id]
+}
+
+// merge adds the entries of an older section than those merged before, which do not
+// replace theirs, as in mergeXref.
+func (l *lazyXref) merge(section []types.Xref) {
+	for _, e := range section {
+		id := e.Ptr.ID
+		switch {
+		case id >= l.size:
+		case l.dense != nil:
+			if l.dense[id] == (types.Xref{}) {
+				l.dense[id] = e
+			}
+		case l.sparse[id] == (types.Xref{}):
+			if l.sparse[id] = e; len(l.sparse) > lazyXrefSparse {
+				l.densify()
+			}
+		}
+	}
+}
+
+// densify moves the entries of the map to a table of all the objects.
+func (l *lazyXref) densify() {
+	if l.dense != nil {
+		return
+	}
+	l.dense = make([]types.Xref, l.size)
+	for id, e := range l.sparse {
+		l.dense[id] = e
+	}
+	l.sparse = nil
+}
+
+// setNext sets the next section to read to the Prev of trailer, if any.
+func (l *lazyXref) setNext(trailer types.Dict) {
+	l.next = -1
+	if prev, ok := trailer["Prev"].(int64); ok && prev >= 0 {
+		l.next = prev
+	}
+}
+
+// readNext reads and merges the next older section, and returns its trailer.
+// After an error, no older section is read.
+func (l *lazyXref) readNext(ctx context.Context, r *Reader) (types.Dict, error) {
+	off := l.next
+	l.next = -1
+	if l.seen[off] {
+		return nil, fmt.Errorf("malformed PDF: xref Prev chain contains a cycle at %d", off)
+	}
+	l.seen[off] = true
+	r.xrefChain = append(r.xrefChain, off)
+	section, _, trailer, err := readXrefSection(ctx, r, off)
+	if err != nil {
+		return nil, err
+	}
+	l.merge(section)
+	l.setNext(trailer)
+	return trailer, nil
+}
+
+// readXrefSection reads the cross-reference section at offset off, a table or a stream,
+// and returns its entries, the reference to the stream, if it is one, and its trailer or
+// the dictionary of the stream. It reads the section as the sections are read when the
+// file is opened without WithLazyXref, with a Reader of the file without a table or a
+// decrypter, whose warnings are added to those of r.
+func readXrefSection(ctx context.Context, r *Reader, off int64) (section []types.Xref, ptr types.Objptr, trailer types.Dict, err error) {
+	sr := &Reader{f: r.f, end: r.end, cfg: r.cfg}
+	defer func() {
+		for _, w := range sr.Warnings() {
+			r.warn(w.Category, types.Objptr{ID: w.ID, Gen: w.Gen}, w.Page, "%s", w.Message)
+		}
+	}()
+	defer catch(&err)
+
+	b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	defer b.release()
+	b.reader = sr
+	if tok := b.readToken(); tok != keyword("xref") {
+		b.unreadToken(tok)
+		ptr, strm, err := readXrefStreamObject(b)
+		if err != nil {
+			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
+		}
+		size, _ := strm.Hdr["Size"].(int64)
+		if section, err = readXrefStreamData(ctx, sr, strm, min(size, int64(r.cfg.maxObjects))); err != nil {
+			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
+		}
+		return section, ptr, strm.Hdr, nil
+	}
+	if section, err = readXrefTableData(b); err != nil {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
+	}
+	trailer, ok := b.readObject().(types.Dict)
+	if !ok {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref table not followed by trailer dictionary")
+	}
+	if section, err = readXrefStm(ctx, sr, trailer, section); err != nil {
+		return nil, types.Objptr{}, nil, err
+	}
+	return section, types.Objptr{}, trailer, nil
+}
@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_lazyXref(t *testing.T) {
+	// A file of many objects, updated three times, the last update rewriting the
+	// catalog and the page tree, so that NPages reads its section alone.
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	}
+	for i := range 1000 {
+		objs = append(objs, fmt.Sprint(i))
+	}
+	size := len(objs) + 1
+	data := buildPDF(objs...)
+	data = update(data, size, map[int]string{4: stream("BT /F1 12 Tf 72 720 Td (Goodbye) Tj ET")})
+	data = update(data, size, map[int]string{6: ""})
+	data = update(data, size, map[int]string{1: objs[0], 2: objs[1]})
+
+	open := func(t *testing.T, data []byte, opts ...Option) *Reader {
+		t.Helper()
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)), opts...)
+		if err != nil {
+			t.Fatal("failed to open PDF:", err)
+		}
+		return r
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		r := open(t, data, WithLazyXref())
+		if n := r.NPages(); n != 1 {
+			t.Errorf("got %d pages, want 1", n)
+		}
+		if got := len(r.xrefChain); got != 1 {
+			t.Errorf("got %d sections read, want 1", got)
+		}
+		if r.lazy.dense != nil {
+			t.Errorf("got a table of all the objects, want a map of those read")
+		}
+
+		txt, err := r.Text()
+		if err != nil {
+			t.Fatal("failed to read text:", err)
+		}
+		if want := "Goodbye"; txt.String() != want {
+			t.Errorf("got text %q, want %q", txt.String(), want)
+		}
+		if got := len(r.xrefChain); got != 4 {
+			t.Errorf("got %d sections read, want 4", got)
+		}
+	})
+
+	t.Run("same as eager", func(t *testing.T) {
+		eager, lazy := open(t, data), open(t, data, WithLazyXref())
+		for _, id := range []uint32{6, 7, 2000} {
+			_, err1 := eager.Object(id, 0)
+			_, err2 := lazy.Object(id, 0)
+			if fmt.Sprint(err1) != fmt.Sprint(err2) {
+				t.Errorf("got error %v for object %d, want %v", err2, id, err1)
+			}
+		}
+		if diff := cmp.Diff(eager.Xref(), lazy.Xref()); diff != "" {
+			t.Errorf("Xref mismatch (-eager +lazy):\n%s", diff)
+		}
+		if diff := cmp.Diff(eager.revisionEnds(), lazy.revisionEnds()); diff != "" {
+			t.Errorf("revisions mismatch (-eager +lazy):\n%s", diff)
+		}
+	})
+
+	t.Run("Root in an older trailer", func(t *testing.T) {
+		i := bytes.LastIndex(data, []byte("/Root 1 0 R /Prev"))
+		noRoot := append(bytes.Clone(data[:i]), bytes.Replace(data[i:], []byte("/Root 1 0 R "), nil, 1)...)
+		r := open(t, noRoot, WithLazyXref())
+		if got := len(r.xrefChain); got != 2 {
+			t.Errorf("got %d sections read, want 2", got)
+		}
+		if n := r.NPages(); n != 1 {
+			t.Errorf("got %d pages, want 1", n)
+		}
+	})
+
+	t.Run("broken Prev", func(t *testing.T) {
+		i := bytes.LastIndex(data, []byte("/Prev "))
+		broken := append(bytes.Clone(data[:i]), strings.Replace(string(data[i:]), "/Prev ", "/Prev 1", 1)...)
+		if _, err := NewReader(bytes.NewReader(broken), int64(len(broken))); err == nil {
+			t.Errorf("opened the file eagerly, want an error")
+		}
+		r := open(t, broken, WithLazyXref())
+		if n := r.NPages(); n != 1 {
+			t.Errorf("got %d pages, want 1", n)
+		}
+		if txt, _ := r.Text(); txt.String() != "" {
+			t.Errorf("got text %q of a page in an unreadable section, want none", txt.String())
+		}
+		if w := r.Warnings(); len(w) == 0 || w[0].Category != XrefWarning {
+			t.Errorf("got warnings %v, want one of the unreadable section", w)
+		}
+	})
+
+	t.Run("xref stream", func(t *testing.T) {
+		data := buildObjStmPDF(objs[:5]...)
+		r := open(t, data, WithLazyXref())
+		txt, err := r.Text()
+		if err != nil {
+			t.Fatal("failed to read text:", err)
+		}
+		if want := "Hello, world"; txt.String() != want {
+			t.Errorf("got text %q, want %q", txt.String(), want)
+		}
+		if diff := cmp.Diff(open(t, data).Xref(), r.Xref()); diff != "" {
+			t.Errorf("Xref mismatch (-eager +lazy):\n%s", diff)
+		}
+	})
+}
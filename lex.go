@@ -7,10 +7,13 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ScriptRock/pdf/internal/decrypter"
 	"github.com/ScriptRock/pdf/internal/types"
@@ -31,10 +34,55 @@ type token any
 // such as "<<", ">>", "[", "]", "{", "}", are also treated as keywords.
 type keyword string
 
+// The tokens of the names and keywords that most files are made of, and of small
+// integers, are read as shared values, so that reading does not allocate them anew.
+var (
+	internedNames    = map[string]token{}
+	internedKeywords = map[string]token{}
+	smallInts        [minSmallInt + 1024]token
+)
+
+// minSmallInt is the negated least of the small integers.
+const minSmallInt = 256
+
+func init() {
+	for _, n := range strings.Fields(`
+		A AcroForm ActualText Annot Annots Artifact Ascent AvgWidth BBox BaseEncoding BaseFont
+		BitsPerComponent Border CIDFontType0 CIDFontType2 CIDSystemInfo CIDToGIDMap CMap CapHeight
+		Catalog ColorSpace Columns Contents Count CropBox D DW Decode DecodeParms Descent
+		DescendantFonts DeviceCMYK DeviceGray DeviceRGB Dests Differences Encoding Encrypt ExtGState
+		Filter First FirstChar Flags FlateDecode Font FontBBox FontDescriptor FontFile FontFile2
+		FontFile3 FontName Form Group Height ID ImageB ImageC ImageI Image Index Info ItalicAngle K
+		Kids Lang LastChar Length Length1 Length2 Length3 Link MCID MacRomanEncoding MarkInfo Matrix
+		MediaBox Metadata MissingWidth N Names OC ObjStm Ordering Outlines P PDF Page Pages Parent
+		Pattern Pg Predictor Prev ProcSet Properties Rect Registry Resources Root Rotate S Shading
+		Size Span StemV StructParents StructTreeRoot Subtype Supplement Text ToUnicode TrueType Type
+		Type0 Type1 Type3 URI W W2 Width Widths WinAnsiEncoding XObject XRef Identity Identity-H
+		Identity-V StandardEncoding FontMatrix CharProcs Ascender MaxWidth XHeight StemH Leading`) {
+		internedNames[n] = types.Name(n)
+	}
+	for _, kw := range strings.Fields(`
+		obj endobj stream endstream R null xref trailer startxref n f
+		q Q cm w J j M d ri i gs m l c v y h re S s f F f* B B* b b* n W W*
+		BT ET Tc Tw Tz TL Tf Tr Ts Td TD Tm T* Tj TJ ' " d0 d1
+		CS cs SC SCN sc scn G g RG rg K k sh BI ID EI Do MP DP BMC BDC EMC BX EX
+		begin end def dict currentdict pop findresource defineresource usecmap
+		begincmap endcmap begincodespacerange endcodespacerange beginbfchar endbfchar
+		beginbfrange endbfrange begincidchar endcidchar begincidrange endcidrange`) {
+		internedKeywords[kw] = keyword(kw)
+	}
+	internedKeywords["true"] = true
+	internedKeywords["false"] = false
+	for i := range smallInts {
+		smallInts[i] = int64(i - minSmallInt)
+	}
+}
+
 // A buffer holds buffered input bytes from the PDF file.
 type buffer struct {
 	r           io.Reader // source of data
 	buf         []byte    // buffered data
+	bufp        *[]byte   // buf, from lexBuffers
 	pos         int       // read index in buf
 	offset      int64     // offset at end of buf; aka offset of next read
 	tmp         []byte    // scratch space for accumulating token
@@ -45,19 +93,57 @@ type buffer struct {
 	eof         bool
 	decrypter   *decrypter.Decrypter
 	objptr      types.Objptr
+	reader      *Reader // reader to record the warnings in, if any
+	strict      bool    // whether problems that parsing can recover from are errors
+	depth       int     // the nesting of the arrays, dictionaries and objects being read
 }
 
+// maxObjectDepth is the greatest nesting of arrays, dictionaries and indirect object
+// definitions that readObject reads, so that deeply nested input cannot overflow the
+// stack, which no recover would catch. PDF implementations are limited to 28. See
+// PDF 32000-1:2008, §C.2.
+const maxObjectDepth = 256
+
+// nest reads one level further into an object, failing once it is too deep; unnest
+// reads one level back out.
+func (b *buffer) nest() {
+	if b.depth++; b.depth > maxObjectDepth {
+		b.errorf("objects nested deeper than %d", maxObjectDepth)
+	}
+}
+
+func (b *buffer) unnest() {
+	b.depth--
+}
+
+// lexBuffers pools the data buffers of buffers, of 4096 bytes.
+var lexBuffers = sync.Pool{New: func() any {
+	b := make([]byte, 0, 4096)
+	return &b
+}}
+
 // newBuffer returns a new buffer reading from r at the given offset.
+// Its data buffer is reused once it is released.
 func newBuffer(r io.Reader, offset int64) *buffer {
+	bufp := lexBuffers.Get().(*[]byte)
 	return &buffer{
 		r:           r,
 		offset:      offset,
-		buf:         make([]byte, 0, 4096),
+		buf:         (*bufp)[:0],
+		bufp:        bufp,
 		allowObjptr: true,
 		allowStream: true,
 	}
 }
 
+// release returns the data buffer of b to be reused, once b is read no more.
+func (b *buffer) release() {
+	if b.bufp != nil {
+		lexBuffers.Put(b.bufp)
+		b.buf, b.bufp = nil, nil
+	}
+}
+
 func (b *buffer) readByte() byte {
 	if b.pos >= len(b.buf) {
 		b.reload()
@@ -74,6 +160,15 @@ func (b *buffer) errorf(format string, args ...any) {
 	panic(fmt.Errorf(format, args...))
 }
 
+// warnf reports a problem in the input that parsing can recover from,
+// or fails as errorf does if b is strict.
+func (b *buffer) warnf(format string, args ...any) {
+	if b.strict {
+		b.errorf(format, args...)
+	}
+	b.reader.warn(SyntaxWarning, b.objptr, 0, "%s at offset %d", fmt.Sprintf(format, args...), b.readOffset())
+}
+
 func (b *buffer) reload() bool {
 	n := cap(b.buf) - int(b.offset%int64(cap(b.buf)))
 	n, err := b.r.Read(b.buf[:n])
@@ -84,7 +179,11 @@ func (b *buffer) reload() bool {
 			b.eof = true
 			return false
 		}
-		b.errorf("malformed PDF: reading at offset %d: %v", b.offset, err)
+		if errors.Is(err, ErrUnsupportedFeature) {
+			// The data of a stream that cannot be decoded is not malformed.
+			panic(err)
+		}
+		b.errorf("malformed PDF: reading at offset %d: %w", b.offset, err)
 		return false
 	}
 	b.offset += int64(n)
@@ -151,8 +250,14 @@ func (b *buffer) readToken() token {
 	case '(':
 		return b.readLiteralString()
 
-	case '[', ']', '{', '}':
-		return keyword(string(c))
+	case '[':
+		return keyword("[")
+	case ']':
+		return keyword("]")
+	case '{':
+		return keyword("{")
+	case '}':
+		return keyword("}")
 
 	case '/':
 		return b.readName()
@@ -174,28 +279,52 @@ func (b *buffer) readToken() token {
 	}
 }
 
+// maxTokenLength is the greatest length of a string, name or keyword token, so that
+// a malformed token, as of a content stream that decompresses to a huge one, cannot
+// grow without bound.
+const maxTokenLength = 32 << 20
+
+// readHexString reads a hexadecimal string, after its opening <. Like Acrobat, it
+// skips the comments and any other characters that are not hexadecimal digits.
+// An odd number of digits ends as if followed by a 0. See PDF 32000-1:2008, §7.3.4.3.
 func (b *buffer) readHexString() token {
 	tmp := b.tmp[:0]
+	var hi int
+	odd, skipped := false, false
 	for {
-	Loop:
 		c := b.readByte()
+		if b.eof {
+			b.errorf("stream ended with open hex string")
+		}
 		if c == '>' {
 			break
 		}
-		if isSpace(c) {
-			goto Loop
-		}
-	Loop2:
-		c2 := b.readByte()
-		if isSpace(c2) {
-			goto Loop2
-		}
-		x := unhex(c)<<4 | unhex(c2)
-		if x < 0 {
-			b.errorf("malformed hex string %c %c %s", c, c2, b.buf[b.pos:])
-			break
+		x := unhex(c)
+		switch {
+		case x < 0 && c == '%':
+			skipped = true
+			for c != '\r' && c != '\n' {
+				c = b.readByte()
+			}
+			continue
+		case x < 0:
+			skipped = skipped || !isSpace(c)
+			continue
+		case odd:
+			tmp = append(tmp, byte(hi<<4|x))
+			if len(tmp) > maxTokenLength {
+				b.errorf("hex string longer than %d bytes", maxTokenLength)
+			}
+		default:
+			hi = x
 		}
-		tmp = append(tmp, byte(x))
+		odd = !odd
+	}
+	if odd {
+		tmp = append(tmp, byte(hi<<4))
+	}
+	if skipped {
+		b.warnf("skipped characters that are not hexadecimal digits in hex string")
 	}
 	b.tmp = tmp
 	return string(tmp)
@@ -217,11 +346,23 @@ func (b *buffer) readLiteralString() token {
 	tmp := b.tmp[:0]
 	depth := 1
 Loop:
-	for !b.eof {
+	for {
 		c := b.readByte()
+		if b.eof {
+			b.errorf("stream ended with open literal string")
+		}
+		if len(tmp) > maxTokenLength {
+			b.errorf("literal string longer than %d bytes", maxTokenLength)
+		}
 		switch c {
 		default:
 			tmp = append(tmp, c)
+		case '\r':
+			// An end-of-line marker in the string is read as a line feed.
+			if b.readByte() != '\n' {
+				b.unreadByte()
+			}
+			tmp = append(tmp, '\n')
 		case '(':
 			depth++
 			tmp = append(tmp, c)
@@ -233,8 +374,8 @@ Loop:
 		case '\\':
 			switch c = b.readByte(); c {
 			default:
-				b.errorf("invalid escape sequence \\%c", c)
-				tmp = append(tmp, '\\', c)
+				// The backslash of an unknown escape is ignored.
+				b.unreadByte()
 			case 'n':
 				tmp = append(tmp, '\n')
 			case 'r':
@@ -264,9 +405,7 @@ Loop:
 					}
 					x = x*8 + int(c-'0')
 				}
-				if x > 255 {
-					b.errorf("invalid octal escape \\%03o", x)
-				}
+				// The high-order overflow of an escape above \377 is ignored.
 				tmp = append(tmp, byte(x))
 			}
 		}
@@ -283,6 +422,9 @@ func (b *buffer) readName() token {
 			b.unreadByte()
 			break
 		}
+		if len(tmp) >= maxTokenLength {
+			b.errorf("name longer than %d bytes", maxTokenLength)
+		}
 		if c == '#' {
 			x := unhex(b.readByte())<<4 | unhex(b.readByte())
 			if x < 0 {
@@ -294,6 +436,9 @@ func (b *buffer) readName() token {
 		tmp = append(tmp, c)
 	}
 	b.tmp = tmp
+	if t, ok := internedNames[string(tmp)]; ok {
+		return t
+	}
 	return types.Name(string(tmp))
 }
 
@@ -305,32 +450,111 @@ func (b *buffer) readKeyword() token {
 			b.unreadByte()
 			break
 		}
+		if len(tmp) >= maxTokenLength {
+			b.errorf("keyword longer than %d bytes", maxTokenLength)
+		}
 		tmp = append(tmp, c)
 	}
 	b.tmp = tmp
-	s := string(tmp)
+	if t, ok := internedKeywords[string(tmp)]; ok {
+		return t
+	}
 	switch {
-	case s == "true":
-		return true
-	case s == "false":
-		return false
-	case isInteger(s):
-		x, err := strconv.ParseInt(s, 10, 64)
+	case isInteger(tmp):
+		if x, ok := parseInt(tmp); ok {
+			if i := x + minSmallInt; i >= 0 && i < int64(len(smallInts)) {
+				return smallInts[i]
+			}
+			return x
+		}
+		// ParseInt clamps integers too large for int64, as Acrobat does.
+		x, err := strconv.ParseInt(string(tmp), 10, 64)
 		if err != nil {
-			b.errorf("invalid integer %s", s)
+			b.warnf("integer %s out of range", tmp)
 		}
 		return x
-	case isReal(s):
-		x, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			b.errorf("invalid real %s", s)
+	case isReal(tmp):
+		if x, err := strconv.ParseFloat(string(tmp), 64); err == nil {
+			return x
 		}
+	}
+	if len(tmp) > 0 && (isDigit(tmp[0]) || tmp[0] == '+' || tmp[0] == '-' || tmp[0] == '.') {
+		x := numberPrefix(tmp)
+		b.warnf("malformed number %s read as %v", tmp, x)
 		return x
 	}
 	return keyword(string(tmp))
 }
 
-func isInteger(s string) bool {
+// numberPrefix returns the number that the token s, which begins as a number does but is
+// not one, is read as: its longest prefix that is a number, which may have an exponent,
+// or zero if it has no digits. Numbers out of range are clamped to the greatest in range.
+func numberPrefix(s []byte) token {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits, real := 0, false
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		real = true
+		for i++; i < len(s) && isDigit(s[i]); i++ {
+			digits++
+		}
+	}
+	if digits > 0 && i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			i, real = j, true
+		}
+	}
+
+	switch {
+	case digits == 0 && real:
+		return 0.0
+	case digits == 0:
+		return int64(0)
+	case real:
+		x, _ := strconv.ParseFloat(string(s[:i]), 64)
+		return max(min(x, math.MaxFloat64), -math.MaxFloat64)
+	}
+	x, _ := strconv.ParseInt(string(s[:i]), 10, 64)
+	return x
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parseInt returns the value of the integer s, as isInteger reports it to be,
+// and reports whether it has few enough digits not to overflow.
+func parseInt(s []byte) (int64, bool) {
+	neg := s[0] == '-'
+	if s[0] == '+' || s[0] == '-' {
+		s = s[1:]
+	}
+	if len(s) > 18 {
+		return 0, false
+	}
+	var x int64
+	for _, c := range s {
+		x = x*10 + int64(c-'0')
+	}
+	if neg {
+		x = -x
+	}
+	return x, true
+}
+
+func isInteger(s []byte) bool {
 	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
 		s = s[1:]
 	}
@@ -345,7 +569,7 @@ func isInteger(s string) bool {
 	return true
 }
 
-func isReal(s string) bool {
+func isReal(s []byte) bool {
 	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
 		s = s[1:]
 	}
@@ -365,8 +589,54 @@ func isReal(s string) bool {
 	return ndot == 1
 }
 
+// readInlineImage reads the dictionary and data of an inline image, following the
+// BI operator that begins it, up to and including the EI operator that ends it.
+// See PDF 32000-1:2008, §8.9.7.
+func (b *buffer) readInlineImage() (types.Dict, []byte) {
+	dict := types.Dict{}
+	for {
+		tok := b.readToken()
+		if tok == keyword("ID") || tok == io.EOF {
+			break
+		}
+		key, ok := tok.(types.Name)
+		if !ok {
+			b.errorf("malformed inline image: dictionary key %v", objfmt(tok))
+		}
+		dict[key] = b.readObject()
+	}
+
+	// A single white-space character follows ID, and the data ends at an EI
+	// between white space and white space or a delimiter.
+	b.readByte()
+	var data []byte
+	for !b.eof {
+		data = append(data, b.readByte())
+		n := len(data)
+		if n < 2 || data[n-2] != 'E' || data[n-1] != 'I' || n > 2 && !isSpace(data[n-3]) {
+			continue
+		}
+		c := b.readByte()
+		b.unreadByte()
+		if isSpace(c) || isDelim(c) || b.eof {
+			return dict, data[:max(n-3, 0)]
+		}
+	}
+	b.warnf("malformed inline image: missing EI")
+	return dict, data[:max(len(data)-1, 0)]
+}
+
 func (b *buffer) readObject() types.Object {
 	tok := b.readToken()
+	// The keywords left over from a sloppily written object are skipped,
+	// along with the endobj of an endstream endobj pair.
+	for tok == keyword("endstream") || tok == keyword("endobj") {
+		kw := tok
+		b.warnf("unexpected keyword %q parsing object", kw)
+		if tok = b.readToken(); kw == keyword("endstream") && tok == keyword("endobj") {
+			tok = b.readToken()
+		}
+	}
 	if kw, ok := tok.(keyword); ok {
 		switch kw {
 		case "null":
@@ -407,11 +677,13 @@ func (b *buffer) readObject() types.Object {
 			case keyword("obj"):
 				old := b.objptr
 				b.objptr = types.Objptr{ID: uint32(t1), Gen: uint16(t2)}
+				b.nest()
 				obj := b.readObject()
+				b.unnest()
 				if _, ok := obj.(types.Stream); !ok {
 					tok4 := b.readToken()
 					if tok4 != keyword("endobj") {
-						b.errorf("missing endobj after indirect object definition")
+						b.warnf("missing endobj after indirect object definition")
 						b.unreadToken(tok4)
 					}
 				}
@@ -426,6 +698,8 @@ func (b *buffer) readObject() types.Object {
 }
 
 func (b *buffer) readArray() types.Object {
+	b.nest()
+	defer b.unnest()
 	var x types.Array
 	for {
 		tok := b.readToken()
@@ -442,6 +716,7 @@ func (b *buffer) readArray() types.Object {
 }
 
 func (b *buffer) readDict() types.Object {
+	b.nest()
 	x := make(types.Dict)
 	for {
 		tok := b.readToken()
@@ -458,6 +733,7 @@ func (b *buffer) readDict() types.Object {
 		}
 		x[n] = b.readObject()
 	}
+	b.unnest()
 
 	if !b.allowStream {
 		return x
@@ -491,6 +767,10 @@ func isSpace(b byte) bool {
 	return false
 }
 
+func isEOL(b byte) bool {
+	return b == '\r' || b == '\n'
+}
+
 func isDelim(b byte) bool {
 	switch b {
 	case '<', '>', '(', ')', '[', ']', '{', '}', '/', '%':
@@ -0,0 +1,251 @@
+package pdf
+
+import (
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+func Test_buffer_readObject(t *testing.T) {
+	testCases := map[string]struct {
+		input string
+		want  types.Object
+	}{
+		"integer": {
+			input: "12",
+			want:  int64(12),
+		},
+		"dict": {
+			input: "<</Type /Page /Count 3>>",
+			want:  types.Dict{"Type": types.Name("Page"), "Count": int64(3)},
+		},
+		"numbers": {
+			input: "[0 -0 +7 -12 -256 -257 1023 1280 00042 9223372036854775807 -9223372036854775808 1.5 -.5 true false]",
+			want: types.Array{int64(0), int64(0), int64(7), int64(-12), int64(-256), int64(-257), int64(1023), int64(1280),
+				int64(42), int64(9223372036854775807), int64(-9223372036854775808), 1.5, -0.5, true, false},
+		},
+		"names and keywords": {
+			input: "[/Type /Ty#70e /F1 /Identity-H null]",
+			want:  types.Array{types.Name("Type"), types.Name("Type"), types.Name("F1"), types.Name("Identity-H"), nil},
+		},
+		"object definition": {
+			input: "4 0 obj [1 (a)] endobj",
+			want:  types.Objdef{Ptr: types.Objptr{ID: 4}, Obj: types.Array{int64(1), "a"}},
+		},
+		"missing endobj": {
+			input: "4 0 obj <</A 1>> 5 0 obj <</B 2>> endobj",
+			want:  types.Objdef{Ptr: types.Objptr{ID: 4}, Obj: types.Dict{"A": int64(1)}},
+		},
+		"stray endstream endobj": {
+			input: "endstream endobj 4 0 obj 7 endobj",
+			want:  types.Objdef{Ptr: types.Objptr{ID: 4}, Obj: int64(7)},
+		},
+		"stray endobj": {
+			input: "endobj <</A 1>>",
+			want:  types.Dict{"A": int64(1)},
+		},
+		"literal string escapes": {
+			input: `(\(a\) \d\e \101\0612 \7 \777 \\)`,
+			want:  "(a) de A12 \x07 \xff \\",
+		},
+		"hex strings": {
+			input: "[<4142> <414> <41 4\n2 %comment <42>\n43 zz> <>]",
+			want:  types.Array{"AB", "A@", "ABC", ""},
+		},
+		"literal string line endings": {
+			input: "(a\r\nb\rc\nd\\\r\ne\\\rf\\\ng)",
+			want:  "a\nb\nc\ndefg",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := newBuffer(strings.NewReader(tc.input), 0)
+			b.allowEOF = true
+
+			var got types.Object
+			err := func() (err error) {
+				defer catch(&err)
+				got = b.readObject()
+				return nil
+			}()
+			if err != nil {
+				t.Fatal("failed to read object:", err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("object did not match expectation:", diff)
+			}
+		})
+	}
+}
+
+func Test_buffer_readToken_numbers(t *testing.T) {
+	testCases := map[string]token{
+		"42":                                 int64(42),
+		"-0042":                              int64(-42),
+		"+7":                                 int64(7),
+		"12345678901234567890":               int64(math.MaxInt64),
+		"-12345678901234567890":              int64(math.MinInt64),
+		"1.5":                                1.5,
+		"-.5":                                -0.5,
+		"5.":                                 5.0,
+		".":                                  0.0,
+		"-":                                  int64(0),
+		"-.":                                 0.0,
+		"34.5-":                              34.5,
+		"1.2.3":                              1.2,
+		"12abc":                              int64(12),
+		"1.2e3":                              1200.0,
+		"1E-2":                               0.01,
+		"1e":                                 int64(1),
+		"3e+":                                int64(3),
+		"--5":                                int64(0),
+		"1" + strings.Repeat("0", 400) + ".": math.MaxFloat64,
+		"Tf":                                 keyword("Tf"),
+		"T*":                                 keyword("T*"),
+	}
+
+	for input, want := range testCases {
+		b := newBuffer(strings.NewReader(input), 0)
+		b.allowEOF = true
+		var got token
+		err := func() (err error) {
+			defer catch(&err)
+			got = b.readToken()
+			return nil
+		}()
+		if err != nil {
+			t.Errorf("reading %.20q: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("reading %.20q: got %#v, want %#v", input, got, want)
+		}
+	}
+}
+
+func Test_buffer_readObject_unterminated(t *testing.T) {
+	for _, input := range []string{"(open", "(open (nested)", "(open \\", "<4142"} {
+		b := newBuffer(strings.NewReader(input), 0)
+		b.allowEOF = true
+		err := func() (err error) {
+			defer catch(&err)
+			b.readObject()
+			return nil
+		}()
+		if err == nil || !strings.Contains(err.Error(), "stream ended with open") {
+			t.Errorf("reading %q: got error %v, want an open string", input, err)
+		}
+	}
+}
+
+func Test_buffer_readObject_deep(t *testing.T) {
+	testCases := map[string]struct {
+		input string
+		err   bool
+	}{
+		"nested arrays":              {input: strings.Repeat("[", 1_000_000), err: true},
+		"nested dictionaries":        {input: strings.Repeat("<</A ", 1_000_000), err: true},
+		"nested object definitions":  {input: strings.Repeat("1 0 obj ", 1_000_000), err: true},
+		"arrays nested to the limit": {input: strings.Repeat("[", maxObjectDepth) + strings.Repeat("]", maxObjectDepth)},
+		"stray keywords":             {input: strings.Repeat("endstream endobj endobj ", 100_000) + "7"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := newBuffer(strings.NewReader(tc.input), 0)
+			b.allowEOF = true
+			err := func() (err error) {
+				defer catch(&err)
+				b.readObject()
+				return nil
+			}()
+			if tc.err && (err == nil || !strings.Contains(err.Error(), "nested deeper")) {
+				t.Errorf("got error %v, want objects nested too deep", err)
+			}
+			if !tc.err && err != nil {
+				t.Error("failed to read object:", err)
+			}
+		})
+	}
+}
+
+func Fuzz_buffer_readObject(f *testing.F) {
+	f.Add("<</Type /Page /Count 3 /Kids [4 0 R] /Name (a\\(b\\)) /Hex <4142>>>")
+	f.Add("4 0 obj <</Length 3>> stream\nabc\nendstream endobj")
+	f.Add("[0 -0 +7 1.5 -.5 1e5 true false null /Ty#70e]")
+	f.Add("<<<</A [[[ ]]]>>")
+	f.Add("(unbalanced (parens) \\")
+	f.Add("<0123456789abcdefABCDEF g>")
+	f.Add("[34.5- 1.2e3 . -. 12345678901234567890]")
+	f.Add(strings.Repeat("[<</A ", 1_000))
+	f.Add(strings.Repeat("endstream endobj ", 1_000) + "7")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		b := newBuffer(strings.NewReader(input), 0)
+		b.allowEOF = true
+		defer func() {
+			// The lexer panics with an error value on malformed input; any
+			// other panic, of the runtime, is a bug.
+			if r := recover(); r != nil {
+				if _, ok := r.(runtime.Error); ok {
+					panic(r)
+				}
+			}
+		}()
+		for range 100 {
+			tok := b.readToken()
+			if tok == io.EOF {
+				break
+			}
+			b.unreadToken(tok)
+			b.readObject()
+		}
+	})
+}
+
+// benchContent is a representative page content stream, of text and graphics operators.
+var benchContent = strings.Repeat("q 1 0 0 1 72 720 cm BT /F1 12 Tf 14.4 TL 0 0 Td (Hello, world) Tj "+
+	"[(Kerned) -120 (text) 250.5 (here)] TJ T* <48656c6c6f> Tj ET Q "+
+	"0.5 0.25 0.75 rg 10 10 200 300 re f /GS1 gs /Im1 Do\n", 200)
+
+// benchObjects are representative objects of a file, of dictionaries with many names.
+var benchObjects = strings.Repeat("12 0 obj <</Type /Page /Parent 3 0 R /MediaBox [0 0 612 792] "+
+	"/Resources <</Font <</F1 5 0 R /F2 6 0 R>> /XObject <</Im1 7 0 R>> /ProcSet [/PDF /Text /ImageB]>> "+
+	"/Contents 8 0 R /Annots [9 0 R 10 0 R] /Rotate 0>> endobj\n"+
+	"5 0 obj <</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding "+
+	"/FirstChar 32 /LastChar 40 /Widths [278 278 355 556 556 889 667 191 333]>> endobj\n", 200)
+
+func Benchmark_buffer_readToken(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchContent)))
+	for range b.N {
+		buf := newBuffer(strings.NewReader(benchContent), 0)
+		buf.allowEOF = true
+		for buf.readToken() != io.EOF {
+		}
+	}
+}
+
+func Benchmark_buffer_readObject(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchObjects)))
+	for range b.N {
+		buf := newBuffer(strings.NewReader(benchObjects), 0)
+		buf.allowEOF = true
+		for {
+			tok := buf.readToken()
+			if tok == io.EOF {
+				break
+			}
+			buf.unreadToken(tok)
+			buf.readObject()
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// Linearization holds the parameters of a linearized file, one written for fast web
+// view, from its linearization parameter dictionary. See PDF 32000-1:2008, Annex F.
+type Linearization struct {
+	Length int64 // L, the length of the file.
+	// HintOffset and HintLength are the offset in the file of the primary hint stream
+	// and its length, of H; OverflowHintOffset and OverflowHintLength are those of
+	// the overflow hint stream, or zero if there is none.
+	HintOffset, HintLength                 int64
+	OverflowHintOffset, OverflowHintLength int64
+	FirstPage                              uint32 // O, the object number of the page object of the first page.
+	FirstPageEnd                           int64  // E, the offset of the end of the first page.
+	Pages                                  int    // N, the number of pages of the document.
+	MainXrefOffset                         int64  // T, the offset of the first entry of the main cross-reference table.
+	FirstPageNumber                        int    // P, the number from 0 of the page of O, the first page.
+}
+
+// linearization holds the linearization parameters of a Reader, read when first needed.
+type linearization struct {
+	once   sync.Once
+	params Linearization
+	ok     bool
+	end    int64 // the offset of the end of the linearization parameter dictionary.
+}
+
+// WithLinearizedFirstPage makes opening a linearized file read the cross-reference section
+// of its first page, at the start of the file, and not the main one at its end, so that the
+// first page is read from the start of the file alone. Only the objects of the first page
+// section resolve, the others resolving to null: the Reader reads the first page, and not the
+// others. It opens files that are not linearized, or are updated since, as usual. It makes
+// NewHTTPReaderAt fetch the start of the file when it opens it, and not its end.
+func WithLinearizedFirstPage() Option {
+	return func(c *config) { c.linearizedFirstPage = true }
+}
+
+// IsLinearized reports whether the file is linearized, with a linearization parameter
+// dictionary whose length is that of the file. A file updated since it was linearized
+// is not. See PDF 32000-1:2008, §F.2.
+func (r *Reader) IsLinearized() bool {
+	_, ok := r.Linearization()
+	return ok
+}
+
+// Linearization returns the linearization parameters of the file, and reports
+// whether it is linearized, as IsLinearized does.
+func (r *Reader) Linearization() (Linearization, bool) {
+	l := &r.lin
+	l.once.Do(func() { l.params, l.end, l.ok = r.readLinearization() })
+	return l.params, l.ok
+}
+
+// HintStream returns the decoded data of the primary hint stream of a linearized file.
+// Its hint tables are not interpreted. See PDF 32000-1:2008, §F.4.
+func (r *Reader) HintStream() ([]byte, error) {
+	lin, ok := r.Linearization()
+	if !ok {
+		return nil, fmt.Errorf("file is not linearized")
+	}
+	if lin.HintOffset <= 0 || lin.HintOffset >= r.end {
+		return nil, fmt.Errorf("hint stream offset %d outside the file", lin.HintOffset)
+	}
+	strm, err := func() (obj types.Objdef, err error) {
+		defer catch(&err)
+		b := newBuffer(io.NewSectionReader(r.f, lin.HintOffset, r.end-lin.HintOffset), lin.HintOffset)
+		defer b.release()
+		b.decrypter = r.decrypter
+		obj, _ = b.readObject().(types.Objdef)
+		return obj, nil
+	}()
+	if err != nil {
+		return nil, fmt.Errorf("reading hint stream at offset %d: %w", lin.HintOffset, err)
+	}
+	v := Value{r: r, ptr: strm.Ptr, data: strm.Obj}
+	if v.Kind() != Stream {
+		return nil, fmt.Errorf("no hint stream at offset %d", lin.HintOffset)
+	}
+	rc := v.Reader()
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading hint stream %v: %w", objfmt(strm.Ptr), err)
+	}
+	return data, nil
+}
+
+// readLinearization reads the linearization parameter dictionary, the first object of
+// a linearized file, and returns the parameters and the offset of the end of the object.
+func (r *Reader) readLinearization() (lin Linearization, end int64, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	b := newBuffer(io.NewSectionReader(r.f, 0, r.end), 0)
+	defer b.release()
+	b.allowStream = false
+	def, isDef := b.readObject().(types.Objdef)
+	dict, isDict := def.Obj.(types.Dict)
+	if !isDef || !isDict || dict["Linearized"] == nil {
+		return Linearization{}, 0, false
+	}
+
+	v := Value{r: r, ptr: def.Ptr, data: dict}
+	lin = Linearization{
+		Length:          v.Key("L").Int64(),
+		FirstPage:       uint32(v.Key("O").Int64()),
+		FirstPageEnd:    v.Key("E").Int64(),
+		Pages:           int(v.Key("N").Int64()),
+		MainXrefOffset:  v.Key("T").Int64(),
+		FirstPageNumber: int(v.Key("P").Int64()),
+	}
+	if h := v.Key("H"); h.Len() == 2 || h.Len() == 4 {
+		lin.HintOffset, lin.HintLength = h.Index(0).Int64(), h.Index(1).Int64()
+		if h.Len() == 4 {
+			lin.OverflowHintOffset, lin.OverflowHintLength = h.Index(2).Int64(), h.Index(3).Int64()
+		}
+	}
+	// An update appended to the file leaves it linearized no more.
+	if lin.Length != r.end {
+		return Linearization{}, 0, false
+	}
+	return lin, b.readOffset(), true
+}
+
+// readFirstPageXref reads the cross-reference section of the first page of a linearized
+// file, which follows its linearization parameter dictionary, and its trailer, without
+// the main section that its Prev refers to.
+func (r *Reader) readFirstPageXref(ctx context.Context) error {
+	_, ok := r.Linearization()
+	if !ok {
+		return fmt.Errorf("file is not linearized")
+	}
+	off := r.lin.end
+	b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	defer b.release()
+	b.reader = r
+	for isSpace(b.readByte()) && !b.eof {
+	}
+	b.unreadByte()
+	r.xrefChain = []int64{b.readOffset()}
+
+	var (
+		section []types.Xref
+		ptr     types.Objptr
+		trailer types.Dict
+		err     error
+	)
+	if tok := b.readToken(); tok == keyword("xref") {
+		if section, err = readXrefTableData(b); err != nil {
+			return fmt.Errorf("malformed PDF: first page %v", err)
+		}
+		if trailer, ok = b.readObject().(types.Dict); !ok {
+			return fmt.Errorf("malformed PDF: first page xref table not followed by trailer dictionary")
+		}
+	} else {
+		b.unreadToken(tok)
+		var strm types.Stream
+		if ptr, strm, err = readXrefStreamObject(b); err != nil {
+			return fmt.Errorf("malformed PDF: first page %v", err)
+		}
+		size, _ := strm.Hdr["Size"].(int64)
+		if section, err = readXrefStreamData(ctx, r, strm, min(size, int64(r.cfg.maxObjects))); err != nil {
+			return fmt.Errorf("malformed PDF: first page xref stream: %v", err)
+		}
+		trailer = strm.Hdr
+	}
+	table, err := mergeXref(nil, section, r.cfg.maxObjects)
+	if err != nil {
+		return err
+	}
+	r.xref, r.trailerptr, r.trailer = table, ptr, trailer
+	r.firstPageOnly = true
+	return nil
+}
+
+// firstPage returns page i of a Reader opened with WithLinearizedFirstPage, if it is the
+// first page of the linearization parameters, and reports whether it is.
+func (r *Reader) firstPage(i int) (*Page, bool) {
+	lin, ok := r.Linearization()
+	if !r.firstPageOnly || !ok || i != lin.FirstPageNumber+1 || lin.FirstPage >= uint32(len(r.xref)) {
+		return nil, false
+	}
+	page := r.resolve(types.Objptr{}, r.xref[lin.FirstPage].Ptr)
+	if page.Key("Type").Name() != "Page" {
+		return nil, false
+	}
+	return &Page{v: page, num: i}, true
+}
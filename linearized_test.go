@@ -0,0 +1,179 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// linearizedPDF returns a linearized two page file, with the hint stream "hints".
+// The first page section is that of objects 1 to 6, and the second page, padded
+// so that reading it would read far beyond the first, is in the main section.
+func linearizedPDF() ([]byte, Linearization) {
+	build := func(lin Linearization, prev int64) ([]byte, Linearization, int64) {
+		var b bytes.Buffer
+		b.WriteString("%PDF-1.7\n")
+		offsets := map[int]int{}
+		obj := func(id int, body string) {
+			offsets[id] = b.Len()
+			fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", id, body)
+		}
+		obj(1, fmt.Sprintf("<</Linearized 1 /L %010d /H [%010d %010d] /O 4 /E %010d /N 2 /T %010d>>",
+			lin.Length, lin.HintOffset, lin.HintLength, lin.FirstPageEnd, lin.MainXrefOffset))
+
+		// The first page section, of objects 1 to 6, whose entries are filled in below.
+		firstXref := b.Len()
+		fmt.Fprintf(&b, "xref\n1 6\n%strailer\n<</Size 10 /Root 2 0 R /Prev %010d>>\nstartxref\n0\n%%%%EOF\n",
+			strings.Repeat("0000000000 00000 n \n", 6), prev)
+		obj(2, "<</Type /Catalog /Pages 7 0 R>>")
+		var out Linearization
+		out.HintOffset = int64(b.Len())
+		obj(3, stream("hints"))
+		out.HintLength = int64(b.Len()) - out.HintOffset
+		obj(4, "<</Type /Page /Parent 7 0 R /Resources <</Font <</F1 6 0 R>>>> /Contents 5 0 R>>")
+		obj(5, stream("BT /F1 12 Tf 72 720 Td (Page one) Tj ET"))
+		obj(6, "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+		out.FirstPageEnd = int64(b.Len())
+
+		obj(7, "<</Type /Pages /Kids [4 0 R 8 0 R] /Count 2>>")
+		obj(8, "<</Type /Page /Parent 7 0 R /Resources <</Font <</F1 6 0 R>>>> /Contents 9 0 R>>")
+		obj(9, stream("BT /F1 12 Tf 72 720 Td (Page two) Tj ET"+strings.Repeat(" ", 200_000)))
+		mainXref := int64(b.Len())
+		out.MainXrefOffset = mainXref + int64(len("xref\n0 1\n"))
+		fmt.Fprintf(&b, "xref\n0 1\n0000000000 65535 f \n7 3\n")
+		for id := 7; id <= 9; id++ {
+			fmt.Fprintf(&b, "%010d 00000 n \n", offsets[id])
+		}
+		fmt.Fprintf(&b, "trailer\n<</Size 10>>\nstartxref\n%d\n%%%%EOF\n", firstXref)
+		out.Length = int64(b.Len())
+
+		data := b.Bytes()
+		for id := 1; id <= 6; id++ {
+			copy(data[firstXref+len("xref\n1 6\n")+20*(id-1):], fmt.Sprintf("%010d", offsets[id]))
+		}
+		return data, out, mainXref
+	}
+
+	_, lin, prev := build(Linearization{}, 0)
+	data, _, _ := build(lin, prev)
+	lin.FirstPage, lin.Pages = 4, 2
+	return data, lin
+}
+
+func TestReader_Linearization(t *testing.T) {
+	data, want := linearizedPDF()
+	r := openPDF(t, data)
+	got, ok := r.Linearization()
+	if !ok || !r.IsLinearized() {
+		t.Fatal("got a file that is not linearized")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Linearization mismatch (-want +got):\n%s", diff)
+	}
+	hints, err := r.HintStream()
+	if err != nil {
+		t.Fatal("failed to read hint stream:", err)
+	}
+	if string(hints) != "hints" {
+		t.Errorf("got hint stream %q, want %q", hints, "hints")
+	}
+	if text, err := r.Page(2); err != nil || text.String() != "Page two" {
+		t.Errorf("got page 2 %q, %v, want %q", text.String(), err, "Page two")
+	}
+
+	for name, data := range map[string][]byte{
+		"not linearized": textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"),
+		"updated":        update(data, 10, map[int]string{9: stream("BT /F1 12 Tf 72 720 Td (Updated) Tj ET")}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, data)
+			if r.IsLinearized() {
+				t.Error("got IsLinearized true")
+			}
+			if _, err := r.HintStream(); err == nil {
+				t.Error("got no error reading hint stream")
+			}
+		})
+	}
+}
+
+// offsetsReaderAt records the offsets of the reads of a file.
+type offsetsReaderAt struct {
+	*bytes.Reader
+	mu      sync.Mutex
+	offsets []int64
+}
+
+func (r *offsetsReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	r.offsets = append(r.offsets, off)
+	r.mu.Unlock()
+	return r.Reader.ReadAt(p, off)
+}
+
+func TestReader_linearizedFirstPage(t *testing.T) {
+	data, lin := linearizedPDF()
+	f := &offsetsReaderAt{Reader: bytes.NewReader(data)}
+	r, err := NewReader(f, int64(len(data)), WithLinearizedFirstPage())
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	if got := r.NPages(); got != 2 {
+		t.Errorf("got %d pages, want 2", got)
+	}
+	got, err := r.Page(1)
+	if err != nil {
+		t.Fatal("failed to read page 1:", err)
+	}
+	if want := "Page one"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	for _, off := range f.offsets {
+		if off >= lin.FirstPageEnd {
+			t.Errorf("read at offset %d, beyond the first page section ending at %d", off, lin.FirstPageEnd)
+		}
+	}
+	if _, err := r.Page(2); err == nil {
+		t.Error("got no error reading page 2, outside the first page section")
+	}
+
+	// A file that is not linearized opens as usual.
+	r = openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"))
+	if got, err := r.Page(1); err != nil || got.String() != "Hello" {
+		t.Errorf("got text %q, %v, want %q", got.String(), err, "Hello")
+	}
+}
+
+func TestOpenHTTP_linearizedFirstPage(t *testing.T) {
+	data, _ := linearizedPDF()
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	r, err := OpenHTTP(context.Background(), srv.URL, srv.Client(), WithLinearizedFirstPage())
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	got, err := r.Page(1)
+	if err != nil {
+		t.Fatal("failed to read page 1:", err)
+	}
+	if want := "Page one"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
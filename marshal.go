@@ -0,0 +1,215 @@
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// MarshalPDF writes the value v to w in PDF syntax, as it would be written in a file,
+// unlike String. Indirect references in v are written as references. A stream is written
+// as its dictionary followed by its data as it is in the file, still encoded, but not
+// encrypted, with its Length set to that of the data. See PDF 32000-1:2008, §7.3.
+func (v Value) MarshalPDF(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := marshal(bw, v.r, v.data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteObject writes the object obj to w in PDF syntax, as Value.MarshalPDF does.
+// The object is a Value, a PageObject, or one of the Go types of objects: nil, bool,
+// int64, float64, string, and the Name, Dict, Array, Objptr and Objdef types of objects
+// read by a Reader. A stream is written from the Data of its PageObject, or else by
+// Value.MarshalPDF, which reads it from the file.
+func WriteObject(w io.Writer, obj any) error {
+	if v, ok := obj.(Value); ok {
+		return v.MarshalPDF(w)
+	}
+	bw := bufio.NewWriter(w)
+	var err error
+	if o, ok := obj.(PageObject); ok {
+		if s, ok := o.Object.(types.Stream); ok {
+			err = writeStream(bw, nil, s.Hdr, o.Data)
+		} else {
+			err = marshal(bw, nil, o.Object)
+		}
+	} else {
+		err = marshal(bw, nil, obj)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func marshal(w *bufio.Writer, r *Reader, x any) error {
+	switch x := x.(type) {
+	default:
+		return fmt.Errorf("cannot marshal %T as PDF", x)
+	case nil:
+		w.WriteString("null")
+	case bool:
+		w.WriteString(strconv.FormatBool(x))
+	case int64:
+		w.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("cannot marshal %v as a PDF real", x)
+		}
+		// Reals have no exponent notation, and have a decimal point, to read as reals.
+		s := strconv.FormatFloat(x, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		w.WriteString(s)
+	case string:
+		marshalString(w, x)
+	case types.Name:
+		marshalName(w, x)
+	case types.Dict:
+		return marshalDict(w, r, x)
+	case types.Array:
+		w.WriteByte('[')
+		for i, elem := range x {
+			if i > 0 {
+				w.WriteByte(' ')
+			}
+			if err := marshal(w, r, elem); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	case types.Stream:
+		return marshalStream(w, r, x)
+	case types.Objptr:
+		fmt.Fprintf(w, "%d %d R", x.ID, x.Gen)
+	case types.Objdef:
+		fmt.Fprintf(w, "%d %d obj\n", x.Ptr.ID, x.Ptr.Gen)
+		if err := marshal(w, r, x.Obj); err != nil {
+			return err
+		}
+		w.WriteString("\nendobj")
+	}
+	return nil
+}
+
+func marshalDict(w *bufio.Writer, r *Reader, d types.Dict) error {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	w.WriteString("<<")
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(' ')
+		}
+		marshalName(w, types.Name(k))
+		w.WriteByte(' ')
+		if err := marshal(w, r, d[types.Name(k)]); err != nil {
+			return err
+		}
+	}
+	w.WriteString(">>")
+	return nil
+}
+
+func marshalStream(w *bufio.Writer, r *Reader, s types.Stream) error {
+	if r == nil {
+		return fmt.Errorf("cannot marshal stream %v without its file", objfmt(s.Ptr))
+	}
+	data, err := r.rawStreamData(s)
+	if err != nil {
+		return err
+	}
+	return writeStream(w, r, s.Hdr, data)
+}
+
+// writeStream writes the stream of the dictionary hdr and the encoded data,
+// with its Length set to that of the data.
+func writeStream(w *bufio.Writer, r *Reader, hdr types.Dict, data []byte) error {
+	hdr = maps.Clone(hdr)
+	if hdr == nil {
+		hdr = types.Dict{}
+	}
+	hdr["Length"] = int64(len(data))
+	if err := marshalDict(w, r, hdr); err != nil {
+		return err
+	}
+	w.WriteString("\nstream\n")
+	w.Write(data)
+	w.WriteString("\nendstream")
+	return nil
+}
+
+// rawStreamData returns the data of the stream s as it is in the file, still encoded,
+// but not encrypted, or as much of it as is read before an error.
+func (r *Reader) rawStreamData(s types.Stream) ([]byte, error) {
+	rd, err := r.streamReader(s, Value{r: r, ptr: s.Ptr, data: s}.Key("Length").Int64())
+	if err != nil {
+		return nil, fmt.Errorf("reading stream %v: %w", objfmt(s.Ptr), err)
+	}
+	data, err := io.ReadAll(rd)
+	rd.Close()
+	if err != nil {
+		return data, fmt.Errorf("reading stream %v: %w", objfmt(s.Ptr), err)
+	}
+	return data, nil
+}
+
+// marshalString writes the string s as a literal string, or as a hexadecimal
+// string if it holds binary data. See PDF 32000-1:2008, §7.3.4.
+func marshalString(w *bufio.Writer, s string) {
+	for i := range len(s) {
+		switch c := s[i]; {
+		case c >= ' ' && c <= '~', c == '\n', c == '\r', c == '\t', c == '\b', c == '\f':
+		default:
+			fmt.Fprintf(w, "<%x>", s)
+			return
+		}
+	}
+
+	w.WriteByte('(')
+	for i := range len(s) {
+		switch c := s[i]; c {
+		case '(', ')', '\\':
+			w.WriteByte('\\')
+			w.WriteByte(c)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\r':
+			w.WriteString(`\r`)
+		case '\t':
+			w.WriteString(`\t`)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\f':
+			w.WriteString(`\f`)
+		default:
+			w.WriteByte(c)
+		}
+	}
+	w.WriteByte(')')
+}
+
+// marshalName writes the name n, with the characters outside the printable ASCII range,
+// and the delimiters and number sign, written as #-escapes. See PDF 32000-1:2008, §7.3.5.
+func marshalName(w *bufio.Writer, n types.Name) {
+	w.WriteByte('/')
+	for i := range len(n) {
+		if c := n[i]; c <= ' ' || c > '~' || c == '#' || isDelim(c) {
+			fmt.Fprintf(w, "#%02X", c)
+		} else {
+			w.WriteByte(c)
+		}
+	}
+}
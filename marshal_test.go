@@ -0,0 +1,148 @@
+package pdf
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+func TestWriteObject(t *testing.T) {
+	testCases := map[string]struct {
+		input types.Object
+		want  string
+	}{
+		"null":      {input: nil, want: "null"},
+		"real":      {input: 1e21, want: "1000000000000000000000.0"},
+		"fraction":  {input: -0.000125, want: "-0.000125"},
+		"string":    {input: "a (b) \\ c\n", want: `(a \(b\) \\ c\n)`},
+		"binary":    {input: "\x00\xffA", want: "<00ff41>"},
+		"name":      {input: types.Name("A B#(c)/é"), want: "/A#20B#23#28c#29#2F#C3#A9"},
+		"reference": {input: types.Objptr{ID: 3, Gen: 1}, want: "3 1 R"},
+		"dict": {
+			input: types.Dict{"Kids": types.Array{types.Objptr{ID: 3}, true}, "Count": int64(-1)},
+			want:  "<</Count -1 /Kids [3 0 R true]>>",
+		},
+		"definition": {
+			input: types.Objdef{Ptr: types.Objptr{ID: 4}, Obj: types.Name("X")},
+			want:  "4 0 obj\n/X\nendobj",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteObject(&b, tc.input); err != nil {
+				t.Fatal("failed to write object:", err)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if err := WriteObject(&strings.Builder{}, types.Stream{}); err == nil {
+		t.Error("wrote a stream without its file")
+	}
+}
+
+func TestValue_MarshalPDF_stream(t *testing.T) {
+	data := buildPDF(
+		"<</Type /Catalog>>",
+		"<</Length 3 0 R /Filter /FlateDecode>>\nstream\nxyz\nendstream",
+		"3",
+	)
+	v, err := openPDF(t, data).Object(2, 0)
+	if err != nil {
+		t.Fatal("failed to read object:", err)
+	}
+
+	var b bytes.Buffer
+	if err := v.MarshalPDF(&b); err != nil {
+		t.Fatal("failed to marshal stream:", err)
+	}
+	if got, want := b.String(), "<</Filter /FlateDecode /Length 3>>\nstream\nxyz\nendstream"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestWriteObject_roundTrip checks that objects written by WriteObject read back the same.
+func TestWriteObject_roundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randString := func() string {
+		b := make([]byte, rnd.Intn(8))
+		for i := range b {
+			if rnd.Intn(2) == 0 {
+				b[i] = byte(rnd.Intn(256))
+			} else {
+				const special = "()\\/#<> \n\r\tab%"
+				b[i] = special[rnd.Intn(len(special))]
+			}
+		}
+		return string(b)
+	}
+	var randObject func(depth int) types.Object
+	randObject = func(depth int) types.Object {
+		n := 8
+		if depth > 3 {
+			n = 6
+		}
+		switch rnd.Intn(n) {
+		case 0:
+			return nil
+		case 1:
+			return rnd.Intn(2) == 0
+		case 2:
+			return rnd.Int63n(1<<40) - 1<<39
+		case 3:
+			return (rnd.Float64() - 0.5) * float64(rnd.Int63n(1<<30))
+		case 4:
+			return randString()
+		case 5:
+			if rnd.Intn(2) == 0 {
+				return types.Objptr{ID: uint32(rnd.Intn(1000) + 1), Gen: uint16(rnd.Intn(3))}
+			}
+			return types.Name(randString())
+		case 6:
+			a := types.Array{}
+			for range rnd.Intn(4) {
+				a = append(a, randObject(depth+1))
+			}
+			return a
+		default:
+			d := types.Dict{}
+			for range rnd.Intn(4) {
+				d[types.Name(randString())] = randObject(depth + 1)
+			}
+			return d
+		}
+	}
+
+	for range 2000 {
+		obj := randObject(0)
+		var b strings.Builder
+		if err := WriteObject(&b, obj); err != nil {
+			t.Fatalf("failed to write %#v: %v", obj, err)
+		}
+
+		buf := newBuffer(strings.NewReader(b.String()), 0)
+		buf.allowEOF = true
+		var got types.Object
+		err := func() (err error) {
+			defer catch(&err)
+			got = buf.readObject()
+			return nil
+		}()
+		if err != nil {
+			t.Fatalf("failed to read %q: %v", b.String(), err)
+		}
+		if diff := cmp.Diff(got, obj, cmpopts.EquateEmpty()); diff != "" {
+			t.Fatalf("%q did not read back as written: %s", b.String(), diff)
+		}
+	}
+}
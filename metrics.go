@@ -0,0 +1,89 @@
+package pdf
+
+import "strings"
+
+// The widths of the printable ASCII glyphs of the standard fonts, from space to
+// asciitilde, in glyph space units, from the Adobe Font Metrics of the fonts.
+// The codes of these glyphs are those of ASCII in the standard Latin encodings,
+// except for the quotes at 0x27 and 0x60, whose widths differ little.
+var (
+	courierWidths = [95]float64{
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+	}
+	helveticaWidths = [95]float64{
+		278, 278, 355, 556, 556, 889, 667, 222, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		222, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	timesWidths = [95]float64{
+		250, 333, 408, 500, 500, 833, 778, 333, 333, 333, 500, 564, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+		921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+		556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+		333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+		500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+	}
+)
+
+// standardWidths returns the widths of the printable ASCII glyphs of the standard
+// font named name, or of a font that is known by the name of one, such as Arial for
+// Helvetica, and reports whether there are any. The widths of the regular style of
+// a family stand in for those of its other styles, to space the text of fonts that
+// have no widths of their own. See PDF 32000-1:2008, §9.6.2.2.
+func standardWidths(name string) (span, bool) {
+	var w *[95]float64
+	switch standardFamily(name) {
+	case "Courier":
+		w = &courierWidths
+	case "Helvetica":
+		w = &helveticaWidths
+	case "Times":
+		w = &timesWidths
+	default:
+		return span{}, false
+	}
+	return span{first: ' ', last: '~', linear: w[:]}, true
+}
+
+// standardMetrics returns the ascender and descender, in glyph space units, of the
+// regular style of the standard font named name, or of a font known by the name
+// of one, from its Adobe Font Metrics, and reports whether there are any.
+func standardMetrics(name string) (ascent, descent float64, ok bool) {
+	switch standardFamily(name) {
+	case "Courier":
+		return 629, -157, true
+	case "Helvetica":
+		return 718, -207, true
+	case "Times":
+		return 683, -217, true
+	}
+	return 0, 0, false
+}
+
+// standardFamily returns the family of the standard fonts, Courier, Helvetica or
+// Times, of the font named name, or of the font it is known by, or else "".
+func standardFamily(name string) string {
+	if _, base, ok := strings.Cut(name, "+"); ok {
+		name = base
+	}
+	family, _, _ := strings.Cut(name, "-")
+	family, _, _ = strings.Cut(family, ",")
+
+	switch family {
+	case "Courier", "CourierNew", "CourierNewPS":
+		return "Courier"
+	case "Helvetica", "Arial", "ArialMT":
+		return "Helvetica"
+	case "Times", "TimesNewRoman", "TimesNewRomanPS", "TimesNewRomanPSMT":
+		return "Times"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// WithMmap makes Open map the file into memory and read it from there, rather than
+// with a read system call for each read, which for large files saves copying their
+// data twice. If the file cannot be mapped, as on systems without mmap, Open reads it
+// as a plain file. The file is unmapped by Reader.Close.
+func WithMmap() Option {
+	return func(c *config) { c.mmap = true }
+}
+
+// A mappedFile is an io.ReaderAt over the data of a file mapped into memory.
+// Reads copy the data, so that none of it is referenced once the file is closed
+// and unmapped; reads after that fail. A mappedFile is safe for concurrent use.
+type mappedFile struct {
+	mu     sync.RWMutex
+	data   []byte
+	closed bool
+}
+
+func (m *mappedFile) ReadAt(p []byte, off int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0, fmt.Errorf("read of closed file")
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file, once no reads are in progress.
+func (m *mappedFile) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	err := unmap(m.data)
+	m.data = nil
+	return err
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package pdf
+
+import (
+	"fmt"
+	"os"
+)
+
+// mapFile fails, as files are not mapped into memory on this system.
+func mapFile(f *os.File, size int64) (*mappedFile, error) {
+	return nil, fmt.Errorf("mapping %s: not supported", f.Name())
+}
+
+func unmap(data []byte) error {
+	return nil
+}
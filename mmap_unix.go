@@ -0,0 +1,25 @@
+//go:build unix
+
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the size bytes of f into memory, read-only.
+func mapFile(f *os.File, size int64) (*mappedFile, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, fmt.Errorf("cannot map %d bytes", size)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", f.Name(), err)
+	}
+	return &mappedFile{data: data}, nil
+}
+
+func unmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// Object returns the indirect object with the given object number and generation,
+// as found through the cross-reference table, in the file or in an object stream.
+// Unlike the references resolved by Value.Key and Value.Index, which resolve to null
+// if they cannot be read, Object returns the error reading the object, or an error
+// if the object is not defined by the cross-reference table. See PDF 32000-1:2008, §7.3.10.
+func (r *Reader) Object(id uint32, gen uint16) (Value, error) {
+	ptr := types.Objptr{ID: id, Gen: gen}
+	xref, ok := r.xrefEntry(id)
+	if !ok {
+		return Value{}, fmt.Errorf("object %v not defined: beyond the cross-reference table", objfmt(ptr))
+	}
+	switch {
+	case xref.Free:
+		return Value{}, fmt.Errorf("object %v not defined: the cross-reference entry is free", objfmt(ptr))
+	case xref.Ptr != ptr:
+		return Value{}, fmt.Errorf("object %v not defined: the cross-reference entry is for %v", objfmt(ptr), objfmt(xref.Ptr))
+	case !xref.InStream && xref.Offset == 0:
+		return Value{}, fmt.Errorf("object %v not defined: no cross-reference entry", objfmt(ptr))
+	}
+
+	obj, err := r.load(ptr)
+	if err != nil {
+		return Value{}, err
+	}
+	return r.resolve(ptr, obj), nil
+}
+
+// Objects calls fn for each object defined by the cross-reference table, in order
+// of object number, with the object or the error reading it, as Object returns them,
+// until fn returns false. Free entries are skipped.
+func (r *Reader) Objects(fn func(id uint32, gen uint16, v Value, err error) bool) {
+	for id, xref := range r.xrefTable() {
+		if xref.Free || !xref.InStream && xref.Offset == 0 || xref.Ptr.ID != uint32(id) {
+			continue
+		}
+		v, err := r.Object(xref.Ptr.ID, xref.Ptr.Gen)
+		if !fn(xref.Ptr.ID, xref.Ptr.Gen, v, err) {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_Object(t *testing.T) {
+	// Object 2 is in the object stream 3, and object 4 is malformed.
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	var offsets []int
+	obj := func(id int, body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+	obj(1, "<</Type /Catalog /Pages 2 0 R>>")
+	pages := "<</Type /Pages /Kids [] /Count 0>>"
+	obj(3, fmt.Sprintf("<</Type /ObjStm /N 1 /First 4 /Length %d>>\nstream\n2 0 %s\nendstream", 4+len(pages), pages))
+	obj(4, "<</Oops")
+	xref := b.Len()
+	entries := "\x00\x00\x00\xff" + // The free object 0.
+		"\x01" + off16(offsets[0]) + "\x00" +
+		"\x02\x00\x03\x00" + // Object 0 in stream 3.
+		"\x01" + off16(offsets[1]) + "\x00" +
+		"\x01" + off16(offsets[2]) + "\x00" +
+		"\x01" + off16(xref) + "\x00" +
+		"\x00\x00\x00\x00"
+	obj(5, fmt.Sprintf("<</Type /XRef /Size 7 /Root 1 0 R /W [1 2 1] /Length %d>>\nstream\n%s\nendstream", len(entries), entries))
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+
+	r := openPDF(t, b.Bytes())
+	type object struct {
+		ID  uint32
+		Gen uint16
+		V   string
+		Err bool
+	}
+	var got []object
+	r.Objects(func(id uint32, gen uint16, v Value, err error) bool {
+		got = append(got, object{id, gen, v.String(), err != nil})
+		return true
+	})
+	want := []object{
+		{ID: 1, V: "<</Pages 2 0 R /Type /Catalog>>"},
+		{ID: 2, V: "<</Count 0 /Kids [] /Type /Pages>>"},
+		{ID: 3, V: fmt.Sprintf("<</First 4 /Length %d /N 1 /Type /ObjStm>>@%d", 4+len(pages), bytes.Index(b.Bytes(), []byte("stream\n2 0"))+len("stream\n"))},
+		{ID: 4, V: "<nil>", Err: true},
+		{ID: 5, V: got[len(got)-1].V},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("objects did not match expectation:", diff)
+	}
+
+	if v, err := r.Object(2, 0); err != nil || v.Key("Type").Name() != "Pages" {
+		t.Errorf("got object 2 %v, %v, want the page tree", v, err)
+	}
+	for _, ptr := range [][2]int{{0, 0}, {2, 1}, {6, 0}, {100, 0}} {
+		if v, err := r.Object(uint32(ptr[0]), uint16(ptr[1])); err == nil {
+			t.Errorf("got object %d %d %v, want an error", ptr[0], ptr[1], v)
+		}
+	}
+}
+
+// off16 returns the offset as two big-endian bytes.
+func off16(off int) string {
+	return string([]byte{byte(off >> 8), byte(off)})
+}
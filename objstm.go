@@ -0,0 +1,239 @@
+package pdf
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// defaultObjectStreams is the number of decoded object streams a Reader caches.
+const defaultObjectStreams = 16
+
+// An objStm is a decoded object stream. See PDF 32000-1:2008, §7.5.7.
+type objStm struct {
+	r    *Reader
+	ptr  types.Objptr
+	data []byte
+	// ids are the object numbers of the objects in the stream, in the order of its table,
+	// with any that are listed more than once.
+	ids []uint32
+	// offsets are the offsets in data of the objects in the stream, by object number,
+	// as the table gives them.
+	offsets map[uint32]int64
+	// first is the offset in data of the first object.
+	first int64
+	// extends is the object stream that the stream extends, if hasExtends.
+	extends    types.Objptr
+	hasExtends bool
+
+	// scanned are the offsets of the objects found by reading them in order,
+	// for streams whose table has the offsets wrong.
+	scanned     map[uint32]int64
+	scannedOnce sync.Once
+}
+
+// objStmCache holds the most recently used decoded object streams of a Reader,
+// up to the number set by WithObjectStreamCache.
+type objStmCache struct {
+	mu    sync.Mutex
+	lru   *list.List // of *objStm, most recently used first.
+	items map[types.Objptr]*list.Element
+}
+
+// objStm returns the decoded object stream strmptr, from the cache if it is there.
+func (r *Reader) objStm(strmptr types.Objptr) (*objStm, error) {
+	c := &r.objStms
+	c.mu.Lock()
+	if e, ok := c.items[strmptr]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*objStm), nil
+	}
+	c.mu.Unlock()
+
+	s, err := r.decodeObjStm(strmptr)
+	if err != nil || r.cfg.objectStreams <= 0 {
+		return s, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.items == nil {
+		c.lru = list.New()
+		c.items = map[types.Objptr]*list.Element{}
+	}
+	if _, ok := c.items[strmptr]; !ok {
+		c.items[strmptr] = c.lru.PushFront(s)
+		for c.lru.Len() > r.cfg.objectStreams {
+			delete(c.items, c.lru.Remove(c.lru.Back()).(*objStm).ptr)
+		}
+	}
+	return s, nil
+}
+
+// maxObjStmBytes is the greatest number of bytes of the decoded data of an object
+// stream, which is kept in memory while its objects are read.
+const maxObjStmBytes = 1 << 27
+
+// decodeObjStm reads and decodes the object stream strmptr, and its table of objects.
+func (r *Reader) decodeObjStm(strmptr types.Objptr) (*objStm, error) {
+	if xref, _ := r.xrefEntry(strmptr.ID); xref.InStream {
+		return nil, fmt.Errorf("object stream %v is itself in an object stream", strmptr)
+	}
+	strm := r.resolve(types.Objptr{}, strmptr)
+	if strm.Kind() != Stream {
+		return nil, fmt.Errorf("%v is not a stream", strmptr)
+	}
+	if strm.Key("Type").Name() != "ObjStm" {
+		return nil, fmt.Errorf("%v is not an object stream", strmptr)
+	}
+	// N and First, like any value, may be indirect references, which Key resolves.
+	n, first := strm.Key("N"), strm.Key("First")
+	switch {
+	case n.Kind() != Integer:
+		return nil, fmt.Errorf("object stream %v has N %v, not an integer", strmptr, n)
+	case first.Kind() != Integer:
+		return nil, fmt.Errorf("object stream %v has First %v, not an integer", strmptr, first)
+	case n.Int64() < 0 || first.Int64() <= 0:
+		return nil, fmt.Errorf("object stream %v has invalid N %d or First %d", strmptr, n.Int64(), first.Int64())
+	}
+
+	rc := strm.Reader()
+	defer rc.Close()
+	data, err := readAll(rc, maxObjStmBytes)
+	if err != nil {
+		return nil, fmt.Errorf("reading object stream %v: %w", strmptr, err)
+	}
+	if first.Int64() > int64(len(data)) {
+		return nil, fmt.Errorf("object stream %v has First %d beyond its %d bytes", strmptr, first.Int64(), len(data))
+	}
+
+	s := &objStm{r: r, ptr: strmptr, data: data, first: first.Int64(), offsets: map[uint32]int64{}}
+	err = func() (err error) {
+		defer catch(&err)
+		b := newBuffer(bytes.NewReader(data[:s.first]), 0)
+		defer b.release()
+		b.allowEOF = true
+		for range n.Int64() {
+			id, ok1 := b.readToken().(int64)
+			off, ok2 := b.readToken().(int64)
+			if !ok1 || !ok2 || id < 0 || id > math.MaxUint32 {
+				return fmt.Errorf("object stream %v has a malformed table of %d objects", strmptr, n.Int64())
+			}
+			s.ids = append(s.ids, uint32(id))
+			if _, dup := s.offsets[uint32(id)]; !dup {
+				s.offsets[uint32(id)] = s.first + off
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strm.data.(types.Stream).Hdr["Extends"].(type) {
+	case nil:
+	case types.Objptr:
+		s.extends, s.hasExtends = ext, true
+	default:
+		r.warn(SyntaxWarning, strmptr, 0, "object stream Extends %v is not a reference", objfmt(ext))
+	}
+	return s, nil
+}
+
+// object reads the object id of the stream, and reports whether the stream has it.
+// If the table of the stream has the offset of the object wrong, so that it is not
+// at the start of an object that parses without problems, the object is found by
+// reading the objects in order.
+func (s *objStm) object(id uint32) (types.Object, bool, error) {
+	off, ok := s.offsets[id]
+	if !ok {
+		return nil, false, nil
+	}
+	obj, err := s.objectAt(off, true)
+	if err == nil {
+		return obj, true, nil
+	}
+
+	s.scannedOnce.Do(s.scan)
+	scanned, ok := s.scanned[id]
+	if !ok || scanned == off {
+		if obj, err := s.objectAt(off, false); err == nil {
+			return obj, true, nil
+		}
+		return nil, true, fmt.Errorf("object %d at offset %d of object stream %v: %w", id, off, s.ptr, err)
+	}
+	s.r.warn(XrefWarning, s.ptr, 0, "object stream has object %d at offset %d, not %d", id, scanned, off)
+	obj, err = s.objectAt(scanned, false)
+	return obj, true, err
+}
+
+// objectAt reads the object at the offset off of the stream, which must be at the
+// start of a token. If strict, problems that parsing can recover from are errors.
+func (s *objStm) objectAt(off int64, strict bool) (obj types.Object, err error) {
+	if off < s.first || off >= int64(len(s.data)) {
+		return nil, fmt.Errorf("offset outside the stream's objects")
+	}
+	if c := s.data[off-1]; !isSpace(c) && !isDelim(c) && !isDelim(s.data[off]) {
+		return nil, fmt.Errorf("offset within a token")
+	}
+	defer catch(&err)
+	b := newBuffer(bytes.NewReader(s.data[off:]), off)
+	defer b.release()
+	b.allowEOF = true
+	b.allowStream = false
+	b.strict = strict
+	b.reader = s.r
+	return b.readObject(), nil
+}
+
+// scan finds the offsets of the objects of the stream by reading them in order,
+// the ith object being that of the ith entry of the table, as far as they parse.
+func (s *objStm) scan() {
+	s.scanned = map[uint32]int64{}
+	off := s.first
+	for _, id := range s.ids {
+		for off < int64(len(s.data)) && isSpace(s.data[off]) {
+			off++
+		}
+		if off >= int64(len(s.data)) {
+			return
+		}
+		end, ok := s.objectEnd(off)
+		if !ok {
+			return
+		}
+		if _, dup := s.scanned[id]; !dup {
+			s.scanned[id] = off
+		}
+		off = end
+	}
+}
+
+// objectEnd returns the offset of the end of the object at off, if it parses.
+// Unlike readObject, it reads no further than the object: the top-level object of
+// an object stream cannot be a reference, so an integer is not followed by more.
+func (s *objStm) objectEnd(off int64) (end int64, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	b := newBuffer(bytes.NewReader(s.data[off:]), off)
+	defer b.release()
+	b.allowEOF = true
+	b.allowStream = false
+	switch tok := b.readToken(); tok {
+	case keyword("<<"), keyword("["):
+		b.unreadToken(tok)
+		b.readObject()
+	case io.EOF:
+		return 0, false
+	}
+	return b.readOffset(), true
+}
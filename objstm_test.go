@@ -0,0 +1,216 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// countingReaderAt counts the reads from the offset off.
+type countingReaderAt struct {
+	io.ReaderAt
+	off   int64
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off == c.off {
+		c.reads++
+	}
+	return c.ReaderAt.ReadAt(p, off)
+}
+
+func TestReader_objectStreamCache(t *testing.T) {
+	// Objects 2, 3 and 4 are in the object stream 5, which lists object 3 twice, of which
+	// the first is read. Object 7 is said to be in it, but is in the stream 6 it extends.
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	var offsets []int
+	obj := func(id int, body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+	objStm := func(id int, hdr string, objs ...string) {
+		var table, data string
+		for i := 0; i < len(objs); i += 2 {
+			table += fmt.Sprintf("%s %d ", objs[i], len(data))
+			data += objs[i+1] + " "
+		}
+		obj(id, fmt.Sprintf("<</Type /ObjStm /N %d /First %d /Length %d %s>>\nstream\n%s%s\nendstream",
+			len(objs)/2, len(table), len(table)+len(data), hdr, table, data))
+	}
+	obj(1, "<</Type /Catalog /Pages 2 0 R>>")
+	objStm(5, "/Extends 6 0 R", "2", "<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"3", "<</Type /Page /Parent 2 0 R>>", "4", "<</Type /Page /Parent 2 0 R>>", "3", "(shadowed)")
+	objStm(6, "", "7", "(extended)")
+	xref := b.Len()
+	inStream := func(strm, i int) string { return "\x02\x00" + string(rune(strm)) + string(rune(i)) }
+	entries := "\x00\x00\x00\xff" +
+		"\x01" + off16(offsets[0]) + "\x00" +
+		inStream(5, 0) + inStream(5, 1) + inStream(5, 2) +
+		"\x01" + off16(offsets[1]) + "\x00" +
+		"\x01" + off16(offsets[2]) + "\x00" +
+		inStream(5, 3) +
+		"\x01" + off16(xref) + "\x00"
+	obj(8, fmt.Sprintf("<</Type /XRef /Size 9 /Root 1 0 R /W [1 2 1] /Length %d>>\nstream\n%s\nendstream", len(entries), entries))
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+	data := b.Bytes()
+	stream5 := int64(bytes.Index(data, []byte("2 0 3 ")))
+
+	testCases := map[string]struct {
+		opts  []Option
+		reads int
+	}{
+		"default": {reads: 1},
+		// Opening the file reads the root of the page tree, and NPages reads it again.
+		"no cache": {opts: []Option{WithObjectStreamCache(0)}, reads: 6},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f := &countingReaderAt{ReaderAt: bytes.NewReader(data), off: stream5}
+			r, err := NewReader(f, int64(len(data)), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			if n := r.NPages(); n != 2 {
+				t.Errorf("got %d pages, want 2", n)
+			}
+			for _, id := range []uint32{2, 3, 4} {
+				if v, err := r.Object(id, 0); err != nil || v.Kind() != Dict {
+					t.Errorf("got object %d %v, %v, want a dictionary", id, v, err)
+				}
+			}
+			if v, err := r.Object(7, 0); err != nil || v.RawString() != "extended" {
+				t.Errorf("got object 7 %v, %v, want (extended)", v, err)
+			}
+			if f.reads != tc.reads {
+				t.Errorf("got %d reads of object stream 5, want %d", f.reads, tc.reads)
+			}
+		})
+	}
+}
+
+// buildObjStmPDF is like buildPDF, but compresses the objects other than streams
+// into an object stream, and writes a cross-reference stream.
+func buildObjStmPDF(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	strm := len(objs) + 1
+	var entries bytes.Buffer
+	entries.Write([]byte{0, 0, 0, 0, 0, 0xff, 0xff})
+	var table, data string
+	var n int
+	for i, obj := range objs {
+		if strings.Contains(obj, "\nstream\n") {
+			entries.Write(binary.BigEndian.AppendUint32([]byte{1}, uint32(b.Len())))
+			entries.Write([]byte{0, 0})
+			fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+			continue
+		}
+		entries.Write(binary.BigEndian.AppendUint32([]byte{2}, uint32(strm)))
+		entries.Write([]byte{0, byte(n)})
+		table += fmt.Sprintf("%d %d ", i+1, len(data))
+		data += obj + " "
+		n++
+	}
+	entries.Write(binary.BigEndian.AppendUint32([]byte{1}, uint32(b.Len())))
+	entries.Write([]byte{0, 0})
+	fmt.Fprintf(&b, "%d 0 obj\n<</Type /ObjStm /N %d /First %d /Length %d>>\nstream\n%s%s\nendstream\nendobj\n",
+		strm, n, len(table), len(table)+len(data), table, data)
+	xref := b.Len()
+	entries.Write(binary.BigEndian.AppendUint32([]byte{1}, uint32(xref)))
+	entries.Write([]byte{0, 0})
+	fmt.Fprintf(&b, "%d 0 obj\n<</Type /XRef /Size %d /Root 1 0 R /W [1 4 2] /Length %d>>\nstream\n%s\nendstream\nendobj\n",
+		strm+1, strm+2, entries.Len(), entries.Bytes())
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+	return b.Bytes()
+}
+
+func TestReader_objectStreamRepair(t *testing.T) {
+	// objStmPDF returns a file whose objects 3 and 4 are in the object stream 2, of the
+	// given header entries and table, and whose object 6 gives the First of stream 2.
+	objStmPDF := func(hdr, table string) []byte {
+		var b bytes.Buffer
+		b.WriteString("%PDF-1.5\n")
+		var offsets []int
+		obj := func(id int, body string) {
+			offsets = append(offsets, b.Len())
+			fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", id, body)
+		}
+		data := "<</Type /Page /Count 12>> 170"
+		obj(1, "<</Type /Catalog>>")
+		obj(2, fmt.Sprintf("<</Type /ObjStm %s /Length %d>>\nstream\n%s%s\nendstream", hdr, len(table)+len(data), table, data))
+		obj(6, fmt.Sprint(len(table)))
+		xref := b.Len()
+		entries := "\x00\x00\x00\xff" +
+			"\x01" + off16(offsets[0]) + "\x00" +
+			"\x01" + off16(offsets[1]) + "\x00" +
+			"\x02\x00\x02\x00" + "\x02\x00\x02\x01" +
+			"\x01" + off16(xref) + "\x00" +
+			"\x01" + off16(offsets[2]) + "\x00"
+		obj(5, fmt.Sprintf("<</Type /XRef /Size 7 /Root 1 0 R /W [1 2 1] /Length %d>>\nstream\n%s\nendstream", len(entries), entries))
+		fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+		return b.Bytes()
+	}
+	// The objects are at offsets 0 and 26 after First, the table being 12 bytes long.
+	testCases := map[string]struct {
+		data    []byte
+		wantErr bool
+	}{
+		"valid":              {data: objStmPDF("/N 2 /First 12", "3 0 4 26    ")},
+		"indirect N, First":  {data: objStmPDF("/N 2 /First 6 0 R", "3 0 4 26    ")},
+		"offsets one after":  {data: objStmPDF("/N 2 /First 12", "3 1 4 27    ")},
+		"offsets one before": {data: objStmPDF("/N 2 /First 12", "3 0 4 25    ")},
+		"offsets past end":   {data: objStmPDF("/N 2 /First 12", "3 0 4 99    ")},
+		"missing First":      {data: objStmPDF("/N 2", "3 0 4 26    "), wantErr: true},
+		"zero First":         {data: objStmPDF("/N 2 /First 0", "3 0 4 26    "), wantErr: true},
+		"First past end":     {data: objStmPDF("/N 2 /First 999", "3 0 4 26    "), wantErr: true},
+		"missing N":          {data: objStmPDF("/First 12", "3 0 4 26    "), wantErr: true},
+		"malformed table":    {data: objStmPDF("/N 2 /First 12", "3 0 /A 26   "), wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, tc.data)
+			page, err := r.Object(3, 0)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tc.wantErr)
+			}
+			checkRecovered(t, err)
+			if tc.wantErr {
+				return
+			}
+			if got := page.Key("Count").Int64(); got != 12 {
+				t.Errorf("got object 3 %v, want the page dictionary", page)
+			}
+			if n, err := r.Object(4, 0); err != nil || n.Int64() != 170 {
+				t.Errorf("got object 4 %v, %v, want 170", n, err)
+			}
+		})
+	}
+}
+
+func TestReader_objectStreamTooLong(t *testing.T) {
+	// The data decodes to a byte more than an object stream may have.
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("3 0 (x) "))
+	zw.Write(make([]byte, maxObjStmBytes-7))
+	zw.Close()
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog>>",
+		fmt.Sprintf("<</Type /ObjStm /N 1 /First 4 /Filter /FlateDecode /Length %d>>\nstream\n%s\nendstream", z.Len(), z.Bytes()),
+	))
+
+	_, err := r.decodeObjStm(types.Objptr{ID: 2})
+	if want := fmt.Sprintf("longer than %d bytes", maxObjStmBytes); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want data %s", err, want)
+	}
+}
@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"image"
+	"log/slog"
+)
+
+// An OCR recognizes the text in images, for the text extraction of scanned pages,
+// whose text is only in images. See WithOCR. An OCR of a Reader used by several
+// goroutines must be safe for concurrent use.
+type OCR interface {
+	// Recognize returns the words in the image img, drawn on the page within pageBox.
+	Recognize(img image.Image, pageBox Rect) ([]Word, error)
+}
+
+// A Word is a word in an image recognized by an OCR.
+type Word struct {
+	Text string
+	// Bounds are the bounds of the word in the image, in its pixel coordinates.
+	Bounds image.Rectangle
+}
+
+// textBounds records the bounds of the runs of text rendered to it.
+type textBounds []Rect
+
+func (b *textBounds) Render(run TextRun) {
+	*b = append(*b, Rect{Point{run.X, run.Y}, Point{run.X + run.W, run.Y + run.H}})
+}
+
+// covers reports whether any of the text is within the rectangle r. The text
+// may have no width, as that of fonts without metrics, or no height.
+func (b textBounds) covers(r Rect) bool {
+	for _, t := range b {
+		if t.Min.X <= r.Max.X && t.Max.X >= r.Min.X && t.Min.Y <= r.Max.Y && t.Max.Y >= r.Min.Y {
+			return true
+		}
+	}
+	return false
+}
+
+// recognize returns the words that ocr recognizes in the image XObject img, drawn
+// with the transformation matrix m, as runs of text placed where they are on the page.
+// The runs of words on the same line are separated by a space.
+func recognize(ocr OCR, img Value, m [6]float64) ([]TextRun, error) {
+	decoded, err := img.Image()
+	if err != nil {
+		slog.Debug("skipping image for OCR", slog.String("err", err.Error()))
+		return nil, nil
+	}
+	words, err := ocr.Recognize(decoded, unitSquare(m))
+	if err != nil {
+		return nil, err
+	}
+
+	b := decoded.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	var (
+		runs []TextRun
+		last image.Rectangle
+	)
+	for i, word := range words {
+		r := word.Bounds.Sub(b.Min)
+		// Image space is the unit square, with the first row of the image at its top.
+		box := transformRect(m, Rect{
+			Point{float64(r.Min.X) / w, 1 - float64(r.Max.Y)/h},
+			Point{float64(r.Max.X) / w, 1 - float64(r.Min.Y)/h},
+		})
+		content := word.Text
+		if i > 0 && r.Min.X >= last.Max.X && r.Min.Y < last.Max.Y && r.Max.Y > last.Min.Y {
+			content = " " + content
+		}
+		last = r
+		runs = append(runs, TextRun{
+			X: box.Min.X, Y: box.Min.Y,
+			W: box.Max.X - box.Min.X, H: box.Max.Y - box.Min.Y,
+			CTM:  m,
+			Box:  [4]float64{box.Min.X, box.Min.Y, box.Max.X, box.Max.Y},
+			Text: content,
+		})
+	}
+	return runs, nil
+}
@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeOCR recognizes the same words in every image.
+type fakeOCR struct {
+	words []Word
+	err   error
+
+	boxes []Rect
+}
+
+func (o *fakeOCR) Recognize(img image.Image, pageBox Rect) ([]Word, error) {
+	o.boxes = append(o.boxes, pageBox)
+	return o.words, o.err
+}
+
+func TestReader_OCR(t *testing.T) {
+	scanned := func(content string) []byte {
+		return buildPDF(
+			"<</Type /Catalog /Pages 2 0 R>>",
+			"<</Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 200]>>",
+			"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R>> /XObject <</Im1 6 0 R>>>>>>",
+			stream(content),
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+			"<</Type /XObject /Subtype /Image /Width 4 /Height 2 /BitsPerComponent 8 /ColorSpace /DeviceGray /Length 8>>\n"+
+				"stream\n\x00\x00\x00\x00\xff\xff\xff\xff\nendstream",
+		)
+	}
+	words := []Word{
+		{Text: "Hello", Bounds: image.Rect(0, 0, 2, 1)},
+		{Text: "world", Bounds: image.Rect(2, 0, 4, 1)},
+		{Text: "Again", Bounds: image.Rect(0, 1, 2, 2)},
+	}
+
+	testCases := map[string]struct {
+		content string
+		want    string
+		boxes   []Rect
+	}{
+		"scanned": {
+			content: "q 200 0 0 100 0 100 cm /Im1 Do Q",
+			want:    "Hello world\nAgain",
+			boxes:   []Rect{{Point{0, 100}, Point{200, 200}}},
+		},
+		"with digital text": {
+			content: "BT /F1 12 Tf 10 20 Td (Footer) Tj ET q 200 0 0 100 0 100 cm /Im1 Do Q",
+			want:    "Footer\n\nHello world\nAgain",
+			boxes:   []Rect{{Point{0, 100}, Point{200, 200}}},
+		},
+		"with an OCR layer": {
+			content: "q 200 0 0 100 0 100 cm /Im1 Do Q BT 3 Tr /F1 12 Tf 10 150 Td (Recognized) Tj ET",
+			want:    "Recognized",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data := scanned(tc.content)
+			ocr := &fakeOCR{words: words}
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithOCR(ocr))
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+			if diff := cmp.Diff(ocr.boxes, tc.boxes); diff != "" {
+				t.Error("recognized images did not match expectation:", diff)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		data := scanned("q 200 0 0 100 0 100 cm /Im1 Do Q")
+		ocr := &fakeOCR{err: errors.New("no engine")}
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithOCR(ocr))
+		if err != nil {
+			t.Fatal("failed to open PDF:", err)
+		}
+		if _, err := r.Text(); !errors.Is(err, ocr.err) {
+			t.Errorf("got error %v, want %v", err, ocr.err)
+		}
+	})
+}
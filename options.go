@@ -0,0 +1,223 @@
+package pdf
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/ScriptRock/pdf/text"
+)
+
+// An Option configures how a Reader opens and reads a file.
+type Option func(*config)
+
+type config struct {
+	password string
+	ctx      context.Context
+
+	allLayers bool
+	layers    []string
+
+	duplicateOffset float64
+	bidi            bool
+	clippedText     bool
+	unmapped        UnmappedPolicy
+	builder         text.BuilderOptions
+	ocr             OCR
+
+	httpBlockSize   int
+	httpCacheBlocks int
+
+	objectStreams  int
+	readBufferSize int
+	contentCache   int
+
+	mmap bool
+
+	maxObjects        int
+	lazyXref          bool
+	strictGenerations bool
+	strictFilters     bool
+
+	linearizedFirstPage bool
+}
+
+// WithPassword sets a password to try if the file is encrypted and cannot be opened
+// with the empty password.
+func WithPassword(pw string) Option {
+	return func(c *config) { c.password = pw }
+}
+
+// WithContext sets a context bounding the opening of the file: reading the
+// cross-reference sections, and reading the objects of the file to look for its
+// catalog when the trailer has none, stops with the context's error once it is done.
+// Use the Context variants of the text extraction methods to bound those.
+func WithContext(ctx context.Context) Option {
+	return func(c *config) { c.ctx = ctx }
+}
+
+// context returns the context set with WithContext, or context.Background.
+func (c *config) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// WithDuplicateText makes text extraction drop a run of text that repeats the one
+// before it, at an origin within offset of it in each direction, in user space units.
+// Such runs are drawn by producers that simulate bold text or shadows, and the run
+// that is kept is made bold. See text.Builder.DuplicateOffset.
+func WithDuplicateText(offset float64) Option {
+	return func(c *config) { c.duplicateOffset = offset }
+}
+
+// WithClippedText makes text extraction keep the runs of text drawn entirely outside
+// the visible region of a page, which it drops by default: outside the crop box of the
+// page, or clipped away by the clipping path, as text hidden by moving it off the page
+// or by clipping it to a tiny rectangle is. The clipping path is approximated by its
+// bounds, and a run partly within the region is kept whole. The runs dropped from a
+// page are counted in a ContentWarning.
+func WithClippedText() Option {
+	return func(c *config) { c.clippedText = true }
+}
+
+// An UnmappedPolicy is what text extraction does with the character codes of a font
+// that have no text, by its encoding or ToUnicode CMap.
+type UnmappedPolicy int
+
+const (
+	UnmappedReplace UnmappedPolicy = iota // Extract the code as U+FFFD, the replacement character.
+	UnmappedDrop                          // Extract nothing of the code.
+	UnmappedHex                           // Extract the code as U+FFFD followed by the code in hex, as "\uFFFD<81>".
+	UnmappedError                         // Fail, with an *UnmappedCodeError.
+)
+
+// WithUnmappedCodes sets what text extraction does with the character codes that have
+// no text, which it extracts as U+FFFD by default. The text of the fonts that cannot be
+// read at all is decoded as PDFDocEncoding, whose unmapped codes are treated the same.
+func WithUnmappedCodes(p UnmappedPolicy) Option {
+	return func(c *config) { c.unmapped = p }
+}
+
+// WithBuilderOptions sets the thresholds by which text extraction breaks the
+// text of a page into words, lines and paragraphs.
+func WithBuilderOptions(o text.BuilderOptions) Option {
+	return func(c *config) { c.builder = o }
+}
+
+// WithBidi makes text extraction reorder each line of text from visual to logical
+// order, for documents in right-to-left scripts whose glyphs are drawn in visual
+// order. See text.Text.Logical.
+func WithBidi() Option {
+	return func(c *config) { c.bidi = true }
+}
+
+// WithOCR makes text extraction recognize the text in the images of a page with ocr,
+// for the images without any text drawn over them, visible or not, as on a scanned page
+// or a scanned part of one. The words recognized are placed where they are drawn on the
+// page, as text shown by the image, and so are in its text with the rest.
+func WithOCR(ocr OCR) Option {
+	return func(c *config) { c.ocr = ocr }
+}
+
+// WithObjectStreamCache sets the number of decoded object streams that a Reader keeps,
+// to read the objects compressed in them without decoding them again. The default is 16;
+// zero or less keeps none.
+func WithObjectStreamCache(n int) Option {
+	return func(c *config) { c.objectStreams = n }
+}
+
+// defaultContentCache is the greatest number of operations of the content of a page
+// that the Page keeps.
+const defaultContentCache = 50_000
+
+// WithContentCache sets the greatest number of operations of the content streams of a
+// page that a Page keeps, once it has read them, so that its methods that interpret
+// them again, such as Text, Render and Contents, do so without decoding and parsing
+// the streams again. The operations of a page of more are not kept. The default is
+// 50,000; zero or less keeps none. The pages read by the methods of Reader that read
+// each page once, such as Text and Page, keep none.
+func WithContentCache(n int) Option {
+	return func(c *config) { c.contentCache = n }
+}
+
+// defaultMaxObjects is the greatest number of indirect objects of a file, the limit
+// of PDF implementations. See PDF 32000-1:2008, §C.2.
+const defaultMaxObjects = 8_388_607
+
+// WithMaxObjects sets the greatest number of objects of a file that its cross-reference
+// sections may define, so that a malformed file cannot make a Reader allocate a table for
+// billions of them. Opening a file with more fails. The default is 8,388,607, the limit of
+// PDF implementations.
+func WithMaxObjects(n int) Option {
+	return func(c *config) { c.maxObjects = n }
+}
+
+// WithLazyXref makes opening a file read only its newest cross-reference section, and
+// the older sections of its Prev chain of incremental updates, newest first, as objects
+// that the sections read so far lack are resolved. Reading a few objects of a file of many,
+// such as those of NPages, Info and Producer, then reads few of its sections, and its
+// entries are kept in a map rather than a table of all the objects of the file, until
+// there are many. Methods that need all the sections, such as Xref, Objects and Validate,
+// read them. The keys that the trailer of an update lacks, such as Info and Encrypt, are
+// taken only from the older trailers read to find the Root.
+func WithLazyXref() Option {
+	return func(c *config) { c.lazyXref = true }
+}
+
+// WithStrictGenerations makes a reference resolve only to an object of its generation.
+// By default, as some incremental writers give references the wrong generation, a
+// reference to an object in use of another generation resolves to that object.
+// Either way, a reference to a free object resolves to null.
+func WithStrictGenerations() Option {
+	return func(c *config) { c.strictGenerations = true }
+}
+
+// WithStrictFilters makes the data of a stream decode only as its filters declare.
+// By default, as some writers declare FlateDecode on data that is not compressed,
+// or compress the data of content streams twice, FlateDecode data without a zlib
+// header is inflated as raw deflate data, or else read as it is, and a content
+// stream whose decoded data is zlib data is inflated again, each with a warning.
+func WithStrictFilters() Option {
+	return func(c *config) { c.strictFilters = true }
+}
+
+// defaultReadBufferSize is the size of the reads of the data of streams.
+const defaultReadBufferSize = 32 << 10
+
+// WithReadBufferSize sets the size of the reads of the data of a stream from the file,
+// which is buffered so that decoding it makes few reads, however little its filters read
+// at a time, as they do from a file on a network drive or an HTTP server. The default is
+// 32 KiB; zero or less reads the data as the filters do.
+func WithReadBufferSize(n int) Option {
+	return func(c *config) { c.readBufferSize = n }
+}
+
+// seekerReaderAt adapts an io.ReadSeeker to an io.ReaderAt,
+// serializing access to the underlying seek position.
+type seekerReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+func (s *seekerReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *seekerReaderAt) Close() error {
+	if c, ok := s.rs.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
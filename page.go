@@ -5,32 +5,71 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"runtime/debug"
+	"slices"
+	"strings"
+	"sync/atomic"
 
 	"github.com/ScriptRock/pdf/internal/state"
+	"github.com/ScriptRock/pdf/internal/types"
 	"github.com/ScriptRock/pdf/text"
 )
 
 // A Page represent a single Page in a PDF file.
 // The methods interpret a Page dictionary stored in V.
 type Page struct {
-	v value
+	v Value
+	// num is the number of the page, or zero if it is not known.
+	num int
+
+	// ops are the operations of the content streams of the page, kept once they
+	// are read, unless uncached, to be interpreted again without reading them
+	// again. See WithContentCache.
+	ops      atomic.Pointer[[]Operation]
+	uncached bool
 }
 
 // Page returns the page for the given page number.
 // Page numbers are indexed starting at 1, not 0.
 // If the page is not found, Page returns an error.
 func (r *Reader) Page(i int) (text.Text, error) {
+	return r.PageContext(context.Background(), i)
+}
+
+// PageContext is like Page, but stops with the error of ctx once it is done.
+func (r *Reader) PageContext(ctx context.Context, i int) (text.Text, error) {
+	p, err := r.GetPage(i)
+	if err != nil {
+		return nil, err
+	}
+	// The page is read once, and its operations would be kept for nothing.
+	p.uncached = true
+	return p.TextContext(ctx)
+}
+
+// GetPage returns the page dictionary for the given page number.
+// Page numbers are indexed starting at 1, not 0.
+// If the page is not found, GetPage returns an error.
+func (r *Reader) GetPage(i int) (*Page, error) {
 	if n := r.NPages(); i < 1 || i > n {
 		return nil, fmt.Errorf("page %d out of range: [1, %d]", i, n)
 	}
+	if p, ok := r.firstPage(i); ok {
+		return p, nil
+	}
 
 	n := i - 1 // 0-indexed
 	page := r.trailerValue().Key("Root").Key("Pages")
+	seen := map[types.Objptr]bool{}
 Search:
 	for page.Key("Type").Name() == "Pages" {
+		if seen[page.ptr] {
+			return nil, fmt.Errorf("page %d not found: page tree contains a cycle", i)
+		}
+		seen[page.ptr] = true
+
 		count := int(page.Key("Count").Int64())
 		if count < n {
 			break
@@ -49,11 +88,12 @@ Search:
 
 			case "Page":
 				if n == 0 {
-					return (&Page{kid}).Text()
+					return &Page{v: kid, num: i}, nil
 				}
 				n--
 			}
 		}
+		break
 	}
 
 	return nil, fmt.Errorf("page %d not found", i)
@@ -61,67 +101,260 @@ Search:
 
 // NPages returns the number of pages in the PDF file.
 func (r *Reader) NPages() int {
-	return int(r.trailerValue().Key("Root").Key("Pages").Key("Count").Int64())
+	pages := r.trailerValue().Key("Root").Key("Pages")
+	if lin, ok := r.Linearization(); r.firstPageOnly && ok && pages.IsNull() {
+		// The page tree is outside the first page section.
+		return lin.Pages
+	}
+	return int(pages.Key("Count").Int64())
 }
 
-func (p Page) findInherited(key string) value {
-	for v := p.v; !v.IsNull(); v = v.Key("Parent") {
+func (p *Page) findInherited(key string) Value {
+	v := p.v
+	for range maxPageTreeDepth {
+		if v.IsNull() {
+			break
+		}
 		if r := v.Key(key); !r.IsNull() {
 			return r
 		}
+		v = v.Key("Parent")
+	}
+	return Value{}
+}
+
+// font returns the font v, of the given resource name, used by the page.
+// A font whose encoding cannot be read decodes its text as PDFDocEncoding,
+// so that the text of the other fonts of the page can still be read.
+func (p *Page) font(ctx context.Context, v Value, name string) *font {
+	f, err := newFont(ctx, v)
+	if err != nil {
+		if ctx.Err() != nil {
+			panic(ctx.Err())
+		}
+		p.v.r.warn(FontWarning, v.ptr, p.num, "failed to read font %s: %v", name, err)
+	} else if msg := fontTextProblem(v); v.Kind() == Dict && msg != "" {
+		p.v.r.warn(FontWarning, v.ptr, p.num, "%s", msg)
 	}
-	return value{}
+	f.setUnmapped(p.v.r.cfg.unmapped, p.num)
+	return f
 }
 
-// resources returns the resources dictionary associated with the page.
-func (p Page) resources() value {
-	return p.findInherited("Resources")
+// Text returns the structured text on the page. A page whose content cannot be read
+// fails with an error of ErrUnsupportedFeature, such as that of a filter that is not
+// supported, or of ErrCorruptContent, a *ContentError, for errors.Is; a page without
+// text has none, and no error.
+func (p *Page) Text() (text.Text, error) {
+	return p.TextContext(context.Background())
 }
 
-// fonts returns a list of the fonts associated with the page.
-func (p Page) fonts() []string {
-	return p.resources().Key("Font").Keys()
+// TextContext is like Text, but stops with the error of ctx once it is done.
+func (p *Page) TextContext(ctx context.Context) (text.Text, error) {
+	t, _, err := p.extract(ctx, nil, false, p.v.r.cfg.ocr, p.v.r.cfg.builder)
+	return t, err
 }
 
-// font returns the font with the given name associated with the page.
-func (p Page) font(name string) *font {
-	return newFont(p.resources().Key("Font").Key(name))
+// TextWithOptions is like TextContext, but builds the text of the page with the
+// options o rather than those set with WithBuilderOptions, such as to put it in
+// reading order with text.BuilderOptions.ReadingOrder.
+func (p *Page) TextWithOptions(ctx context.Context, o text.BuilderOptions) (text.Text, error) {
+	t, _, err := p.extract(ctx, nil, false, p.v.r.cfg.ocr, o)
+	return t, err
 }
 
-// Text returns the structured text on the page.
-func (p *Page) Text() (result text.Text, err error) {
-	// TODO: return errors everywhere.
+// extract returns the text on the page and, if byMCID is true, the text of each
+// marked-content sequence with a marked-content identifier, by identifier.
+// See PDF 32000-1:2008, §14.7.4.
+//
+// If r is not nil, the runs of text shown are rendered to r instead, as they are;
+// a drawingRenderer is told of the text-showing operators and images too.
+// If ocr is not nil, the words it recognizes in the images that no text is drawn
+// over are rendered with them, when each image is drawn. The text is built with
+// the options o.
+func (p *Page) extract(ctx context.Context, r Renderer, byMCID bool, ocr OCR, o text.BuilderOptions) (result text.Text, mcids map[int64]text.Text, err error) {
+	// The content fails by panicking, with the errors of contentError.
 	defer func() {
 		if r := recover(); r != nil {
-			result = nil
-			err = fmt.Errorf("failed to read page text: %v\n%s", r, debug.Stack())
+			result, mcids = nil, nil
+			if ctx.Err() != nil {
+				err = ctx.Err()
+				return
+			}
+			err = contentError(ctx, r, -1, "").(error)
 		}
 	}()
 
-	decoders := make(map[string]*font)
-	for _, f := range p.fonts() {
-		decoders[f] = p.font(f)
+	var covered textBounds
+	if ocr != nil {
+		// The images with text over them already have theirs.
+		if _, _, err := p.extract(ctx, &covered, false, nil, o); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	content := newContentReader(ctx, p)
 	var (
+		ocrErr error
 		out    text.Builder
 		gState state.Graphics
+		path   clipPath
+
+		// marked holds the open marked-content sequences, innermost last.
+		marked []markedContent
+		// compat is the depth of the compatibility sections open, in which
+		// unknown operators are skipped silently. See PDF 32000-1:2008, §7.8.2.
+		compat int
+		hidden = p.v.r.hiddenLayers()
+		byID   = map[int64]*text.Builder{}
+
+		box     = p.box()
+		dropped droppedText
 	)
+	out.DuplicateOffset = p.v.r.cfg.duplicateOffset
+	out.Options = o
+	out.Page = p.num
+	drawing, _ := r.(drawingRenderer)
+	hiddenNow := func() bool { return len(marked) > 0 && marked[len(marked)-1].hidden }
+	renderer := func() state.Renderer {
+		if hiddenNow() {
+			if r != nil {
+				return discardRenderer{}
+			}
+			return discardRenderer{&out}
+		}
+		if r != nil {
+			return runRenderer{r}
+		}
+		// The glyphs of a sequence with an ActualText, including those of the
+		// sequences nested in it, are replaced by that text.
+		for i := range marked {
+			if marked[i].replaced {
+				return &marked[i]
+			}
+		}
+		if byMCID {
+			for i := len(marked) - 1; i >= 0; i-- {
+				if id, ok := marked[i].mcid(); ok {
+					if byID[id] == nil {
+						byID[id] = &text.Builder{DuplicateOffset: out.DuplicateOffset, Options: out.Options, Page: out.Page}
+					}
+					return multiRenderer{builderRenderer{&out}, builderRenderer{byID[id]}}
+				}
+			}
+		}
+		return builderRenderer{&out}
+	}
+	// shown returns the renderer of the text shown now, which drops the
+	// runs outside the visible region of the page, unless they are kept.
+	shown := func() state.Renderer {
+		rd := renderer()
+		if _, ok := rd.(discardRenderer); ok || p.v.r.cfg.clippedText || drawing != nil {
+			return rd
+		}
+		region, bounded := visibleRegion(box, &gState)
+		if !bounded {
+			return rd
+		}
+		return clipRenderer{rd, region, &dropped}
+	}
 
-	forEachStream(p, func(stk *stack, op string) {
+	var do func(stk *stack, op string)
+	do = func(stk *stack, op string) {
 		n := stk.Len()
-		args := make([]value, n)
+		args := make([]Value, n)
 		for i := range n {
 			args[n-1-i] = stk.Pop()
 		}
+		if msg := checkOperands(op, args); msg != "" {
+			p.v.r.warn(ContentWarning, p.v.ptr, p.num, "%s", msg)
+			return
+		}
+		if !contentOperators[op] {
+			if compat == 0 {
+				p.v.r.warn(ContentWarning, p.v.ptr, p.num, "skipping unknown operator %s", op)
+			}
+			return
+		}
 
+		if path.do(op, args, &gState) {
+			return
+		}
 		switch op {
+		case "BX":
+			compat++
+		case "EX":
+			compat = max(compat-1, 0)
+
 		case "q":
 			gState.Push()
 		case "Q":
 			gState.Pop()
 		case "cm":
 			gState.CM(args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64(), args[4].Float64(), args[5].Float64())
+		case "gs":
+			content.extGState(args[0].Name(), &gState)
+
+		case "BMC":
+			marked = append(marked, markedContent{hidden: len(marked) > 0 && marked[len(marked)-1].hidden})
+		case "BDC":
+			mc := markedContent{hidden: len(marked) > 0 && marked[len(marked)-1].hidden}
+			if len(args) == 2 {
+				props := args[1]
+				if props.Kind() == Name {
+					props = content.res.lookup("Properties", props.Name())
+				}
+				mc.props = props
+				if args[0].Name() == "OC" {
+					mc.hidden = mc.hidden || !visible(props, hidden)
+				}
+				if actual := props.Key("ActualText"); actual.Kind() == String {
+					mc.replaced = true
+					mc.actualText = actual.Text()
+				}
+			}
+			marked = append(marked, mc)
+		case "EMC":
+			if len(marked) == 0 {
+				break
+			}
+			// The actual text is rendered before the sequence ends, so that it is
+			// in the text of the MCID of the sequence, if it has one.
+			if mc := &marked[len(marked)-1]; mc.replaced && mc.drawn {
+				mc.replaced = false
+				renderer().Render(state.Run{X: mc.x, Y: mc.y, W: mc.w, H: mc.h, Font: mc.font, Box: mc.box, Text: mc.actualText})
+			}
+			marked = marked[:len(marked)-1]
+
+		case "Do":
+			xobj := content.res.lookup("XObject", args[0].Name())
+			if xobj.Key("Subtype").Name() == "Form" {
+				if oc := xobj.Key("OC"); oc.IsNull() || visible(oc, hidden) {
+					content.drawForm(xobj, &gState, do)
+				}
+				break
+			}
+			if xobj.Key("Subtype").Name() != "Image" || hiddenNow() {
+				break
+			}
+			if drawing != nil {
+				drawing.drawImage(unitSquare(gState.CTM()).Intersect(box))
+			}
+			if ocr == nil || covered.covers(unitSquare(gState.CTM())) {
+				break
+			}
+			rd := renderer()
+			runs, err := recognize(ocr, xobj, gState.CTM())
+			if err != nil {
+				// The OCR is not run again on the other images.
+				ocr, ocrErr = nil, err
+			}
+			for _, run := range runs {
+				rd.Render(state.Run(run))
+			}
+		case "BI":
+			if drawing != nil && !hiddenNow() {
+				drawing.drawImage(unitSquare(gState.CTM()).Intersect(box))
+			}
 
 		case "Tc":
 			gState.Tc(args[0].Float64())
@@ -131,6 +364,8 @@ func (p *Page) Text() (result text.Text, err error) {
 			gState.Tz(args[0].Float64())
 		case "TL":
 			gState.TL(args[0].Float64())
+		case "Tr":
+			gState.Tr(int(args[0].Float64()))
 		case "BT":
 			gState.BT()
 		case "ET":
@@ -144,7 +379,13 @@ func (p *Page) Text() (result text.Text, err error) {
 		case "T*":
 			gState.Tstar()
 		case "Tf":
-			gState.Tf(decoders[args[0].Name()], args[1].Float64())
+			f, ok := content.fonts[args[0].Name()]
+			if !ok {
+				p.v.r.warn(ContentWarning, p.v.ptr, p.num, "unknown font %v", args[0])
+				gState.Tf(nil, args[1].Float64())
+				break
+			}
+			gState.Tf(f, args[1].Float64())
 
 		case `"`:
 			gState.Tw(args[0].Float64())
@@ -155,42 +396,298 @@ func (p *Page) Text() (result text.Text, err error) {
 			gState.Tstar()
 			fallthrough
 		case "Tj":
-			gState.Tj(&out, args[0].RawString())
+			if drawing != nil && !hiddenNow() {
+				drawing.showText()
+			}
+			gState.Tj(shown(), args[0].RawString())
 		case "TJ":
+			if drawing != nil && !hiddenNow() {
+				drawing.showText()
+			}
 			arr := args[0]
 			for i := range arr.Len() {
 				switch e := arr.Index(i); e.Kind() {
-				case stringKind:
-					gState.Tj(&out, e.RawString())
-				case integerKind:
+				case String:
+					gState.Tj(shown(), e.RawString())
+				case Integer:
 					gState.TJDisplace(float64(e.Int64()))
-				case realKind:
+				case Real:
 					gState.TJDisplace(e.Float64())
+				default:
+					p.v.r.warn(ContentWarning, p.v.ptr, p.num, "skipping TJ array element %v, not a string or a number", e)
 				}
 			}
 		}
-	})
+	}
+	forEachStream(ctx, p, do)
+	p.setFontStats(content)
+
+	if dropped.runs > 0 {
+		p.v.r.warn(ContentWarning, p.v.ptr, p.num, "dropped %d runs of text, of %d glyphs, outside the visible region of the page", dropped.runs, dropped.glyphs)
+	}
+	if ocrErr != nil {
+		return nil, nil, fmt.Errorf("failed to recognize page text: %w", ocrErr)
+	}
+
+	order := func(t text.Text) text.Text { return t }
+	if p.v.r.cfg.bidi {
+		order = text.Text.Logical
+	}
+	if byMCID {
+		mcids = make(map[int64]text.Text, len(byID))
+		for id, b := range byID {
+			mcids[id] = order(b.Text())
+		}
+	}
+	return order(out.Text()), mcids, nil
+}
+
+// An operandKind is the kind of value that an operator takes as an operand.
+type operandKind int
+
+const (
+	numberOperand     operandKind = iota // An Integer or a Real.
+	nameOperand                          // A Name.
+	stringOperand                        // A String.
+	arrayOperand                         // An Array.
+	propertiesOperand                    // A property list: a Dict, or the Name of one of the Properties resources.
+)
+
+func (k operandKind) String() string {
+	switch k {
+	case numberOperand:
+		return "a number"
+	case nameOperand:
+		return "a name"
+	case stringOperand:
+		return "a string"
+	case arrayOperand:
+		return "an array"
+	case propertiesOperand:
+		return "a property list"
+	}
+	return fmt.Sprintf("operandKind(%d)", int(k))
+}
+
+// matches reports whether v is of the kind k.
+func (k operandKind) matches(v Value) bool {
+	switch v.Kind() {
+	case Integer, Real:
+		return k == numberOperand
+	case Name:
+		return k == nameOperand || k == propertiesOperand
+	case String:
+		return k == stringOperand
+	case Array:
+		return k == arrayOperand
+	case Dict:
+		return k == propertiesOperand
+	}
+	return false
+}
+
+// numbers returns the kinds of n operands that are numbers.
+func numbers(n int) []operandKind {
+	kinds := make([]operandKind, n)
+	for i := range kinds {
+		kinds[i] = numberOperand
+	}
+	return kinds
+}
+
+// operands holds the kinds of the operands of the operators interpreted by Page.Text,
+// and of some that it skips, of the glyph procedures of Type 3 fonts, of the graphics
+// state and painting that do not place text and of the paths that clip it. The operands of any other operator are
+// discarded with it, as is an operator with operands of the wrong number or kinds.
+var operands = map[string][]operandKind{
+	"q": nil, "Q": nil, "cm": numbers(6), "gs": {nameOperand},
+	"BMC": {nameOperand}, "BDC": {nameOperand, propertiesOperand}, "EMC": nil, "Do": {nameOperand},
+	"Tc": numbers(1), "Tw": numbers(1), "Tz": numbers(1), "TL": numbers(1), "Tf": {nameOperand, numberOperand}, "Tr": numbers(1),
+	"BT": nil, "ET": nil, "Td": numbers(2), "TD": numbers(2), "Tm": numbers(6), "T*": nil,
+	"Tj": {stringOperand}, "TJ": {arrayOperand}, "'": {stringOperand}, `"`: {numberOperand, numberOperand, stringOperand},
+	"d0": numbers(2), "d1": numbers(6),
+	"sh": {nameOperand}, "ri": {nameOperand}, "i": numbers(1), "j": numbers(1), "J": numbers(1), "M": numbers(1), "w": numbers(1),
+	"d": {arrayOperand, numberOperand},
+	"m": numbers(2), "l": numbers(2), "c": numbers(6), "v": numbers(4), "y": numbers(4), "re": numbers(4),
+}
+
+// checkOperands returns why the operator op cannot be interpreted with the operands
+// args, as they are not of the number or kinds that it takes, or "" if it can.
+func checkOperands(op string, args []Value) string {
+	kinds, ok := operands[op]
+	if !ok {
+		return ""
+	}
+	if len(args) != len(kinds) {
+		return fmt.Sprintf("skipping operator %s with %d operands, not %d", op, len(args), len(kinds))
+	}
+	for i, k := range kinds {
+		if !k.matches(args[i]) {
+			return fmt.Sprintf("skipping operator %s with operand %d %v, not %v", op, i+1, args[i], k)
+		}
+	}
+	return ""
+}
+
+// contentOperators holds the operators of content streams, of PDF 32000-1:2008, Annex A.
+// BI stands for the inline images, which interpret reads from BI to EI.
+var contentOperators = map[string]bool{
+	"b": true, "B": true, "b*": true, "B*": true, "BDC": true, "BI": true, "BMC": true, "BT": true,
+	"BX": true, "c": true, "cm": true, "CS": true, "cs": true, "d": true, "d0": true, "d1": true,
+	"Do": true, "DP": true, "EMC": true, "ET": true, "EX": true, "f": true, "F": true, "f*": true,
+	"G": true, "g": true, "gs": true, "h": true, "i": true, "j": true, "J": true, "K": true,
+	"k": true, "l": true, "m": true, "M": true, "MP": true, "n": true, "q": true, "Q": true,
+	"re": true, "RG": true, "rg": true, "ri": true, "s": true, "S": true, "SC": true, "sc": true,
+	"SCN": true, "scn": true, "sh": true, "T*": true, "Tc": true, "Td": true, "TD": true, "Tf": true,
+	"Tj": true, "TJ": true, "TL": true, "Tm": true, "Tr": true, "Ts": true, "Tw": true, "Tz": true,
+	"v": true, "w": true, "W": true, "W*": true, "y": true, "'": true, `"`: true,
+}
+
+// A markedContent is an open marked-content sequence. See PDF 32000-1:2008, §14.6.
+type markedContent struct {
+	// props is the property list of the sequence.
+	props Value
+	// hidden is whether the sequence is in an optional content group that is off.
+	hidden bool
+
+	// replaced is whether the glyphs of the sequence are replaced by its actualText,
+	// rendered where they are drawn when the sequence ends. See PDF 32000-1:2008, §14.9.4.
+	replaced   bool
+	actualText string
+
+	// The extent of the glyphs drawn in the sequence, their bounds on the page,
+	// and the font of the first.
+	drawn      bool
+	x, y, w, h float64
+	box        [4]float64
+	font       string
+}
+
+// Render records the extent of the glyphs of s, rendered in place of the actual text.
+func (mc *markedContent) Render(run state.Run) {
+	if !mc.drawn {
+		mc.drawn = true
+		mc.x, mc.y, mc.h, mc.box, mc.font = run.X, run.Y, run.H, run.Box, run.Font
+	}
+	mc.box = [4]float64{min(mc.box[0], run.Box[0]), min(mc.box[1], run.Box[1]), max(mc.box[2], run.Box[2]), max(mc.box[3], run.Box[3])}
+	mc.w = max(mc.w, run.X+run.W-mc.x)
+	mc.h = max(mc.h, run.H)
+}
+
+// mcid returns the marked-content identifier of the sequence, if it has one.
+func (mc *markedContent) mcid() (int64, bool) {
+	id := mc.props.Key("MCID")
+	return id.Int64(), id.Kind() == Integer
+}
 
-	return out.Text(), nil
+// A drawingRenderer is a Renderer that extract also tells of the text-showing operators
+// and the images of the page, other than those hidden, as TextCoverage counts them. The
+// runs rendered to it are not clipped to the page.
+type drawingRenderer interface {
+	Renderer
+	// showText is called for each text-showing operator, before its runs are rendered.
+	showText()
+	// drawImage is called for each image XObject and inline image, with its bounds on the page.
+	drawImage(bounds Rect)
+}
+
+// A multiRenderer renders text to each of its renderers.
+type multiRenderer []state.Renderer
+
+func (m multiRenderer) Render(run state.Run) {
+	for _, r := range m {
+		r.Render(run)
+	}
+}
+
+// A discardRenderer discards the text rendered to it, skipping it in the
+// text.Builder b, if any, so that it leaves no gap in the line.
+type discardRenderer struct{ b *text.Builder }
+
+func (r discardRenderer) Render(run state.Run) {
+	if r.b != nil {
+		r.b.Skip(run.X, run.Y, run.W, run.H)
+	}
+}
+
+// A builderRenderer renders text to a text.Builder.
+type builderRenderer struct{ b *text.Builder }
+
+func (r builderRenderer) Render(run state.Run) {
+	r.b.RenderSpaced(run.X, run.Y, run.W, run.H, run.SpaceWidth, run.Font, run.Text)
 }
 
 // forEachStream interprets each stream in the reader as a PostScript stream,
-// running `do` against every PostScript operation.
-func forEachStream(p *Page, do func(stk *stack, op string)) {
+// running `do` against every PostScript operation. The operations are kept,
+// and interpreted again from the next time, as WithContentCache sets.
+func forEachStream(ctx context.Context, p *Page, do func(stk *stack, op string)) {
+	if ops := p.ops.Load(); ops != nil {
+		replay(ctx, *ops, do)
+		return
+	}
+	limit := p.v.r.cfg.contentCache
+	if p.uncached || limit <= 0 {
+		interpretContents(ctx, p, do)
+		return
+	}
+
+	keep := true
+	ops := []Operation{}
+	interpretContents(ctx, p, func(stk *stack, op string) {
+		if keep {
+			if len(ops) < limit {
+				ops = append(ops, Operation{Op: op, Operands: slices.Clone(stk.stack)})
+			} else {
+				keep, ops = false, nil
+			}
+		}
+		do(stk, op)
+	})
+	if keep {
+		p.ops.Store(&ops)
+	}
+}
+
+// replay runs do against the operations ops, kept by forEachStream,
+// each with the stack of its operands as it was when it was read.
+func replay(ctx context.Context, ops []Operation, do func(stk *stack, op string)) {
+	var stk stack
+	var op string
+	defer func() {
+		if r := recover(); r != nil {
+			panic(contentError(ctx, r, -1, op))
+		}
+	}()
+	for i, o := range ops {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				panic(err)
+			}
+		}
+		stk.stack = append(stk.stack[:0], o.Operands...)
+		op = o.Op
+		do(&stk, op)
+	}
+}
+
+// interpretContents interprets the content streams of the page p, running do against
+// each of their operations.
+func interpretContents(ctx context.Context, p *Page, do func(stk *stack, op string)) {
 	v := p.v.Key("Contents")
-	if v.Kind() == streamKind {
-		interpret(v.Reader(), do)
+	if v.Kind() == Stream {
+		interpret(ctx, v.contentStream(), do)
 		return
 	}
 
+	// The streams are concatenated, but each ends the token it ends with,
+	// so they are separated by white space.
 	var rr []io.Reader
 	for i := 0; i < v.Len(); i++ {
 		v := v.Index(i)
-		if v.Kind() == streamKind {
-			rr = append(rr, v.Reader())
+		if v.Kind() == Stream {
+			rr = append(rr, v.contentStream(), strings.NewReader("\n"))
 		}
 	}
 
-	interpret(io.MultiReader(rr...), do)
-
+	interpret(ctx, io.MultiReader(rr...), do)
 }
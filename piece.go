@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"sort"
+	"time"
+)
+
+// A Piece is the data of an application in a page-piece dictionary, which the
+// application that created a document or page keeps there for itself, as Adobe
+// Illustrator keeps its own copy of the artwork. See PDF 32000-1:2008, §14.5.
+type Piece struct {
+	// Application is the name of the application, the key of its data dictionary.
+	Application string
+	// LastModified is when the application last changed its data, or the zero Time
+	// if the data dictionary lacks its LastModified date.
+	LastModified time.Time
+	// Private is the private data of the application, in a form that only it reads.
+	Private Value
+}
+
+// PieceInfo returns the page-piece dictionary of the document, the PieceInfo of its
+// catalog, or a null Value if it has none. See Pieces for its data dictionaries.
+func (r *Reader) PieceInfo() Value {
+	return r.trailerValue().Key("Root").Key("PieceInfo")
+}
+
+// Pieces returns the data dictionaries of the page-piece dictionary of the
+// document, in order of the names of their applications.
+func (r *Reader) Pieces() []Piece {
+	return pieces(r.PieceInfo())
+}
+
+// PieceInfo returns the page-piece dictionary of the page, or a null Value
+// if it has none. See Pieces for its data dictionaries.
+func (p *Page) PieceInfo() Value {
+	return p.v.Key("PieceInfo")
+}
+
+// Pieces returns the data dictionaries of the page-piece dictionary of the
+// page, in order of the names of their applications.
+func (p *Page) Pieces() []Piece {
+	return pieces(p.PieceInfo())
+}
+
+// pieces returns the data dictionaries of the page-piece dictionary info.
+func pieces(info Value) []Piece {
+	if info.Kind() != Dict {
+		return nil
+	}
+	keys := info.Keys()
+	sort.Strings(keys)
+	var ps []Piece
+	for _, k := range keys {
+		data := info.Key(k)
+		if data.Kind() != Dict {
+			continue
+		}
+		modified, _ := data.Key("LastModified").Date()
+		ps = append(ps, Piece{Application: k, LastModified: modified, Private: data.Key("Private")})
+	}
+	return ps
+}
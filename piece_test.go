@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValue_Date(t *testing.T) {
+	testCases := map[string]struct {
+		s      string
+		want   time.Time
+		wantOK bool
+	}{
+		"full":           {"D:20230514101520+02'00'", time.Date(2023, 5, 14, 10, 15, 20, 0, time.FixedZone("", 2*3600)), true},
+		"west of UTC":    {"D:19991231235959-05'30", time.Date(1999, 12, 31, 23, 59, 59, 0, time.FixedZone("", -(5*3600+30*60))), true},
+		"UTC":            {"D:20230514101520Z00'00'", time.Date(2023, 5, 14, 10, 15, 20, 0, time.UTC), true},
+		"year only":      {"D:2023", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		"without prefix": {"20230514", time.Date(2023, 5, 14, 0, 0, 0, 0, time.UTC), true},
+		"UTF-16":         {"\xfe\xff\x00D\x00:\x002\x000\x002\x003\x000\x005", time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		"invalid month":  {"D:20231301", time.Time{}, false},
+		"not a date":     {"yesterday", time.Time{}, false},
+		"signed year":    {"D:+023", time.Time{}, false},
+		"invalid offset": {"D:20230514101520+25'00'", time.Time{}, false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := Value{data: tc.s}.Date()
+			if ok != tc.wantOK || !got.Equal(tc.want) {
+				t.Errorf("got %v, %t, want %v, %t", got, ok, tc.want, tc.wantOK)
+			}
+			_, offset := got.Zone()
+			if _, want := tc.want.Zone(); offset != want {
+				t.Errorf("got zone offset %d, want %d", offset, want)
+			}
+		})
+	}
+}
+
+func TestPage_Pieces(t *testing.T) {
+	// The page-piece dictionaries are as Adobe Illustrator writes them.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /PieceInfo <</Illustrator 4 0 R /Empty null>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /LastModified (D:20230514101520+02'00') /PieceInfo <</Illustrator 4 0 R>>>>",
+		"<</LastModified (D:20230514101520+02'00') /Private 5 0 R>>",
+		"<</AIMetaData 6 0 R /AIPrivateData1 7 0 R /ContainerVersion 12 /CreatorVersion 27 /NumBlock 1 /RoundtripVersion 17>>",
+		stream("%!PS-Adobe-3.0\n%%Creator: Adobe Illustrator(R) 24.0\n%%Title: logo.ai"),
+		stream("%AI12_CompressedData"),
+	))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	want := time.Date(2023, 5, 14, 8, 15, 20, 0, time.UTC)
+	for name, pieces := range map[string][]Piece{"page": p.Pieces(), "document": r.Pieces()} {
+		if len(pieces) != 1 {
+			t.Fatalf("%s: got %d pieces, want 1", name, len(pieces))
+		}
+		got := pieces[0]
+		if got.Application != "Illustrator" || !got.LastModified.Equal(want) {
+			t.Errorf("%s: got %s modified %v, want Illustrator modified %v", name, got.Application, got.LastModified, want)
+		}
+		if v := got.Private.Key("CreatorVersion").Int64(); v != 27 {
+			t.Errorf("%s: got CreatorVersion %d, want 27", name, v)
+		}
+		if got.Private.Key("AIMetaData").Kind() != Stream {
+			t.Errorf("%s: got AIMetaData %v, want a stream", name, got.Private.Key("AIMetaData"))
+		}
+	}
+	if got := p.PieceInfo().Keys(); len(got) != 1 || got[0] != "Illustrator" {
+		t.Errorf("got page PieceInfo keys %v, want [Illustrator]", got)
+	}
+}
@@ -0,0 +1,142 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A ProducerKind is a family of software that produces PDF files.
+type ProducerKind int
+
+const (
+	ProducerUnknown        ProducerKind = iota // Software not in the table of Producer.
+	ProducerWord                               // Microsoft Word, saving or printing to PDF.
+	ProducerLibreOffice                        // LibreOffice, or OpenOffice.org before it.
+	ProducerGhostscript                        // Ghostscript, as ps2pdf and many print drivers use.
+	ProducerInDesign                           // Adobe InDesign.
+	ProducerDistiller                          // Adobe Acrobat Distiller.
+	ProducerWkhtmltopdf                        // wkhtmltopdf, rendering HTML with Qt WebKit.
+	ProducerChrome                             // Chrome, or Chromium and Skia, printing HTML.
+	ProducerQuartz                             // The Quartz PDFContext of macOS and iOS.
+	ProducerIText                              // The iText library, for Java or .NET.
+	ProducerTeX                                // pdfTeX, XeTeX, LuaTeX or dvipdfmx.
+	ProducerCairo                              // The cairo graphics library.
+	ProducerReportLab                          // The ReportLab library for Python.
+	ProducerMicrosoftPrint                     // Microsoft Print to PDF.
+	ProducerScanner                            // The software of a scanner or a multifunction printer.
+)
+
+func (k ProducerKind) String() string {
+	switch k {
+	case ProducerUnknown:
+		return "unknown"
+	case ProducerWord:
+		return "Microsoft Word"
+	case ProducerLibreOffice:
+		return "LibreOffice"
+	case ProducerGhostscript:
+		return "Ghostscript"
+	case ProducerInDesign:
+		return "Adobe InDesign"
+	case ProducerDistiller:
+		return "Acrobat Distiller"
+	case ProducerWkhtmltopdf:
+		return "wkhtmltopdf"
+	case ProducerChrome:
+		return "Chrome"
+	case ProducerQuartz:
+		return "Quartz"
+	case ProducerIText:
+		return "iText"
+	case ProducerTeX:
+		return "TeX"
+	case ProducerCairo:
+		return "cairo"
+	case ProducerReportLab:
+		return "ReportLab"
+	case ProducerMicrosoftPrint:
+		return "Microsoft Print to PDF"
+	case ProducerScanner:
+		return "scanner"
+	}
+	return fmt.Sprintf("ProducerKind(%d)", int(k))
+}
+
+// A Producer is the software that produced a document, as its metadata names it.
+type Producer struct {
+	Kind ProducerKind
+	// Version is the version of the software, as the metadata gives it, such as
+	// "2016" or "9.54.0", or "" if it is not known.
+	Version string
+
+	// The strings that name the software: the Producer and Creator entries of the
+	// document information dictionary, and the xmp:CreatorTool and pdf:Producer
+	// properties of the XMP metadata, or "" for those that the document lacks.
+	InfoProducer, InfoCreator string
+	CreatorTool, XMPProducer  string
+}
+
+// producerPatterns holds the patterns of the strings that name the software of each
+// kind. The first submatch of a pattern, if any, is the version of the software.
+var producerPatterns = []struct {
+	kind ProducerKind
+	re   *regexp.Regexp
+}{
+	{ProducerWord, regexp.MustCompile(`(?i)microsoft[®\s]*(?:office\s+)?word(?:\s+(\d{4}|\d+(?:\.\d+)*)\b)?`)},
+	{ProducerWord, regexp.MustCompile(`(?i)pdfmaker\s+(\d+(?:\.\d+)*)\s+for\s+word`)},
+	{ProducerLibreOffice, regexp.MustCompile(`(?i)(?:libreoffice|openoffice(?:\.org)?)(?:[\s/]+(\d+(?:\.\d+)*))?`)},
+	{ProducerGhostscript, regexp.MustCompile(`(?i)ghostscript(?:\s+(\d+(?:\.\d+)*))?`)},
+	{ProducerInDesign, regexp.MustCompile(`(?i)indesign(?:\s+((?:cc\s+)?\d+(?:\.\d+)*|cs\d*))?`)},
+	{ProducerDistiller, regexp.MustCompile(`(?i)distiller(?:\s+(\d+(?:\.\d+)*))?`)},
+	{ProducerWkhtmltopdf, regexp.MustCompile(`(?i)wkhtmltopdf(?:\s+(\d+(?:\.\d+)*))?`)},
+	{ProducerChrome, regexp.MustCompile(`(?i)skia/pdf(?:\s+m(\d+))?`)},
+	{ProducerChrome, regexp.MustCompile(`(?i)(?:chrome|chromium|headlesschrome)/(\d+(?:\.\d+)*)`)},
+	{ProducerQuartz, regexp.MustCompile(`(?i)(?:mac\s*os(?:\s*x)?|ios)\s+(?:version\s+)?(\d+(?:\.\d+)*).*quartz`)},
+	{ProducerQuartz, regexp.MustCompile(`(?i)quartz\s+pdfcontext`)},
+	{ProducerIText, regexp.MustCompile(`(?i)itext(?:sharp)?\S*\s+(\d+(?:\.\d+)*)`)},
+	{ProducerTeX, regexp.MustCompile(`(?i)(?:pdf|xe|lua)tex(?:[-\s]+(\d+(?:\.\d+)*))?`)},
+	{ProducerTeX, regexp.MustCompile(`(?i)x?dvipdfmx`)},
+	{ProducerCairo, regexp.MustCompile(`(?i)\bcairo(?:\s+(\d+(?:\.\d+)*))?`)},
+	{ProducerReportLab, regexp.MustCompile(`(?i)reportlab`)},
+	{ProducerMicrosoftPrint, regexp.MustCompile(`(?i)microsoft:?\s+print\s+to\s+pdf`)},
+	{ProducerScanner, regexp.MustCompile(`(?i)\b(?:scansnap|kodak\s+capture|paperport|epson\s+scan|canon\s+ir|imagerunner|xerox|ricoh|kyocera|konica\s+minolta|bizhub|hp\s+digital\s+sending|sharp\s+mx)`)},
+}
+
+// parseProducer returns the kind and version of the software named by s,
+// or ProducerUnknown if it is not in producerPatterns.
+func parseProducer(s string) (ProducerKind, string) {
+	for _, p := range producerPatterns {
+		if m := p.re.FindStringSubmatch(s); m != nil {
+			var version string
+			if len(m) > 1 {
+				version = m[1]
+			}
+			return p.kind, version
+		}
+	}
+	return ProducerUnknown, ""
+}
+
+// Producer returns the software that produced the document, as named by its metadata.
+// The application that created the document, named by the xmp:CreatorTool or Creator,
+// is preferred to the software that converted it to PDF, named by the Producer, as the
+// Producer of the documents of many applications is the PDF library that they use.
+// A document whose metadata names no known software is of ProducerUnknown.
+func (r *Reader) Producer() Producer {
+	info := r.Info()
+	p := Producer{
+		InfoProducer: strings.TrimSpace(info["Producer"]),
+		InfoCreator:  strings.TrimSpace(info["Creator"]),
+	}
+	if md, err := r.Metadata(); err == nil && md != nil {
+		p.CreatorTool = xmpProperty(md, xmpNamespace, "CreatorTool")
+		p.XMPProducer = xmpProperty(md, xmpPDFNamespace, "Producer")
+	}
+	for _, s := range [...]string{p.CreatorTool, p.InfoCreator, p.InfoProducer, p.XMPProducer} {
+		if p.Kind, p.Version = parseProducer(s); p.Kind != ProducerUnknown {
+			break
+		}
+	}
+	return p
+}
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseProducer(t *testing.T) {
+	testCases := []struct {
+		s           string
+		wantKind    ProducerKind
+		wantVersion string
+	}{
+		{"Microsoft® Word 2016", ProducerWord, "2016"},
+		{"Microsoft® Word for Microsoft 365", ProducerWord, ""},
+		{"Acrobat PDFMaker 21 for Word", ProducerWord, "21"},
+		{"LibreOffice 7.3", ProducerLibreOffice, "7.3"},
+		{"OpenOffice.org 3.2", ProducerLibreOffice, "3.2"},
+		{"GPL Ghostscript 9.54.0", ProducerGhostscript, "9.54.0"},
+		{"Adobe InDesign 18.2 (Windows)", ProducerInDesign, "18.2"},
+		{"Adobe InDesign CC 2017 (Macintosh)", ProducerInDesign, "CC 2017"},
+		{"Acrobat Distiller 21.0 (Windows)", ProducerDistiller, "21.0"},
+		{"wkhtmltopdf 0.12.6", ProducerWkhtmltopdf, "0.12.6"},
+		{"Skia/PDF m120", ProducerChrome, "120"},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/119.0.6045.105 Safari/537.36", ProducerChrome, "119.0.6045.105"},
+		{"macOS Version 13.4 (Build 22F66) Quartz PDFContext", ProducerQuartz, "13.4"},
+		{"Mac OS X 10.15.7 Quartz PDFContext", ProducerQuartz, "10.15.7"},
+		{"iText® 7.1.16 ©2000-2021 iText Group NV (AGPL-version)", ProducerIText, "7.1.16"},
+		{"iTextSharp™ 5.5.13 ©2000-2018 iText Group NV", ProducerIText, "5.5.13"},
+		{"pdfTeX-1.40.25", ProducerTeX, "1.40.25"},
+		{"xdvipdfmx (20210318)", ProducerTeX, ""},
+		{"cairo 1.16.0 (https://cairographics.org)", ProducerCairo, "1.16.0"},
+		{"ReportLab PDF Library - www.reportlab.com", ProducerReportLab, ""},
+		{"Microsoft: Print To PDF", ProducerMicrosoftPrint, ""},
+		{"ScanSnap Manager #S1500", ProducerScanner, ""},
+		{"KODAK Capture Pro Software", ProducerScanner, ""},
+		{"Qt 4.8.7", ProducerUnknown, ""},
+		{"", ProducerUnknown, ""},
+	}
+
+	for _, tc := range testCases {
+		kind, version := parseProducer(tc.s)
+		if kind != tc.wantKind || version != tc.wantVersion {
+			t.Errorf("parseProducer(%q) = %v, %q, want %v, %q", tc.s, kind, version, tc.wantKind, tc.wantVersion)
+		}
+	}
+}
+
+func TestReader_Producer(t *testing.T) {
+	const xmp = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:CreatorTool="Adobe InDesign 18.2 (Windows)"/>
+<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/"><pdf:Producer>Adobe PDF Library 17.0</pdf:Producer></rdf:Description>
+</rdf:RDF></x:xmpmeta>
+<?xpacket end="w"?>`
+	withInfo := func(data []byte) []byte {
+		return bytes.Replace(data, []byte("/Root 1 0 R>>"), []byte("/Root 1 0 R /Info 3 0 R>>"), 1)
+	}
+
+	testCases := map[string]struct {
+		data []byte
+		want Producer
+	}{
+		"none": {
+			data: buildPDF("<</Type /Catalog /Pages 2 0 R>>", "<</Type /Pages /Kids [] /Count 0>>"),
+		},
+		"info in UTF-16": {
+			// The Producer is "LibreOffice 7.3" in UTF-16 with a byte order mark.
+			data: withInfo(buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [] /Count 0>>",
+				"<</Creator (Writer) /Producer <FEFF004C0069006200720065004F0066006600690063006500200037002E0033>>>",
+			)),
+			want: Producer{Kind: ProducerLibreOffice, Version: "7.3", InfoProducer: "LibreOffice 7.3", InfoCreator: "Writer"},
+		},
+		"creator tool preferred": {
+			data: withInfo(buildPDF(
+				"<</Type /Catalog /Pages 2 0 R /Metadata 4 0 R>>",
+				"<</Type /Pages /Kids [] /Count 0>>",
+				"<</Producer (GPL Ghostscript 9.54.0)>>",
+				"<</Type /Metadata /Subtype /XML /Length "+strconv.Itoa(len(xmp))+">>\nstream\n"+xmp+"\nendstream",
+			)),
+			want: Producer{
+				Kind: ProducerInDesign, Version: "18.2",
+				InfoProducer: "GPL Ghostscript 9.54.0",
+				CreatorTool:  "Adobe InDesign 18.2 (Windows)", XMPProducer: "Adobe PDF Library 17.0",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := openPDF(t, tc.data).Producer()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("producer mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
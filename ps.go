@@ -5,6 +5,7 @@
 package pdf
 
 import (
+	"context"
 	"io"
 
 	"github.com/ScriptRock/pdf/internal/types"
@@ -12,30 +13,30 @@ import (
 
 // A stack represents a stack of values.
 type stack struct {
-	stack []value
+	stack []Value
 }
 
 func (stk *stack) Len() int {
 	return len(stk.stack)
 }
 
-func (stk *stack) Push(v value) {
+func (stk *stack) Push(v Value) {
 	stk.stack = append(stk.stack, v)
 }
 
-func (stk *stack) Pop() value {
+func (stk *stack) Pop() Value {
 	n := len(stk.stack)
 	if n == 0 {
-		return value{}
+		return Value{}
 	}
 	v := stk.stack[n-1]
-	stk.stack[n-1] = value{}
+	stk.stack[n-1] = Value{}
 	stk.stack = stk.stack[:n-1]
 	return v
 }
 
-func newDict() value {
-	return value{data: make(types.Dict)}
+func newDict() Value {
+	return Value{data: make(types.Dict)}
 }
 
 // interpret interprets the content in a stream as a basic PostScript program,
@@ -44,6 +45,8 @@ func newDict() value {
 // to implement op.
 //
 // interpret handles the operators "dict", "currentdict", "begin", "end", "def", and "pop" itself.
+// It reads an inline image, from BI to EI, as the operator "BI", whose operands are the
+// image dictionary and a string of the image data.
 //
 // interpret is not a full-blown PostScript interpreter. Its job is to handle the
 // very limited PostScript found in certain supporting file formats embedded
@@ -51,15 +54,30 @@ func newDict() value {
 // points to Unicode code points.
 //
 // There is no support for executable blocks, among other limitations.
-func interpret(rd io.Reader, do func(stk *stack, op string)) {
+//
+// interpret panics with the error of ctx once it is done, and with a *ContentError
+// if the stream is malformed; see contentError.
+func interpret(ctx context.Context, rd io.Reader, do func(stk *stack, op string)) {
 	b := newBuffer(rd, 0)
+	defer b.release()
 	b.allowEOF = true
 	b.allowObjptr = false
 	b.allowStream = false
 	var stk stack
 	var dicts []types.Dict
+	var op string // the operator being run, if any
+	defer func() {
+		if r := recover(); r != nil {
+			panic(contentError(ctx, r, b.readOffset(), op))
+		}
+	}()
 Reading:
-	for {
+	for n := 0; ; n++ {
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				panic(err)
+			}
+		}
 		tok := b.readToken()
 		if tok == io.EOF {
 			break
@@ -69,27 +87,29 @@ Reading:
 			default:
 				for i := len(dicts) - 1; i >= 0; i-- {
 					if v, ok := dicts[i][types.Name(kw)]; ok {
-						stk.Push(value{data: v})
+						stk.Push(Value{data: v})
 						continue Reading
 					}
 				}
-				do(&stk, string(kw))
+				op = string(kw)
+				do(&stk, op)
+				op = ""
 				continue
 			case "null", "[", "]", "<<", ">>":
 				break
 			case "dict":
 				stk.Pop()
-				stk.Push(value{data: make(types.Dict)})
+				stk.Push(Value{data: make(types.Dict)})
 				continue
 			case "currentdict":
 				if len(dicts) == 0 {
 					panic("no current dictionary")
 				}
-				stk.Push(value{data: dicts[len(dicts)-1]})
+				stk.Push(Value{data: dicts[len(dicts)-1]})
 				continue
 			case "begin":
 				d := stk.Pop()
-				if d.Kind() != dictKind {
+				if d.Kind() != Dict {
 					panic("cannot begin non-dict")
 				}
 				dicts = append(dicts, d.data.(types.Dict))
@@ -114,10 +134,19 @@ Reading:
 			case "pop":
 				stk.Pop()
 				continue
+			case "BI":
+				// An inline image, whose data is not made of tokens.
+				dict, data := b.readInlineImage()
+				stk.Push(Value{data: dict})
+				stk.Push(Value{data: string(data)})
+				op = "BI"
+				do(&stk, op)
+				op = ""
+				continue
 			}
 		}
 		b.unreadToken(tok)
 		obj := b.readObject()
-		stk.Push(value{data: obj})
+		stk.Push(Value{data: obj})
 	}
 }
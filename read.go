@@ -43,19 +43,34 @@
 // they are implemented only in terms of the Value API and could be moved outside
 // the package. Equally important, traversal of other PDF data structures can be implemented
 // in other packages as needed.
+//
+// # Malformed input
+//
+// No function or method in this package panics, whatever the input. Errors
+// found while opening a file or extracting text are returned as errors; errors
+// found while resolving a Value are reported, in keeping with the accessors
+// above, as a null Value, and errors found while decoding a stream are returned
+// from the stream's Read method.
 package pdf
 
 // BUG(rsc): The library makes no attempt at efficiency. A value cache maintained in the Reader
 // would probably help significantly.
 
 import (
+	"bufio"
 	"bytes"
-	"compress/zlib"
+	"context"
 	"encoding/ascii85"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"golang.org/x/image/ccitt"
 
 	"github.com/ScriptRock/pdf/internal/decrypter"
 	"github.com/ScriptRock/pdf/internal/encoding"
@@ -64,21 +79,40 @@ import (
 )
 
 // A Reader is a single PDF file open for reading.
+//
+// A Reader is safe for concurrent use by multiple goroutines, which may resolve values
+// and extract the text of pages at the same time, provided that the io.ReaderAt it reads
+// is too, as files are. Each read of an object lexes it with a buffer of its own, and
+// the decoded object streams that are shared are not modified once decoded.
 type Reader struct {
+	cfg        config
 	f          io.ReaderAt
 	end        int64
 	xref       []types.Xref
+	lazy       *lazyXref // the table of a Reader opened with WithLazyXref, in place of xref.
 	trailer    types.Dict
 	trailerptr types.Objptr
 	decrypter  *decrypter.Decrypter
+	xrefChain  []int64 // offsets of the xref sections, following Prev from startxref.
+	objStms    objStmCache
+	// bufReaders pools the buffered readers of streams, of cfg.readBufferSize.
+	bufReaders sync.Pool
+	warnings   warnings
+	fontStats  fontStats
+	lin        linearization
+	// firstPageOnly is whether only the first page section of a linearized file is read.
+	firstPageOnly bool
 }
 
 // Open opens a file for reading.
 // Reader.Close should be called when done with the Reader.
-func Open(file string) (*Reader, error) {
+func Open(file string, opts ...Option) (*Reader, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	f, err := os.Open(file)
 	if err != nil {
-		f.Close()
 		return nil, err
 	}
 	fi, err := f.Stat()
@@ -86,80 +120,178 @@ func Open(file string) (*Reader, error) {
 		f.Close()
 		return nil, err
 	}
-	reader, err := NewReader(f, fi.Size())
+	var rd interface {
+		io.ReaderAt
+		io.Closer
+	} = f
+	if cfg.mmap {
+		if m, err := mapFile(f, fi.Size()); err != nil {
+			slog.Debug("reading file unmapped", slog.Any("err", err))
+		} else {
+			f.Close()
+			rd = m
+		}
+	}
+	reader, err := NewReader(rd, fi.Size(), opts...)
+	if err != nil {
+		rd.Close()
+	}
 	return reader, err
 }
 
 // NewReader opens a file for reading, using the data in f with the given total size.
-func NewReader(f io.ReaderAt, size int64) (*Reader, error) {
-	return NewReaderEncrypted(f, size, "")
+func NewReader(f io.ReaderAt, size int64, opts ...Option) (*Reader, error) {
+	cfg := config{
+		objectStreams:  defaultObjectStreams,
+		readBufferSize: defaultReadBufferSize,
+		contentCache:   defaultContentCache,
+		maxObjects:     defaultMaxObjects,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	r, err := newReader(cfg.context(), f, size, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 // NewReaderEncrypted opens a file for reading, using the data in f with the given total size.
-// If the PDF is encrypted, NewReaderEncrypted calls pw repeatedly to obtain passwords
-// to try. If pw returns the empty string, NewReaderEncrypted stops trying to decrypt
-// the file and returns an error.
+// If the PDF is encrypted and cannot be opened with the empty password, NewReaderEncrypted
+// tries pw. It is equivalent to NewReader with the WithPassword option.
 func NewReaderEncrypted(f io.ReaderAt, size int64, pw string) (*Reader, error) {
+	return NewReader(f, size, WithPassword(pw))
+}
+
+// NewReaderFromBytes opens the file held in b for reading.
+func NewReaderFromBytes(b []byte, opts ...Option) (*Reader, error) {
+	return NewReader(bytes.NewReader(b), int64(len(b)), opts...)
+}
+
+// NewReaderFromSeeker opens the file read from rs for reading. The size of the file is
+// found by seeking to its end. Reads from rs are serialized, so rs need not support
+// concurrent use. If rs is an io.Closer, Reader.Close closes it.
+func NewReaderFromSeeker(rs io.ReadSeeker, opts ...Option) (*Reader, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find file size: %w", err)
+	}
+	return NewReader(&seekerReaderAt{rs: rs}, size, opts...)
+}
+
+func newReader(ctx context.Context, f io.ReaderAt, size int64, cfg config) (_ *Reader, err error) {
+	defer catch(&err)
+
 	buf := make([]byte, 10)
 	f.ReadAt(buf, 0)
-	if !bytes.HasPrefix(buf, []byte("%PDF-1.")) || buf[7] < '0' || buf[7] > '7' || buf[8] != '\r' && buf[8] != '\n' {
+	if !validHeader(buf) {
 		return nil, fmt.Errorf("not a PDF file: invalid header")
 	}
-	end := size
-	const endChunk = 100
-	buf = make([]byte, endChunk)
-	f.ReadAt(buf, end-endChunk)
-	for len(buf) > 0 && buf[len(buf)-1] == '\n' || buf[len(buf)-1] == '\r' {
-		buf = buf[:len(buf)-1]
-	}
-	buf = bytes.TrimRight(buf, "\r\n\t ")
-	if !bytes.HasSuffix(buf, []byte("%%EOF")) {
-		return nil, fmt.Errorf("not a PDF file: missing %%%%EOF")
-	}
-	i := findLastLine(buf, "startxref")
-	if i < 0 {
-		return nil, fmt.Errorf("malformed PDF file: missing final startxref")
-	}
-
 	r := &Reader{
 		f:   f,
-		end: end,
+		end: size,
+		cfg: cfg,
 	}
-	pos := end - endChunk + int64(i)
-	b := newBuffer(io.NewSectionReader(f, pos, end-pos), pos)
-	if b.readToken() != keyword("startxref") {
-		return nil, fmt.Errorf("malformed PDF file: missing startxref")
+	if cfg.linearizedFirstPage && r.IsLinearized() {
+		if err := r.readFirstPageXref(ctx); err != nil {
+			r.warn(XrefWarning, types.Objptr{}, 0, "reading the main cross-reference section, as that of the first page fails: %v", err)
+		}
 	}
-	startxref, ok := b.readToken().(int64)
-	if !ok {
-		return nil, fmt.Errorf("malformed PDF file: startxref not followed by integer")
+	if !r.firstPageOnly {
+		if err := r.readXref(ctx); err != nil {
+			return nil, err
+		}
 	}
-	b = newBuffer(io.NewSectionReader(r.f, startxref, r.end-startxref), startxref)
-	xref, trailerptr, trailer, err := readXref(r, b)
-	if err != nil {
+	if r.trailer["Encrypt"] != nil {
+		if err := r.initEncryptWith(cfg.password); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.checkCatalog(ctx); err != nil {
 		return nil, err
 	}
-	r.xref = xref
-	r.trailer = trailer
-	r.trailerptr = trailerptr
-	if trailer["Encrypt"] == nil {
-		return r, nil
+	return r, nil
+}
+
+// validHeader reports whether buf begins with the header of a PDF file, of the versions
+// 1.0 to 1.7 or 2.x, followed by an end of line.
+func validHeader(buf []byte) bool {
+	switch {
+	case len(buf) < 9 || buf[8] != '\r' && buf[8] != '\n':
+		return false
+	case bytes.HasPrefix(buf, []byte("%PDF-1.")):
+		return buf[7] >= '0' && buf[7] <= '7'
+	case bytes.HasPrefix(buf, []byte("%PDF-2.")):
+		return isDigit(buf[7])
 	}
-	err = r.initEncrypt("")
+	return false
+}
+
+// initEncryptWith sets up the decryption of the file with the empty password,
+// or else with the password pw, if any.
+func (r *Reader) initEncryptWith(pw string) error {
+	err := r.initEncrypt("")
 	if err == nil {
-		return r, nil
+		return nil
 	}
 	if pw == "" || err != decrypter.ErrInvalidPassword {
-		return nil, err
+		return err
 	}
-
 	if r.initEncrypt(pw) == nil {
-		return r, nil
+		return nil
 	}
-	return nil, err
+	return err
 }
 
-// Close closes the underlying Reader if it is an io.Closer.
+// endChunk is the length of the end of a file in which its startxref is looked for.
+const endChunk = 1024
+
+// readXref reads the cross-reference table and trailer of the file,
+// which are found through the startxref line at its end.
+func (r *Reader) readXref(ctx context.Context) error {
+	end := r.end
+	buf := make([]byte, min(end, endChunk))
+	r.f.ReadAt(buf, end-int64(len(buf)))
+	buf = bytes.TrimRight(buf, "\r\n\t ")
+	if !bytes.HasSuffix(buf, []byte("%%EOF")) {
+		return fmt.Errorf("not a PDF file: missing %%%%EOF")
+	}
+	offsets := startxrefOffsets(buf)
+	if len(offsets) == 0 {
+		return fmt.Errorf("malformed PDF file: missing final startxref")
+	}
+	i := slices.IndexFunc(offsets, r.isXrefAt)
+	if i < 0 {
+		return fmt.Errorf("malformed PDF file: startxref %d points at no cross-reference section", offsets[0])
+	}
+	if i > 0 {
+		r.warn(XrefWarning, types.Objptr{}, 0, "startxref %d points at no cross-reference section; using the startxref %d before it", offsets[0], offsets[i])
+	}
+	startxref := offsets[i]
+	if r.cfg.lazyXref {
+		lazy, trailerptr, trailer, err := readLazyXref(ctx, r, startxref)
+		if err != nil {
+			return err
+		}
+		r.lazy, r.trailer, r.trailerptr = lazy, trailer, trailerptr
+		return nil
+	}
+	r.xrefChain = []int64{startxref}
+	b := newBuffer(io.NewSectionReader(r.f, startxref, r.end-startxref), startxref)
+	xref, trailerptr, trailer, err := readXref(ctx, r, b)
+	if err != nil {
+		return err
+	}
+	r.xref = xref
+	r.trailer = trailer
+	r.trailerptr = trailerptr
+	return nil
+}
+
+// Close closes the underlying Reader if it is an io.Closer. Values read from the
+// Reader remain valid, but the data of streams is read from the file as it is read,
+// so their readers fail once it is closed.
 func (r *Reader) Close() error {
 	if c, ok := r.f.(io.Closer); ok {
 		return c.Close()
@@ -168,100 +300,193 @@ func (r *Reader) Close() error {
 	return nil
 }
 
-func (r *Reader) trailerValue() value {
-	return value{r: r, ptr: r.trailerptr, data: r.trailer}
+func (r *Reader) trailerValue() Value {
+	return Value{r: r, ptr: r.trailerptr, data: r.trailer}
 }
 
-// Text returns a structured Text for all pages of the pdf.
+// Text returns a structured Text for all pages of the pdf. If the text of a page
+// cannot be read, Text fails with a *PageError, of the page and of the error of
+// Page.Text.
 func (r *Reader) Text() (text.Text, error) {
+	return r.TextContext(context.Background())
+}
+
+// TextContext is like Text, but stops with the error of ctx once it is done.
+func (r *Reader) TextContext(ctx context.Context) (text.Text, error) {
 	var b text.Builder
-	for i := range r.NPages() {
-		if i > 0 {
+	err := r.WalkTextContext(ctx, func(page int, t text.Text) error {
+		if page > 1 {
 			b.WriteNewline()
 		}
-		t, err := r.Page(i + 1)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read page text: %w", err)
-		}
 		b.Add(t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return b.Text(), nil
 }
 
-func readXref(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
+// Document returns the text of the document page by page, with the page labels.
+func (r *Reader) Document() (text.Document, error) {
+	return r.DocumentContext(context.Background())
+}
+
+// DocumentContext is like Document, but stops with the error of ctx once it is done.
+func (r *Reader) DocumentContext(ctx context.Context) (text.Document, error) {
+	labels := r.PageLabels()
+	doc := text.Document{Pages: make([]text.Page, r.NPages())}
+	for i := range doc.Pages {
+		t, err := r.PageContext(ctx, i+1)
+		if err != nil {
+			return text.Document{}, &PageError{Page: i + 1, Err: err}
+		}
+		doc.Pages[i] = text.Page{Number: i + 1, Text: t}
+		if i < len(labels) {
+			doc.Pages[i].Label = labels[i]
+		}
+	}
+	return doc, nil
+}
+
+func readXref(ctx context.Context, r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
 	tok := b.readToken()
 	if tok == keyword("xref") {
-		return readXrefTable(r, b)
+		return readXrefTable(ctx, r, b)
 	}
 	if _, ok := tok.(int64); ok {
 		b.unreadToken(tok)
-		return readXrefStream(r, b)
+		return readXrefStream(ctx, r, b)
 	}
 	return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: cross-reference table not found: %v", tok)
 }
 
-func readXrefStream(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
-	obj1 := b.readObject()
-	obj, ok := obj1.(types.Objdef)
-	if !ok {
-		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: cross-reference table not found: %v", objfmt(obj1))
-	}
-	strmptr := obj.Ptr
-	strm, ok := obj.Obj.(types.Stream)
-	if !ok {
-		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: cross-reference table not found: %v", objfmt(obj))
+// mergeXref merges the entries of one cross-reference section into table.
+// Sections are merged newest first, following the Prev chain of an incrementally
+// updated file, so an entry of any type shadows the entries of older sections:
+// an object freed by an update stays freed, even if an older section has it in use.
+// The table grows to hold at most limit objects.
+func mergeXref(table, section []types.Xref, limit int) ([]types.Xref, error) {
+	for _, e := range section {
+		x := int(e.Ptr.ID)
+		if x >= limit {
+			return nil, fmt.Errorf("malformed PDF: object %d beyond the limit of %d objects", x, limit)
+		}
+		for cap(table) <= x {
+			table = append(table[:cap(table)], types.Xref{})
+		}
+		if len(table) <= x {
+			table = table[:x+1]
+		}
+		if table[x] == (types.Xref{}) {
+			table[x] = e
+		}
 	}
-	if strm.Hdr["Type"] != types.Name("XRef") {
-		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref stream does not have type XRef")
+	return table, nil
+}
+
+func readXrefStream(ctx context.Context, r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
+	strmptr, strm, err := readXrefStreamObject(b)
+	if err != nil {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 	}
 	size, ok := strm.Hdr["Size"].(int64)
 	if !ok {
 		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref stream missing Size")
 	}
+	if size < 0 || size > int64(r.cfg.maxObjects) {
+		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref stream Size %d outside [0, %d]", size, r.cfg.maxObjects)
+	}
 	table := make([]types.Xref, size)
 
-	table, err := readXrefStreamData(r, strm, table, size)
+	section, err := readXrefStreamData(ctx, r, strm, size)
 	if err != nil {
 		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 	}
+	if table, err = mergeXref(table, section, r.cfg.maxObjects); err != nil {
+		return nil, types.Objptr{}, nil, err
+	}
 
+	seen := map[int64]bool{}
 	for prevoff := strm.Hdr["Prev"]; prevoff != nil; {
 		off, ok := prevoff.(int64)
 		if !ok {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
-		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
-		obj1 := b.readObject()
-		obj, ok := obj1.(types.Objdef)
-		if !ok {
-			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream not found: %v", objfmt(obj1))
+		if seen[off] {
+			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev chain contains a cycle at %d", off)
 		}
-		prevstrm, ok := obj.Obj.(types.Stream)
-		if !ok {
-			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream not found: %v", objfmt(obj))
-		}
-		prevoff = prevstrm.Hdr["Prev"]
-		prev := value{r: r, data: prevstrm}
-		if prev.Kind() != streamKind {
-			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream is not stream: %v", prev)
+		seen[off] = true
+		if err := ctx.Err(); err != nil {
+			return nil, types.Objptr{}, nil, err
 		}
-		if prev.Key("Type").Name() != "XRef" {
-			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream does not have type XRef")
+		r.xrefChain = append(r.xrefChain, off)
+		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+		_, prevstrm, err := readXrefStreamObject(b)
+		if err != nil {
+			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream: %v", err)
 		}
-		psize := prev.Key("Size").Int64()
+		prevoff = prevstrm.Hdr["Prev"]
+		psize, _ := prevstrm.Hdr["Size"].(int64)
 		if psize > size {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref prev stream larger than last stream")
 		}
-		if table, err = readXrefStreamData(r, prev.data.(types.Stream), table, psize); err != nil {
+		section, err := readXrefStreamData(ctx, r, prevstrm, psize)
+		if err != nil {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: reading xref prev stream: %v", err)
 		}
+		if table, err = mergeXref(table, section, r.cfg.maxObjects); err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		mergeTrailer(strm.Hdr, prevstrm.Hdr)
 	}
 
 	return table, strmptr, strm.Hdr, nil
 }
 
-func readXrefStreamData(r *Reader, strm types.Stream, table []types.Xref, size int64) ([]types.Xref, error) {
+// inheritedTrailerKeys are the keys of the trailer of an incrementally updated file
+// that an update without them keeps from the trailers before it, as careless writers
+// of updates leave them out.
+var inheritedTrailerKeys = [...]types.Name{"Root", "Info", "ID", "Encrypt"}
+
+// mergeTrailer adds to the trailer of an update the inherited keys of the older
+// trailer that it lacks. A key of the update whose value is an explicit null, as
+// /Encrypt null of an update that removes the encryption, is not replaced.
+func mergeTrailer(trailer, older types.Dict) {
+	for _, key := range inheritedTrailerKeys {
+		if _, ok := trailer[key]; !ok {
+			if v, ok := older[key]; ok {
+				trailer[key] = v
+			}
+		}
+	}
+}
+
+// readXrefStreamObject reads a cross-reference stream object from b.
+func readXrefStreamObject(b *buffer) (types.Objptr, types.Stream, error) {
+	obj1 := b.readObject()
+	obj, ok := obj1.(types.Objdef)
+	if !ok {
+		return types.Objptr{}, types.Stream{}, fmt.Errorf("cross-reference stream not found: %v", objfmt(obj1))
+	}
+	strm, ok := obj.Obj.(types.Stream)
+	if !ok {
+		return types.Objptr{}, types.Stream{}, fmt.Errorf("cross-reference stream not found: %v", objfmt(obj))
+	}
+	if strm.Hdr["Type"] != types.Name("XRef") {
+		return types.Objptr{}, types.Stream{}, fmt.Errorf("xref stream does not have type XRef")
+	}
+	return obj.Ptr, strm, nil
+}
+
+// maxXrefFieldWidth is the greatest width in bytes of a field of the entries of
+// a cross-reference stream, which are read as int64s.
+const maxXrefFieldWidth = 8
+
+// readXrefStreamData returns the entries of the cross-reference stream strm, whose
+// subsections, given by its Index, must be of the objects below size.
+// See PDF 32000-1:2008, §7.5.8.2.
+func readXrefStreamData(ctx context.Context, r *Reader, strm types.Stream, size int64) ([]types.Xref, error) {
 	index, _ := strm.Hdr["Index"].(types.Array)
 	if index == nil {
 		index = types.Array{int64(0), size}
@@ -277,7 +502,7 @@ func readXrefStreamData(r *Reader, strm types.Stream, table []types.Xref, size i
 	var w []int
 	for _, x := range ww {
 		i, ok := x.(int64)
-		if !ok || int64(int(i)) != i {
+		if !ok || i < 0 || i > maxXrefFieldWidth {
 			return nil, fmt.Errorf("invalid W array %v", objfmt(ww))
 		}
 		w = append(w, int(i))
@@ -286,65 +511,78 @@ func readXrefStreamData(r *Reader, strm types.Stream, table []types.Xref, size i
 		return nil, fmt.Errorf("invalid W array %v", objfmt(ww))
 	}
 
-	v := value{r: r, data: strm}
+	v := Value{r: r, data: strm}
 	wtotal := 0
 	for _, wid := range w {
 		wtotal += wid
 	}
 	buf := make([]byte, wtotal)
 	data := v.Reader()
+	defer data.Close()
+	var section []types.Xref
 	for len(index) > 0 {
 		start, ok1 := index[0].(int64)
 		n, ok2 := index[1].(int64)
 		if !ok1 || !ok2 {
 			return nil, fmt.Errorf("malformed Index pair %v %v %T %T", objfmt(index[0]), objfmt(index[1]), index[0], index[1])
 		}
+		if start < 0 || n < 0 || n > size-start {
+			return nil, fmt.Errorf("Index subsection %d %d outside Size %d", start, n, size)
+		}
 		index = index[2:]
 		for i := 0; i < int(n); i++ {
+			if i%4096 == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
 			_, err := io.ReadFull(data, buf)
 			if err != nil {
 				return nil, fmt.Errorf("error reading xref stream: %v", err)
 			}
-			v1 := decodeInt(buf[0:w[0]])
+			v1, ok1 := decodeInt(buf[0:w[0]])
 			if w[0] == 0 {
 				v1 = 1
 			}
-			v2 := decodeInt(buf[w[0] : w[0]+w[1]])
-			v3 := decodeInt(buf[w[0]+w[1] : w[0]+w[1]+w[2]])
-			x := int(start) + i
-			for cap(table) <= x {
-				table = append(table[:cap(table)], types.Xref{})
-			}
-			if table[x].Ptr != (types.Objptr{}) {
-				continue
+			v2, ok2 := decodeInt(buf[w[0] : w[0]+w[1]])
+			v3, ok3 := decodeInt(buf[w[0]+w[1] : w[0]+w[1]+w[2]])
+			if !ok1 || !ok2 || !ok3 {
+				return nil, fmt.Errorf("xref stream entry of object %d overflows: % x", start+int64(i), buf)
 			}
+			x := uint32(start + int64(i))
 			switch v1 {
 			case 0:
-				table[x] = types.Xref{Ptr: types.Objptr{Gen: 65535}}
+				section = append(section, types.Xref{Ptr: types.Objptr{ID: x, Gen: uint16(v3)}, Free: true})
 			case 1:
-				table[x] = types.Xref{Ptr: types.Objptr{ID: uint32(x), Gen: uint16(v3)}, Offset: int64(v2)}
+				section = append(section, types.Xref{Ptr: types.Objptr{ID: x, Gen: uint16(v3)}, Offset: v2})
 			case 2:
-				table[x] = types.Xref{Ptr: types.Objptr{ID: uint32(x)}, InStream: true, Stream: types.Objptr{ID: uint32(v2)}, Offset: int64(v3)}
+				if v2 > math.MaxUint32 {
+					return nil, fmt.Errorf("xref stream entry of object %d in object stream %d", x, v2)
+				}
+				section = append(section, types.Xref{Ptr: types.Objptr{ID: x}, InStream: true, Stream: types.Objptr{ID: uint32(v2)}, Offset: v3})
 			default:
-				slog.Debug("invalid xref stream type", slog.Int("v1", v1), slog.Any("buf", buf))
+				r.warn(XrefWarning, types.Objptr{ID: x}, 0, "xref stream entry has invalid type %d", v1)
 			}
 		}
 	}
-	return table, nil
+	return section, nil
 }
 
-func decodeInt(b []byte) int {
-	x := 0
+// decodeInt returns the big-endian integer b, of up to 8 bytes, and reports
+// whether it fits in an int64.
+func decodeInt(b []byte) (int64, bool) {
+	var x uint64
 	for _, c := range b {
-		x = x<<8 | int(c)
+		x = x<<8 | uint64(c)
 	}
-	return x
+	return int64(x), x <= math.MaxInt64
 }
 
-func readXrefTable(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
+func readXrefTable(ctx context.Context, r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict, error) {
 	var table []types.Xref
 
-	table, err := readXrefTableData(b, table)
+	b.reader = r
+	section, err := readXrefTableData(b)
 	if err != nil {
 		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 	}
@@ -353,18 +591,35 @@ func readXrefTable(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict
 	if !ok {
 		return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref table not followed by trailer dictionary")
 	}
+	if section, err = readXrefStm(ctx, r, trailer, section); err != nil {
+		return nil, types.Objptr{}, nil, err
+	}
+	if table, err = mergeXref(table, section, r.cfg.maxObjects); err != nil {
+		return nil, types.Objptr{}, nil, err
+	}
 
+	newest := trailer
+	seen := map[int64]bool{}
 	for prevoff := trailer["Prev"]; prevoff != nil; {
 		off, ok := prevoff.(int64)
 		if !ok {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev is not integer: %v", prevoff)
 		}
+		if seen[off] {
+			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev chain contains a cycle at %d", off)
+		}
+		seen[off] = true
+		if err := ctx.Err(); err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		r.xrefChain = append(r.xrefChain, off)
 		b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+		b.reader = r
 		tok := b.readToken()
 		if tok != keyword("xref") {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev does not point to xref")
 		}
-		table, err = readXrefTableData(b, table)
+		section, err := readXrefTableData(b)
 		if err != nil {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: %v", err)
 		}
@@ -373,6 +628,13 @@ func readXrefTable(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict
 		if !ok {
 			return nil, types.Objptr{}, nil, fmt.Errorf("malformed PDF: xref Prev table not followed by trailer dictionary")
 		}
+		if section, err = readXrefStm(ctx, r, trailer, section); err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		if table, err = mergeXref(table, section, r.cfg.maxObjects); err != nil {
+			return nil, types.Objptr{}, nil, err
+		}
+		mergeTrailer(newest, trailer)
 		prevoff = trailer["Prev"]
 	}
 
@@ -388,123 +650,503 @@ func readXrefTable(r *Reader, b *buffer) ([]types.Xref, types.Objptr, types.Dict
 	return table, types.Objptr{}, trailer, nil
 }
 
-func readXrefTableData(b *buffer, table []types.Xref) ([]types.Xref, error) {
+// readXrefStm adds to the xref table section the entries of the cross-reference stream
+// named by the XRefStm key of a hybrid-reference file's trailer, if any.
+// See PDF 32000-1:2008, §7.5.8.4.
+// The table's entries for objects in use take precedence over the stream's,
+// which in turn take precedence over the table's free entries: the objects
+// found only in the stream are typically listed as free in the table.
+func readXrefStm(ctx context.Context, r *Reader, trailer types.Dict, section []types.Xref) ([]types.Xref, error) {
+	off, ok := trailer["XRefStm"].(int64)
+	if !ok {
+		return section, nil
+	}
+	b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	_, strm, err := readXrefStreamObject(b)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: XRefStm: %v", err)
+	}
+	size, ok := strm.Hdr["Size"].(int64)
+	if !ok {
+		size, _ = trailer["Size"].(int64)
+	}
+	stm, err := readXrefStreamData(ctx, r, strm, min(size, int64(r.cfg.maxObjects)))
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: reading XRefStm: %v", err)
+	}
+
+	var inUse, free []types.Xref
+	for _, e := range section {
+		if e.Free {
+			free = append(free, e)
+		} else {
+			inUse = append(inUse, e)
+		}
+	}
+	merged := append(inUse, stm...)
+	return append(merged, free...), nil
+}
+
+// readXrefTableData returns the entries of the xref table in b, up to its trailer.
+// The table is read by lines, so that the entries of careless writers read as those
+// of 20 bytes do: those of 19 bytes, without the space before their end of line, and
+// those with more white space, or comments, or without any end of line between them.
+// A line of two integers is a subsection header, which numbers the entries after it,
+// however many it declares; a malformed entry is skipped, keeping its object number,
+// and comment lines and any others are skipped too.
+func readXrefTableData(b *buffer) ([]types.Xref, error) {
+	var section []types.Xref
+	next := int64(-1) // the object number of the next entry, or -1 before any header.
 	for {
-		tok := b.readToken()
-		if tok == keyword("trailer") {
-			break
+		c := b.readByte()
+		for isSpace(c) && !b.eof {
+			c = b.readByte()
 		}
-		start, ok1 := tok.(int64)
-		n, ok2 := b.readToken().(int64)
-		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("malformed xref table")
+		if b.eof {
+			return nil, fmt.Errorf("malformed xref table: missing trailer")
 		}
-		for i := 0; i < int(n); i++ {
-			off, ok1 := b.readToken().(int64)
-			gen, ok2 := b.readToken().(int64)
-			alloc, ok3 := b.readToken().(keyword)
-			if !ok1 || !ok2 || !ok3 || alloc != keyword("f") && alloc != keyword("n") {
-				return nil, fmt.Errorf("malformed xref table")
-			}
-			x := int(start) + i
-			for cap(table) <= x {
-				table = append(table[:cap(table)], types.Xref{})
+		b.unreadByte()
+		if !isDigit(c) && c != '%' {
+			if tok := b.readToken(); tok != keyword("trailer") {
+				b.warnf("skipping %v in xref table", objfmt(tok))
+				continue
 			}
-			if len(table) <= x {
-				table = table[:x+1]
+			return section, nil
+		}
+
+		line := readXrefLine(b)
+		if i := bytes.IndexByte(line, '%'); i >= 0 {
+			line = line[:i]
+		}
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// A header is on a line of its own, or on that of the entries after it, in
+		// tables without ends of line.
+		if len(fields)%3 == 2 {
+			if start, n, ok := xrefHeader(fields[:2]); ok {
+				next = start
+				if start+n > math.MaxUint32 {
+					b.warnf("skipping xref table subsection %d %d beyond the greatest object number", start, n)
+					next = -1
+				}
+				if fields = fields[2:]; len(fields) == 0 {
+					continue
+				}
 			}
-			if alloc == "n" && table[x].Offset == 0 {
-				table[x] = types.Xref{Ptr: types.Objptr{ID: uint32(x), Gen: uint16(gen)}, Offset: int64(off)}
+		}
+		if next < 0 {
+			b.warnf("skipping xref table line %q outside any subsection", line)
+			continue
+		}
+		if len(fields)%3 != 0 {
+			b.warnf("skipping malformed xref table entry %q of object %d", line, next)
+			next++
+			continue
+		}
+		for ; len(fields) > 0 && next <= math.MaxUint32; fields = fields[3:] {
+			if e, ok := xrefEntry(uint32(next), fields[:3]); ok {
+				section = append(section, e)
+			} else {
+				b.warnf("skipping malformed xref table entry %q of object %d", bytes.Join(fields[:3], []byte(" ")), next)
 			}
+			next++
 		}
 	}
-	return table, nil
 }
 
-func findLastLine(buf []byte, s string) int {
-	bs := []byte(s)
-	max := len(buf)
+// readXrefLine reads the bytes of b up to the end of the line, or up to the trailer
+// keyword, for tables that have none before it, and the end-of-line marker after them.
+func readXrefLine(b *buffer) []byte {
+	tmp := b.tmp[:0]
 	for {
-		i := bytes.LastIndex(buf[:max], bs)
-		if i <= 0 || i+len(bs) >= len(buf) {
-			return -1
+		c := b.readByte()
+		if b.eof || c == '\n' {
+			break
 		}
-		if (buf[i-1] == '\n' || buf[i-1] == '\r') && (buf[i+len(bs)] == '\n' || buf[i+len(bs)] == '\r') {
-			return i
+		if c == '\r' {
+			if b.readByte() != '\n' {
+				b.unreadByte()
+			}
+			break
 		}
-		max = i
+		if c == 't' && bytes.HasPrefix(b.buf[b.pos-1:], []byte("trailer")) {
+			b.unreadByte()
+			break
+		}
+		if len(tmp) >= maxTokenLength {
+			b.errorf("xref table line longer than %d bytes", maxTokenLength)
+		}
+		tmp = append(tmp, c)
+	}
+	b.tmp = tmp
+	return tmp
+}
+
+// xrefHeader reports whether the fields of an xref table are a subsection header, and
+// returns the first object number of the subsection and its number of entries. An entry
+// without its n or f, of ten and five digits, is not taken for one.
+func xrefHeader(fields [][]byte) (start, n int64, ok bool) {
+	if len(fields) != 2 || !isInteger(fields[0]) || !isInteger(fields[1]) || len(fields[0]) == 10 && len(fields[1]) == 5 {
+		return 0, 0, false
+	}
+	start, err1 := strconv.ParseInt(string(fields[0]), 10, 64)
+	n, err2 := strconv.ParseInt(string(fields[1]), 10, 64)
+	return start, n, err1 == nil && err2 == nil && start >= 0 && n >= 0
+}
+
+// xrefEntry returns the entry of the object id of the fields of a line of an xref table,
+// its offset, generation and n or f.
+func xrefEntry(id uint32, fields [][]byte) (types.Xref, bool) {
+	off, err1 := strconv.ParseInt(string(fields[0]), 10, 64)
+	gen, err2 := strconv.ParseUint(string(fields[1]), 10, 16)
+	if err1 != nil || err2 != nil || off < 0 {
+		return types.Xref{}, false
+	}
+	ptr := types.Objptr{ID: id, Gen: uint16(gen)}
+	switch string(fields[2]) {
+	case "n":
+		return types.Xref{Ptr: ptr, Offset: off}, true
+	case "f":
+		return types.Xref{Ptr: ptr, Free: true}, true
+	}
+	return types.Xref{}, false
+}
+
+// startxrefOffsets returns the offsets given by the startxref keywords of buf, the end of
+// a file, in the order in which they are to be tried: the last first, of those followed
+// by %%EOF, as the one that ends the file is, and then the others, as those in the
+// binary data of a stream, which happens to hold the keyword, may not be.
+func startxrefOffsets(buf []byte) []int64 {
+	const kw = "startxref"
+	var atEOF, others []int64
+	for i := len(buf); i > 0; {
+		if i = bytes.LastIndex(buf[:i], []byte(kw)); i <= 0 {
+			break
+		}
+		if !isSpace(buf[i-1]) && !isDelim(buf[i-1]) {
+			continue
+		}
+		rest := buf[i+len(kw):]
+		if len(rest) == 0 || !isSpace(rest[0]) {
+			continue
+		}
+		rest = bytes.TrimLeft(rest, "\r\n\t ")
+		n := 0
+		for n < len(rest) && isDigit(rest[n]) {
+			n++
+		}
+		off, err := strconv.ParseInt(string(rest[:n]), 10, 64)
+		if n == 0 || err != nil {
+			continue
+		}
+		if bytes.HasPrefix(bytes.TrimLeft(rest[n:], "\r\n\t "), []byte("%%EOF")) {
+			atEOF = append(atEOF, off)
+		} else {
+			others = append(others, off)
+		}
+	}
+	return append(atEOF, others...)
+}
+
+// isXrefAt reports whether a cross-reference section may be at offset: whether the xref
+// keyword of a table is there, or the header of an object, that of a stream.
+func (r *Reader) isXrefAt(offset int64) bool {
+	if offset < 0 || offset >= r.end {
+		return false
 	}
+	buf := make([]byte, 32)
+	n, _ := r.f.ReadAt(buf, offset)
+	buf = bytes.TrimLeft(buf[:n], "\r\n\t ")
+	if bytes.HasPrefix(buf, []byte("xref")) {
+		return true
+	}
+	f := bytes.Fields(buf)
+	return len(f) >= 3 && isInteger(f[0]) && isInteger(f[1]) && bytes.HasPrefix(f[2], []byte("obj"))
 }
 
-func (r *Reader) resolve(parent types.Objptr, x any) value {
+func (r *Reader) resolve(parent types.Objptr, x any) Value {
 	if ptr, ok := x.(types.Objptr); ok {
-		if ptr.ID >= uint32(len(r.xref)) {
-			return value{}
-		}
-		xref := r.xref[ptr.ID]
-		if xref.Ptr != ptr || !xref.InStream && xref.Offset == 0 {
-			return value{}
-		}
-		var obj types.Object
-		if xref.InStream {
-			strm := r.resolve(parent, xref.Stream)
-		Search:
-			for {
-				if strm.Kind() != streamKind {
-					panic("not a stream")
-				}
-				if strm.Key("Type").Name() != "ObjStm" {
-					panic("not an object stream")
-				}
-				n := int(strm.Key("N").Int64())
-				first := strm.Key("First").Int64()
-				if first == 0 {
-					panic("missing First")
-				}
-				b := newBuffer(strm.Reader(), 0)
-				b.allowEOF = true
-				for i := 0; i < n; i++ {
-					id, _ := b.readToken().(int64)
-					off, _ := b.readToken().(int64)
-					if uint32(id) == ptr.ID {
-						b.seekForward(first + off)
-						x = b.readObject()
-						break Search
-					}
-				}
-				ext := strm.Key("Extends")
-				if ext.Kind() != streamKind {
-					panic("cannot find object in stream")
-				}
-				strm = ext
+		obj, err := r.load(ptr)
+		if err != nil {
+			r.warn(XrefWarning, ptr, 0, "failed to resolve object: %v", err)
+			return Value{}
+		}
+		if obj == nil {
+			return Value{}
+		}
+		x = obj
+		parent = ptr
+	}
+	return r.direct(parent, x)
+}
+
+// direct returns the Value of the direct object x, in the indirect object parent.
+// Direct objects need none of the work of resolve, so Value.Key and Value.Index
+// return them through direct alone, which is small enough to be inlined.
+func (r *Reader) direct(parent types.Objptr, x any) Value {
+	switch x.(type) {
+	case nil, bool, int64, float64, types.Name, types.Dict, types.Array, types.Stream, string:
+		return Value{r: r, ptr: parent, data: x}
+	}
+	return unexpectedValue(x)
+}
+
+func unexpectedValue(x any) Value {
+	slog.Debug("unexpected value type in resolve", slog.Any("type", fmt.Sprintf("%T", x)))
+	return Value{}
+}
+
+// xrefEntry returns the cross-reference entry of the object id, and reports whether id
+// is within the table; the entries of object numbers without one are zero.
+func (r *Reader) xrefEntry(id uint32) (types.Xref, bool) {
+	if r.lazy != nil {
+		return r.lazy.entry(r, id)
+	}
+	if id >= uint32(len(r.xref)) {
+		return types.Xref{}, false
+	}
+	return r.xref[id], true
+}
+
+// xrefTable returns the cross-reference table, indexed by object number, reading
+// the sections that a Reader opened with WithLazyXref has not read yet.
+func (r *Reader) xrefTable() []types.Xref {
+	if r.lazy != nil {
+		return r.lazy.table(r)
+	}
+	return r.xref
+}
+
+// load reads the indirect object ptr, following the xref table into the file or into
+// an object stream as necessary. Objects missing from the xref table are nil, the PDF null,
+// as are free objects. Unless WithStrictGenerations is set, a reference to an object in use
+// of another generation than the xref table's is to that object.
+func (r *Reader) load(ptr types.Objptr) (obj types.Object, err error) {
+	if r == nil {
+		return nil, nil
+	}
+	xref, ok := r.xrefEntry(ptr.ID)
+	if !ok || xref.Ptr.ID != ptr.ID || !xref.Free && !xref.InStream && xref.Offset == 0 {
+		return nil, nil
+	}
+	if xref.Free {
+		r.warn(XrefWarning, ptr, 0, "reference to free object")
+		return nil, nil
+	}
+	if xref.Ptr.Gen != ptr.Gen {
+		if r.cfg.strictGenerations {
+			r.warn(XrefWarning, ptr, 0, "reference to object of generation %d", xref.Ptr.Gen)
+			return nil, nil
+		}
+		r.warn(XrefWarning, ptr, 0, "resolving reference to object of generation %d", xref.Ptr.Gen)
+		ptr = xref.Ptr
+	}
+
+	defer catch(&err)
+
+	if xref.InStream {
+		return r.loadFromStream(ptr, xref.Stream)
+	}
+
+	b := newBuffer(io.NewSectionReader(r.f, xref.Offset, r.end-xref.Offset), xref.Offset)
+	defer b.release()
+	b.decrypter = r.decrypter
+	b.reader = r
+	obj = b.readObject()
+	def, ok := obj.(types.Objdef)
+	if !ok {
+		return nil, fmt.Errorf("loading %v: found %T instead of objdef", ptr, obj)
+	}
+	if def.Ptr != ptr {
+		if r.cfg.strictGenerations || def.Ptr.ID != ptr.ID {
+			return nil, fmt.Errorf("loading %v: found %v", ptr, def.Ptr)
+		}
+		r.warn(XrefWarning, ptr, 0, "object is defined as of generation %d", def.Ptr.Gen)
+	}
+	return def.Obj, nil
+}
+
+// loadFromStream reads the object ptr from the object stream strmptr,
+// following any Extends chain.
+func (r *Reader) loadFromStream(ptr, strmptr types.Objptr) (types.Object, error) {
+	seen := map[types.Objptr]bool{}
+	for {
+		if seen[strmptr] {
+			return nil, fmt.Errorf("loading %v: object stream %v extends itself", ptr, strmptr)
+		}
+		seen[strmptr] = true
+
+		s, err := r.objStm(strmptr)
+		if err != nil {
+			return nil, fmt.Errorf("loading %v: %w", ptr, err)
+		}
+		if obj, ok, err := s.object(ptr.ID); ok {
+			if err != nil {
+				return nil, fmt.Errorf("loading %v: %w", ptr, err)
 			}
+			return obj, nil
+		}
+		if !s.hasExtends {
+			return nil, fmt.Errorf("loading %v: cannot find object in stream %v", ptr, strmptr)
+		}
+		strmptr = s.extends
+	}
+}
+
+// catch recovers from a panic, such as those raised by buffer.errorf when the lexer
+// meets malformed input, and reports it in *err instead.
+// It must be called directly by a deferred statement.
+func catch(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(error); ok {
+		*err = e
+	} else {
+		*err = fmt.Errorf("%v", r)
+	}
+}
+
+func (r *Reader) streamReader(s types.Stream, length int64) (*streamReadCloser, error) {
+	n := r.streamLength(s, length)
+	var rd io.Reader = io.NewSectionReader(r.f, s.Offset, n)
+	sr := &streamReadCloser{}
+	if size := r.cfg.readBufferSize; size > 0 {
+		// The decrypters and decompressors read little at a time, which would each be
+		// a read of the file. A stream shorter than the buffer is read at once.
+		if n < int64(size) {
+			rd = bufio.NewReaderSize(rd, int(n))
 		} else {
-			b := newBuffer(io.NewSectionReader(r.f, xref.Offset, r.end-xref.Offset), xref.Offset)
-			b.decrypter = r.decrypter
-			obj = b.readObject()
-			def, ok := obj.(types.Objdef)
-			if !ok {
-				panic(fmt.Errorf("loading %v: found %T instead of types.Objdef", ptr, obj))
-			}
-			if def.Ptr != ptr {
-				panic(fmt.Errorf("loading %v: found %v", ptr, def.Ptr))
+			br, _ := r.bufReaders.Get().(*bufio.Reader)
+			if br == nil {
+				br = bufio.NewReaderSize(rd, size)
 			}
-			x = def.Obj
+			br.Reset(rd)
+			rd = br
+			sr.closers = append(sr.closers, pooledReader{br, &r.bufReaders})
 		}
-		parent = ptr
 	}
+	rd, err := r.decrypter.Decrypt(s.Ptr, rd)
+	if err != nil {
+		sr.Close()
+		return nil, err
+	}
+	sr.Reader = rd
+	return sr, nil
+}
 
-	switch x := x.(type) {
-	case nil, bool, int64, float64, types.Name, types.Dict, types.Array, types.Stream, string:
-		return value{r: r, ptr: parent, data: x}
-	default:
-		panic(fmt.Errorf("unexpected value type %T in resolve", x))
+// A streamReadCloser reads the data of a stream through the readers of its filters,
+// and closes them, returning their buffers to be reused. It reads nothing once closed.
+type streamReadCloser struct {
+	io.Reader
+	closers []io.Closer // innermost first
+}
+
+// push makes rd the reader of s, closing it with the others if it is a Closer.
+func (s *streamReadCloser) push(rd io.Reader) {
+	s.Reader = rd
+	if c, ok := rd.(io.Closer); ok {
+		s.closers = append(s.closers, c)
+	}
+}
+
+func (s *streamReadCloser) Close() error {
+	var err error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if cerr := s.closers[i].Close(); err == nil {
+			err = cerr
+		}
 	}
+	s.closers = nil
+	s.Reader = &errorReadCloser{fmt.Errorf("stream closed")}
+	return err
 }
 
-func (r *Reader) streamReader(s types.Stream, length int64) (io.Reader, error) {
-	rd := io.NewSectionReader(r.f, s.Offset, length)
-	return r.decrypter.Decrypt(s.Ptr, rd)
+// A pooledReader returns a buffered reader to its pool when it is closed.
+type pooledReader struct {
+	br   *bufio.Reader
+	pool *sync.Pool
+}
+
+func (p pooledReader) Close() error {
+	p.br.Reset(nil)
+	p.pool.Put(p.br)
+	return nil
+}
+
+// streamLength returns the length of the data in the stream s.
+// The declared length is used when it is followed by the endstream keyword,
+// as it should be. Otherwise, as happens with many careless writers, the length
+// is found by scanning forward for endstream.
+func (r *Reader) streamLength(s types.Stream, declared int64) int64 {
+	if declared >= 0 && r.endstreamAt(s.Offset+declared) {
+		return declared
+	}
+	n, ok := r.scanEndstream(s.Offset)
+	if !ok {
+		return max(declared, 0)
+	}
+	r.warn(StreamWarning, s.Ptr, 0, "stream Length is %d, but its data is %d bytes", declared, n)
+	return n
+}
+
+// endstreamAt reports whether the endstream keyword follows offset,
+// allowing for the end-of-line marker that should precede it.
+func (r *Reader) endstreamAt(offset int64) bool {
+	buf := make([]byte, 32)
+	n, _ := r.f.ReadAt(buf, offset)
+	buf = bytes.TrimLeft(buf[:n], "\r\n\t ")
+	return bytes.HasPrefix(buf, []byte("endstream"))
+}
+
+// scanEndstream searches forward from offset for the endstream keyword ending a stream's data,
+// and returns the length of the data. As the data may be binary, and may happen to contain
+// endstream, the keyword is accepted only at the start of a line and when followed by
+// white space or endobj.
+func (r *Reader) scanEndstream(offset int64) (int64, bool) {
+	const (
+		chunk = 4096
+		kw    = "endstream"
+	)
+	// Each read starts a little before pos, to see the line ending before
+	// the keyword, and ends a little after the chunk, to see what follows it.
+	buf := make([]byte, 2+chunk+len(kw)+len("endobj"))
+	for pos := offset; pos < r.end; pos += chunk {
+		base := max(pos-2, offset)
+		n, _ := r.f.ReadAt(buf, base)
+		data := buf[:n]
+		atEOF := base+int64(n) >= r.end
+		lo := int(pos - base)
+		for i := lo; i < len(data); i++ {
+			j := bytes.Index(data[i:], []byte(kw))
+			if j < 0 || i+j >= lo+chunk {
+				break
+			}
+			i += j
+			after := data[i+len(kw):]
+			if len(after) < len("endobj") && !atEOF {
+				break // Seen again, with what follows, in the next chunk.
+			}
+			start := base + int64(i)
+			if start != offset && !isEOL(data[i-1]) {
+				continue
+			}
+			if len(after) > 0 && !isSpace(after[0]) && !bytes.HasPrefix(after, []byte("endobj")) {
+				continue
+			}
+			switch {
+			case start-2 >= offset && data[i-2] == '\r' && data[i-1] == '\n':
+				start -= 2
+			case start > offset:
+				start--
+			}
+			return start - offset, true
+		}
+	}
+	return 0, false
 }
 
 type errorReadCloser struct {
@@ -522,81 +1164,381 @@ func (e *errorReadCloser) Close() error {
 // Reader returns the data contained in the stream v.
 // If v.Kind() != Stream, Reader returns a ReadCloser that
 // responds to all reads with a “stream not present” error.
-func (v value) Reader() io.ReadCloser {
+// The reads of a stream with a filter or filter parameters that Reader does not
+// support, such as CCITTFaxDecode data of mixed one- and two-dimensional Group 3
+// encoding, with a K greater than 0, fail with an error of ErrUnsupportedFeature.
+func (v Value) Reader() io.ReadCloser {
+	return v.ReaderN(-1)
+}
+
+// readAll reads the data from rd, as io.ReadAll does, but fails once there
+// is more than limit bytes of it, as that of a stream decoded by Reader has
+// no bound of its own.
+func readAll(rd io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(rd, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("data longer than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// RawReader returns the data of the stream v as it is encoded in the file, before any
+// of its filters are applied, but decrypted if the file is encrypted. It is equivalent
+// to ReaderN(0).
+func (v Value) RawReader() io.ReadCloser {
+	return v.ReaderN(0)
+}
+
+// ReaderN is like Reader, but applies only the first n filters of the stream v,
+// or all of them if n is negative. Like Reader, it leaves image codecs, and the
+// filters following them, undecoded; see UndecodedFilters.
+func (v Value) ReaderN(n int) io.ReadCloser {
+	return v.decode(n, v.r != nil && v.r.cfg.strictFilters)
+}
+
+// decode is ReaderN, with FlateDecode data decoding only as it is declared if strict,
+// whatever the options of the Reader. See WithStrictFilters.
+func (v Value) decode(n int, strict bool) io.ReadCloser {
 	x, ok := v.data.(types.Stream)
 	if !ok {
 		return &errorReadCloser{fmt.Errorf("stream not present")}
 	}
 
-	rd, err := v.r.streamReader(x, v.Key("Length").Int64())
+	sr, err := v.r.streamReader(x, v.Key("Length").Int64())
 	if err != nil {
-		panic(fmt.Errorf("bad decryption: %w", err))
+		return &errorReadCloser{fmt.Errorf("bad decryption: %w", err)}
 	}
-	filter := v.Key("Filter")
-	param := v.Key("DecodeParms")
-	switch filter.Kind() {
-	default:
-		panic(fmt.Errorf("unsupported filter %v", filter))
-	case nullKind:
-		// ok
-	case nameKind:
-		rd = applyFilter(rd, filter.Name(), param)
-	case arrayKind:
-		for i := 0; i < filter.Len(); i++ {
-			rd = applyFilter(rd, filter.Index(i).Name(), param.Index(i))
+	filters, err := streamFilters(v)
+	for i := 0; i < len(filters) && (n < 0 || i < n) && err == nil; i++ {
+		if imageFilters[filters[i].Name] {
+			break
 		}
+		var rd io.Reader
+		if rd, err = v.applyFilter(sr.Reader, filters[i].Name, filters[i].Params, strict); err == nil {
+			sr.push(rd)
+		}
+	}
+	if err != nil {
+		sr.Close()
+		return &errorReadCloser{err}
 	}
+	return sr
+}
 
-	if rc, ok := rd.(io.ReadCloser); ok {
-		return rc
+// DeclaredLength returns the length of the data of the stream v declared by its
+// Length entry, which may not be its actual length in a malformed file.
+// If v.Kind() != Stream, DeclaredLength returns 0.
+func (v Value) DeclaredLength() int64 {
+	if v.Kind() != Stream {
+		return 0
 	}
+	return v.Key("Length").Int64()
+}
 
-	return io.NopCloser(rd)
+// imageFilters are the filters of image codecs, which Reader leaves undecoded:
+// their data is returned as it is encoded, for the caller to decode as an image.
+var imageFilters = map[string]bool{
+	"DCTDecode": true,
+	"JPXDecode": true,
 }
 
-func applyFilter(rd io.Reader, name string, param value) io.Reader {
-	switch name {
-	default:
-		panic("unknown filter " + name)
-	case "FlateDecode":
-		zr, err := zlib.NewReader(rd)
-		if err != nil {
-			panic(err)
+// UndecodedFilters returns the names of the filters of the stream v that Reader leaves
+// undecoded: image codecs such as DCTDecode (JPEG) and JPXDecode (JPEG 2000), and any
+// filters following them. If v.Kind() != Stream, or Reader decodes all of the filters,
+// UndecodedFilters returns nil.
+func (v Value) UndecodedFilters() []string {
+	if v.Kind() != Stream {
+		return nil
+	}
+	var names []string
+	for _, f := range v.Filters() {
+		names = append(names, f.Name)
+	}
+	for i, name := range names {
+		if imageFilters[name] {
+			return names[i:]
 		}
-		pred := param.Key("Predictor")
-		if pred.Kind() == nullKind {
-			return zr
+	}
+	return nil
+}
+
+// A FilterSpec is one of the filters of a stream, with its parameters.
+type FilterSpec struct {
+	// Name is the name of the filter, such as FlateDecode.
+	Name string
+	// Params is the parameter dictionary of the filter, from the DecodeParms
+	// of the stream, or a null Value if it has none.
+	Params Value
+}
+
+// Filters returns the filters of the stream v, in the order that they decode its
+// data, whether its Filter is a name or an array of them, each with its parameters,
+// as Reader applies them. Filters decodes nothing, so it costs little even for the
+// filters that Reader leaves undecoded. If v.Kind() != Stream, v has no filters,
+// or its Filter is malformed, Filters returns nil.
+func (v Value) Filters() []FilterSpec {
+	if v.Kind() != Stream {
+		return nil
+	}
+	filters, _ := streamFilters(v)
+	return filters
+}
+
+// EncodedLength returns the length of the data of the stream v as it is in the file,
+// before any of its filters are applied. It is that of its Length entry, resolved if
+// it is an indirect reference, unless that is wrong, in which case it is the length
+// that Reader finds by scanning for the end of the stream. If v.Kind() != Stream,
+// EncodedLength returns 0.
+func (v Value) EncodedLength() int64 {
+	x, ok := v.data.(types.Stream)
+	if !ok {
+		return 0
+	}
+	return v.r.streamLength(x, v.Key("Length").Int64())
+}
+
+// streamFilters returns the filters of the stream v, in the order that they decode
+// its data, from its Filter, a name or an array of them, and DecodeParms. The parameters
+// are those of the DecodeParms array of the same index as the filter, a null element
+// being no parameters; a lone DecodeParms dictionary is that of a lone filter, and
+// is ignored for several, as there is no telling which of them it belongs to. An empty
+// Filter array is no filters. It returns an error if Filter is neither a name nor an
+// array.
+func streamFilters(v Value) ([]FilterSpec, error) {
+	var filters []FilterSpec
+	switch f := v.Key("Filter"); f.Kind() {
+	case Null:
+		return nil, nil
+	case Name:
+		filters = []FilterSpec{{Name: f.Name()}}
+	case Array:
+		filters = make([]FilterSpec, f.Len())
+		for i := range filters {
+			filters[i].Name = f.Index(i).Name()
 		}
-		columns := param.Key("Columns").Int64()
-		switch pred.Int64() {
-		default:
-			slog.Debug("unknown predictor", slog.Any("pred", pred))
-			panic("pred")
-		case 12:
-			return &pngUpReader{r: zr, hist: make([]byte, 1+columns), tmp: make([]byte, 1+columns)}
+	default:
+		return nil, fmt.Errorf("unsupported filter %v", f)
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	switch param := v.Key("DecodeParms"); param.Kind() {
+	case Dict:
+		if len(filters) == 1 {
+			filters[0].Params = param
 		}
-	case "ASCII85Decode":
-		cleanASCII85 := encoding.NewAlphaReader(rd)
-		decoder := ascii85.NewDecoder(cleanASCII85)
+	case Array:
+		for i := range min(len(filters), param.Len()) {
+			if p := param.Index(i); p.Kind() == Dict {
+				filters[i].Params = p
+			}
+		}
+	}
+	return filters, nil
+}
+
+// applyFilter returns a reader decoding the data of the stream v read from rd
+// with the filter name, of parameters param, strictly as decode does if strict.
+func (v Value) applyFilter(rd io.Reader, name string, param Value, strict bool) (io.Reader, error) {
+	if imageFilters[name] {
+		return rd, nil
+	}
+	decode, ok := filterDecoders[name]
+	if !ok {
+		return nil, unsupported("filter %s", name)
+	}
+	return decode(v, rd, param, strict)
+}
+
+// supportedFilter reports whether Value.Reader decodes the filter name,
+// or leaves it for the caller to.
+func supportedFilter(name string) bool {
+	return imageFilters[name] || filterDecoders[name] != nil
+}
+
+// A filterDecoder returns a reader decoding the data of the stream v read from rd
+// with its filter, of parameters param, strictly as decode does if strict.
+type filterDecoder func(v Value, rd io.Reader, param Value, strict bool) (io.Reader, error)
+
+// filterDecoders are the decoders of the filters that Value.Reader decodes, by name.
+// They are set by init, as they lead back to Value.Reader, which makes a cycle
+// of initialization otherwise.
+var filterDecoders map[string]filterDecoder
+
+func init() {
+	filterDecoders = map[string]filterDecoder{
+		"FlateDecode": func(v Value, rd io.Reader, param Value, strict bool) (io.Reader, error) {
+			zr, err := v.inflate(rd, strict)
+			if err != nil {
+				return nil, fmt.Errorf("FlateDecode: %w", err)
+			}
+			return newPredictorReader(zr, param)
+		},
+		"CCITTFaxDecode": func(_ Value, rd io.Reader, param Value, _ bool) (io.Reader, error) {
+			return newCCITTReader(rd, param)
+		},
+		"ASCII85Decode": func(_ Value, rd io.Reader, param Value, _ bool) (io.Reader, error) {
+			// ASCII85Decode has no parameters: any DecodeParms are ignored.
+			if len(param.Keys()) > 0 {
+				slog.Debug("ignoring ASCII85Decode params", slog.Any("param", param))
+			}
+			return ascii85.NewDecoder(encoding.NewAlphaReader(rd)), nil
+		},
+	}
+}
+
+// newCCITTReader returns a reader decoding the CCITT Group 3 or 4 fax data read from rd,
+// with the parameters param, into rows of 1-bit samples: 0 for black and 1 for white,
+// unless BlackIs1 is true. Without Rows, the rows end at the end-of-block pattern, or,
+// if EndOfBlock is false, with the data. Only pure one-dimensional Group 3 data, of K 0,
+// and Group 4 data, of K less than 0, are decoded: the mixed one- and two-dimensional
+// Group 3 data of K greater than 0 is unsupported. See PDF 32000-1:2008, §7.4.6.
+func newCCITTReader(rd io.Reader, param Value) (io.Reader, error) {
+	var sf ccitt.SubFormat
+	switch k := param.Key("K").Int64(); {
+	case k < 0:
+		sf = ccitt.Group4
+	case k == 0:
+		sf = ccitt.Group3
+	default:
+		return nil, fmt.Errorf("CCITTFaxDecode: %w", unsupported("mixed one- and two-dimensional encoding, K %d", k))
+	}
+
+	columns := int64(1728)
+	if c := param.Key("Columns"); !c.IsNull() {
+		columns = c.Int64()
+	}
+	if columns < 1 || columns > 1<<20 {
+		return nil, fmt.Errorf("CCITTFaxDecode: invalid Columns %d", columns)
+	}
+	rows := param.Key("Rows").Int64()
+	if rows < 0 || rows > 1<<20 {
+		return nil, fmt.Errorf("CCITTFaxDecode: invalid Rows %d", rows)
+	}
+	height := int(rows)
+	if rows == 0 {
+		// The rows end at the end-of-block pattern, or, without one, with the data.
+		height = ccitt.AutoDetectHeight
+	}
+	// With Rows, the end-of-block pattern after them may be missing, whatever EndOfBlock is.
+	eob := param.Key("EndOfBlock")
+	blockless := rows == 0 && eob.Kind() == Bool && !eob.Bool()
+
+	src := &eofReader{Reader: rd}
+	crd := ccitt.NewReader(src, ccitt.MSB, sf, int(columns), height, &ccitt.Options{
+		Align:  param.Key("EncodedByteAlign").Bool(),
+		Invert: param.Key("BlackIs1").Bool(),
+	})
+	if !blockless {
+		return crd, nil
+	}
+	return &blocklessCCITTReader{Reader: crd, src: src, row: (columns + 7) / 8}, nil
+}
+
+// A blocklessCCITTReader reads the rows of CCITT fax data with EndOfBlock false, which
+// end with the data rather than at an end-of-block pattern. The decoder fails to decode
+// the row after the last, and a failure after whole rows, once the data is read to its
+// end, is the end of the rows.
+type blocklessCCITTReader struct {
+	io.Reader
+	src *eofReader
+	row int64 // the number of bytes of a row.
+	n   int64 // the number of bytes read.
+}
+
+func (r *blocklessCCITTReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF && r.src.eof && r.n%r.row == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// newPredictorReader returns a reader undoing the predictor of the parameters param of
+// a FlateDecode filter on the data read from rd: none, or one of the PNG predictors,
+// in rows of Columns samples of Colors components of BitsPerComponent bits, each row
+// with the PNG filter type of its own. See PDF 32000-1:2008, §7.4.4.4.
+func newPredictorReader(rd io.Reader, param Value) (io.Reader, error) {
+	pred := param.Key("Predictor")
+	switch p := pred.Int64(); {
+	case pred.Kind() == Null, p == 1:
+		return rd, nil
+	case p < 10 || p > 15:
+		slog.Debug("unknown predictor", slog.Any("pred", pred))
+		return nil, fmt.Errorf("FlateDecode: %w", unsupported("predictor %v", pred))
+	}
 
-		switch param.Keys() {
-		default:
-			slog.Debug("unexpected ASCII85Decode param", slog.Any("param", param))
-			panic("not expected DecodeParms for ascii85")
-		case nil:
-			return decoder
+	colors, bpc, columns := int64(1), int64(8), int64(1)
+	for _, v := range [...]struct {
+		key string
+		p   *int64
+		max int64
+	}{{"Colors", &colors, 32}, {"BitsPerComponent", &bpc, 16}, {"Columns", &columns, 1 << 20}} {
+		x := param.Key(v.key)
+		if x.IsNull() {
+			continue
 		}
+		if *v.p = x.Int64(); *v.p < 1 || *v.p > v.max {
+			return nil, fmt.Errorf("FlateDecode: invalid %s %v", v.key, x)
+		}
+	}
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("FlateDecode: invalid BitsPerComponent %d", bpc)
 	}
+	bits := colors * bpc
+	if columns*bits > 8<<20 {
+		return nil, fmt.Errorf("FlateDecode: rows of %d columns of %d bits are too long", columns, bits)
+	}
+	return newPNGReader(rd, int((columns*bits+7)/8), int((bits+7)/8)), nil
 }
 
-type pngUpReader struct {
+// A pngReader undoes the PNG predictors of rows of data. See RFC 2083, §6.
+type pngReader struct {
 	r    io.Reader
-	hist []byte
-	tmp  []byte
+	rows *[]byte // prev and cur, from pngRows
+	// prev and cur are the previous row and the current one, each after the
+	// byte of its filter type. prev is zeros for the first row.
+	prev, cur []byte
+	// bpp is the number of bytes of a pixel, or 1 if a pixel is smaller.
+	bpp  int
 	pend []byte
 }
 
-func (r *pngUpReader) Read(b []byte) (int, error) {
+// pngRows pools the rows of pngReaders.
+var pngRows sync.Pool
+
+// newPNGReader returns a reader of the rows of rowLen bytes, of pixels of bpp
+// bytes, PNG-predicted in r.
+func newPNGReader(r io.Reader, rowLen, bpp int) *pngReader {
+	rows, _ := pngRows.Get().(*[]byte)
+	if rows == nil || cap(*rows) < 2*(1+rowLen) {
+		b := make([]byte, 2*(1+rowLen))
+		rows = &b
+	}
+	buf := (*rows)[:2*(1+rowLen)]
+	clear(buf)
+	return &pngReader{r: r, rows: rows, prev: buf[:1+rowLen], cur: buf[1+rowLen:], bpp: bpp}
+}
+
+// Close closes the underlying reader, and returns the rows of r to be reused.
+func (r *pngReader) Close() error {
+	if r.rows != nil {
+		pngRows.Put(r.rows)
+		r.rows, r.prev, r.cur, r.pend = nil, nil, nil, nil
+	}
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (r *pngReader) Read(b []byte) (int, error) {
 	n := 0
 	for len(b) > 0 {
 		if len(r.pend) > 0 {
@@ -606,26 +1548,80 @@ func (r *pngUpReader) Read(b []byte) (int, error) {
 			r.pend = r.pend[m:]
 			continue
 		}
-		_, err := io.ReadFull(r.r, r.tmp)
+		r.prev, r.cur = r.cur, r.prev
+		_, err := io.ReadFull(r.r, r.cur)
 		if err != nil {
 			return n, err
 		}
-		if r.tmp[0] != 2 {
-			return n, fmt.Errorf("malformed PNG-Up encoding")
-		}
-		for i, b := range r.tmp {
-			r.hist[i] += b
+		if err := r.unfilter(); err != nil {
+			return n, err
 		}
-		r.pend = r.hist[1:]
+		r.pend = r.cur[1:]
 	}
 	return n, nil
 }
 
+// unfilter undoes the filter of the current row, given the previous one.
+func (r *pngReader) unfilter() error {
+	cur, prev, bpp := r.cur[1:], r.prev[1:], r.bpp
+	switch r.cur[0] {
+	case 0: // None
+	case 1: // Sub
+		for i := bpp; i < len(cur); i++ {
+			cur[i] += cur[i-bpp]
+		}
+	case 2: // Up
+		for i := range cur {
+			cur[i] += prev[i]
+		}
+	case 3: // Average
+		for i := range cur {
+			var left int
+			if i >= bpp {
+				left = int(cur[i-bpp])
+			}
+			cur[i] += byte((left + int(prev[i])) / 2)
+		}
+	case 4: // Paeth
+		for i := range cur {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = cur[i-bpp], prev[i-bpp]
+			}
+			cur[i] += paeth(left, prev[i], upLeft)
+		}
+	default:
+		return fmt.Errorf("malformed PNG predictor: filter type %d", r.cur[0])
+	}
+	return nil
+}
+
+// paeth returns whichever of a, b and c, the bytes to the left, above and to the
+// upper left, is nearest to a + b - c.
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
 func (r *Reader) initEncrypt(password string) error {
 	// See PDF 32000-1:2008, §7.6.
 	encrypt, _ := r.resolve(types.Objptr{}, r.trailer["Encrypt"]).data.(types.Dict)
 	if encrypt["Filter"] != types.Name("Standard") {
-		return fmt.Errorf("unsupported PDF: encryption filter %v", objfmt(encrypt["Filter"]))
+		return unsupported("encryption filter %v", objfmt(encrypt["Filter"]))
 	}
 
 	ids, ok := r.trailer["ID"].(types.Array)
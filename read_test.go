@@ -0,0 +1,2773 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/ScriptRock/pdf/internal/testpdf"
+	"github.com/ScriptRock/pdf/internal/types"
+	"github.com/ScriptRock/pdf/text"
+)
+
+// buildPDF assembles a PDF file from the given object bodies, numbering them from 1
+// and writing a classic cross-reference table. Object 1 is the document catalog.
+func buildPDF(objs ...string) []byte {
+	f := testpdf.File{Trailer: types.Dict{"Root": types.Objptr{ID: 1}}}
+	for _, obj := range objs {
+		f.Add(testpdf.Raw(obj))
+	}
+	return f.Bytes()
+}
+
+var startxrefRE = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+
+// update appends to the PDF data an incremental update, adding or replacing the given
+// objects. Objects with an empty body are freed by the update.
+func update(data []byte, size int, objs map[int]string) []byte {
+	m := startxrefRE.FindSubmatch(data)
+	prev, _ := strconv.Atoi(string(m[1]))
+
+	b := bytes.NewBuffer(slices.Clip(data))
+	offsets := map[int]int{}
+	var ids []int
+	for id, obj := range objs {
+		ids = append(ids, id)
+		if obj != "" {
+			offsets[id] = b.Len()
+			fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", id, obj)
+		}
+	}
+	slices.Sort(ids)
+
+	xref := b.Len()
+	b.WriteString("xref\n")
+	for _, id := range ids {
+		if off, ok := offsets[id]; ok {
+			fmt.Fprintf(b, "%d 1\n%010d 00000 n \n", id, off)
+		} else {
+			fmt.Fprintf(b, "%d 1\n0000000000 00001 f \n", id)
+		}
+	}
+	fmt.Fprintf(b, "trailer\n<</Size %d /Root 1 0 R /Prev %d>>\nstartxref\n%d\n%%%%EOF\n", size, prev, xref)
+	return b.Bytes()
+}
+
+// stream returns the body of a stream object holding data.
+func stream(data string) string {
+	return fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(data), data)
+}
+
+// textPDF returns a single page PDF showing the given content stream
+// with the font /F1, a WinAnsi-encoded Helvetica.
+func textPDF(content string) []byte {
+	return buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	)
+}
+
+func openPDF(t testing.TB, data []byte) *Reader {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	return r
+}
+
+func TestReader_Text(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestNewReader_sources(t *testing.T) {
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	open := map[string]func() (*Reader, error){
+		"bytes":  func() (*Reader, error) { return NewReaderFromBytes(data) },
+		"seeker": func() (*Reader, error) { return NewReaderFromSeeker(bytes.NewReader(data)) },
+	}
+	file := filepath.Join(t.TempDir(), "hello.pdf")
+	if err := os.WriteFile(file, data, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	open["file"] = func() (*Reader, error) { return Open(file) }
+	open["mmap"] = func() (*Reader, error) { return Open(file, WithMmap()) }
+
+	for name, open := range open {
+		t.Run(name, func(t *testing.T) {
+			r, err := open()
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, world"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestOpen_mmapClose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hello.pdf")
+	if err := os.WriteFile(file, textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(file, WithMmap())
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	contents, err := r.Object(4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.f.(*mappedFile); !ok {
+		t.Logf("file read as %T, not mapped", r.f)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal("failed to close:", err)
+	}
+	if _, err := io.ReadAll(contents.Reader()); err == nil {
+		t.Error("read of stream after Close succeeded")
+	}
+	if got := contents.Key("Length").Int64(); got != 43 {
+		t.Errorf("got Length %d after Close, want 43", got)
+	}
+}
+
+func TestReader_TextContext(t *testing.T) {
+	content := strings.Repeat("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET\n", 10_000)
+	r := openPDF(t, textPDF(content))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.TextContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if _, err := r.TextContext(context.Background()); err != nil {
+		t.Error("failed to read text:", err)
+	}
+}
+
+func TestReader_Layers(t *testing.T) {
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /OCProperties <</OCGs [6 0 R 7 0 R] /D <</OFF [7 0 R]>>>>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <<"+
+			"/Font <</F1 5 0 R>> /Properties <</MC0 6 0 R /MC1 7 0 R /MC2 8 0 R>>>>>>",
+		stream("BT /F1 12 Tf 72 720 Td (Base) Tj "+
+			"/OC /MC0 BDC ( Shown) Tj EMC "+
+			"/OC /MC1 BDC ( Hidden) Tj /Span <<>> BDC ( Nested) Tj EMC EMC "+
+			"/OC /MC2 BDC ( Either) Tj EMC ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /OCG /Name (Shown)>>",
+		"<</Type /OCG /Name (Hidden)>>",
+		"<</Type /OCMD /OCGs [6 0 R 7 0 R] /P /AnyOn>>",
+	)
+
+	testCases := map[string]struct {
+		opts []Option
+		want string
+	}{
+		"default": {
+			want: "Base Shown Either",
+		},
+		"all layers": {
+			opts: []Option{WithAllLayers()},
+			want: "Base Shown Hidden Nested Either",
+		},
+		"selected layers": {
+			opts: []Option{WithLayers("Hidden")},
+			want: "Base Hidden Nested Either",
+		},
+		"no layers": {
+			opts: []Option{WithLayers()},
+			want: "Base",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReaderFromBytes(data, tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			if diff := cmp.Diff(r.Layers(), []string{"Shown", "Hidden"}); diff != "" {
+				t.Error("layers did not match expectation:", diff)
+			}
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_ActualText(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		want    string
+	}{
+		"inline": {
+			content: "(Of) Tj /Span <</ActualText (fi)>> BDC (\\001) Tj EMC (ce) Tj",
+			want:    "Office",
+		},
+		"named": {
+			content: "(Ac) Tj /Span /MC0 BDC (\\002) Tj EMC (ion) Tj",
+			want:    "Action",
+		},
+		"empty": {
+			content: "(exam) Tj /Span <</ActualText ()>> BDC (-) Tj EMC (ple) Tj",
+			want:    "example",
+		},
+		"nested": {
+			content: "/Span <</ActualText (outer)>> BDC (a) Tj /Span <</ActualText (inner)>> BDC (b) Tj EMC EMC",
+			want:    "outer",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <<"+
+					"/Font <</F1 5 0 R>> /Properties <</MC0 <</ActualText <FEFF0074>>>>>>>>>",
+				stream("BT /F1 12 Tf 72 720 Td "+tc.content+" ET"),
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_Sectioned(t *testing.T) {
+	// The heading is drawn after the text it introduces, as in a multi-column layout.
+	const content = "BT /F1 12 Tf 72 600 Td /P <</MCID 0>> BDC (Body text.) Tj EMC ET " +
+		"BT /F1 12 Tf 72 700 Td /P <</MCID 1>> BDC (Intro) Tj EMC ET " +
+		"BT /F1 24 Tf 72 720 Td /H1 <</MCID 2>> BDC (Title) Tj EMC ET"
+	tagged := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /StructTreeRoot 6 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /StructTreeRoot /RoleMap <</Para /P>> /K [<</S /Para /Pg 3 0 R /K 1>> 7 0 R]>>",
+		"<</S /Sect /K [<</S /H1 /Pg 3 0 R /K 2>> <</S /P /K <</Type /MCR /Pg 3 0 R /MCID 0>>>>]>>",
+	)
+
+	r := openPDF(t, tagged)
+	got, err := r.Sectioned()
+	if err != nil {
+		t.Fatal("failed to read sections:", err)
+	}
+	want := text.Content{
+		text.Text{{Size: 12, Page: 1, Content: "Intro\n"}},
+		&text.Section{
+			Title:   text.Text{{Size: 24, Page: 1, Content: "Title"}},
+			Content: text.Content{text.Text{{Size: 12, Page: 1, Content: "Body text.\n"}}},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("sections did not match expectation:", diff)
+	}
+
+	// Without the structure tree, the text is sectioned from its layout.
+	r = openPDF(t, textPDF(content))
+	got, err = r.Sectioned()
+	if err != nil {
+		t.Fatal("failed to read sections:", err)
+	}
+	txt, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if diff := cmp.Diff(got, txt.Sectioned()); diff != "" {
+		t.Error("untagged sections did not match expectation:", diff)
+	}
+}
+
+func TestReader_SectionedActualText(t *testing.T) {
+	// The ActualText of a sequence with an MCID is the text of the MCID.
+	const content = "BT /F1 12 Tf 72 700 Td /P <</MCID 0 /ActualText (Office)>> BDC (O\\001ce) Tj EMC ET"
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /StructTreeRoot 6 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /StructTreeRoot /K [<</S /P /Pg 3 0 R /K 0>>]>>",
+	))
+
+	got, err := r.Sectioned()
+	if err != nil {
+		t.Fatal("failed to read sections:", err)
+	}
+	want := text.Content{text.Text{{Size: 12, Page: 1, Content: "Office\n"}}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("sections did not match expectation:", diff)
+	}
+}
+
+func TestReader_SectionedCycle(t *testing.T) {
+	// The element lists itself twice, which would be read twice at each depth.
+	const content = "BT /F1 12 Tf 72 700 Td /P <</MCID 0>> BDC (Hello) Tj EMC ET"
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /StructTreeRoot 6 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /StructTreeRoot /K 7 0 R>>",
+		"<</S /P /Pg 3 0 R /K [7 0 R 7 0 R 0]>>",
+	))
+
+	got, err := r.Sectioned()
+	if err != nil {
+		t.Fatal("failed to read sections:", err)
+	}
+	want := text.Content{text.Text{{Size: 12, Page: 1, Content: "Hello\n"}}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("sections did not match expectation:", diff)
+	}
+}
+
+func TestReader_Lang(t *testing.T) {
+	const content = "BT /F1 12 Tf 72 700 Td /P <</MCID 0>> BDC (Hello) Tj EMC ET " +
+		"BT /F1 12 Tf 72 680 Td /P <</MCID 1>> BDC (Bonjour) Tj EMC /Span <</MCID 2>> BDC ( Hallo) Tj EMC ET"
+	tagged := buildPDF(
+		// The language is a text string, here in UTF-16 with a byte order mark.
+		"<</Type /Catalog /Pages 2 0 R /StructTreeRoot 6 0 R /Lang <FEFF0065006E002D00470042>>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /StructTreeRoot /K [<</S /P /Pg 3 0 R /K 0>> <</S /P /Lang (fr) /Pg 3 0 R /K [1 <</S /Span /Lang (de) /K 2>>]>>]>>",
+	)
+
+	r := openPDF(t, tagged)
+	if got, want := r.Lang(), "en-GB"; got != want {
+		t.Errorf("got language %q, want %q", got, want)
+	}
+	got, err := r.Sectioned()
+	if err != nil {
+		t.Fatal("failed to read sections:", err)
+	}
+	want := text.Content{
+		text.Text{{Size: 12, Page: 1, Content: "Hello\n"}},
+		text.Text{{Size: 12, Lang: "fr", Page: 1, Content: "Bonjour"}, {Size: 12, Lang: "de", Page: 1, Content: " Hallo\n"}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("sections did not match expectation:", diff)
+	}
+
+	if got := openPDF(t, textPDF("")).Lang(); got != "" {
+		t.Errorf("got language %q for a document without one", got)
+	}
+}
+
+func TestReader_PageLabels(t *testing.T) {
+	const pages = 12
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R /PageLabels <</Kids [3 0 R 4 0 R]>>>>",
+		"",
+		"<</Limits [0 8] /Nums [0 <</S /r>> 8 <</S /D>>]>>",
+		"<</Limits [10 11] /Nums [10 <</P (A-) /S /A /St 26>> 11 <</P (Index)>>]>>",
+	}
+	var kids []string
+	for range pages {
+		objs = append(objs, "<</Type /Page /Parent 2 0 R>>")
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objs)))
+	}
+	objs[1] = fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(kids, " "), pages)
+	r := openPDF(t, buildPDF(objs...))
+
+	want := []string{"i", "ii", "iii", "iv", "v", "vi", "vii", "viii", "1", "2", "A-Z", "Index"}
+	if diff := cmp.Diff(r.PageLabels(), want); diff != "" {
+		t.Error("page labels did not match expectation:", diff)
+	}
+	if got, err := r.PageByLabel("1"); err != nil || got != 9 {
+		t.Errorf("got page %d (%v) for label 1, want 9", got, err)
+	}
+
+	r = openPDF(t, textPDF(""))
+	if diff := cmp.Diff(r.PageLabels(), []string{"1"}); diff != "" {
+		t.Error("page labels of unlabelled document did not match expectation:", diff)
+	}
+}
+
+func TestReader_ResolveDest(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /Dests <</intro [3 0 R /Fit] /old <</D [4 0 R /FitH 500]>>>> /Names <</Dests 5 0 R>>>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R>>",
+		"<</Type /Page /Parent 2 0 R>>",
+		"<</Kids [6 0 R 7 0 R]>>",
+		"<</Limits [(a) (m)] /Names [(a) [3 0 R /XYZ 72 720 null] (m) [1 /FitR 10 20 30 40]]>>",
+		"<</Limits [(section3.2) (z)] /Names [(section3.2) <</D [4 0 R /XYZ 100 600 0]>> (z) [99 0 R /Fit]]>>",
+	))
+
+	type location struct {
+		Page int
+		X, Y float64
+	}
+	testCases := map[string]struct {
+		want    location
+		wantErr bool
+	}{
+		"intro":       {want: location{1, 0, 0}},
+		"old":         {want: location{2, 0, 500}},
+		"a":           {want: location{1, 72, 720}},
+		"m":           {want: location{2, 10, 40}},
+		"section3.2":  {want: location{2, 100, 600}},
+		"z":           {wantErr: true},
+		"nonexistent": {wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			page, x, y, err := r.ResolveDest(name)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(location{page, x, y}, tc.want); diff != "" && !tc.wantErr {
+				t.Error("destination did not match expectation:", diff)
+			}
+		})
+	}
+}
+
+func TestReader_OpenAction(t *testing.T) {
+	withOpen := func(open string) []byte {
+		return buildPDF(
+			"<</Type /Catalog /Pages 2 0 R /Dests <</intro [4 0 R /FitH 500]>> "+open+">>",
+			"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+			"<</Type /Page /Parent 2 0 R>>",
+			"<</Type /Page /Parent 2 0 R>>",
+			"<</S /Named /N /NextPage /Next [6 0 R 5 0 R]>>",
+			"<</S /URI /URI (https://example.com/) /Next 5 0 R>>",
+		)
+	}
+	// A chain of actions longer than is read.
+	long := "null"
+	for range maxActions + 1 {
+		long = "<</S /Named /N /NextPage /Next " + long + ">>"
+	}
+	var longWant []Action
+	for range maxActions {
+		longWant = append(longWant, Action{Type: "Named", Target: "NextPage"})
+	}
+	testCases := map[string]struct {
+		open    string
+		want    []Action
+		wantErr bool
+	}{
+		"none": {},
+		"destination": {
+			open: "/OpenAction [4 0 R /XYZ 72 720 1.5]",
+			want: []Action{{Type: "GoTo", Dest: Dest{Page: 2, Fit: "XYZ", Left: 72, Top: 720, Zoom: 1.5}}},
+		},
+		"GoTo named destination": {
+			open: "/OpenAction <</S /GoTo /D (intro)>>",
+			want: []Action{{Type: "GoTo", Dest: Dest{Page: 2, Fit: "FitH", Top: 500}}},
+		},
+		"URI": {
+			open: "/OpenAction <</S /URI /URI (https://example.com/)>>",
+			want: []Action{{Type: "URI", Target: "https://example.com/"}},
+		},
+		"Launch": {
+			open: "/OpenAction <</S /Launch /Win <</F (cmd.exe) /P (/c calc)>>>>",
+			want: []Action{{Type: "Launch", Target: "cmd.exe"}},
+		},
+		"GoToR": {
+			open: "/OpenAction <</S /GoToR /F <</Type /Filespec /F (other.pdf)>> /D [0 /Fit]>>",
+			want: []Action{{Type: "GoToR", Target: "other.pdf"}},
+		},
+		"Next actions": {
+			open: "/OpenAction <</S /GoTo /D (intro) /Next [<</S /Named /N /LastPage>> <</S /URI /URI (https://example.com/)>>]>>",
+			want: []Action{
+				{Type: "GoTo", Dest: Dest{Page: 2, Fit: "FitH", Top: 500}},
+				{Type: "Named", Target: "LastPage"},
+				{Type: "URI", Target: "https://example.com/"},
+			},
+		},
+		"Next cycle": {
+			open: "/OpenAction 5 0 R",
+			want: []Action{{Type: "Named", Target: "NextPage"}, {Type: "URI", Target: "https://example.com/"}},
+		},
+		"Next chain too long": {
+			open: "/OpenAction " + long,
+			want: longWant,
+		},
+		"missing page": {
+			open:    "/OpenAction [99 0 R /Fit]",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := openPDF(t, withOpen(tc.open)).OpenAction()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("action mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReader_multipleContentStreams(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 4 0 R>>>> /Contents [5 0 R 6 0 R 7 0 R]>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		// The streams end without white space, in the middle of operands.
+		stream("BT /F1 12 Tf 72 720 Td (Hello,) Tj 0"),
+		stream("-14 Td (world) Tj ET"),
+		stream("BT /F1 12 Tf 72 600 Td (again) Tj ET"),
+	))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello,\nworld\n\nagain"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_corruptOperators(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj 5 Td 1 2 3 xyz 9 9 9 9 Tm "+
+		"TJ /F1 Tf (, world) 1 Tj ET"))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+
+	r = openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj 5 Td 1 2 3 xyz 9 9 9 9 Tm "+
+		"TJ /F1 Tf (, world) Tj ET"))
+	if got, err = r.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_compatibilityOperators(t *testing.T) {
+	r := openPDF(t, textPDF("0 0 d0 [3 2 3 2 3 2 3 2] 0 d 1 w 0 j /Sh1 sh BT /F1 12 Tf 72 720 Td "+
+		"BX 1 2 /X xyz BX (x) zyx EX abc EX (Hello) Tj [(, ) 100 (world)] TJ ET bogus 2 J"))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	// Only the unknown operator outside the compatibility sections is reported.
+	var warnings []string
+	for _, w := range r.Warnings() {
+		warnings = append(warnings, w.Message)
+	}
+	if diff := cmp.Diff([]string{"skipping unknown operator bogus"}, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReader_operandKinds(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj 1 0 0 1 (oops) 50 Tm "+
+		"[(, ) [1] /X (world)] TJ 12 /F1 Tf (x) 5 Td ET"))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	var warnings []string
+	for _, w := range r.Warnings() {
+		warnings = append(warnings, w.Message)
+	}
+	want := []string{
+		"skipping operator Tm with operand 5 \"oops\", not a number",
+		"skipping TJ array element [1], not a string or a number",
+		"skipping TJ array element /X, not a string or a number",
+		"skipping operator Tf with operand 1 12, not a name",
+		"skipping operator Td with operand 1 \"x\", not a number",
+	}
+	if diff := cmp.Diff(want, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReader_clippedText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Hello) Tj ET " +
+		"BT /F1 12 Tf 5000 5000 Td (off the page) Tj ET " +
+		"q 0 0 0 0 re W n BT /F1 12 Tf 72 700 Td (clipped away) Tj ET Q " +
+		"q 60 600 100 50 re W n BT /F1 12 Tf 150 620 Td (, world) Tj 100 0 Td (outside the clip) Tj ET Q"
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792]>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream(content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	)
+
+	r := openPDF(t, data)
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello\n\n, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	var warnings []string
+	for _, w := range r.Warnings() {
+		warnings = append(warnings, w.Message)
+	}
+	want := []string{"dropped 3 runs of text, of 40 glyphs, outside the visible region of the page"}
+	if diff := cmp.Diff(want, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	r, err = NewReaderFromBytes(data, WithClippedText())
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	if got, err = r.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	for _, s := range []string{"off the page", "clipped away", "outside the clip"} {
+		if !strings.Contains(got.String(), s) {
+			t.Errorf("got text %q, want it to contain %q", got.String(), s)
+		}
+	}
+}
+
+func TestPage_TextWithOptions(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 72 Td (Page 1) Tj ET BT /F1 12 Tf 72 700 Td (world) Tj ET "+
+		"BT /F1 12 Tf 72 720 Td (Hello,) Tj ET BT /F1 12 Tf 500 760 Td (Header) Tj ET"))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	got, err := p.TextWithOptions(context.Background(), text.BuilderOptions{ReadingOrder: true})
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Header\n\nHello,\nworld\n\nPage 1"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	if got, err = p.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Page 1\n\nworld\n\nHello,\n\nHeader"; got.String() != want {
+		t.Errorf("got text in content order %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_unknownFont(t *testing.T) {
+	testCases := map[string]string{
+		"unknown font": "BT /F2 12 Tf 72 720 Td (Hello) Tj ET",
+		"no font":      "BT 72 720 Td (Hello) Tj ET",
+		"malformed Tf": "BT 1 12 Tf 72 720 Td (Hello) Tj ET",
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, textPDF(content))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestReader_brokenFont(t *testing.T) {
+	testCases := map[string]string{
+		"bad Differences":         "<</Type /Font /Subtype /Type1 /BaseFont /Watermark /Encoding <</Differences [300 /a]>>>>",
+		"bad Differences element": "<</Type /Font /Subtype /Type1 /BaseFont /Watermark /Encoding <</Differences [97 (a)]>>>>",
+		"bad ToUnicode":           "<</Type /Font /Subtype /Type0 /BaseFont /Watermark /Encoding /Identity-H /ToUnicode 7 0 R>>",
+		"malformed ToUnicode":     "<</Type /Font /Subtype /Type0 /BaseFont /Watermark /Encoding /Identity-H /ToUnicode 8 0 R>>",
+		"unsupported encoding":    "<</Type /Font /Subtype /Type0 /BaseFont /Watermark /Encoding /Identity-H>>",
+	}
+
+	for name, broken := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R /F2 6 0 R>>>>>>",
+				stream("BT /F2 12 Tf 72 720 Td (DRAFT) Tj ET BT /F1 12 Tf 72 700 Td (Hello) Tj ET"),
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+				broken,
+				stream("1 beginbfchar <01> <0041> endbfchar 1 endbfrange"),
+				stream("1 beginbfchar <01> <0041> endbfchar ] >> ("),
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if !strings.HasSuffix(got.String(), "Hello") {
+				t.Errorf("got text %q, want it to end with %q", got.String(), "Hello")
+			}
+		})
+	}
+}
+
+func TestReader_inheritedFont(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1 /Resources <</Font <</F1 5 0 R>>>>>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (\\200) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "\u20ac"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_inheritedResources(t *testing.T) {
+	const (
+		helvetica = "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>"
+		symbol    = "<</Type /Font /Subtype /Type1 /BaseFont /Symbol>>"
+	)
+	testCases := map[string]struct {
+		root, node, page string // the resources of the page and its ancestors
+		form             string // a form XObject, object 7
+		content          string
+		want             string
+	}{
+		"root only": {
+			root:    "<</Font <</F1 5 0 R>>>>",
+			content: "BT /F1 12 Tf 72 720 Td (\\200) Tj ET",
+			want:    "\u20ac",
+		},
+		"split between page and ancestors": {
+			root:    "<</Font <</F1 6 0 R /F2 5 0 R>>>>",
+			page:    "<</Font <</F1 5 0 R>>>>",
+			content: "BT /F1 12 Tf 72 720 Td (\\200) Tj /F2 12 Tf ( a) Tj ET",
+			want:    "\u20ac a",
+		},
+		"nearest wins": {
+			root:    "<</Font <</F1 6 0 R>>>>",
+			node:    "<</Font <</F1 5 0 R>>>>",
+			content: "BT /F1 12 Tf 72 720 Td (\\200) Tj ET",
+			want:    "\u20ac",
+		},
+		"form with its own resources": {
+			page:    "<</Font <</F1 6 0 R>> /XObject <</X1 7 0 R>>>>",
+			form:    "<</Type /XObject /Subtype /Form /BBox [0 0 612 792] /Resources <</Font <</F1 5 0 R>>>> /Length 35>>\nstream\nBT /F1 12 Tf 72 720 Td (\\200) Tj ET\nendstream",
+			content: "/X1 Do",
+			want:    "\u20ac",
+		},
+		"form without resources": {
+			page:    "<</Font <</F1 5 0 R>> /XObject <</X1 7 0 R>>>>",
+			form:    "<</Type /XObject /Subtype /Form /BBox [0 0 612 792] /Length 35>>\nstream\nBT /F1 12 Tf 72 720 Td (\\200) Tj ET\nendstream",
+			content: "/X1 Do",
+			want:    "\u20ac",
+		},
+		"form drawing itself": {
+			page:    "<</Font <</F1 5 0 R>> /XObject <</X1 7 0 R>>>>",
+			form:    "<</Type /XObject /Subtype /Form /BBox [0 0 612 792] /Length 42>>\nstream\n/X1 Do BT /F1 12 Tf 72 720 Td (\\200) Tj ET\nendstream",
+			content: "/X1 Do",
+			want:    "\u20ac",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				fmt.Sprintf("<</Type /Pages /Kids [3 0 R] /Count 1 /Resources %s>>", orNull(tc.root)),
+				fmt.Sprintf("<</Type /Pages /Parent 2 0 R /Kids [4 0 R] /Count 1 /Resources %s>>", orNull(tc.node)),
+				fmt.Sprintf("<</Type /Page /Parent 3 0 R /Contents 8 0 R /Resources %s>>", orNull(tc.page)),
+				helvetica,
+				symbol,
+				orNull(tc.form),
+				stream(tc.content),
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_formDraws(t *testing.T) {
+	// Each form draws the next twice, so the last would be drawn 2^31 times.
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</XObject <</X 5 0 R>>>>>>",
+		stream("/X Do"),
+	}
+	for i := range maxFormDepth - 1 {
+		objs = append(objs, fmt.Sprintf("<</Type /XObject /Subtype /Form /BBox [0 0 612 792] "+
+			"/Resources <</XObject <</X %d 0 R>>>> /Length 11>>\nstream\n/X Do /X Do\nendstream", 6+i))
+	}
+	objs = append(objs, "<</Type /XObject /Subtype /Form /BBox [0 0 612 792] /Length 0>>\nstream\n\nendstream")
+	r := openPDF(t, buildPDF(objs...))
+
+	if _, err := r.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	var warnings []string
+	for _, w := range r.Warnings() {
+		warnings = append(warnings, w.Message)
+	}
+	if want := fmt.Sprintf("skipping form XObjects after drawing %d", maxFormDraws); !slices.Contains(warnings, want) {
+		t.Errorf("got warnings %q, want %q", warnings, want)
+	}
+}
+
+func TestReader_ExtGStateFont(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</ExtGState <</GS1 <</Type /ExtGState /LW 2 /Font [5 0 R 12]>>>>>>>>",
+		stream("BT /GS0 gs /GS1 gs 72 720 Td (\\200) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "\u20ac"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+// orNull returns s, or null if it is empty.
+func orNull(s string) string {
+	if s == "" {
+		return "null"
+	}
+	return s
+}
+
+func TestReader_Differences(t *testing.T) {
+	testCases := map[string]struct {
+		encoding string
+		want     string
+	}{
+		"no base encoding": {
+			encoding: "<</Type /Encoding /Differences [183 /bullet]>>",
+			want:     "\u2022 \u2019s\u2014",
+		},
+		"StandardEncoding": {
+			encoding: "<</Type /Encoding /BaseEncoding /StandardEncoding /Differences [183 /bullet]>>",
+			want:     "\u2022 \u2019s\u2014",
+		},
+		"WinAnsiEncoding": {
+			encoding: "<</Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [183 /bullet]>>",
+			want:     "\u2022 's\u00d0",
+		},
+		"several ranges": {
+			encoding: "<</Type /Encoding /Differences [39 /quotesingle 115 /scaron /zcaron 183 /bullet 208 /endash]>>",
+			want:     "\u2022 '\u0161\u2013",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R>>>>>>",
+				stream("BT /F1 12 Tf 72 720 Td (\\267 's\\320) Tj ET"),
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding "+tc.encoding+">>",
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_noEncoding(t *testing.T) {
+	// A nonsymbolic standard font without an Encoding has the built-in StandardEncoding.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R>>>>>>",
+		stream("BT /F1 12 Tf 72 720 Td (\\267 's\\320) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+	))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "\u2022 \u2019s\u2014"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	if w := r.Warnings(); len(w) != 0 {
+		t.Errorf("got warnings %v, want none", w)
+	}
+}
+
+func TestReader_unmappedCodes(t *testing.T) {
+	// F1 leaves 0x81 undefined, and F2 has no text for any of its codes.
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R /F2 6 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (A\x81B) Tj /F2 12 Tf <0041> Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		"<</Type /Font /Subtype /Type0 /BaseFont /MyFont /Encoding /Identity-H /DescendantFonts [<</Subtype /CIDFontType2>>]>>",
+	)
+	testCases := []struct {
+		policy UnmappedPolicy
+		want   string
+	}{
+		{UnmappedReplace, "A\uFFFDB\uFFFD"},
+		{UnmappedDrop, "AB"},
+		{UnmappedHex, "A\uFFFD<81>B\uFFFD<0041>"},
+	}
+	for _, tc := range testCases {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithUnmappedCodes(tc.policy))
+		if err != nil {
+			t.Fatal("failed to open PDF:", err)
+		}
+		got, err := r.Text()
+		if err != nil {
+			t.Fatalf("policy %d: failed to read text: %v", tc.policy, err)
+		}
+		if got.String() != tc.want {
+			t.Errorf("policy %d: got text %q, want %q", tc.policy, got.String(), tc.want)
+		}
+	}
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithUnmappedCodes(UnmappedError))
+	if err != nil {
+		t.Fatal("failed to open PDF:", err)
+	}
+	_, err = r.Text()
+	var ue *UnmappedCodeError
+	if !errors.As(err, &ue) {
+		t.Fatalf("got error %v, want an UnmappedCodeError", err)
+	}
+	if want := (UnmappedCodeError{Font: "Helvetica", Code: "\x81", Page: 1}); *ue != want {
+		t.Errorf("got error %+v, want %+v", *ue, want)
+	}
+	if want := "page 1: font Helvetica has no text for code <81>"; ue.Error() != want {
+		t.Errorf("got message %q, want %q", ue.Error(), want)
+	}
+}
+
+func TestReader_glyphNames(t *testing.T) {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal("failed to parse font:", err)
+	}
+	gid, err := f.GlyphIndex(nil, 'Q')
+	if err != nil {
+		t.Fatal("failed to find glyph:", err)
+	}
+
+	testCases := map[string]struct {
+		differences string
+		content     string
+		want        string
+	}{
+		"Adobe Glyph List":    {"/gcaron /afii10017 /bullet", "(abc)", "\u01e7\u0410\u2022"},
+		"uni":                 {"/uni20AC /uni0066006C /uniD800", "(abc)", "\u20acflc"},
+		"u":                   {"/u1D400 /u20AC /u110000", "(abc)", "\U0001d400\u20acc"},
+		"ligature and suffix": {"/f_f_i /a.sc /A.swash", "(abc)", "ffiaA"},
+		"glyph index":         {fmt.Sprintf("/g%d /gid%d /g99999", gid, gid), "(abc)", "QQc"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Contents 4 0 R /Resources <</Font <</F1 5 0 R>>>>>>",
+				stream("BT /F1 12 Tf 72 720 Td "+tc.content+" Tj ET"),
+				"<</Type /Font /Subtype /TrueType /BaseFont /ABCDEF+GoRegular /FontDescriptor 6 0 R "+
+					"/Encoding <</Type /Encoding /Differences [97 "+tc.differences+"]>>>>",
+				"<</Type /FontDescriptor /FontName /ABCDEF+GoRegular /FontFile2 7 0 R>>",
+				fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(goregular.TTF), goregular.TTF),
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_unbalancedOperators(t *testing.T) {
+	testCases := map[string]string{
+		"Q with empty stack":          "Q Q BT /F1 12 Tf 72 720 Td (Hello) Tj ET q Q Q",
+		"Tj before BT":                "/F1 12 Tf (Hello) Tj",
+		"Td before BT":                "/F1 12 Tf 72 720 Td (Hel) Tj [(lo)] TJ",
+		"missing ET at end of stream": "BT /F1 12 Tf 72 720 Td (Hello) Tj",
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, textPDF(content))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestReader_degenerateScaling(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		want    string
+	}{
+		"zero Tz": {
+			content: "BT /F1 12 Tf 72 720 Td (Hello) Tj 0 Tz (,) Tj 100 Tz ( world) Tj ET",
+			want:    "Hello, world",
+		},
+		"negative Tz": {
+			content: "BT /F1 12 Tf 72 720 Td (Hello) Tj -50 Tz (,) Tj 100 Tz ( world) Tj ET",
+			want:    "Hello, world",
+		},
+		"singular cm": {
+			content: "BT /F1 12 Tf 72 720 Td (Hello) Tj ET q 0 0 0 0 0 0 cm BT (point) Tj ET Q " +
+				"BT /F1 12 Tf 72 720 Td (Hello) Tj ( world) Tj ET",
+			want: "Hello\n\npoint\n\nHello world",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, textPDF(tc.content))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_rotatedText(t *testing.T) {
+	// Each shows two words 1.5 em apart on the page, a line below them, and a
+	// paragraph further below, along and across baselines at an angle.
+	const lines = "[(Hello) -1500 (world)] TJ 0 -14 Td (next) Tj 0 -40 Td (para) Tj ET"
+	testCases := map[string]string{
+		"30 degrees":       "BT /F1 12 Tf 0.8660254 0.5 -0.5 0.8660254 100 100 Tm " + lines,
+		"90 degrees":       "BT /F1 12 Tf 0 1 -1 0 300 100 Tm " + lines,
+		"rotated CTM":      "q 0.8660254 0.5 -0.5 0.8660254 100 100 cm BT /F1 12 Tf " + lines + " Q",
+		"skewed":           "BT /F1 12 Tf 1 0 0.5 1 72 720 Tm " + lines,
+		"rotated and Tz":   "BT /F1 12 Tf 50 Tz 0.8660254 0.5 -0.5 0.8660254 100 100 Tm [(Hello) -3000 (world)] TJ 0 -14 Td (next) Tj 0 -40 Td (para) Tj ET",
+		"rotated and skew": "BT /F1 12 Tf 0 1 -1 0.3 300 100 Tm " + lines,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, textPDF(content))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello world\nnext\n\npara"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestReader_duplicateText(t *testing.T) {
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Report) Tj ET BT /F1 12 Tf 72.4 720.2 Td (Report) Tj ET " +
+		"BT /F1 12 Tf 72 700 Td (for) Tj 0.3 0 Td (for) Tj 30 0 Td (2024) Tj ET")
+
+	testCases := map[string]struct {
+		opts []Option
+		want string
+	}{
+		"default":      {want: "ReportReport\nforfor 2024"},
+		"deduplicated": {opts: []Option{WithDuplicateText(0.5)}, want: "Report\nfor 2024"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_verticalWriting(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange " +
+		"4 beginbfchar <0001> <65E5> <0002> <672C> <0003> <8A9E> <0004> <6587> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	vertPDF := func(enc string) []byte {
+		return buildPDF(
+			"<</Type /Catalog /Pages 2 0 R>>",
+			"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+			"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+			// Two columns, right to left, the first drawn a glyph at a time.
+			stream("BT /F1 12 Tf 1 0 0 1 500 700 Tm <0001> Tj <0002> Tj 1 0 0 1 482 700 Tm <00030004> Tj ET"),
+			"<</Type /Font /Subtype /Type0 /BaseFont /Mincho /Encoding "+enc+
+				" /DescendantFonts [6 0 R] /ToUnicode 7 0 R>>",
+			"<</Type /Font /Subtype /CIDFontType0 /BaseFont /Mincho /DW 1000 /W2 [4 [-900 500 880]]>>",
+			stream(cmap),
+			"<</Type /CMap /CMapName /Custom-V /WMode 1 /UseCMap /Identity-H /Length 0>>\nstream\n\nendstream",
+		)
+	}
+
+	testCases := map[string]struct {
+		data []byte
+		want string
+	}{
+		"horizontal": {data: vertPDF("/Identity-H"), want: "\u65e5\u672c\u8a9e\u6587"},
+		"vertical":   {data: vertPDF("/Identity-V"), want: "\u65e5\u672c\n\u8a9e\u6587"},
+		"WMode":      {data: vertPDF("8 0 R"), want: "\u65e5\u672c\n\u8a9e\u6587"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, tc.data)
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_embeddedCMap(t *testing.T) {
+	// Single-byte codes, mapped to CIDs whose widths differ from those of the codes.
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"/CMapName /Custom def 1 begincodespacerange <00> <FF> endcodespacerange " +
+		"1 beginnotdefrange <00> <1F> 1 endnotdefrange " +
+		"1 begincidrange <41> <43> 100 endcidrange " +
+		"2 begincidchar <20> 3 <44> 200 endcidchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"1 begincodespacerange <00> <FF> endcodespacerange " +
+		"1 beginbfrange <41> <44> <0041> endbfrange 1 beginbfchar <20> <0020> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 10 Tf 72 720 Td (AB D) Tj ET"),
+		"<</Type /Font /Subtype /Type0 /BaseFont /Custom /Encoding 7 0 R /DescendantFonts [6 0 R] /ToUnicode 8 0 R>>",
+		"<</Type /Font /Subtype /CIDFontType2 /BaseFont /Custom /DW 0 /W [3 [250] 100 101 500 200 [1000]]>>",
+		stream(cmap),
+		stream(toUnicode),
+	))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	var got runs
+	if err := p.Render(&got); err != nil {
+		t.Fatal("failed to render page:", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d runs, want 1", len(got))
+	}
+	if want := "AB D"; got[0].Text != want {
+		t.Errorf("got text %q, want %q", got[0].Text, want)
+	}
+	if want := 22.5; got[0].W != want {
+		t.Errorf("got width %v, want %v", got[0].W, want)
+	}
+}
+
+func TestReader_CIDToGIDMap(t *testing.T) {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal("failed to parse font:", err)
+	}
+	gid := func(r rune) int {
+		g, err := f.GlyphIndex(nil, r)
+		if err != nil {
+			t.Fatal("failed to find glyph:", err)
+		}
+		return int(g)
+	}
+	h, i := gid('H'), gid('i')
+
+	testCases := map[string]struct {
+		cidToGID string
+		content  string
+	}{
+		"Identity": {
+			cidToGID: "/Identity",
+			content:  fmt.Sprintf("<%04X%04X>", h, i),
+		},
+		"absent": {
+			content: fmt.Sprintf("<%04X%04X>", h, i),
+		},
+		"stream": {
+			cidToGID: "8 0 R",
+			content:  "<00010002>",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var cidToGID string
+			if tc.cidToGID != "" {
+				cidToGID = "/CIDToGIDMap " + tc.cidToGID
+			}
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+				stream("BT /F1 12 Tf 72 720 Td "+tc.content+" Tj ET"),
+				"<</Type /Font /Subtype /Type0 /BaseFont /GoRegular /Encoding /Identity-H /DescendantFonts [6 0 R]>>",
+				"<</Type /Font /Subtype /CIDFontType2 /BaseFont /GoRegular /FontDescriptor 7 0 R "+cidToGID+">>",
+				"<</Type /FontDescriptor /FontName /GoRegular /FontFile2 9 0 R>>",
+				stream(string([]byte{0, 0, byte(h >> 8), byte(h), byte(i >> 8), byte(i)})),
+				fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(goregular.TTF), goregular.TTF),
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hi"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestReader_bidi(t *testing.T) {
+	// A line of Hebrew with a number in it, drawn left to right in single-byte codes that
+	// a ToUnicode CMap maps to Hebrew letters: "שלום 42".
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"1 begincodespacerange <00> <FF> endcodespacerange " +
+		"4 beginbfchar <61> <05E9> <62> <05DC> <63> <05D5> <64> <05DD> endbfchar " +
+		"1 beginbfrange <20> <39> <0020> endbfrange " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (42 dcba) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Hebrew /ToUnicode 6 0 R>>",
+		stream(cmap),
+	)
+
+	testCases := map[string]struct {
+		opts []Option
+		want string
+	}{
+		"visual":  {want: "42 \u05dd\u05d5\u05dc\u05e9"},
+		"logical": {opts: []Option{WithBidi()}, want: "\u05e9\u05dc\u05d5\u05dd 42"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_BuilderOptions(t *testing.T) {
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 10 Tf 72 720 Td (W) Tj 5 0 Td (I) Tj 5 0 Td (D) Tj 5 0 Td (E) Tj 0 -21 Td (next) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding "+
+			"/FirstChar 32 /LastChar 32 /Widths [250]>>",
+	)
+
+	testCases := map[string]struct {
+		opts text.BuilderOptions
+		want string
+	}{
+		"default":     {want: "WIDE\n\nnext"},
+		"space width": {opts: text.BuilderOptions{UseSpaceWidth: true}, want: "W I D E\n\nnext"},
+		"paragraph gap": {
+			opts: text.BuilderOptions{ParagraphGap: 2.5},
+			want: "WIDE\nnext",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithBuilderOptions(tc.opts))
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_Document(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R /PageLabels <</Nums [0 <</S /r>> 1 <</S /D>>]>>>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 7 0 R>>>> /Contents 5 0 R>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 7 0 R>>>> /Contents 6 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (Preface) Tj ET"),
+		stream("BT /F1 12 Tf 72 720 Td (Body) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	))
+
+	doc, err := r.Document()
+	if err != nil {
+		t.Fatal("failed to read document:", err)
+	}
+	want := text.Document{Pages: []text.Page{
+		{Number: 1, Label: "i", Text: text.Text{{Size: 12, Page: 1, Content: "Preface"}}},
+		{Number: 2, Label: "1", Text: text.Text{{Size: 12, Page: 2, Content: "Body"}}},
+	}}
+	if diff := cmp.Diff(doc, want); diff != "" {
+		t.Error("document did not match expectation:", diff)
+	}
+	if got, err := r.Text(); err != nil || got.String() != doc.String() {
+		t.Errorf("got document text %q, want the text %q, %v", doc.String(), got.String(), err)
+	}
+}
+
+func TestReader_wrongStreamLength(t *testing.T) {
+	const content = "BT /F1 12 Tf 72 720 Td (Hello, xendstream) Tj ET"
+	testCases := map[string]string{
+		"too long":      fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(content)+100, content),
+		"too short":     fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(content)-20, content),
+		"negative":      fmt.Sprintf("<</Length -1>>\nstream\n%s\r\nendstream", content),
+		"missing":       fmt.Sprintf("<<>>\nstream\n%s\rendstream", content),
+		"indirect null": fmt.Sprintf("<</Length 99 0 R>>\nstream\n%s\nendstream", content),
+	}
+
+	for name, strm := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+				strm,
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+			))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, xendstream"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestReader_malformedFlateDecode(t *testing.T) {
+	// Repeated, so that it is compressed, rather than stored as it is in the zlib data.
+	content := strings.Repeat("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET\n", 10)
+	compress := func(data []byte, raw bool) []byte {
+		var b bytes.Buffer
+		if raw {
+			fw, _ := flate.NewWriter(&b, flate.DefaultCompression)
+			fw.Write(data)
+			fw.Close()
+		} else {
+			zw := zlib.NewWriter(&b)
+			zw.Write(data)
+			zw.Close()
+		}
+		return b.Bytes()
+	}
+	testCases := map[string]struct {
+		data    []byte
+		warning string
+	}{
+		"not compressed":      {[]byte(content), "FlateDecode data is not compressed"},
+		"no zlib header":      {compress([]byte(content), true), "FlateDecode data has no zlib header"},
+		"compressed twice":    {compress(compress([]byte(content), false), false), "content stream is compressed twice"},
+		"binary, not deflate": {[]byte("\xff\xfe" + content), "FlateDecode data is not compressed"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data := buildPDF(
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+				"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+				fmt.Sprintf("<</Length %d /Filter /FlateDecode>>\nstream\n%s\nendstream", len(tc.data), tc.data),
+				"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+			)
+			r := openPDF(t, data)
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, world"; !strings.HasSuffix(got.String(), want) {
+				t.Errorf("got text %q, want it to end with %q", got.String(), want)
+			}
+			var warnings []string
+			for _, w := range r.Warnings() {
+				warnings = append(warnings, w.Message)
+			}
+			if !slices.Contains(warnings, tc.warning) {
+				t.Errorf("got warnings %q, want %q", warnings, tc.warning)
+			}
+
+			// Strictly, the data fails to decode, or decodes to data that is not text.
+			strict, err := NewReader(bytes.NewReader(data), int64(len(data)), WithStrictFilters())
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			if got, _ := strict.Text(); strings.Contains(got.String(), "Hello") {
+				t.Errorf("got text %q strictly, want none", got.String())
+			}
+		})
+	}
+}
+
+func TestReader_contentLikeZlibHeader(t *testing.T) {
+	// "(S" is a zlib header: the second content stream is not compressed twice.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 6 0 R>>>> /Contents [4 0 R 5 0 R]>>",
+		stream("BT /F1 12 Tf 72 720 Td"),
+		stream("(Summary) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	))
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Summary"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+	for _, w := range r.Warnings() {
+		t.Error("got warning:", w)
+	}
+}
+
+func TestValue_UndecodedFilters(t *testing.T) {
+	const jpeg = "\xff\xd8\xff\xe0 not really a JPEG \xff\xd9"
+	a85 := make([]byte, ascii85.MaxEncodedLen(len(jpeg)))
+	a85 = append(a85[:ascii85.Encode(a85, []byte(jpeg))], "~>"...)
+
+	testCases := map[string]struct {
+		strm string
+		want []string
+	}{
+		"none": {
+			strm: stream(jpeg),
+		},
+		"DCTDecode": {
+			strm: fmt.Sprintf("<</Length %d /Filter /DCTDecode>>\nstream\n%s\nendstream", len(jpeg), jpeg),
+			want: []string{"DCTDecode"},
+		},
+		"ASCII85Decode JPXDecode": {
+			strm: fmt.Sprintf("<</Length %d /Filter [/ASCII85Decode /JPXDecode]>>\nstream\n%s\nendstream", len(a85), a85),
+			want: []string{"JPXDecode"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF("<</Type /Catalog /Image 2 0 R>>", tc.strm))
+			v := r.trailerValue().Key("Root").Key("Image")
+
+			if diff := cmp.Diff(v.UndecodedFilters(), tc.want); diff != "" {
+				t.Error("undecoded filters did not match expectation:", diff)
+			}
+			got, err := io.ReadAll(v.Reader())
+			if err != nil {
+				t.Fatal("failed to read stream:", err)
+			}
+			if string(got) != jpeg {
+				t.Errorf("got stream data %q, want %q", got, jpeg)
+			}
+		})
+	}
+}
+
+func TestValue_Reader_ASCII85Decode(t *testing.T) {
+	// The data continues past the end-of-data marker, within Length, and the
+	// DecodeParms, which ASCII85Decode has none of, are ignored.
+	const a85 = "<~87cURD_*#T\nDfTZ)~>\r\n"
+	strm := fmt.Sprintf("<</Length %d /Filter /ASCII85Decode /DecodeParms <</Columns 4>>>>\nstream\n%s\nendstream", len(a85), a85)
+	r := openPDF(t, buildPDF("<</Type /Catalog /Data 2 0 R>>", strm))
+	v := r.trailerValue().Key("Root").Key("Data")
+
+	got, err := io.ReadAll(v.Reader())
+	if err != nil {
+		t.Fatal("failed to read stream:", err)
+	}
+	if want := "Hello, world"; string(got) != want {
+		t.Errorf("got stream data %q, want %q", got, want)
+	}
+}
+
+func TestValue_Reader_DecodeParms(t *testing.T) {
+	const text = "Hello, world"
+	deflate := func(data []byte) string {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		return z.String()
+	}
+	a85 := func(data string) string {
+		b := make([]byte, ascii85.MaxEncodedLen(len(data)))
+		return string(b[:ascii85.Encode(b, []byte(data))]) + "~>"
+	}
+	// The text in rows of 4 bytes, each the difference from the row above,
+	// after a byte of 2 for the PNG Up predictor.
+	var up []byte
+	prev := make([]byte, 4)
+	for row := 0; row < len(text); row += 4 {
+		up = append(up, 2)
+		for i, c := range []byte(text[row : row+4]) {
+			up = append(up, c-prev[i])
+			prev[i] = c
+		}
+	}
+	predicted, plain := deflate(up), deflate([]byte(text))
+	const parms = "<</Predictor 12 /Columns 4>>"
+
+	testCases := map[string]struct {
+		filter, parms, data string
+	}{
+		"name and dictionary":        {"/FlateDecode", parms, predicted},
+		"name and array":             {"/FlateDecode", "[" + parms + "]", predicted},
+		"array and dictionary":       {"[/FlateDecode]", parms, predicted},
+		"arrays":                     {"[/ASCII85Decode /FlateDecode]", "[null " + parms + "]", a85(predicted)},
+		"array and lone dictionary":  {"[/ASCII85Decode /FlateDecode]", parms, a85(plain)},
+		"empty array and dictionary": {"[]", parms, text},
+		"empty arrays":               {"[]", "[" + parms + "]", text},
+		"short array":                {"[/ASCII85Decode /FlateDecode]", "[null]", a85(plain)},
+		"no parameters":              {"[/ASCII85Decode /FlateDecode]", "", a85(plain)},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			hdr := "/Filter " + tc.filter
+			if tc.parms != "" {
+				hdr += " /DecodeParms " + tc.parms
+			}
+			strm := fmt.Sprintf("<</Length %d %s>>\nstream\n%s\nendstream", len(tc.data), hdr, tc.data)
+			r := openPDF(t, buildPDF("<</Type /Catalog /Data 2 0 R>>", strm))
+
+			got, err := io.ReadAll(r.trailerValue().Key("Root").Key("Data").Reader())
+			if err != nil {
+				t.Fatal("failed to read stream:", err)
+			}
+			if string(got) != text {
+				t.Errorf("got stream data %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestValue_Filters(t *testing.T) {
+	const data = "s4IA0!!!!~>"
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Image 2 0 R /Wrong 4 0 R /Plain 5 0 R /Empty 6 0 R>>",
+		fmt.Sprintf("<</Length 3 0 R /Filter [/ASCII85Decode /DCTDecode] /DecodeParms [null <</ColorTransform 1>>]>>\nstream\n%s\nendstream", data),
+		fmt.Sprint(len(data)),
+		fmt.Sprintf("<</Length 3 /Filter /FlateDecode>>\nstream\n%s\nendstream", data),
+		stream(data),
+		fmt.Sprintf("<</Length %d /Filter [] /DecodeParms <</Columns 4>>>>\nstream\n%s\nendstream", len(data), data),
+	))
+	root := r.trailerValue().Key("Root")
+	empty := root.Key("Empty")
+
+	img := root.Key("Image")
+	var names []string
+	for _, f := range img.Filters() {
+		names = append(names, f.Name)
+	}
+	if diff := cmp.Diff(names, []string{"ASCII85Decode", "DCTDecode"}); diff != "" {
+		t.Error("filters did not match expectation:", diff)
+	}
+	if f := img.Filters(); !f[0].Params.IsNull() || f[1].Params.Key("ColorTransform").Int64() != 1 {
+		t.Errorf("got parameters %v and %v, want null and the ColorTransform dictionary", f[0].Params, f[1].Params)
+	}
+	if got, want := img.EncodedLength(), int64(len(data)); got != want {
+		t.Errorf("got encoded length %d of the indirect Length, want %d", got, want)
+	}
+
+	wrong := root.Key("Wrong")
+	if f := wrong.Filters(); len(f) != 1 || f[0].Name != "FlateDecode" || !f[0].Params.IsNull() {
+		t.Errorf("got filters %v, want FlateDecode without parameters", f)
+	}
+	if got, want := wrong.EncodedLength(), int64(len(data)); got != want || wrong.DeclaredLength() != 3 {
+		t.Errorf("got encoded length %d and declared length %d, want %d and 3", got, wrong.DeclaredLength(), want)
+	}
+
+	if f := empty.Filters(); f != nil {
+		t.Errorf("got filters %v of an empty Filter array, want none", f)
+	}
+	for _, p := range r.Validate() {
+		if p.ID == 6 {
+			t.Error("got a problem with the empty Filter array:", p)
+		}
+	}
+
+	if f := root.Key("Plain").Filters(); f != nil {
+		t.Errorf("got filters %v of a stream without any, want none", f)
+	}
+	if f, n := root.Filters(), root.EncodedLength(); f != nil || n != 0 {
+		t.Errorf("got filters %v and encoded length %d of a dictionary, want none", f, n)
+	}
+}
+
+func TestValue_ReaderN(t *testing.T) {
+	const text = "Hello, world"
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte(text))
+	zw.Close()
+	a85 := make([]byte, ascii85.MaxEncodedLen(z.Len()))
+	a85 = append(a85[:ascii85.Encode(a85, z.Bytes())], "~>"...)
+
+	strm := fmt.Sprintf("<</Length %d /Filter [/ASCII85Decode /FlateDecode]>>\nstream\n%s\nendstream", len(a85), a85)
+	r := openPDF(t, buildPDF("<</Type /Catalog /Data 2 0 R>>", strm))
+	v := r.trailerValue().Key("Root").Key("Data")
+
+	if got, want := v.DeclaredLength(), int64(len(a85)); got != want {
+		t.Errorf("got declared length %d, want %d", got, want)
+	}
+	for _, tc := range []struct {
+		rc   io.ReadCloser
+		want string
+	}{
+		{v.RawReader(), string(a85)},
+		{v.ReaderN(1), z.String()},
+		{v.ReaderN(2), text},
+		{v.ReaderN(-1), text},
+		{v.Reader(), text},
+	} {
+		got, err := io.ReadAll(tc.rc)
+		if err != nil {
+			t.Fatal("failed to read stream:", err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("got stream data %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestValue_Reader_CCITTFaxDecode(t *testing.T) {
+	const width, height = 153, 55
+	f, err := os.Open("testdata/bw-gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal("failed to decode image:", err)
+	}
+	// The expected samples: one bit per pixel, 1 for white, rows padded to a byte.
+	var want []byte
+	for y := range height {
+		row := make([]byte, (width+7)/8)
+		for x := range width {
+			if img.(*image.Gray).GrayAt(x, y).Y >= 0x80 {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		want = append(want, row...)
+	}
+
+	// A fax of one page for each encoding of the image.
+	pages := map[string]string{
+		"bw-gopher.ccitt_group4":          "/K -1 /Columns 153",
+		"bw-gopher-aligned.ccitt_group3":  "/K 0 /Columns 153 /Rows 55 /EncodedByteAlign true",
+		"bw-gopher-inverted.ccitt_group4": "/K -1 /Columns 153 /Rows 55 /BlackIs1 true",
+	}
+	objs := []string{"<</Type /Catalog /Pages 2 0 R>>", ""}
+	var kids []string
+	for file, parms := range pages {
+		data, err := os.ReadFile("testdata/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs,
+			fmt.Sprintf("<</Type /Page /Parent 2 0 R /Resources <</XObject <</Im1 %d 0 R>>>>>>", len(objs)+2),
+			fmt.Sprintf("<</Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 1 /Length %d "+
+				"/Filter /CCITTFaxDecode /DecodeParms <<%s>>>>\nstream\n%s\nendstream", width, height, len(data), parms, data),
+		)
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objs)-1))
+	}
+	objs[1] = fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(kids, " "), len(kids))
+	r := openPDF(t, buildPDF(objs...))
+
+	for i := range r.NPages() {
+		page := r.trailerValue().Key("Root").Key("Pages").Key("Kids").Index(i)
+		got, err := io.ReadAll(page.Key("Resources").Key("XObject").Key("Im1").Reader())
+		if err != nil {
+			t.Errorf("page %d: failed to read image: %v", i+1, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("page %d: image samples did not match expectation", i+1)
+		}
+	}
+}
+
+func TestValue_Reader_CCITTFaxDecode_EndOfBlock(t *testing.T) {
+	data, err := os.ReadFile("testdata/bw-gopher.ccitt_group4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The last 3 bytes end the end-of-block pattern, whose first 3 bits end the byte before.
+	blockless := data[:len(data)-3]
+	image := func(data []byte, parms string) Value {
+		r := openPDF(t, buildPDF(
+			"<</Type /Catalog>>",
+			fmt.Sprintf("<</Type /XObject /Subtype /Image /Width 153 /Height 55 /BitsPerComponent 1 /Length %d "+
+				"/Filter /CCITTFaxDecode /DecodeParms <</K -1 /Columns 153 %s>>>>\nstream\n%s\nendstream", len(data), parms, data),
+		))
+		return r.resolve(types.Objptr{}, types.Objptr{ID: 2})
+	}
+	want, err := io.ReadAll(image(data, "").Reader())
+	if err != nil {
+		t.Fatal("failed to read image:", err)
+	}
+
+	testCases := map[string]struct {
+		data  []byte
+		parms string
+		err   bool
+	}{
+		"end of block":                      {data: data},
+		"end of block, EndOfBlock false":    {data: data, parms: "/EndOfBlock false"},
+		"no end of block":                   {data: blockless, err: true},
+		"no end of block, EndOfBlock false": {data: blockless, parms: "/EndOfBlock false"},
+		"no end of block, Rows":             {data: blockless, parms: "/Rows 55"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := io.ReadAll(image(tc.data, tc.parms).Reader())
+			if tc.err {
+				if err == nil {
+					t.Error("read image without an error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to read image:", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %d bytes of samples, want those of %d bytes of the image with its end of block", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestValue_Reader_CCITTFaxDecode_mixed(t *testing.T) {
+	// Mixed one- and two-dimensional Group 3 data, of K greater than 0, is unsupported.
+	data, err := os.ReadFile("testdata/bw-gopher-aligned.ccitt_group3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog>>",
+		fmt.Sprintf("<</Type /XObject /Subtype /Image /Width 153 /Height 55 /BitsPerComponent 1 /Length %d "+
+			"/Filter /CCITTFaxDecode /DecodeParms <</K 4 /Columns 153 /Rows 55>>>>\nstream\n%s\nendstream", len(data), data),
+	))
+
+	_, err = io.ReadAll(r.resolve(types.Objptr{}, types.Objptr{ID: 2}).Reader())
+	if !errors.Is(err, ErrUnsupportedFeature) || !strings.Contains(err.Error(), "K 4") {
+		t.Errorf("got error %v, want one of ErrUnsupportedFeature for K 4", err)
+	}
+}
+
+func TestReader_missingEndobj(t *testing.T) {
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	// Blank out every endobj, keeping the xref offsets intact.
+	data = bytes.ReplaceAll(data, []byte("endobj"), []byte("      "))
+	r := openPDF(t, data)
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestReader_hybridReference(t *testing.T) {
+	var (
+		b       bytes.Buffer
+		offsets = map[int]int{}
+	)
+	obj := func(id int, body string) {
+		offsets[id] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+
+	b.WriteString("%PDF-1.7\n")
+	obj(1, "<</Type /Catalog /Pages 2 0 R>>")
+	obj(3, "<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 6 0 R>>>> /Contents 4 0 R>>")
+	obj(4, stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"))
+	// The page tree is only reachable through the cross-reference stream.
+	pages := "<</Type /Pages /Kids [3 0 R] /Count 1>>"
+	obj(5, fmt.Sprintf("<</Type /ObjStm /N 1 /First 4 /Length %d>>\nstream\n2 0 %s\nendstream", 4+len(pages), pages))
+	obj(6, "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+	entries := "\x02\x00\x05\x00" // Object 2 is object 0 in stream 5.
+	obj(7, fmt.Sprintf("<</Type /XRef /Size 8 /W [1 2 1] /Index [2 1] /Length %d>>\nstream\n%s\nendstream", len(entries), entries))
+
+	xref := b.Len()
+	b.WriteString("xref\n0 8\n0000000000 65535 f \n")
+	for id := 1; id < 8; id++ {
+		if off, ok := offsets[id]; ok {
+			fmt.Fprintf(&b, "%010d 00000 n \n", off)
+		} else {
+			b.WriteString("0000000000 00000 f \n")
+		}
+	}
+	fmt.Fprintf(&b, "trailer\n<</Size 8 /Root 1 0 R /XRefStm %d>>\nstartxref\n%d\n%%%%EOF\n", offsets[7], xref)
+
+	r := openPDF(t, b.Bytes())
+	if got := r.NPages(); got != 1 {
+		t.Fatalf("got %d pages, want 1", got)
+	}
+	got, err := r.Text()
+	if err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if want := "Hello, world"; got.String() != want {
+		t.Errorf("got text %q, want %q", got.String(), want)
+	}
+}
+
+func TestNewReader_header(t *testing.T) {
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	testCases := map[string]bool{
+		"%PDF-1.0": true,
+		"%PDF-1.7": true,
+		"%PDF-2.0": true,
+		"%PDF-1.8": false,
+		"%PDF-3.0": false,
+		"%PDF-2.x": false,
+	}
+
+	for header, ok := range testCases {
+		t.Run(header, func(t *testing.T) {
+			data := append([]byte(header), data[len(header):]...)
+			_, err := NewReaderFromBytes(data)
+			if ok && err != nil {
+				t.Errorf("failed to open PDF: %v", err)
+			}
+			if !ok && (err == nil || err.Error() != "not a PDF file: invalid header") {
+				t.Errorf("got error %v, want invalid header", err)
+			}
+		})
+	}
+}
+
+func TestReader_Info_UTF8(t *testing.T) {
+	// PDF 2.0 text strings may be in UTF-8, after its byte order mark.
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		"<</Title <EFBBBF4772C3BC c39f65>>>",
+	)
+	data = bytes.Replace(data, []byte("%PDF-1.7"), []byte("%PDF-2.0"), 1)
+	data = bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Info 3 0 R"), 1)
+
+	r := openPDF(t, data)
+	if got, want := r.Info()["Title"], "Grüße"; got != want {
+		t.Errorf("got title %q, want %q", got, want)
+	}
+}
+
+func TestNewReader_xrefTableTolerance(t *testing.T) {
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	i := bytes.Index(data, []byte("xref\n"))
+	j := bytes.Index(data, []byte("trailer\n"))
+	// The header and six entries of the table, each with its end of line.
+	lines := strings.SplitAfter(string(data[i+len("xref\n"):j]), "\n")[:7]
+	entries := lines[1:]
+
+	testCases := map[string]string{
+		"19-byte entries": "0 6\n" + strings.ReplaceAll(strings.Join(entries, ""), " \n", "\n"),
+		"CRLF and comments": "% comment\r\n0 3\r\n" + strings.Join(entries[:3], "") +
+			"%comment between subsections\n3 3 % header comment\n" + strings.Join(entries[3:], ""),
+		"no ends of line":   "0 6 " + strings.ReplaceAll(strings.Join(entries, ""), "\n", ""),
+		"extra white space": "  0   6  \n\n" + strings.ReplaceAll(strings.Join(entries, "\n"), " ", "  \t"),
+		"malformed entry":   "0 6\n0000000000 65535 x \n" + strings.Join(entries[1:], ""),
+		"miscounted header": "0 4\n" + strings.Join(entries, ""),
+		"junk line":         "0 6\n" + strings.Join(entries[:3], "") + "junk\n" + strings.Join(entries[3:], ""),
+	}
+
+	for name, table := range testCases {
+		t.Run(name, func(t *testing.T) {
+			pdf := slices.Concat(data[:i], []byte("xref\n"+table), data[j:])
+			r := openPDF(t, pdf)
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, world"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestNewReader_xrefStreamBounds(t *testing.T) {
+	// xrefStream returns a file of a catalog and a cross-reference stream with the given
+	// entries, in which the offsets of objects 1 and 2 are written as @1 and @2.
+	xrefStream := func(hdr, entries string) []byte {
+		var b bytes.Buffer
+		b.WriteString("%PDF-1.7\n1 0 obj <</Type /Catalog>> endobj\n")
+		xref := b.Len()
+		entries = strings.NewReplacer("@1", off16(9), "@2", off16(xref)).Replace(entries)
+		fmt.Fprintf(&b, "2 0 obj <</Type /XRef /Root 1 0 R %s /Length %d>> stream\n%s\nendstream endobj\n", hdr, len(entries), entries)
+		fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+		return b.Bytes()
+	}
+	const entries = "\x00\x00\x00\xff\x01@1\x00\x01@2\x00"
+
+	testCases := map[string]struct {
+		data    []byte
+		opts    []Option
+		wantErr bool
+	}{
+		"valid":                {data: xrefStream("/Size 3 /W [1 2 1]", entries)},
+		"width over 8 bytes":   {data: xrefStream("/Size 3 /W [1 9 1]", entries), wantErr: true},
+		"negative width":       {data: xrefStream("/Size 3 /W [1 -2 1]", entries), wantErr: true},
+		"Index beyond Size":    {data: xrefStream("/Size 3 /W [1 2 1] /Index [1 3]", entries), wantErr: true},
+		"negative Index start": {data: xrefStream("/Size 3 /W [1 2 1] /Index [-1 3]", entries), wantErr: true},
+		"negative Index count": {data: xrefStream("/Size 3 /W [1 2 1] /Index [0 -3]", entries), wantErr: true},
+		"Size beyond limit":    {data: xrefStream("/Size 99999999999 /W [1 2 1]", entries), wantErr: true},
+		"objects beyond limit": {data: xrefStream("/Size 3 /W [1 2 1]", entries), opts: []Option{WithMaxObjects(2)}, wantErr: true},
+		"offset overflows": {
+			data:    xrefStream("/Size 2 /W [1 8 1]", "\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\x01\xff\xff\xff\xff\xff\xff\xff\xff\x00"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tc.data), int64(len(tc.data)), tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tc.wantErr)
+			}
+			checkRecovered(t, err)
+			if err == nil {
+				if v, err := r.Object(1, 0); err != nil || v.Key("Type").Name() != "Catalog" {
+					t.Errorf("got object 1 %v, %v, want the catalog", v, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReader_incrementalUpdate(t *testing.T) {
+	base := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+
+	testCases := map[string]struct {
+		objs map[int]string
+		want string
+	}{
+		"replaced": {
+			objs: map[int]string{4: stream("BT /F1 12 Tf 72 720 Td (Goodbye) Tj ET")},
+			want: "Goodbye",
+		},
+		"freed": {
+			objs: map[int]string{4: ""},
+			want: "",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, update(base, 6, tc.objs))
+
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+
+	t.Run("freed object is null", func(t *testing.T) {
+		r := openPDF(t, update(base, 6, map[int]string{4: ""}))
+		if v := r.resolve(types.Objptr{}, types.Objptr{ID: 4}); !v.IsNull() {
+			t.Errorf("freed object resolved to %v", v)
+		}
+		if v := r.resolve(types.Objptr{}, types.Objptr{ID: 5}); v.IsNull() {
+			t.Errorf("object in use resolved to null")
+		}
+	})
+}
+
+func TestReader_incrementalTrailer(t *testing.T) {
+	base := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		"<</Title (Base)>>",
+	)
+	base = bytes.Replace(base, []byte("/Root 1 0 R>>"), []byte("/Root 1 0 R /Info 3 0 R>>"), 1)
+	updated := update(base, 4, map[int]string{2: "<</Type /Pages /Kids [] /Count 0 /Updated true>>"})
+
+	testCases := map[string]struct {
+		data      []byte
+		wantTitle string
+	}{
+		"inherited": {
+			data:      updated,
+			wantTitle: "Base",
+		},
+		"removed by explicit null": {
+			data: bytes.Replace(updated, []byte("/Root 1 0 R /Prev"), []byte("/Root 1 0 R /Info null /Prev"), 1),
+		},
+		"replaced": {
+			data:      bytes.Replace(update(base, 5, map[int]string{4: "<</Title (Update)>>"}), []byte("/Root 1 0 R /Prev"), []byte("/Root 1 0 R /Info 4 0 R /Prev"), 1),
+			wantTitle: "Update",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			trailer := openPDF(t, tc.data).trailerValue()
+			if got := trailer.Key("Info").Key("Title").Text(); got != tc.wantTitle {
+				t.Errorf("got Title %q, want %q", got, tc.wantTitle)
+			}
+			// Each trailer has Info, the null one too.
+			if !trailer.Has("Info") {
+				t.Errorf("got Has(Info) false")
+			}
+			if trailer.Has("Encrypt") {
+				t.Errorf("got Has(Encrypt) true for a trailer without Encrypt")
+			}
+		})
+	}
+}
+
+func TestReader_Xref(t *testing.T) {
+	base := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		"<</Title (Base)>>",
+	)
+	data := update(base, 5, map[int]string{2: "<</Type /Pages /Kids [] /Count 0>>", 3: "", 4: "<</Title (Update)>>"})
+	off := func(data []byte, id int) int64 {
+		return int64(bytes.LastIndex(data, fmt.Appendf(nil, "\n%d 0 obj", id)) + 1)
+	}
+
+	r := openPDF(t, data)
+	want := []XrefEntry{
+		{ID: 0, Gen: 65535, Type: XrefFree},
+		{ID: 1, Type: XrefInUse, Offset: off(data, 1)},
+		{ID: 2, Type: XrefInUse, Offset: off(data, 2)},
+		{ID: 3, Gen: 1, Type: XrefFree},
+		{ID: 4, Type: XrefInUse, Offset: off(data, 4)},
+	}
+	if diff := cmp.Diff(want, r.Xref()); diff != "" {
+		t.Errorf("Xref mismatch (-want +got):\n%s", diff)
+	}
+	if got := r.TrailerDict().Key("Size").Int64(); got != 5 {
+		t.Errorf("got trailer Size %d, want 5", got)
+	}
+	if got, want := want[2].String(), fmt.Sprintf("%010d 00000 n", off(data, 2)); got != want {
+		t.Errorf("got entry %q, want %q", got, want)
+	}
+	if got, want := want[3].String(), "0000000000 00001 f"; got != want {
+		t.Errorf("got entry %q, want %q", got, want)
+	}
+
+	r = openPDF(t, buildObjStmPDF("<</Type /Catalog /Pages 2 0 R>>", "<</Type /Pages /Kids [] /Count 0>>"))
+	if got, want := r.Xref()[2], (XrefEntry{ID: 2, Type: XrefCompressed, Stream: 3, Index: 1}); got != want {
+		t.Errorf("got entry %+v, want %+v", got, want)
+	}
+	if got, want := r.Xref()[2].String(), "0000000003 00001 c"; got != want {
+		t.Errorf("got entry %q, want %q", got, want)
+	}
+	if got := r.TrailerDict().Key("Type").Name(); got != "XRef" {
+		t.Errorf("got trailer Type %q, want XRef", got)
+	}
+}
+
+func TestReader_generationMismatch(t *testing.T) {
+	// Object 3, the page, is of generation 1, but the page tree references 3 0 R.
+	data := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	off := bytes.Index(data, []byte("\n3 0 obj")) + 1
+	data = bytes.Replace(data, []byte("\n3 0 obj"), []byte("\n3 1 obj"), 1)
+	data = bytes.Replace(data, fmt.Appendf(nil, "%010d 00000 n", off), fmt.Appendf(nil, "%010d 00001 n", off), 1)
+
+	testCases := map[string]struct {
+		opts []Option
+		want string // or "", if the page is not found.
+	}{
+		"tolerant": {want: "Hello, world"},
+		"strict":   {opts: []Option{WithStrictGenerations()}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), tc.opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			got, err := r.Text()
+			if tc.want == "" {
+				if err == nil {
+					t.Errorf("got text %q, want an error", got.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got text %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReader_Revision(t *testing.T) {
+	base := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	updated := update(base, 6, map[int]string{4: stream("BT /F1 12 Tf 72 720 Td (Goodbye) Tj ET")})
+	r := openPDF(t, updated)
+
+	if got := r.Revisions(); got != 2 {
+		t.Fatalf("got %d revisions, want 2", got)
+	}
+	for n, want := range map[int]struct {
+		length int
+		text   string
+	}{
+		1: {len(base), "Hello, world"},
+		2: {len(updated), "Goodbye"},
+	} {
+		length, err := r.RevisionLength(n)
+		if err != nil {
+			t.Fatal("failed to get revision length:", err)
+		}
+		if length != int64(want.length) {
+			t.Errorf("revision %d: got length %d, want %d", n, length, want.length)
+		}
+		rev, err := r.Revision(n)
+		if err != nil {
+			t.Fatal("failed to open revision:", err)
+		}
+		got, err := rev.Text()
+		if err != nil {
+			t.Fatal("failed to read text:", err)
+		}
+		if got.String() != want.text {
+			t.Errorf("revision %d: got text %q, want %q", n, got.String(), want.text)
+		}
+	}
+	if _, err := r.Revision(3); err == nil {
+		t.Error("expected error opening revision 3")
+	}
+}
+
+func FuzzNewReader(f *testing.F) {
+	valid := textPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add(bytes.Replace(valid, []byte("endobj"), []byte("endobk"), 1))
+	f.Add(bytes.Replace(valid, []byte("/Count 1"), []byte("/Count 9"), 1))
+	f.Add(bytes.Replace(valid, []byte("stream\n"), []byte("stream "), 1))
+	f.Add(bytes.Replace(valid, []byte("Tj"), []byte("TJ"), 1))
+	f.Add([]byte("%PDF-1.7\nstartxref\n9\n%%EOF\n"))
+	f.Add([]byte("%PDF-1.7\n" + strings.Repeat("[", 100) + "\nstartxref\n9\n%%EOF\n"))
+	f.Add([]byte("%PDF-1.7\n1 0 obj <</Type /XRef /Size 1 /W [1 -5 1]>> stream\n\x01\x02\x03\nendstream\nstartxref\n9\n%%EOF\n"))
+	f.Add([]byte("%PDF-1.7\n"))
+	f.Add([]byte("%PDF-1.7\n1 0 obj " + strings.Repeat("[<</A ", 1_000) + "\nendobj\nstartxref\n9\n%%EOF\n"))
+	const content = "BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"
+	f.Add(buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		fmt.Sprintf("<</Length %d /Filter [] /DecodeParms <</Columns 4>>>>\nstream\n%s\nendstream", len(content), content),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+	))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		checkRecovered(t, err)
+		if err != nil {
+			return
+		}
+		_, err = r.Text()
+		checkRecovered(t, err)
+	})
+}
+
+func FuzzReader_Page(f *testing.F) {
+	f.Add("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	f.Add("BT /F1 12 Tf [(Kerned) -120 (text)] TJ T* (a) ' 1 2 (b) \" ET")
+	f.Add("q 1 0 0 1 72 720 cm BT /F1 Tf Tj ET Q Q Q")
+	f.Add("BT /F2 12 Tf <48656c6c6f> Tj ET /Im1 Do BI /W 1 /H 1 ID \x00 EI")
+	f.Add("BT /F1 12 Tf 3 Tr 1e400 0 Td (x) Tj /OC /MC0 BDC EMC EMC ET")
+	f.Add("BT /F1 12 Tf " + strings.Repeat("[", 1_000) + " TJ ET")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		r, err := NewReader(bytes.NewReader(textPDF(content)), int64(len(textPDF(content))))
+		if err != nil {
+			t.Skip("content breaks the file:", err)
+		}
+		_, err = r.Page(1)
+		checkRecovered(t, err)
+	})
+}
+
+// checkRecovered fails the test if err is that of a runtime panic recovered by the
+// package, which is a bug, like an index out of range, rather than malformed input.
+func checkRecovered(t *testing.T, err error) {
+	t.Helper()
+	if err != nil && strings.Contains(err.Error(), "runtime error") {
+		t.Fatal(err)
+	}
+}
+
+// aesPDF is like textPDF, but encrypts the content stream with AES-128 and the empty
+// user password. See PDF 32000-1:2008, §7.6.
+func aesPDF(content string) []byte {
+	id := []byte("0123456789abcdef")
+	owner := bytes.Repeat([]byte{0xAA}, 32)
+	const perms = -4
+
+	// Algorithm 2 computes the file key, and algorithm 5 the user password entry.
+	h := md5.New()
+	h.Write(passwordPad)
+	h.Write(owner)
+	h.Write([]byte{byte(perms & 0xff), byte(perms >> 8 & 0xff), byte(perms >> 16 & 0xff), byte(perms >> 24 & 0xff)})
+	h.Write(id)
+	key := h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	h.Reset()
+	h.Write(passwordPad)
+	h.Write(id)
+	user := h.Sum(nil)
+	for i := range 20 {
+		k := bytes.Clone(key)
+		for j := range k {
+			k[j] ^= byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(user, user)
+	}
+	user = append(user, make([]byte, 16)...)
+
+	// The content stream, object 4, is encrypted with its own key, after an IV, with padding.
+	h.Reset()
+	h.Write(key)
+	h.Write([]byte{4, 0, 0, 0, 0})
+	h.Write([]byte("sAlT"))
+	block, _ := aes.NewCipher(h.Sum(nil))
+	iv := make([]byte, aes.BlockSize)
+	pad := aes.BlockSize - len(content)%aes.BlockSize
+	enc := append([]byte(content), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(enc, enc)
+	enc = append(iv, enc...)
+
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		fmt.Sprintf("<</Length %d>>\nstream\n%s\nendstream", len(enc), enc),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		fmt.Sprintf("<</Filter /Standard /V 4 /R 4 /Length 128 /P %d /O <%x> /U <%x> "+
+			"/CF <</StdCF <</CFM /AESV2 /AuthEvent /DocOpen /Length 16>>>> /StmF /StdCF /StrF /StdCF>>", perms, owner, user),
+	)
+	return bytes.Replace(data, []byte("/Root 1 0 R>>"), []byte(fmt.Sprintf("/Root 1 0 R /Encrypt 6 0 R /ID [<%x> <%x>]>>", id, id)), 1)
+}
+
+// passwordPad pads passwords to 32 bytes. See PDF 32000-1:2008, §7.6.3.3.
+var passwordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+func TestReader_aes(t *testing.T) {
+	data := aesPDF("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET")
+	for _, size := range []int{defaultReadBufferSize, 16, 0} {
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)), WithReadBufferSize(size))
+		if err != nil {
+			t.Fatal("failed to open PDF:", err)
+		}
+		got, err := r.Text()
+		if err != nil {
+			t.Fatalf("buffer size %d: failed to read text: %v", size, err)
+		}
+		if want := "Hello, world"; got.String() != want {
+			t.Errorf("buffer size %d: got text %q, want %q", size, got.String(), want)
+		}
+
+		contents, err := r.Object(4, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := contents.Reader()
+		rc.Close()
+		if _, err := rc.Read(make([]byte, 1)); err == nil {
+			t.Errorf("buffer size %d: read of closed stream succeeded", size)
+		}
+	}
+}
+
+// assemble adds to f a single page document showing the given content stream, compressed,
+// with the font /F1, a WinAnsi-encoded Helvetica, and returns the file.
+func assemble(f *testpdf.File, content string, length int64) []byte {
+	catalog, pages := f.Reserve(), f.Reserve()
+	contents := testpdf.Flate(nil, []byte(content))
+	contents.Length = length
+	page := f.Add(types.Dict{
+		"Type":   types.Name("Page"),
+		"Parent": pages,
+		"Resources": types.Dict{"Font": types.Dict{"F1": types.Dict{
+			"Type": types.Name("Font"), "Subtype": types.Name("Type1"),
+			"BaseFont": types.Name("Helvetica"), "Encoding": types.Name("WinAnsiEncoding"),
+		}}},
+		"Contents": f.Add(contents),
+	})
+	f.Set(pages, types.Dict{"Type": types.Name("Pages"), "Kids": types.Array{page}, "Count": int64(1)})
+	f.Set(catalog, types.Dict{"Type": types.Name("Catalog"), "Pages": pages})
+	if f.Trailer == nil {
+		f.Trailer = types.Dict{}
+	}
+	f.Trailer["Root"] = catalog
+	return f.Bytes()
+}
+
+func TestReader_fileStructures(t *testing.T) {
+	const content = "BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"
+	encrypted := func(f testpdf.File) *testpdf.File {
+		f.EncryptRC4([]byte("0123456789abcdef"))
+		return &f
+	}
+	testCases := []struct {
+		name   string
+		f      *testpdf.File
+		length int64
+	}{
+		{"xref table", &testpdf.File{}, 0},
+		{"xref stream", &testpdf.File{XrefStream: true}, 0},
+		{"object streams", &testpdf.File{ObjectStreams: 2}, 0},
+		{"rc4", encrypted(testpdf.File{}), 0},
+		{"rc4 object streams", encrypted(testpdf.File{ObjectStreams: 2}), 0},
+		{"long Length", &testpdf.File{XrefStream: true}, 1000},
+		{"short Length", &testpdf.File{ObjectStreams: 2}, 10},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := openPDF(t, assemble(tc.f, content, tc.length))
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, world"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestNewReader_startxrefCandidates(t *testing.T) {
+	// The last object is a stream whose data holds the keyword, as compressed data may.
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 12 Tf 72 720 Td (Hello, world) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+		stream("\x9c\nstartxref\n5\n%%EOF\n\x01"),
+	)
+	last := func(data []byte, old, new string) []byte {
+		i := bytes.LastIndex(data, []byte(old))
+		return append(bytes.Clone(data[:i]), append([]byte(new), data[i+len(old):]...)...)
+	}
+
+	testCases := map[string]struct {
+		data    []byte
+		warning bool
+	}{
+		"valid": {
+			data: data,
+		},
+		"keyword after the trailer on its line": {
+			data: last(data, ">>\nstartxref", ">> startxref"),
+		},
+		"comment before %%EOF": {
+			data:    last(data, "%%EOF", "% the end\n%%EOF"),
+			warning: true,
+		},
+		"appended startxref pointing at nothing": {
+			data:    append(bytes.Clone(data), "startxref\n5\n%%EOF\n"...),
+			warning: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, tc.data)
+			got, err := r.Text()
+			if err != nil {
+				t.Fatal("failed to read text:", err)
+			}
+			if want := "Hello, world"; got.String() != want {
+				t.Errorf("got text %q, want %q", got.String(), want)
+			}
+			if w := r.Warnings(); len(w) > 0 != tc.warning {
+				t.Errorf("got warnings %v, want any %v", w, tc.warning)
+			}
+		})
+	}
+}
+
+func TestNewReader_wrongStartxref(t *testing.T) {
+	for _, delta := range []int64{-20, 3, 1 << 20} {
+		data := assemble(&testpdf.File{StartxrefDelta: delta}, "BT ET", 0)
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Errorf("startxref off by %d: got no error", delta)
+		}
+	}
+}
+
+// slowReaderAt is a ReaderAt, like a file on a network drive, that takes a while to
+// answer each read, and counts them.
+type slowReaderAt struct {
+	io.ReaderAt
+	reads int
+}
+
+func (s *slowReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.reads++
+	time.Sleep(200 * time.Microsecond)
+	return s.ReaderAt.ReadAt(p, off)
+}
+
+func BenchmarkReader_Text_slowReaderAt(b *testing.B) {
+	var content strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&content, "BT /F1 12 Tf 72 %d Td (Line %d of the benchmark page) Tj ET\n", 720-i%700, i)
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte(content.String()))
+	zw.Close()
+	for _, bc := range []struct {
+		name     string
+		contents string
+	}{
+		{"plain", stream(content.String())},
+		{"flate", fmt.Sprintf("<</Length %d /Filter /FlateDecode>>\nstream\n%s\nendstream", z.Len(), z.Bytes())},
+		{"aes", ""},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			data := aesPDF(content.String())
+			if bc.contents != "" {
+				data = buildPDF(
+					"<</Type /Catalog /Pages 2 0 R>>",
+					"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+					"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+					bc.contents,
+					"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+				)
+			}
+			f := &slowReaderAt{ReaderAt: bytes.NewReader(data)}
+			r, err := NewReader(f, int64(len(data)))
+			if err != nil {
+				b.Fatal(err)
+			}
+			f.reads = 0
+			b.ResetTimer()
+			for range b.N {
+				if _, err := r.Text(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(f.reads)/float64(b.N), "reads/op")
+		})
+	}
+}
+
+func TestReader_concurrent(t *testing.T) {
+	// Eight pages, with their fonts and pages in an object stream, and their
+	// content streams compressed.
+	const pages = 8
+	objs := []string{"<</Type /Catalog /Pages 2 0 R>>", ""}
+	var kids []string
+	for i := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (Page %d) Tj ET", i+1)
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write([]byte(content))
+		zw.Close()
+		page := len(objs) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objs = append(objs,
+			fmt.Sprintf("<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 %d 0 R>>>> /Contents %d 0 R>>", page+2, page+1),
+			fmt.Sprintf("<</Length %d /Filter /FlateDecode>>\nstream\n%s\nendstream", z.Len(), z.Bytes()),
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+	}
+	objs[1] = fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(kids, " "), pages)
+	data := buildObjStmPDF(objs...)
+
+	for name, opts := range map[string][]Option{
+		"default":  nil,
+		"no cache": {WithObjectStreamCache(0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), opts...)
+			if err != nil {
+				t.Fatal("failed to open PDF:", err)
+			}
+			var wg sync.WaitGroup
+			for g := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range 2 * pages {
+						n := (g+i)%pages + 1
+						got, err := r.Page(n)
+						if err != nil {
+							t.Errorf("page %d: %v", n, err)
+							return
+						}
+						if want := fmt.Sprintf("Page %d", n); got.String() != want {
+							t.Errorf("page %d: got text %q, want %q", n, got.String(), want)
+						}
+						id := uint32(3*n + 2)
+						if v, err := r.Object(id, 0); err != nil || v.Key("BaseFont").Name() != "Helvetica" {
+							t.Errorf("got object %d %v, %v, want a font", id, v, err)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestReader_deterministic(t *testing.T) {
+	// Two fonts, one missing, marked content and dictionaries nested in arrays
+	// of dictionaries, on several pages.
+	const pages = 4
+	objs := []string{"<</Type /Catalog /Pages 2 0 R /MarkInfo <</Marked true>>>>", ""}
+	var kids []string
+	for i := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td /P <</MCID 0>> BDC (Page %d) Tj EMC "+
+			"/F2 10 Tf 0 -20 Td (Second font) Tj /F3 10 Tf (Missing font) Tj ET", i+1)
+		page := len(objs) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objs = append(objs,
+			fmt.Sprintf("<</Type /Page /Parent 2 0 R /Resources <</Font <</F2 %d 0 R /F1 %d 0 R /F3 99 0 R>>>> "+
+				"/Contents %d 0 R /Annots [<</Subtype /Link /Rect [0 0 10 10] /A <</S /URI /URI (http://example.com)>>>> "+
+				"<</Subtype /Text /Rect [10 10 20 20] /C [1 0 0] /Contents (Note)>>]>>", page+3, page+2, page+1),
+			stream(content),
+			"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>",
+			"<</Type /Font /Subtype /Type1 /BaseFont /Times-Roman /Encoding /MacRomanEncoding>>")
+	}
+	objs[1] = fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(kids, " "), pages)
+	data := buildPDF(objs...)
+
+	// dump returns the objects, text, sections and warnings read from the fixture.
+	dump := func() string {
+		r := openPDF(t, data)
+		var b strings.Builder
+		for id := range uint32(len(objs)) {
+			v, err := r.Object(id+1, 0)
+			fmt.Fprintf(&b, "%d: %v %v\n", id+1, v, err)
+		}
+		txt, err := r.Text()
+		fmt.Fprintf(&b, "%s %v\n", txt.DebugString(), err)
+		sections, err := r.Sectioned()
+		fmt.Fprintf(&b, "%s %v\n", sections.DebugString(), err)
+		for _, w := range r.Warnings() {
+			fmt.Fprintln(&b, w)
+		}
+		return b.String()
+	}
+	want := dump()
+	for range 5 {
+		if got := dump(); got != want {
+			t.Fatalf("got output\n%s\nwant the output of the first run\n%s", got, want)
+		}
+	}
+
+	// Pages read concurrently have the text and warnings that they have when read in turn.
+	r := openPDF(t, data)
+	wantPages := make([]string, pages)
+	for i := range pages {
+		txt, err := r.Page(i + 1)
+		if err != nil {
+			t.Fatalf("page %d: %v", i+1, err)
+		}
+		wantPages[i] = txt.DebugString()
+	}
+	var wantWarnings []string
+	for _, w := range r.Warnings() {
+		wantWarnings = append(wantWarnings, w.String())
+	}
+
+	r = openPDF(t, data)
+	var wg sync.WaitGroup
+	for g := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := g + 1
+			txt, err := r.Page(n)
+			if err != nil {
+				t.Errorf("page %d: %v", n, err)
+				return
+			}
+			if got := txt.DebugString(); got != wantPages[g] {
+				t.Errorf("page %d: got text %q, want %q", n, got, wantPages[g])
+			}
+		}()
+	}
+	wg.Wait()
+	// The warnings of different pages interleave, but each page warns as it does alone.
+	var gotWarnings []string
+	for _, w := range r.Warnings() {
+		gotWarnings = append(gotWarnings, w.String())
+	}
+	slices.Sort(gotWarnings)
+	slices.Sort(wantWarnings)
+	if diff := cmp.Diff(gotWarnings, wantWarnings); diff != "" {
+		t.Error("concurrent warnings did not match expectation:", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"slices"
+
+	"github.com/ScriptRock/pdf/internal/matrix"
+)
+
+// A Point is a point in PDF user space, in points from the origin.
+type Point struct {
+	X, Y float64
+}
+
+// A Rect is a rectangle in PDF user space, from its lower left
+// corner Min to its upper right corner Max.
+type Rect struct {
+	Min, Max Point
+}
+
+// rect returns the rectangle described by the array v of the coordinates of
+// two of its opposite corners, or the empty rectangle if v is not such an array.
+// See PDF 32000-1:2008, §7.9.5.
+func rect(v Value) Rect {
+	if v.Kind() != Array || v.Len() != 4 {
+		return Rect{}
+	}
+	x0, y0, x1, y1 := v.Index(0).Float64(), v.Index(1).Float64(), v.Index(2).Float64(), v.Index(3).Float64()
+	return Rect{Point{min(x0, x1), min(y0, y1)}, Point{max(x0, x1), max(y0, y1)}}
+}
+
+// Empty reports whether the rectangle has no area.
+func (r Rect) Empty() bool {
+	return !(r.Min.X < r.Max.X && r.Min.Y < r.Max.Y)
+}
+
+// Area returns the area of the rectangle.
+func (r Rect) Area() float64 {
+	if r.Empty() {
+		return 0
+	}
+	return (r.Max.X - r.Min.X) * (r.Max.Y - r.Min.Y)
+}
+
+// Intersect returns the largest rectangle contained by both r and s.
+func (r Rect) Intersect(s Rect) Rect {
+	r.Min.X, r.Min.Y = max(r.Min.X, s.Min.X), max(r.Min.Y, s.Min.Y)
+	r.Max.X, r.Max.Y = min(r.Max.X, s.Max.X), min(r.Max.Y, s.Max.Y)
+	if r.Empty() {
+		return Rect{}
+	}
+	return r
+}
+
+// transformRect returns the bounds of the rectangle r transformed by
+// the matrix m, given as the operands of cm.
+func transformRect(m [6]float64, r Rect) Rect {
+	t := matrix.New(m[0], m[1], m[2], m[3], m[4], m[5])
+	var xs, ys []float64
+	for _, p := range [...]Point{r.Min, {r.Max.X, r.Min.Y}, {r.Min.X, r.Max.Y}, r.Max} {
+		x, y := t.Apply(p.X, p.Y)
+		xs, ys = append(xs, x), append(ys, y)
+	}
+	return Rect{
+		Point{slices.Min(xs), slices.Min(ys)},
+		Point{slices.Max(xs), slices.Max(ys)},
+	}
+}
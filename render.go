@@ -0,0 +1,57 @@
+package pdf
+
+import (
+	"context"
+
+	"github.com/ScriptRock/pdf/internal/state"
+)
+
+// A TextRun is a run of text shown by one of the text-showing operators of a
+// page's content streams, or by one of the strings of a TJ operator.
+type TextRun struct {
+	// X and Y are the origin of the run on the page, Y being its baseline, W is its
+	// width and H is the font size, in user space units, as they are given to
+	// text.Builder.Render.
+	X, Y, W, H float64
+	// Font is the name of the font (its BaseFont), and FontSize the text font size (Tfs).
+	Font     string
+	FontSize float64
+	// RenderMode is the text rendering mode (Tr). See PDF 32000-1:2008, §9.3.6.
+	RenderMode int
+	// CTM is the current transformation matrix, [a b c d e f].
+	CTM [6]float64
+	// SpaceWidth is the width of a space in the font, in user space units,
+	// or zero if it is unknown.
+	SpaceWidth float64
+	// Box is the bounds of the run on the page, [llx lly urx ury], in default user
+	// space. The glyphs extend from the descent of the font to its ascent, as its
+	// FontDescriptor gives them, or as those of the standard font of the same name.
+	Box [4]float64
+	// Text is the text of the run, decoded to UTF-8.
+	Text string
+}
+
+// A Renderer receives the runs of text shown on a page.
+type Renderer interface {
+	Render(run TextRun)
+}
+
+// Render renders each run of text shown on the page to r, in the order in which the
+// content streams show them. Page.Text renders the runs to a text.Builder, after
+// replacing those in marked content that has an ActualText; Render renders them as
+// they are. As for Page.Text, text in optional content groups that are hidden
+// is skipped.
+func (p *Page) Render(r Renderer) error {
+	return p.RenderContext(context.Background(), r)
+}
+
+// RenderContext is like Render, but stops with the error of ctx once it is done.
+func (p *Page) RenderContext(ctx context.Context, r Renderer) error {
+	_, _, err := p.extract(ctx, r, false, p.v.r.cfg.ocr, p.v.r.cfg.builder)
+	return err
+}
+
+// A runRenderer renders text to a Renderer.
+type runRenderer struct{ r Renderer }
+
+func (r runRenderer) Render(run state.Run) { r.r.Render(TextRun(run)) }
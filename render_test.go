@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type runs []TextRun
+
+func (rs *runs) Render(run TextRun) { *rs = append(*rs, run) }
+
+func TestPage_Render(t *testing.T) {
+	r := openPDF(t, textPDF("q 2 0 0 2 10 20 cm BT /F1 12 Tf 3 Tr 5 6 Td (A) Tj ET Q "+
+		"/Span <</ActualText (X)>> BDC BT /F1 10 Tf 1 0 0 1 72 720 Tm [(B) -100 (C)] TJ ET EMC"))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	var got runs
+	if err := p.Render(&got); err != nil {
+		t.Fatal("failed to render page:", err)
+	}
+	want := runs{
+		{X: 20, Y: 32, W: 16.008, H: 24, Font: "Helvetica", FontSize: 12, RenderMode: 3, CTM: [6]float64{2, 0, 0, 2, 10, 20}, SpaceWidth: 6.672, Box: [4]float64{20, 27.032, 36.008, 49.232}, Text: "A"},
+		{X: 72, Y: 720, W: 6.67, H: 10, Font: "Helvetica", FontSize: 10, CTM: [6]float64{1, 0, 0, 1, 0, 0}, SpaceWidth: 2.78, Box: [4]float64{72, 717.93, 78.67, 727.18}, Text: "B"},
+		{X: 79.67, Y: 720, W: 7.22, H: 10, Font: "Helvetica", FontSize: 10, CTM: [6]float64{1, 0, 0, 1, 0, 0}, SpaceWidth: 2.78, Box: [4]float64{79.67, 717.93, 86.89, 727.18}, Text: "C"},
+	}
+	if diff := cmp.Diff(got, want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Error("runs did not match expectation:", diff)
+	}
+}
+
+func TestPage_Render_fontMetrics(t *testing.T) {
+	// F1 has an Ascent and Descent, F2 only a CapHeight and a positive Descent,
+	// and F3 is missing, so that its glyphs have the default metrics.
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R /F2 6 0 R>>>> /Contents 4 0 R>>",
+		stream("BT /F1 10 Tf 72 700 Td (A) Tj /F2 20 Tf (B) Tj /F3 10 Tf (C) Tj ET"),
+		"<</Type /Font /Subtype /Type1 /BaseFont /Custom /FirstChar 65 /LastChar 66 /Widths [500 500] "+
+			"/FontDescriptor <</Type /FontDescriptor /Ascent 900 /Descent -300>>>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Other /FirstChar 65 /LastChar 66 /Widths [500 500] "+
+			"/FontDescriptor <</Type /FontDescriptor /CapHeight 700 /Descent 200>>>>",
+	))
+	p, err := r.GetPage(1)
+	if err != nil {
+		t.Fatal("failed to get page:", err)
+	}
+
+	var got runs
+	if err := p.Render(&got); err != nil {
+		t.Fatal("failed to render page:", err)
+	}
+	want := [][4]float64{
+		{72, 697, 77, 709},
+		{77, 696, 87, 714},
+		{87, 697.5, 87, 707.5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d runs, want %d", len(got), len(want))
+	}
+	for i, run := range got {
+		if run.Y != 700 {
+			t.Errorf("got baseline %v of run %q, want 700", run.Y, run.Text)
+		}
+		if diff := cmp.Diff(run.Box, want[i], cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+			t.Errorf("box of run %q did not match expectation: %s", run.Text, diff)
+		}
+	}
+}
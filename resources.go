@@ -0,0 +1,201 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+
+	"github.com/ScriptRock/pdf/internal/state"
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// resources are the resource dictionaries in which the names used by a content stream
+// are looked up, nearest first: those of a form XObject, then those of the page, then
+// those of its ancestors in the page tree. The resources of each category, such as Font
+// or XObject, are merged along the chain, the nearest dictionary defining a name winning,
+// as some files split them between a page and its ancestors. See PDF 32000-1:2008, §7.8.3.
+type resources []Value
+
+// resources returns the resources of the page and its ancestors.
+func (p *Page) resources() resources {
+	var res resources
+	v := p.v
+	for range maxPageTreeDepth {
+		if v.IsNull() {
+			break
+		}
+		if r := v.Key("Resources"); r.Kind() == Dict {
+			res = append(res, r)
+		}
+		v = v.Key("Parent")
+	}
+	return res
+}
+
+// form returns the resources of the form XObject form, drawn with the resources res.
+// A form without resources of its own, as PDF 1.1 allows, uses those it is drawn with.
+func (res resources) form(form Value) resources {
+	if r := form.Key("Resources"); r.Kind() == Dict {
+		return append(resources{r}, res...)
+	}
+	return res
+}
+
+// dict returns the nearest dictionary of the category of resources that defines name.
+func (res resources) dict(category, name string) Value {
+	for _, r := range res {
+		d := r.Key(category)
+		if x, ok := d.data.(types.Dict); ok && x[types.Name(name)] != nil {
+			return d
+		}
+	}
+	return Value{}
+}
+
+// lookup returns the resource named name of the category.
+func (res resources) lookup(category, name string) Value {
+	return res.dict(category, name).Key(name)
+}
+
+// names returns the names of the resources of the category, sorted.
+func (res resources) names(category string) []string {
+	var names []string
+	for _, r := range res {
+		names = append(names, r.Key(category).Keys()...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// maxFormDepth is the greatest depth of form XObjects drawn within each other.
+const maxFormDepth = 32
+
+// maxFormDraws is the greatest number of times form XObjects are drawn on a page,
+// so that forms that each draw the next several times, whose draws grow exponentially
+// with their depth, take no longer to read than a page of that many forms.
+const maxFormDraws = 10_000
+
+// A contentReader holds the state of interpreting the content of a page that
+// is kept across the form XObjects it draws: the resources of the content being
+// interpreted, and the fonts of them.
+type contentReader struct {
+	ctx   context.Context
+	p     *Page
+	res   resources
+	fonts map[string]*font // the fonts of res, by name.
+	// loaded holds the fonts read so far, by object, so that those of
+	// the resources of several forms are read once.
+	loaded map[types.Objptr]*font
+	// all holds the fonts read, in the order they were read.
+	all []*font
+	// forms holds the forms being drawn, innermost last.
+	forms []types.Objptr
+	// draws is the number of times forms have been drawn, up to maxFormDraws,
+	// and one more once forms are skipped for being drawn too many times.
+	draws int
+}
+
+func newContentReader(ctx context.Context, p *Page) *contentReader {
+	c := &contentReader{ctx: ctx, p: p, loaded: map[types.Objptr]*font{}}
+	c.setResources(p.resources())
+	return c
+}
+
+// setResources makes res the resources of the content being interpreted.
+func (c *contentReader) setResources(res resources) {
+	c.res, c.fonts = res, map[string]*font{}
+	for _, name := range res.names("Font") {
+		d := res.dict("Font", name)
+		refs, _ := d.data.(types.Dict)
+		c.fonts[name] = c.font(refs[types.Name(name)], d.Key(name), name)
+	}
+}
+
+// font returns the font v, the value of the object x, of the given name.
+func (c *contentReader) font(x types.Object, v Value, name string) *font {
+	ref, indirect := x.(types.Objptr)
+	if f, ok := c.loaded[ref]; indirect && ok {
+		return f
+	}
+	f := c.p.font(c.ctx, v, name)
+	c.all = append(c.all, f)
+	if indirect {
+		c.loaded[ref] = f
+	}
+	return f
+}
+
+// extGState sets the parameters of the graphics state g that the named ExtGState sets,
+// of those that reading text uses: its Font, the font and size that Tf otherwise sets.
+// The other parameters are ignored. See PDF 32000-1:2008, §8.4.5.
+func (c *contentReader) extGState(name string, g *state.Graphics) {
+	font := c.res.lookup("ExtGState", name).Key("Font")
+	elems, _ := font.data.(types.Array)
+	if len(elems) != 2 {
+		return
+	}
+	f := font.Index(0)
+	g.Tf(c.font(elems[0], f, f.Key("BaseFont").Name()), font.Index(1).Float64())
+}
+
+// drawForm interprets the content stream of the form XObject form with do, as the
+// Do operator draws it: with the graphics state g saved, transformed by the Matrix
+// of the form and clipped to its BBox, and with the resources of the form. Forms that are drawn within
+// themselves, or nested too deep, are skipped, as are all those drawn once maxFormDraws have been.
+func (c *contentReader) drawForm(form Value, g *state.Graphics, do func(stk *stack, op string)) {
+	if slices.Contains(c.forms, form.ptr) || len(c.forms) >= maxFormDepth {
+		c.p.v.r.warn(ContentWarning, form.ptr, c.p.num, "skipping form XObject drawn within itself or nested too deep")
+		return
+	}
+	if c.draws >= maxFormDraws {
+		if c.draws == maxFormDraws {
+			c.p.v.r.warn(ContentWarning, form.ptr, c.p.num, "skipping form XObjects after drawing %d", maxFormDraws)
+			c.draws++
+		}
+		return
+	}
+	c.draws++
+	res, fonts := c.res, c.fonts
+	c.forms = append(c.forms, form.ptr)
+	c.setResources(res.form(form))
+
+	depth := g.Depth()
+	g.Push()
+	if m := form.Key("Matrix"); m.Len() == 6 {
+		g.CM(m.Index(0).Float64(), m.Index(1).Float64(), m.Index(2).Float64(), m.Index(3).Float64(), m.Index(4).Float64(), m.Index(5).Float64())
+	}
+	// The form is clipped to its bounding box. See PDF 32000-1:2008, §8.10.1.
+	if bbox := form.Key("BBox"); bbox.Len() == 4 {
+		b := transformRect(g.CTM(), rect(bbox))
+		g.Clip(b.Min.X, b.Min.Y, b.Max.X, b.Max.Y)
+	}
+	c.interpretForm(form, do)
+	// The form restores the graphics state, even if its q and Q are unbalanced.
+	for g.Depth() > depth {
+		g.Pop()
+	}
+
+	c.forms = c.forms[:len(c.forms)-1]
+	c.res, c.fonts = res, fonts
+}
+
+// interpretForm interprets the content stream of the form with do. A form whose
+// content fails to decode or parse is drawn as far as it does, so that the rest of
+// the page is read still; but failures of the context, and of the code, stop it, as
+// does a code without text under UnmappedError.
+func (c *contentReader) interpretForm(form Value, do func(stk *stack, op string)) {
+	rc := form.contentStream()
+	defer rc.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			err, _ := r.(error)
+			var rerr runtime.Error
+			if errors.As(err, &rerr) || errors.As(err, new(*UnmappedCodeError)) || c.ctx.Err() != nil {
+				panic(r)
+			}
+			c.p.v.r.warn(ContentWarning, form.ptr, c.p.num, "failed to read form XObject: %v", r)
+		}
+	}()
+	interpret(c.ctx, rc, do)
+}
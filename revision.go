@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Revisions returns the number of revisions of the document:
+// one for the original document and one for each incremental update appended to it.
+// See PDF 32000-1:2008, §7.5.6.
+func (r *Reader) Revisions() int {
+	return len(r.revisionEnds())
+}
+
+// Revision returns a Reader for the document as it was at revision n,
+// numbered from 1 for the original document to Revisions for the current one.
+// The returned Reader shares the underlying file with r.
+func (r *Reader) Revision(n int) (_ *Reader, err error) {
+	defer catch(&err)
+
+	end, err := r.RevisionLength(n)
+	if err != nil {
+		return nil, err
+	}
+	rev := &Reader{
+		cfg:       r.cfg,
+		f:         io.NewSectionReader(r.f, 0, end),
+		end:       end,
+		decrypter: r.decrypter,
+	}
+	if err := rev.readXref(context.Background()); err != nil {
+		return nil, fmt.Errorf("revision %d: %w", n, err)
+	}
+	return rev, nil
+}
+
+// RevisionLength returns the length in bytes of revision n of the document:
+// the length of the file up to and including the end-of-line after that revision's
+// %%EOF marker. A signature's ByteRange covering a revision ends at this length.
+func (r *Reader) RevisionLength(n int) (int64, error) {
+	ends := r.revisionEnds()
+	if n < 1 || n > len(ends) {
+		return 0, fmt.Errorf("revision %d out of range: [1, %d]", n, len(ends))
+	}
+	return ends[n-1], nil
+}
+
+// revisionEnds returns the end offsets of the document's revisions, oldest first.
+//
+// Each xref section in the Prev chain completes a revision, which ends at the first
+// %%EOF after both the section and the older sections it builds on. A section written
+// before the sections it refers back to, such as the first-page section of a linearized
+// file, therefore belongs to the same revision as they do.
+func (r *Reader) revisionEnds() []int64 {
+	var (
+		ends   []int64
+		latest int64
+	)
+	r.xrefTable() // reads the whole Prev chain of a Reader opened with WithLazyXref.
+	for i := len(r.xrefChain) - 1; i >= 0; i-- {
+		latest = max(latest, r.xrefChain[i])
+		end := r.eofAfter(latest)
+		if len(ends) == 0 || ends[len(ends)-1] < end {
+			ends = append(ends, end)
+		}
+	}
+	return ends
+}
+
+// eofAfter returns the offset just after the first %%EOF marker, and its end-of-line,
+// following offset. If there is none, eofAfter returns the end of the file.
+func (r *Reader) eofAfter(offset int64) int64 {
+	const (
+		chunk = 4096
+		kw    = "%%EOF"
+	)
+	buf := make([]byte, chunk+len(kw)+2)
+	for pos := offset; pos < r.end; pos += chunk {
+		n, _ := r.f.ReadAt(buf, pos)
+		data := buf[:n]
+		i := bytes.Index(data, []byte(kw))
+		if i < 0 || i >= chunk {
+			continue
+		}
+		end := i + len(kw)
+		switch {
+		case bytes.HasPrefix(data[end:], []byte("\r\n")):
+			end += 2
+		case bytes.HasPrefix(data[end:], []byte("\n")), bytes.HasPrefix(data[end:], []byte("\r")):
+			end++
+		}
+		return min(pos+int64(end), r.end)
+	}
+	return r.end
+}
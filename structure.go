@@ -0,0 +1,254 @@
+package pdf
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ScriptRock/pdf/internal/types"
+	"github.com/ScriptRock/pdf/text"
+)
+
+// Sectioned returns the text of the document as a hierarchy of sections.
+// For a tagged PDF, the hierarchy is that of its structure tree: the text is in its
+// logical reading order, and headings are those of the H1 to H6 structure types.
+// Otherwise, Sectioned is equivalent to sectioning the result of Text, which
+// works from the size and position of the text. See PDF 32000-1:2008, §14.7.
+func (r *Reader) Sectioned() (text.Content, error) {
+	return r.SectionedContext(context.Background())
+}
+
+// SectionedContext is like Sectioned, but stops with the error of ctx once it is done.
+func (r *Reader) SectionedContext(ctx context.Context) (text.Content, error) {
+	root := r.trailerValue().Key("Root").Key("StructTreeRoot")
+	if root.Kind() == Dict {
+		s := structWalker{
+			ctx:     ctx,
+			roleMap: root.Key("RoleMap"),
+			pages:   map[types.Objptr]map[int64]text.Text{},
+			seen:    map[types.Objptr]bool{},
+		}
+		if err := s.walk(root, Value{}, ""); err != nil {
+			return nil, err
+		}
+		s.flush()
+		if len(s.content) > 0 {
+			return s.content, nil
+		}
+	}
+
+	t, err := r.TextContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.Sectioned(), nil
+}
+
+// inlineTypes are the standard structure types of inline-level elements, whose text
+// runs on within that of the enclosing block-level element. See PDF 32000-1:2008, §14.8.4.4.
+var inlineTypes = map[string]bool{
+	"Span": true, "Quote": true, "Note": true, "Reference": true, "BibEntry": true,
+	"Code": true, "Link": true, "Annot": true, "Ruby": true, "RB": true, "RT": true,
+	"RP": true, "Warichu": true, "WT": true, "WP": true, "Lbl": true,
+}
+
+// maxStructDepth is the greatest depth of the structure tree that is read.
+const maxStructDepth = 256
+
+// maxStructElements is the greatest number of elements of the structure tree that are read.
+const maxStructElements = 1 << 20
+
+// A structWalker collects the text of the elements of a structure tree into sections.
+type structWalker struct {
+	ctx     context.Context
+	roleMap Value
+
+	// pages holds the text of the marked-content sequences of the pages read so far.
+	pages map[types.Objptr]map[int64]text.Text
+	// depth is the depth of the element being read, which is limited
+	// in case the tree contains a cycle.
+	depth int
+	// seen holds the indirect elements read, each of which is read once,
+	// and elements is the number of elements read.
+	seen     map[types.Objptr]bool
+	elements int
+
+	content text.Content
+	// open holds the sections that are open, outermost first, with their heading levels.
+	open []openSection
+	// para is the text of the block-level element being read.
+	para text.Builder
+}
+
+type openSection struct {
+	level   int
+	section *text.Section
+}
+
+// walk collects the text of the kids of the structure element elem, whose content
+// is on the page pg and in the language lang unless they say otherwise.
+func (s *structWalker) walk(elem, pg Value, lang string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if p := elem.Key("Pg"); p.Kind() == Dict {
+		pg = p
+	}
+
+	kids := elem.Key("K")
+	if kids.Kind() != Array {
+		dict, _ := elem.data.(types.Dict)
+		return s.walkKid(kids, dict["K"], pg, lang)
+	}
+	raw := kids.data.(types.Array)
+	for i := range kids.Len() {
+		if err := s.walkKid(kids.Index(i), raw[i], pg, lang); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkKid collects the text of the kid kid, which is x as it is written in its parent:
+// a reference to it, if it is an indirect object.
+func (s *structWalker) walkKid(kid Value, x types.Object, pg Value, lang string) error {
+	switch kid.Kind() {
+	case Integer:
+		return s.addMarkedContent(pg, kid.Int64(), lang)
+	case Dict:
+	default:
+		return nil
+	}
+
+	switch kid.Key("Type").Name() {
+	case "MCR":
+		if p := kid.Key("Pg"); p.Kind() == Dict {
+			pg = p
+		}
+		if kid.Key("Stm").IsNull() {
+			return s.addMarkedContent(pg, kid.Key("MCID").Int64(), lang)
+		}
+		return nil
+	case "OBJR":
+		return nil
+	}
+
+	// A structure element.
+	if ptr, ok := x.(types.Objptr); ok {
+		if s.seen[ptr] {
+			return nil
+		}
+		s.seen[ptr] = true
+	}
+	if s.depth >= maxStructDepth || s.elements >= maxStructElements {
+		return nil
+	}
+	s.elements++
+	s.depth++
+	defer func() { s.depth-- }()
+	if l := kid.Key("Lang"); l.Kind() == String {
+		// See PDF 32000-1:2008, §14.9.2.
+		lang = strings.TrimSpace(l.Text())
+	}
+
+	role := s.role(kid.Key("S").Name())
+	if level := headingLevel(role); level > 0 {
+		s.flush()
+		outer := s.para
+		s.para = text.Builder{}
+		if err := s.walk(kid, pg, lang); err != nil {
+			return err
+		}
+		title := s.para.Text().TrimSpace()
+		s.para = outer
+		s.addSection(level, title)
+		return nil
+	}
+	if inlineTypes[role] {
+		return s.walk(kid, pg, lang)
+	}
+
+	s.flush()
+	if err := s.walk(kid, pg, lang); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// role returns the standard structure type that typ is mapped to by the role map.
+func (s *structWalker) role(typ string) string {
+	for range 10 {
+		mapped := s.roleMap.Key(typ)
+		if mapped.Kind() != Name {
+			break
+		}
+		typ = mapped.Name()
+	}
+	return typ
+}
+
+// headingLevel returns the level of the heading structure type role, or 0
+// if role is not a heading. See PDF 32000-1:2008, §14.8.4.3.2.
+func headingLevel(role string) int {
+	switch role {
+	case "H", "H1":
+		return 1
+	case "H2", "H3", "H4", "H5", "H6":
+		return int(role[1] - '0')
+	}
+	return 0
+}
+
+// addMarkedContent adds the text of the marked-content sequence id
+// on the page pg, tagged with the language lang.
+func (s *structWalker) addMarkedContent(pg Value, id int64, lang string) error {
+	if pg.Kind() != Dict {
+		return nil
+	}
+	mcids, ok := s.pages[pg.ptr]
+	if !ok {
+		var err error
+		num, _ := pg.r.pageNumber(pg.ptr)
+		if _, mcids, err = (&Page{v: pg, num: num, uncached: true}).extract(s.ctx, nil, true, pg.r.cfg.ocr, pg.r.cfg.builder); err != nil {
+			return &PageError{Page: num, Err: err}
+		}
+		s.pages[pg.ptr] = mcids
+	}
+	s.para.Add(mcids[id].WithLang(lang))
+	return nil
+}
+
+// flush writes the text of the block-level element read so far
+// to the innermost open section.
+func (s *structWalker) flush() {
+	var b text.Builder
+	b.Add(s.para.Text().TrimSpace())
+	s.para = text.Builder{}
+	if len(b.Text()) == 0 {
+		return
+	}
+	b.Add(text.Text{{Content: "\n"}})
+	t := b.Text()
+	if n := len(s.open); n > 0 {
+		sec := s.open[n-1].section
+		sec.Content = append(sec.Content, t)
+	} else {
+		s.content = append(s.content, t)
+	}
+}
+
+// addSection opens a section with a heading of the given level,
+// closing the open sections at the same or a deeper level.
+func (s *structWalker) addSection(level int, title text.Text) {
+	for n := len(s.open); n > 0 && s.open[n-1].level >= level; n-- {
+		s.open = s.open[:n-1]
+	}
+	sec := &text.Section{Title: title}
+	if n := len(s.open); n > 0 {
+		outer := s.open[n-1].section
+		outer.Content = append(outer.Content, sec)
+	} else {
+		s.content = append(s.content, sec)
+	}
+	s.open = append(s.open, openSection{level: level, section: sec})
+}
@@ -0,0 +1,143 @@
+package text
+
+import (
+	"unicode"
+
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Logical returns the Text with each line reordered from visual order, the order
+// in which its glyphs are laid out from left to right, to logical order, the order
+// in which it is read. This is needed for right-to-left scripts, such as Hebrew and
+// Arabic, whose glyphs are drawn in visual order: the runs of right-to-left text are
+// reversed, and so is the order of the runs in a line that is mostly right-to-left.
+// The Arabic presentation forms of such fonts are replaced by their base letters.
+func (t Text) Logical() Text {
+	var (
+		b     Builder
+		line  []rune
+		parts []int // the index in t of the part of each rune of line
+	)
+	flush := func() {
+		runes, idx := logical(line, parts)
+		for i, r := range runes {
+			p := t[idx[i]]
+			p.Content = presentationBase(r)
+			b.add(p, NoWhitespace)
+		}
+		line, parts = line[:0], parts[:0]
+	}
+
+	for i, p := range t {
+		for _, r := range p.Content {
+			if r != '\n' {
+				line = append(line, r)
+				parts = append(parts, i)
+				continue
+			}
+			flush()
+			p.Content = "\n"
+			b.add(p, NoWhitespace)
+		}
+	}
+	flush()
+	return b.Text()
+}
+
+// logical reorders the visual line, and the values of idx with it, to logical order.
+// A paragraph separator, such as a carriage return, ends the paragraph that bidi
+// orders, so the parts of the line between them are reordered one by one.
+func logical(line []rune, idx []int) ([]rune, []int) {
+	var (
+		runes []rune
+		out   []int
+		start int
+	)
+	for i, r := range line {
+		if p, _ := bidi.LookupRune(r); p.Class() != bidi.B {
+			continue
+		}
+		part, partIdx := logicalParagraph(line[start:i], idx[start:i])
+		runes = append(append(runes, part...), r)
+		out = append(append(out, partIdx...), idx[i])
+		start = i + 1
+	}
+	if start == 0 {
+		return logicalParagraph(line, idx)
+	}
+	part, partIdx := logicalParagraph(line[start:], idx[start:])
+	return append(runes, part...), append(out, partIdx...)
+}
+
+// logicalParagraph is like logical, for a line without paragraph separators.
+// The line is returned as it is if its runs as ordered do not cover it.
+func logicalParagraph(line []rune, idx []int) ([]rune, []int) {
+	var rtl, ltr int
+	for _, r := range line {
+		switch p, _ := bidi.LookupRune(r); p.Class() {
+		case bidi.R, bidi.AL:
+			rtl++
+		case bidi.L:
+			ltr++
+		}
+	}
+	if rtl == 0 {
+		return line, idx
+	}
+
+	var opts []bidi.Option
+	if rtl > ltr {
+		opts = append(opts, bidi.DefaultDirection(bidi.RightToLeft))
+	}
+	var p bidi.Paragraph
+	if _, err := p.SetString(string(line), opts...); err != nil {
+		return line, idx
+	}
+	o, err := p.Order()
+	if err != nil {
+		return line, idx
+	}
+
+	runes := make([]rune, 0, len(line))
+	out := make([]int, 0, len(idx))
+	for i := range o.NumRuns() {
+		if rtl > ltr {
+			// The runs of a right-to-left line are laid out right to left.
+			i = o.NumRuns() - 1 - i
+		}
+		run := o.Run(i)
+		start, end := run.Pos()
+		if run.Direction() != bidi.RightToLeft {
+			runes = append(runes, line[start:end+1]...)
+			out = append(out, idx[start:end+1]...)
+			continue
+		}
+		for j := end; j >= start; j-- {
+			// Mirror the brackets of reversed text.
+			runes = append(runes, []rune(bidi.ReverseString(string(line[j])))...)
+			out = append(out, idx[j])
+		}
+	}
+	if len(runes) != len(line) {
+		return line, idx
+	}
+	return runes, out
+}
+
+// presentationBase returns the base letters of r if it is in one of the
+// Arabic presentation forms blocks, or else r itself.
+func presentationBase(r rune) string {
+	if unicode.In(r, arabicPresentationForms) {
+		return norm.NFKC.String(string(r))
+	}
+	return string(r)
+}
+
+// arabicPresentationForms are the Arabic Presentation Forms-A and -B blocks.
+var arabicPresentationForms = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0xfb50, Hi: 0xfdff, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfeff, Stride: 1},
+	},
+}
@@ -0,0 +1,65 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Text_Logical(t *testing.T) {
+	testCases := map[string]struct {
+		input Text
+		want  Text
+	}{
+		"left to right": {
+			input: Text{{Size: 1, Content: "abc (1)\ndef"}},
+			want:  Text{{Size: 1, Content: "abc (1)\ndef"}},
+		},
+		"right to left": {
+			// שלום עולם, drawn left to right.
+			input: Text{{Size: 1, Content: "םלוע םולש"}},
+			want:  Text{{Size: 1, Content: "שלום עולם"}},
+		},
+		"mixed": {
+			// Latin text and numbers within Hebrew: "שלום PDF 2024 (עולם)".
+			input: Text{{Size: 1, Content: "(םלוע) PDF 2024 םולש"}},
+			want:  Text{{Size: 1, Content: "שלום PDF 2024 (עולם)"}},
+		},
+		"mixed left to right": {
+			// Hebrew within English text: "Say שלום to all".
+			input: Text{{Size: 1, Content: "Say םולש to all"}},
+			want:  Text{{Size: 1, Content: "Say שלום to all"}},
+		},
+		"lines and parts": {
+			input: Text{{Size: 1, Content: "םולש\nbc"}, {Size: 2, Weight: 1, Content: "ב"}, {Size: 1, Content: "א"}},
+			want:  Text{{Size: 1, Content: "שלום\nbcא"}, {Size: 2, Weight: 1, Content: "ב"}},
+		},
+		"leading paragraph separator": {
+			input: Text{{Size: 1, Content: "\x1cםולש"}},
+			want:  Text{{Size: 1, Content: "\x1cשלום"}},
+		},
+		"paragraph separator after Latin": {
+			input: Text{{Size: 1, Content: "abc\x1cםולש"}},
+			want:  Text{{Size: 1, Content: "abc\x1cשלום"}},
+		},
+		"carriage return": {
+			input: Text{{Size: 1, Content: "שלום\rabc"}},
+			want:  Text{{Size: 1, Content: "םולש\rabc"}},
+		},
+		"Arabic presentation forms": {
+			// سلام in presentation forms, drawn left to right.
+			input: Text{{Size: 1, Content: "ﻡﺎﻠﺳ"}},
+			want:  Text{{Size: 1, Content: "سلام"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.input.Logical()
+
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("logical text did not match expectation:", diff)
+			}
+		})
+	}
+}
@@ -1,116 +1,364 @@
 package text
 
 import (
+	"cmp"
+	"math"
+	"slices"
 	"strings"
 	"unicode"
 )
 
+// BuilderOptions are the thresholds by which a Builder breaks the runs of text
+// rendered to it into words, lines and paragraphs. Each is a multiple of the height
+// of the run being rendered, its font size, and a zero value stands for its default.
+type BuilderOptions struct {
+	// WordGap is the least gap after the end of the previous run for a run
+	// to start a new word. The default is 1.
+	WordGap float64
+	// ColumnGap is the least gap after the end of the previous run for a run
+	// to start a new line, as it is likely in another column. The default is 3.
+	ColumnGap float64
+	// LineGap is the least distance below the previous run for a run to start a new
+	// line, and above it for a run to start a new paragraph. The default is 0.9.
+	LineGap float64
+	// ParagraphGap is the least distance below the previous run for a run
+	// to start a new paragraph. The default is 2.
+	ParagraphGap float64
+
+	// UseSpaceWidth makes WordGap and ColumnGap multiples of the width of a space
+	// in the font of the run being rendered, when it is known, instead of its height.
+	UseSpaceWidth bool
+
+	// TabGap, if positive, is the least gap after the end of the previous run on the
+	// same line for a run to be separated from it by a tab, as in a table or between
+	// a label and its value, rather than by a space or a new line. It is a multiple of
+	// the width of a space, when it is known, or else of the height of the run.
+	TabGap float64
+	// TabSpaces makes the separator of a TabGap the number of spaces that
+	// would fill the gap, up to 64, rather than a tab.
+	TabSpaces bool
+
+	// ReadingOrder makes a Builder add the runs rendered to it in reading order, rather
+	// than in the order they are rendered: top to bottom by baseline, the runs within
+	// LineGap below the top of a line being on it, and left to right on each line.
+	// The runs are kept until Text, Add, AddSeparated or WriteNewline is called, and
+	// so take memory in proportion to the text of a page.
+	ReadingOrder bool
+}
+
+// withDefaults returns the options with their zero values replaced by the defaults.
+func (o BuilderOptions) withDefaults() BuilderOptions {
+	for _, v := range []struct {
+		p   *float64
+		def float64
+	}{{&o.WordGap, 1}, {&o.ColumnGap, 3}, {&o.LineGap, 0.9}, {&o.ParagraphGap, 2}} {
+		if *v.p == 0 {
+			*v.p = v.def
+		}
+	}
+	return o
+}
+
 // Builder builds Text
 type Builder struct {
+	// Options are the thresholds for breaking text into words, lines and paragraphs.
+	Options BuilderOptions
+	// DuplicateOffset, if positive, is the greatest distance between the origins of
+	// two consecutive runs of the same content for the second to be dropped. Some
+	// producers simulate bold text, or draw a shadow, by drawing a run twice with
+	// a small offset. The run that is kept is made bold.
+	DuplicateOffset float64
+	// Page is the number of the page of the runs rendered, set as the Page
+	// of their parts, or zero if it is not known.
+	Page int
+
 	// location on the page of the last text rendered.
 	x, y float64
 	text Text
+	// last is the last run rendered, at origin (lastX, lastY).
+	last         string
+	lastX, lastY float64
+	// runs are the runs rendered and not yet added, with ReadingOrder.
+	runs []run
+}
+
+// A run is a run of text rendered to a Builder.
+type run struct {
+	x, y, w, h, space float64
+	font, content     string
+	skipped           bool // whether the run is skipped rather than rendered
 }
 
 // Add adds the Text content to the buffer, merging text parts if possible.
 func (b *Builder) Add(t Text) {
+	b.AddSeparated(t, NoWhitespace)
+}
+
+// AddSeparated is like Add, but separates the Text from the content already in the
+// buffer by the white space ws, unless either of them is empty or already has it there.
+func (b *Builder) AddSeparated(t Text, ws Whitespace) {
+	b.flush()
+	if len(b.text) == 0 {
+		ws = NoWhitespace
+	}
 	for _, part := range t {
-		b.add(part.Size, part.Weight, part.Content, noWhitespace)
+		b.add(part, ws)
+		ws = NoWhitespace
 	}
 }
 
 func (b *Builder) WriteNewline() {
+	b.flush()
 	if len(b.text) == 0 {
 		return
 	}
-	b.append("", newLine)
+	b.append("", NewLine)
 }
 
 // Render adds the content with the given dimensions and font to the text builder.
 // Text blocks are sectioned into lines and paragraphs based on their relative location
-// on the page.
+// on the page: y is the baseline of the content and h its font size, and lines are
+// told apart by the distance between their baselines, whatever the ascent and
+// descent of their fonts.
 func (b *Builder) Render(x, y, w, h float64, font, content string) {
+	b.RenderSpaced(x, y, w, h, 0, font, content)
+}
+
+// RenderSpaced is like Render, given also the width of a space in the font,
+// or zero if it is unknown.
+func (b *Builder) RenderSpaced(x, y, w, h, space float64, font, content string) {
 	if len(content) == 0 {
 		return
 	}
+	b.renderRun(run{x: x, y: y, w: w, h: h, space: space, font: font, content: content})
+}
+
+// Skip accounts for a run of the given dimensions that is not shown, such as
+// one in an optional content group that is off. It adds no text, but a run after
+// it on the same line is separated from the run before it as though the skipped
+// run were there, without text, rather than by the gap it leaves.
+func (b *Builder) Skip(x, y, w, h float64) {
+	b.renderRun(run{x: x, y: y, w: w, h: h, skipped: true})
+}
+
+// renderRun renders the run r, or keeps it with ReadingOrder,
+// unless its geometry is not finite.
+func (b *Builder) renderRun(r run) {
+	for _, v := range [...]float64{r.x, r.y, r.w, r.h} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// Such as text drawn with a singular matrix: it has no place on the page.
+			return
+		}
+	}
+	if b.Options.ReadingOrder {
+		b.runs = append(b.runs, r)
+		return
+	}
+	b.render(r)
+}
+
+// render adds the run r to the text, after the runs before it.
+func (b *Builder) render(r run) {
+	x, y, w, h, space, content := r.x, r.y, r.w, r.h, r.space, r.content
+	if r.skipped {
+		b.skip(r)
+		return
+	}
+	if b.duplicate(x, y, content) {
+		return
+	}
+	b.last, b.lastX, b.lastY = content, x, y
+
+	o := b.Options.withDefaults()
+	unit := h
+	if space > 0 {
+		unit = space
+	}
+	gap := h
+	if o.UseSpaceWidth {
+		gap = unit
+	}
 
-	var ws whitespace
+	var ws Whitespace
 	switch {
 	case len(b.text) == 0:
-	case y > b.y+0.9*h, // Significantly above previous write.
-		y < b.y-2*h: // More than 2 lines below previous write.
+	case y > b.y+o.LineGap*h, // Significantly above previous write.
+		y < b.y-o.ParagraphGap*h: // Lines below previous write.
 		// Next paragraph.
-		ws = newParagraph
+		ws = NewParagraph
 		// fmt.Println(b.y, y, h, content)
-	case y < b.y-0.9*h, // Significantly below previous write.
-		x > b.x+3*h: // Potentially new table column.
+	case y < b.y-o.LineGap*h: // Significantly below previous write.
+		// Next line.
+		ws = NewLine
+	case o.TabGap > 0 && x > b.x+o.TabGap*unit:
+		// Far along the same line.
+		content = b.tab(x-b.x, unit) + content
+	case x > b.x+o.ColumnGap*gap: // Potentially new table column.
 		// Next line.
-		ws = newLine
-	case x > b.x+h:
-		ws = newWord
+		ws = NewLine
+	case x > b.x+o.WordGap*gap:
+		ws = NewWord
 	}
 	b.x = x + w
 	b.y = y
 
 	var weight int
-	if strings.HasSuffix(font, "-Bold") {
+	if strings.HasSuffix(r.font, "-Bold") {
 		weight = 1
 	}
 
-	b.add(h, weight, content, ws)
+	b.add(Part{Size: h, Weight: weight, Page: b.Page, Content: content}, ws)
+}
+
+// skip moves the end of the text on to the end of the skipped run r, if r
+// continues the line, so that the next run is separated from the end of r.
+func (b *Builder) skip(r run) {
+	o := b.Options.withDefaults()
+	switch {
+	case len(b.text) == 0,
+		r.y > b.y+o.LineGap*r.h, r.y < b.y-o.LineGap*r.h, // Not on the same line.
+		r.x > b.x+o.ColumnGap*r.h, // Far along the line, maybe in another column.
+		r.x+r.w <= b.x:
+		return
+	}
+	b.x = r.x + r.w
+}
+
+// flush adds the runs kept with ReadingOrder to the text, in reading order.
+func (b *Builder) flush() {
+	runs := b.runs
+	if len(runs) == 0 {
+		return
+	}
+	b.runs = nil
+
+	o := b.Options.withDefaults()
+	slices.SortStableFunc(runs, func(r, s run) int { return cmp.Compare(s.y, r.y) })
+	for len(runs) > 0 {
+		n := 1
+		for n < len(runs) && runs[n].y >= runs[0].y-o.LineGap*runs[0].h {
+			n++
+		}
+		line := runs[:n]
+		slices.SortStableFunc(line, func(r, s run) int { return cmp.Compare(r.x, s.x) })
+		for _, r := range line {
+			b.render(r)
+		}
+		runs = runs[n:]
+	}
+}
+
+// maxTabSpaces is the greatest number of spaces that a TabGap is filled with.
+const maxTabSpaces = 64
+
+// tab returns the separator of a run from the previous one on the same
+// line, a gap away, for a space of the given width.
+func (b *Builder) tab(gap, space float64) string {
+	if !b.Options.TabSpaces {
+		return "\t"
+	}
+	return strings.Repeat(" ", int(max(1, min(math.Round(gap/space), maxTabSpaces))))
 }
 
-type whitespace int
+// duplicate reports whether the run of content at (x, y) repeats the last run
+// at about the same origin, making the last run bold if so.
+func (b *Builder) duplicate(x, y float64, content string) bool {
+	if b.DuplicateOffset <= 0 || len(b.text) == 0 || content != b.last ||
+		math.Abs(x-b.lastX) > b.DuplicateOffset || math.Abs(y-b.lastY) > b.DuplicateOffset {
+		return false
+	}
+
+	last := &b.text[len(b.text)-1]
+	if last.Weight == 0 && strings.HasSuffix(last.Content, content) {
+		part := Part{Size: last.Size, Weight: 1, Lang: last.Lang, Page: last.Page, Content: content}
+		if last.Content = strings.TrimSuffix(last.Content, content); last.Content == "" {
+			*last = part
+		} else {
+			b.text = append(b.text, part)
+		}
+	}
+	return true
+}
+
+// Whitespace is the white space that separates text added to a Builder from
+// the content before it.
+type Whitespace int
 
 const (
-	noWhitespace whitespace = iota
-	newWord
-	newLine
-	newParagraph
+	NoWhitespace Whitespace = iota
+	NewWord                 // A space.
+	NewLine                 // A newline.
+	NewParagraph            // A blank line.
 )
 
-func (b *Builder) add(size float64, weight int, content string, w whitespace) {
-	isWhitespace := len(strings.TrimSpace(content)) == 0
+// add adds the content of p to the last part, if it has the same size, weight,
+// language and page, or is only white space, which has none to keep; or else to a new part.
+func (b *Builder) add(p Part, w Whitespace) {
+	isWhitespace := len(strings.TrimSpace(p.Content)) == 0
 	if l := len(b.text); l > 0 {
 		last := &b.text[l-1]
-		if isWhitespace || (last.Size == size && last.Weight == weight) {
-			b.append(content, w)
+		if isWhitespace || (last.Size == p.Size && last.Weight == p.Weight && last.Lang == p.Lang && last.Page == p.Page) {
+			b.append(p.Content, w)
 			return
 		}
 	}
 
-	b.text = append(b.text, Part{Size: size, Weight: weight})
-	b.append(content, w)
+	b.text = append(b.text, Part{Size: p.Size, Weight: p.Weight, Lang: p.Lang, Page: p.Page})
+	b.append(p.Content, w)
 }
 
 // The Builder must be non-empty to call append, or else it will panic.
-func (b *Builder) append(s string, w whitespace) {
+func (b *Builder) append(s string, w Whitespace) {
 	last := &b.text[len(b.text)-1]
-	n := len(last.Content)
+	// The content before s ends in this part, or in one before it, if this part is new.
+	end, ok := b.end()
 	m := len(s)
 
 	switch w {
-	case noWhitespace:
-	case newWord:
+	case NoWhitespace:
+	case NewWord:
 		switch {
-		case n > 0 && unicode.IsSpace(rune(last.Content[n-1])):
+		case ok && unicode.IsSpace(rune(end)):
 		case m > 0 && unicode.IsSpace(rune(s[0])):
 		default:
 			last.Content += " "
 		}
-	case newLine:
+	case NewLine:
 		switch {
-		case n > 0 && last.Content[n-1] == '\n':
+		case ok && end == '\n':
 		case m > 0 && s[0] == '\n':
 		default:
 			last.Content += "\n"
 		}
-	case newParagraph:
-		last.Content = strings.TrimRightFunc(last.Content, unicode.IsSpace)
+	case NewParagraph:
+		for i := len(b.text) - 1; i >= 0; i-- {
+			p := &b.text[i]
+			if p.Content = strings.TrimRightFunc(p.Content, unicode.IsSpace); p.Content != "" {
+				break
+			}
+		}
 		s = strings.TrimLeftFunc(s, unicode.IsSpace)
 		last.Content += "\n\n"
 	}
 	last.Content += s
 }
 
-func (b Builder) Text() Text { return b.text }
+// end returns the last byte of the content, if there is any.
+func (b *Builder) end() (byte, bool) {
+	for i := len(b.text) - 1; i >= 0; i-- {
+		if c := b.text[i].Content; c != "" {
+			return c[len(c)-1], true
+		}
+	}
+	return 0, false
+}
+
+// Text returns the text built, with the runs kept with ReadingOrder added.
+func (b Builder) Text() Text {
+	if len(b.runs) > 0 {
+		// Add the runs to a copy, leaving those of b to be added in order with any after them.
+		b.text, b.runs = slices.Clone(b.text), slices.Clone(b.runs)
+		b.flush()
+	}
+	return b.text
+}
@@ -0,0 +1,186 @@
+package text
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Builder_Render(t *testing.T) {
+	type run struct {
+		x, y, w, h, space float64
+		content           string
+	}
+	testCases := map[string]struct {
+		dupOffset float64
+		opts      BuilderOptions
+		runs      []run
+		want      Text
+	}{
+		"words and lines": {
+			runs: []run{{0, 100, 20, 10, 0, "one"}, {40, 100, 20, 10, 0, "two"}, {0, 88, 20, 10, 0, "three"}},
+			want: Text{{Size: 10, Content: "one two\nthree"}},
+		},
+		"non-finite geometry": {
+			runs: []run{
+				{0, 100, 20, 10, 0, "one"},
+				{math.NaN(), math.NaN(), math.NaN(), 0, 0, "lost"},
+				{0, math.Inf(-1), 20, 10, 0, "lost"},
+				{20, 100, 20, 10, 0, "two"},
+			},
+			want: Text{{Size: 10, Content: "onetwo"}},
+		},
+		"tight leading": {
+			runs: []run{{0, 100, 20, 10, 0, "one"}, {0, 79, 20, 10, 0, "two"}},
+			want: Text{{Size: 10, Content: "one\n\ntwo"}},
+		},
+		"tight leading with paragraph gap": {
+			opts: BuilderOptions{ParagraphGap: 2.5},
+			runs: []run{{0, 100, 20, 10, 0, "one"}, {0, 79, 20, 10, 0, "two"}},
+			want: Text{{Size: 10, Content: "one\ntwo"}},
+		},
+		"wide tracking": {
+			runs: []run{{0, 100, 20, 10, 3, "one"}, {25, 100, 20, 10, 3, "two"}},
+			want: Text{{Size: 10, Content: "onetwo"}},
+		},
+		"wide tracking with space width": {
+			opts: BuilderOptions{UseSpaceWidth: true},
+			runs: []run{{0, 100, 20, 10, 3, "one"}, {25, 100, 20, 10, 3, "two"}, {60, 100, 20, 10, 0, "three"}},
+			want: Text{{Size: 10, Content: "one two three"}},
+		},
+		"tab gap": {
+			opts: BuilderOptions{TabGap: 4},
+			runs: []run{
+				{0, 100, 20, 10, 3, "Invoice"}, {32, 100, 10, 10, 3, "number"},
+				{100, 100, 10, 10, 3, "12345"}, {0, 88, 10, 10, 3, "Due"},
+			},
+			want: Text{{Size: 10, Content: "Invoice number\t12345\nDue"}},
+		},
+		"tab gap in spaces": {
+			opts: BuilderOptions{TabGap: 4, TabSpaces: true},
+			runs: []run{{0, 100, 20, 10, 3, "Invoice"}, {38, 100, 10, 10, 3, "12345"}},
+			want: Text{{Size: 10, Content: "Invoice" + strings.Repeat(" ", 6) + "12345"}},
+		},
+		"tab gap in spaces, at most 64": {
+			opts: BuilderOptions{TabGap: 4, TabSpaces: true},
+			runs: []run{{0, 100, 20, 10, 1e-9, "Invoice"}, {1e6, 100, 10, 10, 1e-9, "12345"}},
+			want: Text{{Size: 10, Content: "Invoice" + strings.Repeat(" ", 64) + "12345"}},
+		},
+		"reading order": {
+			opts: BuilderOptions{ReadingOrder: true},
+			runs: []run{
+				{0, 10, 20, 10, 0, "footer"}, {40, 100, 20, 10, 0, "two"}, {0, 101, 20, 10, 0, "one"},
+				{0, 88, 20, 10, 0, "three"}, {0, 200, 20, 10, 0, "header"},
+			},
+			want: Text{{Size: 10, Content: "header\n\none two\nthree\n\nfooter"}},
+		},
+		"duplicates kept": {
+			runs: []run{{0, 100, 20, 10, 0, "one"}, {0.5, 100.5, 20, 10, 0, "one"}},
+			want: Text{{Size: 10, Content: "oneone"}},
+		},
+		"duplicates dropped": {
+			dupOffset: 1,
+			runs: []run{
+				{0, 100, 20, 10, 0, "one"}, {0.5, 100.5, 20, 10, 0, "one"},
+				{40, 100, 20, 10, 0, "two"}, {40.5, 99.5, 20, 10, 0, "two"},
+				{60, 100, 20, 10, 0, "two"},
+			},
+			want: Text{{Size: 10, Weight: 1, Content: "one"}, {Size: 10, Content: " "}, {Size: 10, Weight: 1, Content: "two"}, {Size: 10, Content: "two"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{DuplicateOffset: tc.dupOffset, Options: tc.opts}
+			for _, r := range tc.runs {
+				b.RenderSpaced(r.x, r.y, r.w, r.h, r.space, "", r.content)
+			}
+
+			if diff := cmp.Diff(b.Text(), tc.want); diff != "" {
+				t.Error("rendered text did not match expectation:", diff)
+			}
+		})
+	}
+}
+
+func Test_Builder_Skip(t *testing.T) {
+	testCases := map[string]struct {
+		opts BuilderOptions
+		want string
+	}{
+		"in order":      {want: "one two\nthree four"},
+		"reading order": {opts: BuilderOptions{ReadingOrder: true}, want: "one two\nthree four"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{Options: tc.opts}
+			b.Render(0, 100, 20, 10, "", "one")
+			// A wide skipped run leaves no column gap before the run after it.
+			b.Skip(20, 100, 60, 10)
+			b.Render(95, 100, 20, 10, "", "two")
+			// A skipped run on another line, or in another column, is not in the line.
+			b.Skip(0, 50, 300, 10)
+			b.Skip(300, 100, 60, 10)
+			b.Render(400, 100, 20, 10, "", "three")
+			b.Render(435, 100, 20, 10, "", "four")
+
+			if got := b.Text().String(); got != tc.want {
+				t.Errorf("got text %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_Builder_AddSeparated(t *testing.T) {
+	testCases := map[string]struct {
+		texts []Text
+		ws    Whitespace
+		want  Text
+	}{
+		"lines": {
+			texts: []Text{{{Size: 1, Content: "a"}}, {{Size: 1, Content: "b"}}, {{Size: 2, Content: "c"}}},
+			ws:    NewLine,
+			want:  Text{{Size: 1, Content: "a\nb"}, {Size: 2, Content: "\nc"}},
+		},
+		"lines already separated": {
+			texts: []Text{{{Size: 1, Content: "a\n"}}, {{Size: 2, Content: "b"}}, {{Size: 1, Content: "\nc"}}},
+			ws:    NewLine,
+			want:  Text{{Size: 1, Content: "a\n"}, {Size: 2, Content: "b"}, {Size: 1, Content: "\nc"}},
+		},
+		"words": {
+			texts: []Text{{{Size: 1, Content: "a"}}, nil, {{Size: 1, Content: "b"}, {Size: 2, Content: "c"}}},
+			ws:    NewWord,
+			want:  Text{{Size: 1, Content: "a b"}, {Size: 2, Content: "c"}},
+		},
+		"paragraphs": {
+			texts: []Text{{{Size: 1, Content: "a"}, {Size: 2, Content: " \n"}}, {{Size: 3, Content: "b"}}},
+			ws:    NewParagraph,
+			want:  Text{{Size: 1, Content: "a"}, {Size: 3, Content: "\n\nb"}},
+		},
+		"pages": {
+			texts: []Text{{{Size: 1, Page: 1, Content: "a"}}, {{Size: 1, Page: 1, Content: "b"}}, {{Size: 1, Page: 2, Content: "c"}}},
+			ws:    NewLine,
+			want:  Text{{Size: 1, Page: 1, Content: "a\nb"}, {Size: 1, Page: 2, Content: "\nc"}},
+		},
+		"whitespace between sizes": {
+			texts: []Text{{{Size: 1, Content: "a"}}, {{Size: 2, Content: "\n"}}, {{Size: 2, Content: "b"}}},
+			want:  Text{{Size: 1, Content: "a\n"}, {Size: 2, Content: "b"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var b Builder
+			for _, text := range tc.texts {
+				b.AddSeparated(text, tc.ws)
+			}
+
+			if diff := cmp.Diff(b.Text(), tc.want); diff != "" {
+				t.Error("built text did not match expectation:", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package text
+
+import "sort"
+
+// Document is the text of a whole document, page by page.
+type Document struct {
+	Pages []Page
+}
+
+// Page is the text of a page of a Document.
+type Page struct {
+	// Number is the page number, from 1.
+	Number int
+	// Label is the page label, the page number shown by a viewer.
+	Label string
+	Text  Text
+}
+
+// Text returns the text of the document, with a newline between each page and the next.
+func (d Document) Text() Text {
+	var b Builder
+	for i, p := range d.Pages {
+		if i > 0 {
+			b.Add(Text{{Content: "\n"}})
+		}
+		b.Add(p.Text.WithPage(p.Number))
+	}
+	return b.Text()
+}
+
+// String renders the Document without sizing information.
+func (d Document) String() string {
+	return d.Text().String()
+}
+
+// Sectioned attempts to process the text of the whole document into a structured
+// hierarchy of sections, as Text.Sectioned does, but without the page headers and
+// footers: the first and last lines of the pages that recur on at least half of
+// them. A section that continues on the next page is carried over the page break.
+func (d Document) Sectioned() Content {
+	repeated := d.repeatedLines()
+
+	var (
+		b     Builder
+		lines int
+	)
+	for _, p := range d.Pages {
+		page := p.Text.Lines()
+		first, last := contentLines(page)
+		for j, line := range page {
+			if (j == first || j == last) && repeated[line.String()] {
+				continue
+			}
+			if lines > 0 {
+				b.Add(Text{{Content: "\n"}})
+			}
+			b.Add(line.WithPage(p.Number))
+			lines++
+		}
+	}
+	return b.Text().Sectioned()
+}
+
+// repeatedLines returns the first and last lines of the pages that recur on
+// at least half of them, if there are at least three pages.
+func (d Document) repeatedLines() map[string]bool {
+	if len(d.Pages) < 3 {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, p := range d.Pages {
+		lines := p.Text.Lines()
+		first, last := contentLines(lines)
+		if first < 0 {
+			continue
+		}
+		counts[lines[first].String()]++
+		if last != first {
+			counts[lines[last].String()]++
+		}
+	}
+
+	repeated := map[string]bool{}
+	for line, n := range counts {
+		if 2*n >= len(d.Pages) {
+			repeated[line] = true
+		}
+	}
+	return repeated
+}
+
+// contentLines returns the indexes of the first and last of the lines,
+// those of Text.Lines, that are not empty, or -1 if there are none.
+func contentLines(lines []Text) (first, last int) {
+	first, last = -1, -1
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	return first, last
+}
+
+// Offset returns the byte offset in String of the start of the text of
+// the page with the given index in Pages.
+func (d Document) Offset(page int) int {
+	var off int
+	for _, p := range d.Pages[:page] {
+		off += len(p.Text.String()) + 1 // With the newline after the page.
+	}
+	return off
+}
+
+// PageAt returns the index in Pages of the page that the byte
+// offset in String is in, or -1 if it is out of range.
+func (d Document) PageAt(offset int) int {
+	// The offsets of the ends of the pages, at the newlines after them.
+	ends := make([]int, len(d.Pages))
+	var off int
+	for i, p := range d.Pages {
+		off += len(p.Text.String())
+		ends[i] = off
+		off++
+	}
+	if offset < 0 || len(d.Pages) == 0 || offset > ends[len(ends)-1] {
+		return -1
+	}
+	return sort.SearchInts(ends, offset)
+}
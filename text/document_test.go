@@ -0,0 +1,69 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Document_Sectioned(t *testing.T) {
+	page := func(n int, lines ...Text) Page {
+		var b Builder
+		b.Add(Text{{Size: 8, Content: "ACME Corp annual report\n"}})
+		for _, line := range lines {
+			b.Add(line)
+			b.Add(Text{{Content: "\n"}})
+		}
+		b.Add(Text{{Size: 8, Content: "Confidential"}})
+		return Page{Number: n, Text: b.Text()}
+	}
+	doc := Document{Pages: []Page{
+		page(1, Text{{Size: 10, Content: "Intro text"}}, Text{}, Text{{Size: 16, Content: "Results"}}, Text{}, Text{{Size: 10, Content: "Results start here"}}),
+		page(2, Text{{Size: 10, Content: "and continue here."}}),
+		page(3, Text{{Size: 10, Content: "and end here."}}),
+	}}
+
+	got := doc.Sectioned()
+	want := Content{
+		Text{{Size: 10, Page: 1, Content: "Intro text"}},
+		&Section{
+			Title: Text{{Size: 16, Page: 1, Content: "Results"}},
+			Content: Content{Text{
+				{Size: 10, Page: 1, Content: "Results start here\n"},
+				{Size: 10, Page: 2, Content: "and continue here.\n"},
+				{Size: 10, Page: 3, Content: "and end here."},
+			}},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("sections did not match expectation:", diff)
+	}
+}
+
+func Test_Document_PageAt(t *testing.T) {
+	doc := Document{Pages: []Page{
+		{Number: 1, Text: Text{{Content: "one"}}},
+		{Number: 2, Text: Text{{Content: "two\n"}}},
+		{Number: 3},
+		{Number: 4, Text: Text{{Content: "four"}}},
+	}}
+	s := doc.String()
+	if want := "one\ntwo\n\n\nfour"; s != want {
+		t.Fatalf("got %q, want %q", s, want)
+	}
+
+	var got []int
+	for off := range len(s) + 2 {
+		got = append(got, doc.PageAt(off))
+	}
+	want := []int{0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 3, 3, 3, 3, 3, -1}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("pages did not match expectation:", diff)
+	}
+
+	for i := range doc.Pages {
+		if off := doc.Offset(i); doc.PageAt(off) != i {
+			t.Errorf("page %d: got page %d at its offset %d", i, doc.PageAt(off), off)
+		}
+	}
+}
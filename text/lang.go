@@ -0,0 +1,71 @@
+package text
+
+import "unicode"
+
+// scripts are the scripts that Script reports, with their ISO 15924 codes.
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	{"Latn", unicode.Latin},
+	{"Cyrl", unicode.Cyrillic},
+	{"Grek", unicode.Greek},
+	{"Arab", unicode.Arabic},
+	{"Hebr", unicode.Hebrew},
+	{"Hani", unicode.Han},
+	{"Hira", unicode.Hiragana},
+	{"Kana", unicode.Katakana},
+	{"Hang", unicode.Hangul},
+	{"Deva", unicode.Devanagari},
+	{"Thai", unicode.Thai},
+	{"Armn", unicode.Armenian},
+	{"Geor", unicode.Georgian},
+	{"Beng", unicode.Bengali},
+	{"Taml", unicode.Tamil},
+}
+
+// Script returns the ISO 15924 code, such as "Latn", "Cyrl" or "Hani", of the script
+// with the most letters in the Text, or "" if it has none in the scripts known to it.
+// It is a guess at the writing system of text whose language is not declared, not
+// the language itself: English and French are both "Latn".
+func (t Text) Script() string {
+	counts := make([]int, len(scripts))
+	for _, p := range t {
+		for _, r := range p.Content {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			for i, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[i]++
+					break
+				}
+			}
+		}
+	}
+
+	var script string
+	var most int
+	for i, n := range counts {
+		if n > most {
+			script, most = scripts[i].code, n
+		}
+	}
+	return script
+}
+
+// WithLang returns the Text with its parts in the language with the given tag,
+// except for those already tagged with a language.
+func (t Text) WithLang(lang string) Text {
+	if lang == "" {
+		return t
+	}
+	tagged := make(Text, len(t))
+	for i, p := range t {
+		if p.Lang == "" {
+			p.Lang = lang
+		}
+		tagged[i] = p
+	}
+	return tagged
+}
@@ -0,0 +1,24 @@
+package text
+
+import "testing"
+
+func Test_Text_Script(t *testing.T) {
+	testCases := map[string]struct {
+		input string
+		want  string
+	}{
+		"empty":    {},
+		"digits":   {input: "1, 2, 3."},
+		"latin":    {input: "Hello, world", want: "Latn"},
+		"cyrillic": {input: "Привет, world", want: "Cyrl"},
+		"han":      {input: "日本語の文章", want: "Hani"},
+		"arabic":   {input: "مرحبا 123", want: "Arab"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := (Text{{Content: tc.input}}).Script(); got != tc.want {
+				t.Errorf("got script %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
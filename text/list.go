@@ -0,0 +1,196 @@
+package text
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A List is a bulleted (unordered) or numbered (ordered) list.
+type List struct {
+	Ordered bool
+	Items   []ListItem
+}
+
+// A ListItem is an item of a List: its text, without its bullet or number,
+// and the list nested in it, if any.
+type ListItem struct {
+	Text Text
+	List *List
+}
+
+func (l List) String() string { return "\n\n" + l.format(0, Text.String) + "\n" }
+
+func (l List) DebugString() string { return "\n\n" + l.format(0, Text.DebugString) + "\n" }
+
+func (l List) markdown(int) string { return l.String() }
+
+// format renders the items of the list, one per line, with their text rendered by text,
+// each preceded by "- " or its number, and indented to the given depth.
+func (l List) format(indent int, text func(Text) string) string {
+	var b strings.Builder
+	for i, item := range l.Items {
+		marker := "- "
+		if l.Ordered {
+			marker = strconv.Itoa(i+1) + ". "
+		}
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString(marker)
+		b.WriteString(text(item.Text))
+		b.WriteByte('\n')
+		if item.List != nil {
+			// Indented to the text of the item, as Markdown requires.
+			b.WriteString(item.List.format(indent+len(marker), text))
+		}
+	}
+	return b.String()
+}
+
+// bullets are the characters that mark the items of unordered lists.
+var bullets = []string{"•", "▪", "-", "*"}
+
+// A listMarker is the bullet or number at the start of a list item.
+type listMarker struct {
+	// style identifies the kind of marker: the bullet, or the form of the
+	// number, such as "1." or "a)". Items of the same list have the same style.
+	style   string
+	ordered bool
+	n       int
+	// text is the text of the item, after the marker.
+	text Text
+}
+
+// parseMarker returns the marker at the start of the line, one of those of Text.Lines.
+func parseMarker(line Text) (listMarker, bool) {
+	s := line.String()
+	for _, b := range bullets {
+		rest, ok := strings.CutPrefix(s, b)
+		if !ok {
+			continue
+		}
+		// An ASCII bullet is only one if it is followed by a space, as in "- item".
+		if r, _ := utf8.DecodeRuneInString(rest); len(b) == 1 && !unicode.IsSpace(r) || rest == "" {
+			return listMarker{}, false
+		}
+		return listMarker{style: b, text: trimPrefix(line, len(b))}, true
+	}
+
+	// A number or letter, and a period or parenthesis: "1.", "12)", "a." or "b)".
+	var (
+		i     int
+		n     int
+		style string
+	)
+	switch {
+	case s != "" && s[0] >= '0' && s[0] <= '9':
+		for i < len(s) && i < 4 && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		n, _ = strconv.Atoi(s[:i])
+		style = "1"
+	case s != "" && s[0] >= 'a' && s[0] <= 'z':
+		i, n, style = 1, int(s[0]-'a')+1, "a"
+	default:
+		return listMarker{}, false
+	}
+	if i+1 >= len(s) || s[i] != '.' && s[i] != ')' || s[i+1] != ' ' {
+		return listMarker{}, false
+	}
+	return listMarker{style: style + s[i:i+1], ordered: true, n: n, text: trimPrefix(line, i+1)}, true
+}
+
+// trimPrefix returns the Text without its first n bytes, trimmed of white space.
+func trimPrefix(t Text, n int) Text {
+	var trimmed Text
+	for _, p := range t {
+		cut := min(n, len(p.Content))
+		p.Content = p.Content[cut:]
+		n -= cut
+		trimmed = append(trimmed, p)
+	}
+	return trimmed.TrimSpace()
+}
+
+// parseList returns the list of at least two items that starts at lines[i], where lines
+// are those of Text.Lines, and the index of the line after it; or nil if there is none.
+func parseList(lines []Text, i int) (*List, int) {
+	m, ok := parseMarker(lines[i])
+	if !ok {
+		return nil, i
+	}
+	l, next := parseItems(lines, i, m, nil)
+	if len(l.Items) < 2 {
+		return nil, i
+	}
+	return l, next
+}
+
+// parseItems returns the list of items with the style of the marker m of lines[i],
+// nested within the lists with the outer styles, and the index of the line after it.
+// A line directly after an item that is not itself an item continues the item;
+// a line with a different style of marker starts a nested list.
+func parseItems(lines []Text, i int, m listMarker, outer []string) (*List, int) {
+	l := &List{Ordered: m.ordered}
+	var item Builder
+	end := func() {
+		l.Items[len(l.Items)-1].Text = item.Text()
+		item = Builder{}
+	}
+
+	l.Items = append(l.Items, ListItem{})
+	item.Add(m.text)
+	last := m.n
+	for i++; i < len(lines); {
+		line := lines[i]
+		if len(line) == 0 {
+			// A blank line ends the list, unless the next item follows it.
+			if i+1 < len(lines) && !isHeading(lines, i+1) {
+				if next, ok := parseMarker(lines[i+1]); ok && next.style == m.style && (!m.ordered || next.n == last+1) {
+					i++
+					continue
+				}
+			}
+			break
+		}
+
+		next, ok := parseMarker(line)
+		switch {
+		case !ok:
+			item.AddSeparated(line, NewWord)
+			i++
+		case next.style == m.style:
+			if m.ordered && next.n != last+1 {
+				end()
+				return l, i
+			}
+			end()
+			l.Items = append(l.Items, ListItem{})
+			item.Add(next.text)
+			last = next.n
+			i++
+		case contains(outer, next.style):
+			end()
+			return l, i
+		case l.Items[len(l.Items)-1].List != nil:
+			// A second nested list does not belong to the item.
+			end()
+			return l, i
+		default:
+			nested, j := parseItems(lines, i, next, append(outer, m.style))
+			l.Items[len(l.Items)-1].List = nested
+			i = j
+		}
+	}
+	end()
+	return l, i
+}
+
+func contains(styles []string, style string) bool {
+	for _, s := range styles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestText_Sectioned_lists(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		want     Content
+		markdown string
+	}{
+		"bullets": {
+			input: "Intro.\n• one\n• two\ncontinued\n\nAfter.",
+			want: Content{
+				Text{{Content: "Intro."}},
+				&List{Items: []ListItem{
+					{Text: Text{{Content: "one"}}},
+					{Text: Text{{Content: "two continued"}}},
+				}},
+				Text{{Content: "After."}},
+			},
+			markdown: "Intro.\n\n- one\n- two continued\n\nAfter.",
+		},
+		"numbered": {
+			input: "1. first\n\n2) not the same\n2. second\n3. third",
+			want: Content{
+				Text{{Content: "1. first\n\n2) not the same"}},
+				&List{Ordered: true, Items: []ListItem{
+					{Text: Text{{Content: "second"}}},
+					{Text: Text{{Content: "third"}}},
+				}},
+				Text(nil),
+			},
+		},
+		"blank lines between items": {
+			input: "a) first\n\nb) second\n\nc) third",
+			want: Content{
+				Text(nil),
+				&List{Ordered: true, Items: []ListItem{
+					{Text: Text{{Content: "first"}}},
+					{Text: Text{{Content: "second"}}},
+					{Text: Text{{Content: "third"}}},
+				}},
+				Text(nil),
+			},
+			markdown: "\n\n1. first\n2. second\n3. third\n\n",
+		},
+		"nested": {
+			input: "1. first\n- inner\n- also\n2. second\n• x",
+			want: Content{
+				Text(nil),
+				&List{Ordered: true, Items: []ListItem{
+					{Text: Text{{Content: "first"}}, List: &List{Items: []ListItem{
+						{Text: Text{{Content: "inner"}}},
+						{Text: Text{{Content: "also"}}},
+					}}},
+					{Text: Text{{Content: "second"}}, List: &List{Items: []ListItem{
+						{Text: Text{{Content: "x"}}},
+					}}},
+				}},
+				Text(nil),
+			},
+			markdown: "\n\n1. first\n   - inner\n   - also\n2. second\n   - x\n\n",
+		},
+		"single item": {
+			input: "- alone\ntext",
+			want:  Content{Text{{Content: "- alone\ntext"}}},
+		},
+		"not bullets": {
+			input: "-5 degrees\n*emphasis*\n-",
+			want:  Content{Text{{Content: "-5 degrees\n*emphasis*\n-"}}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := Text{{Content: tc.input}}.Sectioned()
+
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("content did not match expectation:", diff)
+			}
+			if tc.markdown != "" {
+				if diff := cmp.Diff(got.Markdown(), tc.markdown); diff != "" {
+					t.Error("markdown did not match expectation:", diff)
+				}
+			}
+		})
+	}
+}
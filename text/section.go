@@ -14,19 +14,27 @@ func (t Text) Sectioned() Content {
 		sized   Builder
 	)
 
-	parts := t.Split("\n")
-	for i, line := range parts {
-		line = line.TrimSpace()
+	lines := t.Lines()
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
 		// Drop page numbers.
 		if _, err := strconv.Atoi(line.String()); err == nil {
 			continue
 		}
 
-		if isHeading(parts, i) {
+		if isHeading(lines, i) {
 			content.writeText(sized.Text())
 			sized = Builder{}
 
 			content.writeSection(line)
+		} else if list, next := parseList(lines, i); list != nil {
+			content.writeText(sized.Text())
+			sized = Builder{}
+
+			content.writeList(list)
+			i = next - 1
+			continue
 		} else {
 			sized.Add(line)
 		}
@@ -57,12 +65,22 @@ func (c *Content) writeText(content Text) {
 	*c = append(*c, content.TrimSpace())
 }
 
-func (c *Content) writeSection(title Text) {
-	n := title.Size()
+func (c *Content) writeList(l *List) {
+	for i := len(*c) - 1; i >= 0; i-- {
+		if s, ok := (*c)[i].(*Section); ok {
+			// Write the list to the last section.
+			s.Content.writeList(l)
+			return
+		}
+	}
+
+	*c = append(*c, l)
+}
 
+func (c *Content) writeSection(title Text) {
 	for i := len(*c) - 1; i >= 0; i-- {
 		if s, ok := (*c)[i].(*Section); ok {
-			if n < s.Title.Size() {
+			if compareSize(title, s.Title) < 0 {
 				// Write the text to the last section.
 				s.Content.writeSection(title)
 				return
@@ -89,6 +107,22 @@ func (c Content) DebugString() string {
 	return buf.String()
 }
 
+// Markdown renders the Content as Markdown, with the titles of
+// sections as headings and lists in list syntax.
+func (c Content) Markdown() string { return c.markdown(0) }
+
+func (c Content) markdown(depth int) string {
+	var buf strings.Builder
+	for _, s := range c {
+		if m, ok := s.(interface{ markdown(int) string }); ok {
+			buf.WriteString(m.markdown(depth))
+		} else {
+			buf.WriteString(s.String())
+		}
+	}
+	return buf.String()
+}
+
 func (c Content) Headings() []string { return c.headings(0) }
 
 func (c Content) headings(depth int) []string {
@@ -161,6 +195,23 @@ func (s Section) debugString(depth int) string {
 	return b.String()
 }
 
+func (s Section) markdown(depth int) string {
+	var b strings.Builder
+
+	t := s.Title.String()
+	if len(t) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(strings.Repeat("#", depth+1))
+		b.WriteRune(' ')
+		b.WriteString(t)
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(s.Content.markdown(depth + 1))
+
+	return b.String()
+}
+
 func (s Section) String() string {
 	var b strings.Builder
 
@@ -178,22 +229,22 @@ func (s Section) String() string {
 	return b.String()
 }
 
-// many leet hax in here.
+// many leet hax in here. The lines are those of Text.Lines.
 func isHeading(lines []Text, i int) bool {
-	line := lines[i].TrimSpace()
+	line := lines[i]
 	content := line.String()
 	if content == "" {
 		return false
 	}
 
-	if i > 0 && lines[i-1].TrimSpace().String() != "" {
+	if i > 0 && lines[i-1].String() != "" {
 		// Stuff on previous line.
 		return false
 	}
 
 	var nextLineWithContent Text
 	for j := i + 1; j < len(lines); j++ {
-		if lines[j].TrimSpace().String() != "" {
+		if lines[j].String() != "" {
 			nextLineWithContent = lines[j]
 			break
 		}
@@ -215,5 +266,5 @@ func isHeading(lines []Text, i int) bool {
 		return false
 	}
 
-	return line.Size() > nextLineWithContent.Size()
+	return compareSize(line, nextLineWithContent) > 0
 }
@@ -24,3 +24,29 @@ func TestSections_writeText(t *testing.T) {
 		t.Error("Sections didn't match expectation:", diff)
 	}
 }
+
+func TestText_Sectioned_sizeNoise(t *testing.T) {
+	input := Text{
+		{Size: 12.001, Content: "First heading\n\n"},
+		{Size: 10, Content: "Some text.\n\n"},
+		{Size: 11.999, Content: "Second heading\n\n"},
+		{Size: 10, Content: "More text.\n\n"},
+		{Size: 12, Weight: 1, Content: "Bold heading\n\n"},
+		{Size: 12, Content: "Plain heading\n\n"},
+		{Size: 10, Content: "Last text."},
+	}
+
+	var got []string
+	for _, c := range input.Sectioned() {
+		if s, ok := c.(*Section); ok {
+			got = append(got, s.Title.String())
+		} else if c.String() != "" {
+			t.Errorf("got content %q outside a section", c.String())
+		}
+	}
+	// The plain heading is within the bold one, and the others are at the same level.
+	want := []string{"First heading", "Second heading", "Bold heading"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("top-level sections did not match expectation:", diff)
+	}
+}
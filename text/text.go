@@ -1,7 +1,9 @@
 package text
 
 import (
+	"cmp"
 	"fmt"
+	"math"
 	"strings"
 	"unicode"
 )
@@ -9,11 +11,16 @@ import (
 // Text represents minimally structured text extracted from a PDF.
 type Text []Part
 
-// Part is a part of Text with the same size and font weight.
+// Part is a part of Text with the same size, font weight and language.
 type Part struct {
 	Size float64
 	// bitmask of styles, currently just 1 for bold.
-	Weight  int
+	Weight int
+	// Lang is the language of the text, as a language tag such as "en-US",
+	// if the document declares it for the text; see Text.WithLang.
+	Lang string
+	// Page is the number of the page the text is on, from 1, or zero if it is not known.
+	Page    int
 	Content string
 }
 
@@ -28,29 +35,81 @@ func (t Text) String() string {
 }
 
 // DebugString renders the Text as a string with annotation at each change of
-// text size.
+// text size, weight or page. The page is annotated only if it is known.
 func (t Text) DebugString() string {
 	var b strings.Builder
 	for _, p := range t {
-		fmt.Fprintf(&b, "[%.1f|%b]", p.Size, p.Weight)
+		fmt.Fprintf(&b, "[%.1f|%b", p.Size, p.Weight)
+		if p.Page != 0 {
+			fmt.Fprintf(&b, "|p%d", p.Page)
+		}
+		b.WriteString("]")
 		b.WriteString(p.Content)
 	}
 
 	return b.String()
 }
 
-// Size is calculated to be the maximum size of any segment in the string.
+// WithPage returns the Text with its parts on the page with the given number,
+// except for those already on a page.
+func (t Text) WithPage(page int) Text {
+	if page == 0 {
+		return t
+	}
+	paged := make(Text, len(t))
+	for i, p := range t {
+		if p.Page == 0 {
+			p.Page = page
+		}
+		paged[i] = p
+	}
+	return paged
+}
+
+// sizeQuantum is the granularity, in points, to which sizes are rounded for
+// comparison, so that sizes that differ only by rounding noise compare equal.
+const sizeQuantum = 0.25
+
+// quantize rounds the size to a multiple of sizeQuantum.
+func quantize(size float64) float64 {
+	return math.Round(size/sizeQuantum) * sizeQuantum
+}
+
+// Size is calculated to be the maximum size of any segment in the string,
+// rounded to a quarter point.
 func (t Text) Size() float64 {
 	var ms float64
 
 	for _, p := range t {
-		v := p.Size + float64(p.Weight)/100
-		ms = max(ms, v)
+		ms = max(ms, quantize(p.Size))
 	}
 
 	return ms
 }
 
+// Weight is the maximum weight of any segment in the string of the maximum Size.
+func (t Text) Weight() int {
+	var mw int
+
+	size := t.Size()
+	for _, p := range t {
+		if quantize(p.Size) == size {
+			mw = max(mw, p.Weight)
+		}
+	}
+
+	return mw
+}
+
+// compareSize compares the Size of two Texts, and then their Weight, returning
+// -1 if a is smaller or lighter than b, 1 if it is larger or heavier, or else 0.
+func compareSize(a, b Text) int {
+	if c := cmp.Compare(a.Size(), b.Size()); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Weight(), b.Weight())
+}
+
 // TrimSpace trims whitespace from both ends of the Text.
 func (t Text) TrimSpace() Text {
 	l := len(t)
@@ -89,7 +148,8 @@ func (s Text) Split(sep string) []Text {
 				parts = append(parts, current.text)
 				current = Builder{}
 			}
-			current.add(p.Size, p.Weight, line, noWhitespace)
+			p.Content = line
+			current.add(p, NoWhitespace)
 		}
 	}
 
@@ -99,3 +159,53 @@ func (s Text) Split(sep string) []Text {
 
 	return parts
 }
+
+// Lines splits the Text into lines, each trimmed of white space at either end.
+// Empty lines are kept, as empty Texts, so that each line of the Text has its index.
+func (t Text) Lines() []Text {
+	lines := t.Split("\n")
+	for i, line := range lines {
+		lines[i] = line.TrimSpace()
+	}
+	return lines
+}
+
+// Words splits the Text into words: runs of letters, marks and digits, including
+// the hyphens and apostrophes within words, such as "well-known" or "don't", and
+// the separators within numbers, such as "1,234.56".
+func (t Text) Words() []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(t.String())
+	for i, r := range runes {
+		if isWordRune(r) || i > 0 && i+1 < len(runes) && len(word) > 0 && joins(r, runes[i-1], runes[i+1]) {
+			word = append(word, r)
+			continue
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+}
+
+// joins reports whether r, between prev and next, is within a word or number.
+func joins(r, prev, next rune) bool {
+	switch r {
+	case '-', '\u2010', '\u2011', '\'', '\u2019':
+		return isWordRune(prev) && isWordRune(next)
+	case ',', '.':
+		return unicode.IsDigit(prev) && unicode.IsDigit(next)
+	}
+	return false
+}
@@ -39,6 +39,14 @@ func Test_Text_Split(t *testing.T) {
 				{{Size: 2, Weight: 2, Content: "d"}},
 			},
 		},
+		"parts on different pages": {
+			input: Text{{Size: 1, Page: 1, Content: "a\nb"}, {Size: 1, Page: 2, Content: "c\nd"}},
+			want: []Text{
+				{{Size: 1, Page: 1, Content: "a"}},
+				{{Size: 1, Page: 1, Content: "b"}, {Size: 1, Page: 2, Content: "c"}},
+				{{Size: 1, Page: 2, Content: "d"}},
+			},
+		},
 	}
 
 	opt := cmp.AllowUnexported(Builder{})
@@ -53,6 +61,36 @@ func Test_Text_Split(t *testing.T) {
 	}
 }
 
+func Test_Text_Split_roundTrip(t *testing.T) {
+	inputs := []Text{
+		{{Size: 1, Content: "a\nb"}, {Size: 2, Weight: 1, Content: "c\n\nd"}},
+		{{Size: 1, Content: "a\n"}, {Size: 2, Content: "\n"}, {Size: 2, Content: "B"}},
+		{{Size: 1, Content: "a"}, {Size: 2, Content: " "}, {Size: 2, Content: "B\nc"}},
+		{{Size: 1, Content: "a\n"}, {Size: 2, Weight: 1, Content: "B"}, {Size: 1, Content: "\nc\n"}},
+		{{Size: 2, Content: "\n"}, {Size: 1, Content: "x"}},
+	}
+
+	for _, input := range inputs {
+		var want, got Builder
+		want.Add(input)
+		for i, line := range input.Split("\n") {
+			if i > 0 {
+				got.Add(Text{{Content: "\n"}})
+			}
+			got.Add(line)
+		}
+
+		// A newline may end one part or start the next, so the lines are compared.
+		if got, want := got.Text().String(), want.Text().String(); got != want {
+			t.Errorf("%s: got rejoined text %q, want %q", input.DebugString(), got, want)
+		}
+		trim := cmp.Transformer("TrimSpace", Text.TrimSpace)
+		if diff := cmp.Diff(got.Text().Split("\n"), want.Text().Split("\n"), trim); diff != "" {
+			t.Errorf("%s: rejoined lines did not match expectation: %s", input.DebugString(), diff)
+		}
+	}
+}
+
 func Test_Text_TrimSpace(t *testing.T) {
 	testCases := map[string]struct {
 		input Text
@@ -83,3 +121,49 @@ func Test_Text_TrimSpace(t *testing.T) {
 		})
 	}
 }
+
+func Test_Text_Lines(t *testing.T) {
+	input := Text{{Size: 1, Content: " a \n\n b"}, {Size: 2, Weight: 1, Content: "c \n \nd"}}
+	want := []Text{
+		{{Size: 1, Content: "a"}},
+		nil,
+		{{Size: 1, Content: "b"}, {Size: 2, Weight: 1, Content: "c"}},
+		nil,
+		{{Size: 2, Weight: 1, Content: "d"}},
+	}
+
+	if diff := cmp.Diff(input.Lines(), want); diff != "" {
+		t.Error("lines did not match expectation:", diff)
+	}
+}
+
+func Test_Text_Words(t *testing.T) {
+	testCases := map[string]struct {
+		input string
+		want  []string
+	}{
+		"empty":       {},
+		"punctuation": {input: "Hello, world! (Again.)", want: []string{"Hello", "world", "Again"}},
+		"hyphens":     {input: "well-known - co‐op -dash-", want: []string{"well-known", "co‐op", "dash"}},
+		"apostrophes": {input: "don't 'quote' l’été", want: []string{"don't", "quote", "l’été"}},
+		"numbers":     {input: "1,234.56 and 7. 8, 9.", want: []string{"1,234.56", "and", "7", "8", "9"}},
+		"unicode":     {input: "näive 日本語\tcafé\n", want: []string{"näive", "日本語", "café"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := Text{{Content: tc.input}}.Words()
+
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("words did not match expectation:", diff)
+			}
+		})
+	}
+}
+
+func Test_Text_DebugString(t *testing.T) {
+	got := Text{{Size: 10, Content: "a"}, {Size: 12, Weight: 1, Page: 3, Content: "b"}}.DebugString()
+	if want := "[10.0|0]a[12.0|1|p3]b"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
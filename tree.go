@@ -0,0 +1,179 @@
+package pdf
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// maxTreeDepth is the greatest depth of a name or number tree that is read.
+const maxTreeDepth = 32
+
+// A tree is a name tree, with string keys, or a number tree, with integer keys:
+// a balanced tree of dictionaries, each holding either Kids, the nodes below it, or
+// the Names or Nums array of its keys and values. A node's Limits are the least
+// and greatest keys below it. See PDF 32000-1:2008, §7.9.6 and §7.9.7.
+type tree[K string | int64] struct {
+	root Value
+}
+
+// nameTree returns the name tree root.
+func nameTree(root Value) tree[string] { return tree[string]{root} }
+
+// numberTree returns the number tree root.
+func numberTree(root Value) tree[int64] { return tree[int64]{root} }
+
+// Walk calls fn for each key and value of the tree, in order, until fn returns false.
+// A malformed tree is walked as far as possible: Walk returns an error if the tree
+// contains a cycle, or its keys are out of order or do not match the Limits of the
+// nodes above them, after visiting the entries it can.
+func (t tree[K]) Walk(fn func(key K, v Value) bool) error {
+	w := treeWalker[K]{fn: fn, seen: map[types.Objptr]bool{}}
+	w.walk(t.root, nil, 0)
+	return w.err
+}
+
+// Lookup returns the value for key, or a null value if there is none. Lookup descends
+// only into the nodes whose Limits include key, unless the Limits are malformed.
+func (t tree[K]) Lookup(key K) Value {
+	seen := map[types.Objptr]bool{}
+	var lookup func(node Value, depth int) Value
+	lookup = func(node Value, depth int) Value {
+		if node.Kind() != Dict || depth > maxTreeDepth {
+			return Value{}
+		}
+		entries := node.Key(entriesKey[K]())
+		for i := 0; i+1 < entries.Len(); i += 2 {
+			if k, ok := treeKey[K](entries.Index(i)); ok && k == key {
+				return entries.Index(i + 1)
+			}
+		}
+		kids := node.Key("Kids")
+		for i := range kids.Len() {
+			kid := kids.Index(i)
+			if seen[kid.ptr] {
+				continue
+			}
+			if lo, hi, ok := limits[K](kid); ok && (key < lo || key > hi) {
+				continue
+			}
+			seen[kid.ptr] = true
+			if v := lookup(kid, depth+1); !v.IsNull() {
+				return v
+			}
+		}
+		return Value{}
+	}
+	v := lookup(t.root, 0)
+	if v.IsNull() {
+		// The Limits may be wrong, so look through all the entries.
+		t.Walk(func(k K, kv Value) bool {
+			if k == key {
+				v = kv
+			}
+			return k != key
+		})
+	}
+	return v
+}
+
+// entriesKey returns the key of the array of keys and values in the nodes of a tree.
+func entriesKey[K string | int64]() string {
+	var k K
+	if _, ok := any(k).(string); ok {
+		return "Names"
+	}
+	return "Nums"
+}
+
+type treeWalker[K string | int64] struct {
+	fn   func(K, Value) bool
+	seen map[types.Objptr]bool
+	err  error
+
+	// last is the last key visited, if any.
+	last    K
+	visited bool
+	stopped bool
+}
+
+// walk walks the node, whose keys are within the bounds if they are non-nil.
+func (w *treeWalker[K]) walk(node Value, bounds *[2]K, depth int) {
+	if node.Kind() != Dict || w.stopped {
+		return
+	}
+	if depth > maxTreeDepth {
+		w.fail(fmt.Errorf("tree deeper than %d", maxTreeDepth))
+		return
+	}
+
+	entries := node.Key(entriesKey[K]())
+	for i := 0; i+1 < entries.Len() && !w.stopped; i += 2 {
+		k, ok := treeKey[K](entries.Index(i))
+		if !ok {
+			w.fail(fmt.Errorf("malformed tree key %v", entries.Index(i)))
+			continue
+		}
+		if bounds != nil && (k < bounds[0] || k > bounds[1]) {
+			w.fail(fmt.Errorf("tree key %v outside Limits [%v %v]", k, bounds[0], bounds[1]))
+		}
+		if w.visited && k <= w.last {
+			w.fail(fmt.Errorf("tree key %v out of order after %v", k, w.last))
+		}
+		w.last, w.visited = k, true
+		w.stopped = !w.fn(k, entries.Index(i+1))
+	}
+
+	kids := node.Key("Kids")
+	for i := range kids.Len() {
+		kid := kids.Index(i)
+		if kid.Kind() != Dict || w.stopped {
+			continue
+		}
+		if w.seen[kid.ptr] {
+			w.fail(fmt.Errorf("tree contains a cycle at %v", kid.ptr))
+			continue
+		}
+		w.seen[kid.ptr] = true
+
+		kidBounds := bounds
+		if lo, hi, ok := limits[K](kid); ok {
+			kidBounds = &[2]K{lo, hi}
+		} else {
+			w.fail(fmt.Errorf("tree node %v has malformed Limits %v", kid.ptr, kid.Key("Limits")))
+		}
+		w.walk(kid, kidBounds, depth+1)
+	}
+}
+
+func (w *treeWalker[K]) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// limits returns the Limits of the tree node, the least and greatest keys below it.
+func limits[K string | int64](node Value) (lo, hi K, ok bool) {
+	l := node.Key("Limits")
+	if l.Len() != 2 {
+		return lo, hi, false
+	}
+	lo, ok1 := treeKey[K](l.Index(0))
+	hi, ok2 := treeKey[K](l.Index(1))
+	return lo, hi, ok1 && ok2 && cmp.Compare(lo, hi) <= 0
+}
+
+// treeKey returns the tree key v: a string for a name tree, or an integer for a number tree.
+func treeKey[K string | int64](v Value) (K, bool) {
+	var k K
+	switch p := any(&k).(type) {
+	case *string:
+		*p = v.RawString()
+		return k, v.Kind() == String
+	case *int64:
+		*p = v.Int64()
+		return k, v.Kind() == Integer
+	}
+	return k, false
+}
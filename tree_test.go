@@ -0,0 +1,141 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_tree_Walk(t *testing.T) {
+	testCases := map[string]struct {
+		objs    []string
+		want    []string
+		wantErr bool
+	}{
+		"leaf root": {
+			objs: []string{"<</Names [(a) 1 (b) 2]>>"},
+			want: []string{"a=1", "b=2"},
+		},
+		"multi-level": {
+			objs: []string{
+				"<</Kids [3 0 R 4 0 R]>>",
+				"<</Limits [(a) (c)] /Kids [5 0 R 6 0 R]>>",
+				"<</Limits [(d) (e)] /Names [(d) 4 (e) 5]>>",
+				"<</Limits [(a) (b)] /Names [(a) 1 (b) 2]>>",
+				"<</Limits [(c) (c)] /Names [(c) 3]>>",
+			},
+			want: []string{"a=1", "b=2", "c=3", "d=4", "e=5"},
+		},
+		"key outside Limits": {
+			objs: []string{
+				"<</Kids [3 0 R 4 0 R]>>",
+				"<</Limits [(a) (b)] /Names [(a) 1 (c) 3]>>",
+				"<</Limits [(d) (e)] /Names [(d) 4]>>",
+			},
+			want:    []string{"a=1", "c=3", "d=4"},
+			wantErr: true,
+		},
+		"missing Limits": {
+			objs: []string{
+				"<</Kids [3 0 R]>>",
+				"<</Names [(a) 1]>>",
+			},
+			want:    []string{"a=1"},
+			wantErr: true,
+		},
+		"inverted Limits": {
+			objs: []string{
+				"<</Kids [3 0 R]>>",
+				"<</Limits [(z) (a)] /Names [(a) 1]>>",
+			},
+			want:    []string{"a=1"},
+			wantErr: true,
+		},
+		"out of order": {
+			objs:    []string{"<</Names [(b) 2 (a) 1]>>"},
+			want:    []string{"b=2", "a=1"},
+			wantErr: true,
+		},
+		"cycle": {
+			objs: []string{
+				"<</Kids [3 0 R]>>",
+				"<</Limits [(a) (a)] /Names [(a) 1] /Kids [3 0 R]>>",
+			},
+			want:    []string{"a=1"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := openPDF(t, buildPDF(append([]string{"<</Type /Catalog /Tree 2 0 R>>"}, tc.objs...)...))
+
+			var got []string
+			err := nameTree(r.trailerValue().Key("Root").Key("Tree")).Walk(func(key string, v Value) bool {
+				got = append(got, key+"="+v.String())
+				return true
+			})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Error("entries did not match expectation:", diff)
+			}
+		})
+	}
+}
+
+func Test_tree_Lookup(t *testing.T) {
+	r := openPDF(t, buildPDF(
+		"<</Type /Catalog /Tree 2 0 R>>",
+		"<</Kids [3 0 R 4 0 R]>>",
+		"<</Limits [0 9] /Nums [0 (zero) 9 (nine)]>>",
+		// Limits that wrongly exclude the key 20.
+		"<</Limits [10 19] /Nums [10 (ten) 20 (twenty)]>>",
+	))
+	tr := numberTree(r.trailerValue().Key("Root").Key("Tree"))
+
+	got := map[int64]string{}
+	for _, key := range []int64{0, 9, 10, 20, 5} {
+		got[key] = tr.Lookup(key).RawString()
+	}
+	want := map[int64]string{0: "zero", 9: "nine", 10: "ten", 20: "twenty", 5: ""}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("lookups did not match expectation:", diff)
+	}
+
+	var keys []int64
+	tr.Walk(func(key int64, v Value) bool {
+		keys = append(keys, key)
+		return key < 9
+	})
+	if diff := cmp.Diff(keys, []int64{0, 9}); diff != "" {
+		t.Error("stopped walk did not match expectation:", diff)
+	}
+}
+
+func Benchmark_tree_Walk(b *testing.B) {
+	// A leaf with 10,000 entries, whose values are direct destination dictionaries.
+	var names strings.Builder
+	for i := range 10_000 {
+		fmt.Fprintf(&names, "(n%05d) <</D [%d /XYZ 0 0 null]>> ", i, i)
+	}
+	r := openPDF(b, buildPDF("<</Type /Catalog /Tree 2 0 R>>", "<</Names ["+names.String()+"]>>"))
+	tree := nameTree(r.trailerValue().Key("Root").Key("Tree"))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		var n int
+		tree.Walk(func(key string, v Value) bool {
+			if v.Key("D").Index(1).Name() == "XYZ" {
+				n++
+			}
+			return true
+		})
+		if n != 10_000 {
+			b.Fatalf("walked %d destinations, want 10000", n)
+		}
+	}
+}
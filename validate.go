@@ -0,0 +1,267 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A ProblemCategory is the kind of a Problem.
+type ProblemCategory string
+
+// The categories of problems found by Validate.
+const (
+	XrefProblem       ProblemCategory = "xref"       // An object is not where the cross-reference table says.
+	LengthProblem     ProblemCategory = "length"     // The Length of a stream is not that of its data.
+	FilterProblem     ProblemCategory = "filter"     // A stream is encoded with an unsupported filter.
+	PageTreeProblem   ProblemCategory = "page tree"  // A page tree node is malformed or miscounted.
+	FontProblem       ProblemCategory = "font"       // The text of a font may not decode.
+	ContentProblem    ProblemCategory = "content"    // The content of a page fails to decode or parse.
+	EncryptionProblem ProblemCategory = "encryption" // The encryption dictionary is unusual.
+)
+
+// A Problem is a structural problem in a PDF file, found by Validate.
+type Problem struct {
+	Category ProblemCategory
+	// ID and Gen are the object number and generation of the object with
+	// the problem, as for Object, or zero if it is not in an object.
+	ID      uint32
+	Gen     uint16
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.ID == 0 {
+		return fmt.Sprintf("%s: %s", p.Category, p.Message)
+	}
+	return fmt.Sprintf("%s: %d %d R: %s", p.Category, p.ID, p.Gen, p.Message)
+}
+
+// Validate checks the structure of the file, without extracting its text, and returns
+// the problems it finds, which may make reading the file fail or lose some of its text.
+// It checks the objects of the cross-reference table and the lengths and filters of their
+// streams, the page tree, and the fonts and content streams of the pages.
+// A file without problems returns none.
+func (r *Reader) Validate() []Problem {
+	v := validator{r: r}
+	v.objects()
+	v.encryption()
+	root := r.trailerValue().Key("Root").Key("Pages")
+	v.pageTree(root, map[types.Objptr]bool{root.ptr: true}, 0)
+	return v.problems
+}
+
+type validator struct {
+	r        *Reader
+	problems []Problem
+	// fonts holds the fonts checked so far.
+	fonts map[types.Objptr]bool
+}
+
+func (v *validator) report(c ProblemCategory, ptr types.Objptr, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Category: c, ID: ptr.ID, Gen: ptr.Gen, Message: fmt.Sprintf(format, args...)})
+}
+
+// objects checks each object of the cross-reference table, and the length and filters of streams.
+func (v *validator) objects() {
+	var last uint32
+	v.r.Objects(func(id uint32, gen uint16, obj Value, err error) bool {
+		ptr := types.Objptr{ID: id, Gen: gen}
+		last = id
+		if err != nil {
+			v.report(XrefProblem, ptr, "%v", err)
+			return true
+		}
+		s, ok := obj.data.(types.Stream)
+		if !ok {
+			return true
+		}
+
+		if declared := obj.Key("Length"); declared.Kind() != Integer {
+			v.report(LengthProblem, ptr, "stream has no Length")
+		} else if n := v.r.streamLength(s, declared.Int64()); n != declared.Int64() {
+			v.report(LengthProblem, ptr, "stream Length is %d, but its data is %d bytes", declared.Int64(), n)
+		}
+
+		filters, err := streamFilters(obj)
+		if err != nil {
+			v.report(FilterProblem, ptr, "malformed Filter %v", obj.Key("Filter"))
+		}
+		for _, f := range filters {
+			if !supportedFilter(f.Name) {
+				v.report(FilterProblem, ptr, "unsupported filter %q", f.Name)
+			}
+		}
+		return true
+	})
+
+	if size := v.r.trailerValue().Key("Size").Int64(); size <= int64(last) {
+		v.report(XrefProblem, types.Objptr{}, "trailer Size is %d, but object %d is defined", size, last)
+	}
+}
+
+// encryption checks the encryption dictionary of an encrypted file. See PDF 32000-1:2008, §7.6.
+func (v *validator) encryption() {
+	ptr, _ := v.r.trailer["Encrypt"].(types.Objptr)
+	encrypt := v.r.trailerValue().Key("Encrypt")
+	if encrypt.Kind() != Dict {
+		return
+	}
+	switch ver := encrypt.Key("V").Int64(); ver {
+	case 1, 2, 4, 5:
+	default:
+		v.report(EncryptionProblem, ptr, "unknown encryption algorithm V %d", ver)
+	}
+	switch rev := encrypt.Key("R").Int64(); rev {
+	case 2, 3, 4, 5, 6:
+	default:
+		v.report(EncryptionProblem, ptr, "unknown security handler revision R %d", rev)
+	}
+	if n := encrypt.Key("Length"); !n.IsNull() && (n.Int64()%8 != 0 || n.Int64() < 40 || n.Int64() > 256) {
+		v.report(EncryptionProblem, ptr, "invalid key Length %d", n.Int64())
+	}
+	if encrypt.Key("P").Kind() != Integer {
+		v.report(EncryptionProblem, ptr, "no permissions P")
+	}
+}
+
+// maxPageTreeDepth is the greatest depth of the page tree that is checked.
+const maxPageTreeDepth = 256
+
+// pageTree checks the page tree node, and returns the number of pages in it.
+// The nodes seen so far are those checked, false, or being checked, true.
+func (v *validator) pageTree(node Value, seen map[types.Objptr]bool, depth int) int {
+	if depth > maxPageTreeDepth {
+		v.report(PageTreeProblem, node.ptr, "page tree is too deep")
+		return 0
+	}
+
+	switch typ := node.Key("Type").Name(); {
+	case node.Kind() != Dict:
+		v.report(PageTreeProblem, node.ptr, "page tree node is %v, not a dictionary", node)
+		return 0
+	case typ == "Page":
+		v.page(&Page{v: node})
+		return 1
+	case typ != "Pages":
+		v.report(PageTreeProblem, node.ptr, "page tree node has Type %v", node.Key("Type"))
+	}
+
+	kids := node.Key("Kids")
+	if kids.Kind() != Array {
+		v.report(PageTreeProblem, node.ptr, "page tree node has no Kids")
+	}
+	var n int
+	elems, _ := kids.data.(types.Array)
+	for i := range kids.Len() {
+		// Direct kids cannot be in the tree twice, nor in a cycle.
+		ref, ok := elems[i].(types.Objptr)
+		if !ok {
+			v.report(PageTreeProblem, node.ptr, "page tree node kid %d is not an indirect reference", i)
+			n += v.pageTree(kids.Index(i), seen, depth+1)
+			continue
+		}
+		if checking, dup := seen[ref]; checking {
+			v.report(PageTreeProblem, node.ptr, "page tree contains a cycle through %v", objfmt(ref))
+			continue
+		} else if dup {
+			v.report(PageTreeProblem, node.ptr, "page tree node %v is in the tree more than once", objfmt(ref))
+			continue
+		}
+		seen[ref] = true
+		n += v.pageTree(kids.Index(i), seen, depth+1)
+		seen[ref] = false
+	}
+	if count := node.Key("Count"); count.Int64() != int64(n) {
+		v.report(PageTreeProblem, node.ptr, "page tree node has Count %v, but its kids have %d pages", count, n)
+	}
+	return n
+}
+
+// page checks the fonts and content streams of the page.
+func (v *validator) page(p *Page) {
+	res := p.resources()
+	for _, name := range res.names("Font") {
+		fonts := res.dict("Font", name)
+		refs, _ := fonts.data.(types.Dict)
+		ref, ok := refs[types.Name(name)].(types.Objptr)
+		if ok && v.fonts[ref] {
+			continue
+		}
+		if ok {
+			if v.fonts == nil {
+				v.fonts = map[types.Objptr]bool{}
+			}
+			v.fonts[ref] = true
+		}
+		v.font(fonts.Key(name))
+	}
+
+	contents := p.v.Key("Contents")
+	streams := []Value{contents}
+	if contents.Kind() == Array {
+		streams = nil
+		for i := range contents.Len() {
+			streams = append(streams, contents.Index(i))
+		}
+	}
+	for _, s := range streams {
+		if s.Kind() != Stream {
+			if !s.IsNull() {
+				v.report(ContentProblem, p.v.ptr, "content is %v, not a stream", s)
+			}
+			continue
+		}
+		// The data that the Reader recovers from malformed FlateDecode data is
+		// not that of the stream as its filters declare.
+		rc := s.decode(-1, true)
+		_, err := io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			v.report(ContentProblem, s.ptr, "content stream fails to decode: %v", err)
+			return
+		}
+	}
+	if _, err := p.Contents(); err != nil {
+		v.report(ContentProblem, p.v.ptr, "content fails to parse: %v", err)
+	}
+}
+
+// font checks that the text of the font can be decoded to Unicode: that it has
+// a ToUnicode map, or is a simple font with an encoding. See PDF 32000-1:2008, §9.10.
+func (v *validator) font(f Value) {
+	if f.Kind() != Dict {
+		v.report(FontProblem, f.ptr, "font is %v, not a dictionary", f)
+		return
+	}
+	if msg := fontTextProblem(f); msg != "" {
+		v.report(FontProblem, f.ptr, "%s", msg)
+	}
+}
+
+// fontTextProblem describes why the text of the font dictionary f may not decode
+// to Unicode, or returns "" if it should.
+func fontTextProblem(f Value) string {
+	if !f.Key("ToUnicode").IsNull() {
+		return ""
+	}
+	switch sub := f.Key("Subtype").Name(); sub {
+	case "Type0", "Type3":
+		return fmt.Sprintf("%s font %s has no ToUnicode map", sub, f.Key("BaseFont").Name())
+	default:
+		if f.Key("Encoding").IsNull() && !standardFonts[f.Key("BaseFont").Name()] {
+			return fmt.Sprintf("font %s has neither an Encoding nor a ToUnicode map", f.Key("BaseFont").Name())
+		}
+	}
+	return ""
+}
+
+// standardFonts are the names of the standard 14 fonts, whose built-in
+// encodings are known. See PDF 32000-1:2008, §9.6.2.2.
+var standardFonts = map[string]bool{
+	"Times-Roman": true, "Times-Bold": true, "Times-Italic": true, "Times-BoldItalic": true,
+	"Helvetica": true, "Helvetica-Bold": true, "Helvetica-Oblique": true, "Helvetica-BoldOblique": true,
+	"Courier": true, "Courier-Bold": true, "Courier-Oblique": true, "Courier-BoldOblique": true,
+	"Symbol": true, "ZapfDingbats": true,
+}
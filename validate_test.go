@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_Validate(t *testing.T) {
+	if got := openPDF(t, textPDF("BT /F1 12 Tf (Hello) Tj ET")).Validate(); got != nil {
+		t.Errorf("got problems with a valid file: %v", got)
+	}
+
+	data := buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R 3 0 R 2 0 R] /Count 3>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R /F2 5 0 R>>>> /Contents [4 0 R 7 0 R]>>",
+		"<</Length 100>>\nstream\nBT /F1 12 Tf (Hello) Tj ET\nendstream",
+		"<</Type /Font /Subtype /Type0 /BaseFont /Arial /Encoding /Identity-H>>",
+		"<</Length 3 /Filter [/ASCII85Decode /LZWDecode]>>\nstream\nabc\nendstream",
+		"<</Length 3 /Filter /FlateDecode>>\nstream\nabc\nendstream",
+		"(misplaced)",
+	)
+	data = bytes.Replace(data, []byte("8 0 obj"), []byte("9 0 obj"), 1)
+
+	var got []string
+	for _, p := range openPDF(t, data).Validate() {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"length: 4 0 R: stream Length is 100, but its data is 26 bytes",
+		"filter: 6 0 R: unsupported filter \"LZWDecode\"",
+		"xref: 8 0 R: loading {8 0}: found {9 0}",
+		"font: 5 0 R: Type0 font Arial has no ToUnicode map",
+		"content: 7 0 R: content stream fails to decode: FlateDecode: zlib: invalid header",
+		"page tree: 2 0 R: page tree node 3 0 R is in the tree more than once",
+		"page tree: 2 0 R: page tree contains a cycle through 2 0 R",
+		"page tree: 2 0 R: page tree node has Count 3, but its kids have 1 pages",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("problems did not match expectation:", diff)
+	}
+
+	// Direct kids are each reported, but not as in the tree twice.
+	data = buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [<</Type /Page>> <</Type /Page>>] /Count 2>>",
+	)
+	got = nil
+	for _, p := range openPDF(t, data).Validate() {
+		got = append(got, p.String())
+	}
+	want = []string{
+		"page tree: 2 0 R: page tree node kid 0 is not an indirect reference",
+		"page tree: 2 0 R: page tree node kid 1 is not an indirect reference",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error("problems with direct kids did not match expectation:", diff)
+	}
+}
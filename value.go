@@ -5,68 +5,71 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ScriptRock/pdf/internal/encoding"
 	"github.com/ScriptRock/pdf/internal/types"
 )
 
-// A value is a single PDF value, such as an integer, dictionary, or array.
+// A Value is a single PDF value, such as an integer, dictionary, or array.
 // The zero value is a PDF null (Kind() == Null, IsNull() = true).
-type value struct {
+type Value struct {
 	r    *Reader
 	ptr  types.Objptr
 	data any
 }
 
 // IsNull reports whether the value is a null. It is equivalent to Kind() == Null.
-func (v value) IsNull() bool {
+func (v Value) IsNull() bool {
 	return v.data == nil
 }
 
-// A valueKind specifies the kind of data underlying a Value.
-type valueKind int
+// A ValueKind specifies the kind of data underlying a Value.
+type ValueKind int
 
 // The PDF value kinds.
 const (
-	nullKind valueKind = iota
-	boolKind
-	integerKind
-	realKind
-	stringKind
-	nameKind
-	dictKind
-	arrayKind
-	streamKind
+	Null ValueKind = iota
+	Bool
+	Integer
+	Real
+	String
+	Name
+	Dict
+	Array
+	Stream
 )
 
 // Kind reports the kind of value underlying v.
-func (v value) Kind() valueKind {
+func (v Value) Kind() ValueKind {
 	switch v.data.(type) {
 	default:
-		return nullKind
+		return Null
 	case bool:
-		return boolKind
+		return Bool
 	case int64:
-		return integerKind
+		return Integer
 	case float64:
-		return realKind
+		return Real
 	case string:
-		return stringKind
+		return String
 	case types.Name:
-		return nameKind
+		return Name
 	case types.Dict:
-		return dictKind
+		return Dict
 	case types.Array:
-		return arrayKind
+		return Array
 	case types.Stream:
-		return streamKind
+		return Stream
 	}
 }
 
-// String returns a textual representation of the value v.
+// String returns a textual representation of the value v, with the keys of
+// dictionaries in sorted order, so that it is the same from run to run.
 // Note that String is not the accessor for values with Kind() == String.
 // To access such values, see RawString, Text, and TextFromUTF16.
-func (v value) String() string {
+func (v Value) String() string {
 	return objfmt(v.data)
 }
 
@@ -75,13 +78,7 @@ func objfmt(x any) string {
 	default:
 		return fmt.Sprint(x)
 	case string:
-		if encoding.IsPDFDocEncoded(x) {
-			return strconv.Quote(encoding.PDFDocDecode(x))
-		}
-		if encoding.IsUTF16(x) {
-			return strconv.Quote(encoding.UTF16Decode(x[2:]))
-		}
-		return strconv.Quote(x)
+		return strconv.Quote(encoding.DecodeText(x))
 	case types.Name:
 		return "/" + string(x)
 	case types.Dict:
@@ -130,7 +127,7 @@ func objfmt(x any) string {
 
 // Bool returns v's boolean value.
 // If v.Kind() != Bool, Bool returns false.
-func (v value) Bool() bool {
+func (v Value) Bool() bool {
 	x, ok := v.data.(bool)
 	if !ok {
 		return false
@@ -140,7 +137,7 @@ func (v value) Bool() bool {
 
 // Int64 returns v's int64 value.
 // If v.Kind() != Int64, Int64 returns 0.
-func (v value) Int64() int64 {
+func (v Value) Int64() int64 {
 	x, ok := v.data.(int64)
 	if !ok {
 		return 0
@@ -150,7 +147,7 @@ func (v value) Int64() int64 {
 
 // Float64 returns v's float64 value, converting from integer if necessary.
 // If v.Kind() != Float64 and v.Kind() != Int64, Float64 returns 0.
-func (v value) Float64() float64 {
+func (v Value) Float64() float64 {
 	x, ok := v.data.(float64)
 	if !ok {
 		x, ok := v.data.(int64)
@@ -164,7 +161,7 @@ func (v value) Float64() float64 {
 
 // RawString returns v's string value.
 // If v.Kind() != String, RawString returns the empty string.
-func (v value) RawString() string {
+func (v Value) RawString() string {
 	x, ok := v.data.(string)
 	if !ok {
 		return ""
@@ -173,27 +170,89 @@ func (v value) RawString() string {
 }
 
 // Text returns v's string value interpreted as a “text string” (defined in the PDF spec)
-// and converted to UTF-8.
+// and converted to UTF-8. Text strings are in PDFDocEncoding, or in UTF-16BE or, since
+// PDF 2.0, UTF-8 with a byte order mark; UTF-16LE with one is accepted too.
 // If v.Kind() != String, Text returns the empty string.
-func (v value) Text() string {
+func (v Value) Text() string {
 	x, ok := v.data.(string)
 	if !ok {
 		return ""
 	}
-	if encoding.IsPDFDocEncoded(x) {
-		return encoding.PDFDocDecode(x)
+	return encoding.DecodeText(x)
+}
+
+// Date returns v's string value interpreted as a date, as in the CreationDate and ModDate
+// of the document information dictionary: D:YYYYMMDDHHmmSSOHH'mm', where the components
+// after the year are optional, the prefix D: is too, and O is the relation of local time
+// to UTC, '+', '-' or 'Z'. A date without one is taken to be in UTC.
+// See PDF 32000-1:2008, §7.9.4.
+// If v.Kind() != String or the string is not a date, Date returns the zero Time and false.
+func (v Value) Date() (time.Time, bool) {
+	if v.Kind() != String {
+		return time.Time{}, false
+	}
+	return parseDate(v.Text())
+}
+
+// parseDate parses the date s, as Value.Date does.
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	// num consumes the number of n digits at the start of s, if there is one.
+	num := func(n int) (int, bool) {
+		if len(s) < n {
+			return 0, false
+		}
+		x, err := strconv.Atoi(s[:n])
+		if err != nil || strings.ContainsAny(s[:n], "+-") {
+			return 0, false
+		}
+		s = s[n:]
+		return x, true
+	}
+
+	year, ok := num(4)
+	if !ok {
+		return time.Time{}, false
+	}
+	// The month and day default to 1, the other components to 0.
+	fields := [5]int{1, 1}
+	for i := range fields {
+		x, ok := num(2)
+		if !ok {
+			break
+		}
+		fields[i] = x
 	}
-	if encoding.IsUTF16(x) {
-		return encoding.UTF16Decode(x[2:])
+	month, day, hour, minute, sec := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || sec > 59 {
+		return time.Time{}, false
 	}
-	return x
+
+	loc := time.UTC
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+		oh, _ := num(2)
+		s = strings.TrimPrefix(s, "'")
+		om, _ := num(2)
+		if oh > 23 || om > 59 {
+			return time.Time{}, false
+		}
+		if offset := sign * (oh*3600 + om*60); offset != 0 {
+			loc = time.FixedZone("", offset)
+		}
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, sec, 0, loc), true
 }
 
 // TextFromUTF16 returns v's string value interpreted as big-endian UTF-16
 // and then converted to UTF-8.
 // If v.Kind() != String or if the data is not valid UTF-16, TextFromUTF16 returns
 // the empty string.
-func (v value) TextFromUTF16() string {
+func (v Value) TextFromUTF16() string {
 	x, ok := v.data.(string)
 	if !ok {
 		return ""
@@ -212,7 +271,7 @@ func (v value) TextFromUTF16() string {
 // The returned name does not include the leading slash:
 // if v corresponds to the name written using the syntax /Helvetica,
 // Name() == "Helvetica".
-func (v value) Name() string {
+func (v Value) Name() string {
 	x, ok := v.data.(types.Name)
 	if !ok {
 		return ""
@@ -224,22 +283,42 @@ func (v value) Name() string {
 // Like the result of the Name method, the key should not include a leading slash.
 // If v is a stream, Key applies to the stream's header dictionary.
 // If v.Kind() != Dict and v.Kind() != Stream, Key returns a null Value.
-func (v value) Key(key string) value {
+func (v Value) Key(key string) Value {
+	x, ok := v.data.(types.Dict)
+	if !ok {
+		strm, ok := v.data.(types.Stream)
+		if !ok {
+			return Value{}
+		}
+		x = strm.Hdr
+	}
+	obj := x[types.Name(key)]
+	if _, ok := obj.(types.Objptr); ok {
+		return v.r.resolve(v.ptr, obj)
+	}
+	return v.r.direct(v.ptr, obj)
+}
+
+// Has reports whether the dictionary v has the key, telling a key whose value is
+// an explicit null, for which Key returns a null Value, from one that is absent.
+// If v is a stream, Has applies to the stream's header dictionary.
+func (v Value) Has(key string) bool {
 	x, ok := v.data.(types.Dict)
 	if !ok {
 		strm, ok := v.data.(types.Stream)
 		if !ok {
-			return value{}
+			return false
 		}
 		x = strm.Hdr
 	}
-	return v.r.resolve(v.ptr, x[types.Name(key)])
+	_, ok = x[types.Name(key)]
+	return ok
 }
 
-// Keys returns a sorted list of the keys in the dictionary v.
-// If v is a stream, Keys applies to the stream's header dictionary.
+// Keys returns a sorted list of the keys in the dictionary v, including those
+// whose value is null. If v is a stream, Keys applies to the stream's header dictionary.
 // If v.Kind() != Dict and v.Kind() != Stream, Keys returns nil.
-func (v value) Keys() []string {
+func (v Value) Keys() []string {
 	x, ok := v.data.(types.Dict)
 	if !ok {
 		strm, ok := v.data.(types.Stream)
@@ -259,17 +338,20 @@ func (v value) Keys() []string {
 // Index returns the i'th element in the array v.
 // If v.Kind() != Array or if i is outside the array bounds,
 // Index returns a null Value.
-func (v value) Index(i int) value {
+func (v Value) Index(i int) Value {
 	x, ok := v.data.(types.Array)
 	if !ok || i < 0 || i >= len(x) {
-		return value{}
+		return Value{}
+	}
+	if _, ok := x[i].(types.Objptr); ok {
+		return v.r.resolve(v.ptr, x[i])
 	}
-	return v.r.resolve(v.ptr, x[i])
+	return v.r.direct(v.ptr, x[i])
 }
 
 // Len returns the length of the array v.
 // If v.Kind() != Array, Len returns 0.
-func (v value) Len() int {
+func (v Value) Len() int {
 	x, ok := v.data.(types.Array)
 	if !ok {
 		return 0
@@ -280,10 +362,10 @@ func (v value) Len() int {
 // RawElements returns the elements in the array.
 // If v.Kind() != Array, RawElements returns nil.
 // RawElements only returns values with kinds matching those given.
-func (v value) RawElements(kinds ...valueKind) []any {
+func (v Value) RawElements(kinds ...ValueKind) []any {
 	var ee []any
 
-	kk := map[valueKind]bool{}
+	kk := map[ValueKind]bool{}
 	for _, k := range kinds {
 		kk[k] = true
 	}
@@ -295,15 +377,15 @@ func (v value) RawElements(kinds ...valueKind) []any {
 		}
 
 		switch e.Kind() {
-		case boolKind:
+		case Bool:
 			ee = append(ee, e.Bool())
-		case integerKind:
+		case Integer:
 			ee = append(ee, e.Int64())
-		case realKind:
+		case Real:
 			ee = append(ee, e.Float64())
-		case stringKind:
+		case String:
 			ee = append(ee, e.RawString())
-		case nameKind:
+		case Name:
 			ee = append(ee, e.Name())
 		}
 	}
@@ -0,0 +1,88 @@
+package pdf
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/ScriptRock/pdf/internal/types"
+)
+
+// A WarningCategory is the kind of a Warning.
+type WarningCategory string
+
+// The categories of warnings collected by a Reader.
+const (
+	XrefWarning    WarningCategory = "xref"    // A reference or xref entry is inconsistent, and was repaired.
+	SyntaxWarning  WarningCategory = "syntax"  // An object is malformed, and was read as far as it parses.
+	StreamWarning  WarningCategory = "stream"  // The data of a stream is not as declared, or may decode wrongly.
+	FontWarning    WarningCategory = "font"    // A font is unreadable, or its text may not decode.
+	ContentWarning WarningCategory = "content" // An operator of a content stream was skipped.
+)
+
+// A Warning is a problem that reading a PDF file recovered from, but which
+// may have changed or lost some of what was read.
+type Warning struct {
+	Category WarningCategory
+	// ID and Gen are the object number and generation of the object with
+	// the problem, as for Object, or zero if it is not known.
+	ID  uint32
+	Gen uint16
+	// Page is the number of the page being read, or zero if it is not known.
+	Page    int
+	Message string
+}
+
+func (w Warning) String() string {
+	s := string(w.Category)
+	if w.Page != 0 {
+		s += fmt.Sprintf(": page %d", w.Page)
+	}
+	if w.ID != 0 {
+		s += fmt.Sprintf(": %d %d R", w.ID, w.Gen)
+	}
+	return s + ": " + w.Message
+}
+
+// maxWarnings is the number of warnings a Reader keeps; later ones are only logged.
+const maxWarnings = 1000
+
+// warnings holds the warnings of a Reader.
+type warnings struct {
+	mu   sync.Mutex
+	list []Warning
+}
+
+// Warnings returns the warnings collected since the Reader was opened, or since
+// ResetWarnings, in the order they occurred. Reading text, resolving values and
+// validating the file all add to them. At most 1000 warnings are kept.
+func (r *Reader) Warnings() []Warning {
+	w := &r.warnings
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Warning(nil), w.list...)
+}
+
+// ResetWarnings discards the warnings collected so far, so that those of
+// the next operation on the Reader can be told apart.
+func (r *Reader) ResetWarnings() {
+	w := &r.warnings
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = nil
+}
+
+// warn records a warning of the category c about the object ptr, on page, and logs it.
+func (r *Reader) warn(c WarningCategory, ptr types.Objptr, page int, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	slog.Debug(msg, slog.String("category", string(c)), slog.Any("ptr", ptr), slog.Int("page", page))
+	if r == nil {
+		return
+	}
+	w := &r.warnings
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.list) < maxWarnings {
+		w.list = append(w.list, Warning{Category: c, ID: ptr.ID, Gen: ptr.Gen, Page: page, Message: msg})
+	}
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_Warnings(t *testing.T) {
+	r := openPDF(t, textPDF("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"))
+	if _, err := r.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	if got := r.Warnings(); got != nil {
+		t.Errorf("got warnings reading a valid file: %v", got)
+	}
+
+	r = openPDF(t, buildPDF(
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /Resources <</Font <</F1 5 0 R>>>> /Contents 4 0 R>>",
+		"<</Length 100>>\nstream\nBT /F1 12 Tf 72 Td /F2 12 Tf (Hello) Tj ET\nendstream",
+		"<</Type /Font /Subtype /Type3 /BaseFont /Poetica>>",
+	))
+	if _, err := r.Text(); err != nil {
+		t.Fatal("failed to read text:", err)
+	}
+	var got []string
+	for _, w := range r.Warnings() {
+		got = append(got, w.String())
+	}
+	want := []string{
+		"font: page 1: 5 0 R: failed to read font F1: unsupported encoding <nil> of font Poetica",
+		"stream: 4 0 R: stream Length is 100, but its data is 42 bytes",
+		"content: page 1: 3 0 R: skipping operator Td with 1 operands, not 2",
+		"content: page 1: 3 0 R: unknown font /F2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	r.ResetWarnings()
+	if _, err := r.Object(1, 0); err != nil {
+		t.Fatal("failed to read object:", err)
+	}
+	if got := r.Warnings(); got != nil {
+		t.Errorf("got warnings after ResetWarnings: %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package pdf
+
+import "fmt"
+
+// An XrefType is the type of an entry of the cross-reference table.
+type XrefType int
+
+// The types of the entries of the cross-reference table. See PDF 32000-1:2008, §7.5.4 and §7.5.8.3.
+const (
+	XrefFree       XrefType = iota // The object number is not in use.
+	XrefInUse                      // The object is in the file, outside any object stream.
+	XrefCompressed                 // The object is in an object stream.
+)
+
+func (t XrefType) String() string {
+	switch t {
+	case XrefFree:
+		return "free"
+	case XrefInUse:
+		return "in use"
+	case XrefCompressed:
+		return "compressed"
+	}
+	return fmt.Sprintf("XrefType(%d)", int(t))
+}
+
+// An XrefEntry is an entry of the cross-reference table of a file.
+type XrefEntry struct {
+	ID   uint32
+	Gen  uint16 // The generation of the next use of the object number, for a free entry.
+	Type XrefType
+	// Offset is the offset in the file of an object in use.
+	Offset int64
+	// Stream is the object number of the object stream of a compressed object,
+	// and Index the index of the object in it.
+	Stream uint32
+	Index  int64
+}
+
+// String returns the entry in the form of a line of a cross-reference table, such as
+// "0000000017 00000 n". A compressed entry, which such tables cannot hold, has the
+// object number of its object stream and its index in place of the offset and the
+// generation, and the type c.
+func (e XrefEntry) String() string {
+	switch e.Type {
+	case XrefFree:
+		return fmt.Sprintf("%010d %05d f", 0, e.Gen)
+	case XrefCompressed:
+		return fmt.Sprintf("%010d %05d c", e.Stream, e.Index)
+	}
+	return fmt.Sprintf("%010d %05d n", e.Offset, e.Gen)
+}
+
+// Xref returns the entries of the cross-reference table of the file, in order of
+// object number, as the file is read: those of the sections of its incremental
+// updates merged, the newest entry of each object number winning. Object numbers
+// without an entry are left out.
+func (r *Reader) Xref() []XrefEntry {
+	var entries []XrefEntry
+	for id, xref := range r.xrefTable() {
+		if xref.Ptr.ID != uint32(id) || !xref.Free && !xref.InStream && xref.Offset == 0 {
+			continue
+		}
+		e := XrefEntry{ID: xref.Ptr.ID, Gen: xref.Ptr.Gen}
+		switch {
+		case xref.Free:
+			e.Type = XrefFree
+		case xref.InStream:
+			e.Type, e.Stream, e.Index = XrefCompressed, xref.Stream.ID, xref.Offset
+		default:
+			e.Type, e.Offset = XrefInUse, xref.Offset
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// TrailerDict returns the trailer dictionary of the file, or the dictionary of its
+// cross-reference stream, with the keys that its incremental updates inherit from
+// older trailers, such as Root and Info, merged into it. See PDF 32000-1:2008, §7.5.5.
+func (r *Reader) TrailerDict() Value {
+	return r.trailerValue()
+}